@@ -0,0 +1,261 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Andrew44Ashraf/fintech-service/internal/database"
+	"github.com/Andrew44Ashraf/fintech-service/internal/ids"
+	"github.com/Andrew44Ashraf/fintech-service/internal/logging"
+	"github.com/Andrew44Ashraf/fintech-service/internal/repository"
+)
+
+type accountOutput struct {
+	AccountID     int     `json:"account_id,omitempty"`
+	PublicID      string  `json:"public_id,omitempty"`
+	AccountNumber string  `json:"account_number,omitempty"`
+	ProductCode   string  `json:"product_code"`
+	Balance       float64 `json:"balance"`
+	DryRun        bool    `json:"dry_run"`
+}
+
+type statusOutput struct {
+	AccountID int       `json:"account_id"`
+	From      string    `json:"from"`
+	To        string    `json:"to"`
+	Operator  string    `json:"operator"`
+	Reason    string    `json:"reason"`
+	ChangedAt time.Time `json:"changed_at"`
+	DryRun    bool      `json:"dry_run"`
+}
+
+type balanceOutput struct {
+	AccountID int        `json:"account_id"`
+	Balance   float64    `json:"balance"`
+	AsOf      *time.Time `json:"as_of,omitempty"`
+}
+
+type transactionOutput struct {
+	ID           int       `json:"id"`
+	Type         string    `json:"type"`
+	Status       string    `json:"status"`
+	Amount       float64   `json:"amount"`
+	FinalBalance float64   `json:"final_balance"`
+	ExternalRef  string    `json:"external_ref,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// runOpen opens an account, e.g.
+//
+//	fintechctl open -product savings -initial 100 -ref crm-1234
+func runOpen(ctx context.Context, args []string, stdout io.Writer) error {
+	fs := flag.NewFlagSet("open", flag.ContinueOnError)
+	product := fs.String("product", repository.DefaultProduct, "product code")
+	initial := fs.Float64("initial", 0, "initial balance")
+	ref := fs.String("ref", "", "external reference, unique across accounts")
+	description := fs.String("description", "", "account description")
+	output := outputFlag(fs)
+	dryRun := dryRunFlag(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := checkOutput(*output); err != nil {
+		return err
+	}
+
+	db := database.Connect()
+	defer db.Close()
+
+	repo := repository.NewAccountRepository(db, logging.Discard())
+	create := repo.CreateAccount
+	if *dryRun {
+		create = repo.CheckCreateAccount
+	}
+	account, err := create(ctx, *product, *initial, repository.Details{ExternalRef: *ref, Description: *description})
+	if err != nil {
+		return err
+	}
+	out := accountOutput{
+		AccountID:     account.ID,
+		PublicID:      account.PublicID,
+		AccountNumber: account.Number,
+		ProductCode:   account.ProductCode,
+		Balance:       account.Balance,
+		DryRun:        *dryRun,
+	}
+
+	return render(stdout, *output, out, func(tw *tabwriter.Writer) {
+		fmt.Fprintln(tw, "ACCOUNT\tPUBLIC ID\tNUMBER\tPRODUCT\tBALANCE")
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%.2f\n", idOrDash(out.AccountID), strOrDash(out.PublicID),
+			strOrDash(ids.FormatAccountNumber(out.AccountNumber)), out.ProductCode, out.Balance)
+		dryRunNote(tw, out.DryRun)
+	})
+}
+
+// statusCommand returns the command that moves accounts to status, e.g.
+//
+//	fintechctl freeze -account 42 -reason "card reported stolen"
+//	fintechctl close -account 42 -reason "customer request" -dry-run
+func statusCommand(name, status string) func(context.Context, []string, io.Writer) error {
+	return func(ctx context.Context, args []string, stdout io.Writer) error {
+		fs := flag.NewFlagSet(name, flag.ContinueOnError)
+		accountID := fs.Int("account", 0, "account ID (required)")
+		reason := fs.String("reason", "", "why, for the audit trail (required)")
+		operator := operatorFlag(fs)
+		output := outputFlag(fs)
+		dryRun := dryRunFlag(fs)
+		if err := fs.Parse(args); err != nil {
+			return err
+		}
+		if err := checkOutput(*output); err != nil {
+			return err
+		}
+		if *accountID <= 0 {
+			return errors.New("-account is required")
+		}
+		if *reason == "" {
+			return errors.New("-reason is required")
+		}
+
+		db := database.Connect()
+		defer db.Close()
+
+		repo := repository.NewAccountRepository(db, logging.Discard())
+		change, err := repo.ChangeAccountStatus(ctx, *accountID, 0, status, *operator, *reason, *dryRun)
+		if err != nil {
+			return err
+		}
+
+		out := statusOutput{
+			AccountID: change.AccountID,
+			From:      change.From,
+			To:        change.To,
+			Operator:  change.Operator,
+			Reason:    change.Reason,
+			ChangedAt: change.ChangedAt,
+			DryRun:    *dryRun,
+		}
+		return render(stdout, *output, out, func(tw *tabwriter.Writer) {
+			fmt.Fprintln(tw, "ACCOUNT\tFROM\tTO\tOPERATOR\tREASON")
+			fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\n", out.AccountID, out.From, out.To, out.Operator, out.Reason)
+			dryRunNote(tw, out.DryRun)
+		})
+	}
+}
+
+// runBalance prints an account's balance, now or at an instant, e.g.
+//
+//	fintechctl balance -account 42 -as-of 2026-03-31T23:59:59Z
+func runBalance(ctx context.Context, args []string, stdout io.Writer) error {
+	fs := flag.NewFlagSet("balance", flag.ContinueOnError)
+	accountID := fs.Int("account", 0, "account ID (required)")
+	asOfFlag := fs.String("as-of", "", "RFC 3339 instant (default now)")
+	output := outputFlag(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := checkOutput(*output); err != nil {
+		return err
+	}
+	if *accountID <= 0 {
+		return errors.New("-account is required")
+	}
+
+	db := database.Connect()
+	defer db.Close()
+
+	repo := repository.NewAccountRepository(db, logging.Discard())
+	out := balanceOutput{AccountID: *accountID}
+	var err error
+	if *asOfFlag != "" {
+		asOf, perr := time.Parse(time.RFC3339, *asOfFlag)
+		if perr != nil {
+			return fmt.Errorf("-as-of: %w", perr)
+		}
+		asOf = asOf.UTC()
+		out.AsOf = &asOf
+		out.Balance, err = repo.GetBalanceAsOf(ctx, *accountID, asOf)
+	} else {
+		out.Balance, err = repo.GetAccountBalance(ctx, *accountID)
+	}
+	if err != nil {
+		return err
+	}
+
+	return render(stdout, *output, out, func(tw *tabwriter.Writer) {
+		fmt.Fprintln(tw, "ACCOUNT\tBALANCE")
+		fmt.Fprintf(tw, "%d\t%.2f\n", out.AccountID, out.Balance)
+	})
+}
+
+// runHistory prints an account's transactions, newest first, e.g.
+//
+//	fintechctl history -account 42 -limit 50
+func runHistory(ctx context.Context, args []string, stdout io.Writer) error {
+	fs := flag.NewFlagSet("history", flag.ContinueOnError)
+	accountID := fs.Int("account", 0, "account ID (required)")
+	limit := fs.Int("limit", 20, "number of transactions")
+	offset := fs.Int("offset", 0, "transactions to skip")
+	ref := fs.String("ref", "", "only the transaction with this external reference")
+	output := outputFlag(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := checkOutput(*output); err != nil {
+		return err
+	}
+	switch {
+	case *accountID <= 0:
+		return errors.New("-account is required")
+	case *limit <= 0 || *offset < 0:
+		return errors.New("-limit must be positive and -offset not negative")
+	}
+
+	db := database.Connect()
+	defer db.Close()
+
+	repo := repository.NewTransactionRepository(db, logging.Discard())
+	txs, err := repo.GetTransactions(ctx, *accountID, *limit, *offset, *ref)
+	if err != nil {
+		return err
+	}
+
+	out := make([]transactionOutput, 0, len(txs))
+	for _, t := range txs {
+		out = append(out, transactionOutput{
+			ID:           t.ID,
+			Type:         t.Type,
+			Status:       t.Status,
+			Amount:       t.Amount,
+			FinalBalance: t.FinalBalance,
+			ExternalRef:  t.Details.ExternalRef,
+			CreatedAt:    t.CreatedAt,
+		})
+	}
+	return render(stdout, *output, out, func(tw *tabwriter.Writer) {
+		fmt.Fprintln(tw, "ID\tTIME\tTYPE\tSTATUS\tAMOUNT\tBALANCE")
+		for _, t := range out {
+			fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%.2f\t%.2f\n",
+				t.ID, t.CreatedAt.Format(time.RFC3339), t.Type, t.Status, t.Amount, t.FinalBalance)
+		}
+	})
+}
+
+func idOrDash(id int) string {
+	if id == 0 {
+		return "-"
+	}
+	return fmt.Sprint(id)
+}
+
+func strOrDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Andrew44Ashraf/fintech-service/internal/database"
+	"github.com/Andrew44Ashraf/fintech-service/internal/logging"
+	"github.com/Andrew44Ashraf/fintech-service/internal/repository"
+)
+
+type adjustmentOutput struct {
+	TransactionID         int       `json:"transaction_id,omitempty"`
+	AccountID             int       `json:"account_id"`
+	Type                  string    `json:"type"`
+	Amount                float64   `json:"amount"`
+	FinalBalance          float64   `json:"final_balance"`
+	ReversedTransactionID int       `json:"reversed_transaction_id,omitempty"`
+	Operator              string    `json:"operator"`
+	Reason                string    `json:"reason"`
+	CreatedAt             time.Time `json:"created_at"`
+	DryRun                bool      `json:"dry_run"`
+}
+
+// runAdjust posts a manual credit or debit, e.g.
+//
+//	fintechctl adjust -account 42 -amount -12.50 -reason "duplicate card payment"
+func runAdjust(ctx context.Context, args []string, stdout io.Writer) error {
+	fs := flag.NewFlagSet("adjust", flag.ContinueOnError)
+	accountID := fs.Int("account", 0, "account ID (required)")
+	amount := fs.Float64("amount", 0, "positive to credit, negative to debit (required)")
+	reason := fs.String("reason", "", "why, for the audit trail (required)")
+	operator := operatorFlag(fs)
+	output := outputFlag(fs)
+	dryRun := dryRunFlag(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := checkOutput(*output); err != nil {
+		return err
+	}
+	switch {
+	case *accountID <= 0:
+		return errors.New("-account is required")
+	case *amount == 0:
+		return errors.New("-amount is required")
+	case *reason == "":
+		return errors.New("-reason is required")
+	}
+
+	db := database.Connect()
+	defer db.Close()
+
+	repo := repository.NewTransactionRepository(db, logging.Discard())
+	adj, err := repo.PostAdjustment(ctx, *accountID, *amount, *operator, *reason, *dryRun)
+	if err != nil {
+		return err
+	}
+	return printAdjustment(stdout, *output, adj)
+}
+
+// runReverse undoes a posted transaction, e.g.
+//
+//	fintechctl reverse -tx 1234 -reason "deposit bounced"
+func runReverse(ctx context.Context, args []string, stdout io.Writer) error {
+	fs := flag.NewFlagSet("reverse", flag.ContinueOnError)
+	txID := fs.Int("tx", 0, "transaction ID (required)")
+	reason := fs.String("reason", "", "why, for the audit trail (required)")
+	operator := operatorFlag(fs)
+	output := outputFlag(fs)
+	dryRun := dryRunFlag(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := checkOutput(*output); err != nil {
+		return err
+	}
+	switch {
+	case *txID <= 0:
+		return errors.New("-tx is required")
+	case *reason == "":
+		return errors.New("-reason is required")
+	}
+
+	db := database.Connect()
+	defer db.Close()
+
+	repo := repository.NewTransactionRepository(db, logging.Discard())
+	adj, err := repo.ReverseTransaction(ctx, *txID, *operator, *reason, *dryRun)
+	if err != nil {
+		return err
+	}
+	return printAdjustment(stdout, *output, adj)
+}
+
+func printAdjustment(w io.Writer, format string, adj *repository.Adjustment) error {
+	out := adjustmentOutput{
+		AccountID:             adj.AccountID,
+		Type:                  string(adj.Type),
+		Amount:                adj.Amount,
+		FinalBalance:          adj.FinalBalance,
+		ReversedTransactionID: adj.ReversedTransactionID,
+		Operator:              adj.Operator,
+		Reason:                adj.Reason,
+		CreatedAt:             adj.CreatedAt,
+		DryRun:                adj.DryRun,
+	}
+	// The ID of a rolled back transaction is never used
+	if !adj.DryRun {
+		out.TransactionID = adj.TransactionID
+	}
+
+	return render(w, format, out, func(tw *tabwriter.Writer) {
+		fmt.Fprintln(tw, "TRANSACTION\tACCOUNT\tTYPE\tAMOUNT\tBALANCE\tREVERSES")
+		fmt.Fprintf(tw, "%s\t%d\t%s\t%.2f\t%.2f\t%s\n",
+			idOrDash(out.TransactionID), out.AccountID, out.Type, out.Amount, out.FinalBalance, idOrDash(out.ReversedTransactionID))
+		dryRunNote(tw, out.DryRun)
+	})
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Andrew44Ashraf/fintech-service/internal/database"
+	"github.com/Andrew44Ashraf/fintech-service/internal/logging"
+	"github.com/Andrew44Ashraf/fintech-service/internal/repository"
+	"github.com/Andrew44Ashraf/fintech-service/internal/tenant"
+)
+
+type apiKeyOutput struct {
+	ID        int        `json:"id,omitempty"`
+	Tenant    string     `json:"tenant"`
+	Name      string     `json:"name"`
+	Prefix    string     `json:"prefix,omitempty"`
+	Key       string     `json:"key,omitempty"`
+	CreatedBy string     `json:"created_by"`
+	CreatedAt time.Time  `json:"created_at"`
+	RevokedBy string     `json:"revoked_by,omitempty"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+	DryRun    bool       `json:"dry_run,omitempty"`
+}
+
+// runAPIKeys manages the tenant's API keys for the REST and gRPC APIs, e.g.
+//
+//	fintechctl apikeys create -name payouts-service
+//	fintechctl apikeys list
+//	fintechctl apikeys revoke -id 3
+func runAPIKeys(ctx context.Context, args []string, stdout io.Writer) error {
+	if len(args) == 0 {
+		return errors.New("expected create, list or revoke")
+	}
+	sub, args := args[0], args[1:]
+
+	fs := flag.NewFlagSet("apikeys "+sub, flag.ContinueOnError)
+	output := outputFlag(fs)
+	var (
+		name     *string
+		id       *int
+		operator *string
+		dryRun   *bool
+	)
+	switch sub {
+	case "create":
+		name = fs.String("name", "", "what the key is for (required)")
+		operator = operatorFlag(fs)
+		dryRun = dryRunFlag(fs)
+	case "list":
+	case "revoke":
+		id = fs.Int("id", 0, "key ID (required)")
+		operator = operatorFlag(fs)
+		dryRun = dryRunFlag(fs)
+	default:
+		return fmt.Errorf("unknown subcommand %q: expected create, list or revoke", sub)
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := checkOutput(*output); err != nil {
+		return err
+	}
+
+	db := database.Connect()
+	defer db.Close()
+	repo := repository.NewAPIKeyRepository(db, logging.Discard())
+
+	switch sub {
+	case "create":
+		if *name == "" {
+			return errors.New("-name is required")
+		}
+		out := apiKeyOutput{Tenant: tenant.FromContext(ctx), Name: *name, CreatedBy: *operator, CreatedAt: time.Now().UTC(), DryRun: *dryRun}
+		if !*dryRun {
+			key, secret, err := repo.CreateKey(ctx, *name, *operator)
+			if err != nil {
+				return err
+			}
+			out = toAPIKeyOutput(*key)
+			out.Key = secret
+		}
+		return render(stdout, *output, out, func(tw *tabwriter.Writer) {
+			fmt.Fprintln(tw, "ID\tTENANT\tNAME\tKEY")
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", idOrDash(out.ID), out.Tenant, out.Name, out.Key)
+			if out.Key != "" {
+				fmt.Fprintln(tw, "store the key now: it cannot be shown again")
+			}
+			dryRunNote(tw, out.DryRun)
+		})
+
+	case "revoke":
+		if *id <= 0 {
+			return errors.New("-id is required")
+		}
+		key, err := activeKey(ctx, repo, *id)
+		if err != nil {
+			return err
+		}
+		if !*dryRun {
+			if err := repo.RevokeKey(ctx, *id, *operator); err != nil {
+				return err
+			}
+		}
+		out := toAPIKeyOutput(*key)
+		now := time.Now().UTC()
+		out.RevokedBy, out.RevokedAt, out.DryRun = *operator, &now, *dryRun
+		return render(stdout, *output, out, func(tw *tabwriter.Writer) {
+			fmt.Fprintln(tw, "ID\tNAME\tPREFIX\tREVOKED BY")
+			fmt.Fprintf(tw, "%d\t%s\t%s\t%s\n", out.ID, out.Name, out.Prefix, out.RevokedBy)
+			dryRunNote(tw, out.DryRun)
+		})
+	}
+
+	keys, err := repo.ListKeys(ctx)
+	if err != nil {
+		return err
+	}
+	out := make([]apiKeyOutput, 0, len(keys))
+	for _, k := range keys {
+		out = append(out, toAPIKeyOutput(k))
+	}
+	return render(stdout, *output, out, func(tw *tabwriter.Writer) {
+		fmt.Fprintln(tw, "ID\tNAME\tPREFIX\tCREATED BY\tCREATED\tREVOKED")
+		for _, k := range out {
+			revoked := "-"
+			if k.RevokedAt != nil {
+				revoked = k.RevokedAt.Format(time.RFC3339) + " by " + k.RevokedBy
+			}
+			fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\t%s\n",
+				k.ID, k.Name, k.Prefix, k.CreatedBy, k.CreatedAt.Format(time.RFC3339), revoked)
+		}
+	})
+}
+
+// activeKey returns the tenant's key with the given ID if it can still be
+// revoked.
+func activeKey(ctx context.Context, repo *repository.APIKeyRepository, id int) (*repository.APIKey, error) {
+	keys, err := repo.ListKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, k := range keys {
+		if k.ID == id && k.RevokedAt == nil {
+			return &k, nil
+		}
+	}
+	return nil, repository.ErrAPIKeyNotFound
+}
+
+func toAPIKeyOutput(k repository.APIKey) apiKeyOutput {
+	return apiKeyOutput{
+		ID:        k.ID,
+		Tenant:    k.TenantID,
+		Name:      k.Name,
+		Prefix:    k.Prefix,
+		CreatedBy: k.CreatedBy,
+		CreatedAt: k.CreatedAt,
+		RevokedBy: k.RevokedBy,
+		RevokedAt: k.RevokedAt,
+	}
+}
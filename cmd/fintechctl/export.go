@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/Andrew44Ashraf/fintech-service/internal/database"
+	"github.com/Andrew44Ashraf/fintech-service/internal/export"
+	"github.com/Andrew44Ashraf/fintech-service/internal/logging"
+	"github.com/Andrew44Ashraf/fintech-service/internal/repository"
+	"github.com/Andrew44Ashraf/fintech-service/internal/statements"
+)
+
+// runExport writes the statement for one account and period, e.g.
+//
+//	fintechctl export -account 42 -from 2026-03-01 -to 2026-03-31 -format camt053 -o march.xml
+func runExport(ctx context.Context, args []string, stdout io.Writer) error {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	accountID := fs.Int("account", 0, "account ID (required)")
+	fromFlag := fs.String("from", "", "first day, YYYY-MM-DD (required)")
+	toFlag := fs.String("to", "", "last day, inclusive, YYYY-MM-DD (required)")
+	format := fs.String("format", export.FormatCamt053, "json, csv, html, camt053 or mt940")
+	output := fs.String("o", "", "output file (default stdout)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if *accountID <= 0 {
+		return errors.New("-account is required")
+	}
+	from, err := time.Parse("2006-01-02", *fromFlag)
+	if err != nil {
+		return fmt.Errorf("-from: %w", err)
+	}
+	to, err := time.Parse("2006-01-02", *toFlag)
+	if err != nil {
+		return fmt.Errorf("-to: %w", err)
+	}
+	to = to.AddDate(0, 0, 1)
+	if !from.Before(to) {
+		return errors.New("-to must not be before -from")
+	}
+	if !statements.Supports(*format) && !export.Supports(*format) {
+		return fmt.Errorf("unsupported format %q", *format)
+	}
+
+	db := database.Connect()
+	defer db.Close()
+
+	repo := repository.NewStatementRepository(db, logging.Discard())
+	stmt, err := repo.GetStoredStatement(ctx, *accountID, from, to)
+	if err == nil && stmt == nil {
+		stmt, err = repo.BuildStatement(ctx, *accountID, from, to)
+	}
+	if err != nil {
+		return err
+	}
+
+	w := stdout
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+
+	if export.Supports(*format) {
+		return export.Render(w, stmt, *format, time.Now())
+	}
+	return statements.Render(w, stmt, *format)
+}
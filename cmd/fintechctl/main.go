@@ -0,0 +1,95 @@
+// Command fintechctl is the operator CLI for the fintech service. It talks
+// to the service database directly using the same DB_* environment
+// variables as the API server. Commands act for the tenant named by
+// FINTECH_TENANT, or the default tenant. Commands that print records take
+// -output table|json, and commands that change data take -dry-run.
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/Andrew44Ashraf/fintech-service/internal/repository"
+	"github.com/Andrew44Ashraf/fintech-service/internal/tenant"
+)
+
+// command is a fintechctl subcommand.
+type command struct {
+	summary string
+	run     func(ctx context.Context, args []string, stdout io.Writer) error
+}
+
+var commands = map[string]command{
+	"open":      {summary: "open an account", run: runOpen},
+	"freeze":    {summary: "stop all postings to an account", run: statusCommand("freeze", repository.AccountFrozen)},
+	"unfreeze":  {summary: "allow postings to a frozen account again", run: statusCommand("unfreeze", repository.AccountActive)},
+	"close":     {summary: "close an account with a zero balance", run: statusCommand("close", repository.AccountClosed)},
+	"adjust":    {summary: "post a manual credit or debit with a reason", run: runAdjust},
+	"reverse":   {summary: "reverse a posted transaction with a reason", run: runReverse},
+	"balance":   {summary: "show an account's balance, now or at an instant", run: runBalance},
+	"history":   {summary: "list an account's transactions", run: runHistory},
+	"apikeys":   {summary: "create, list or revoke API keys", run: runAPIKeys},
+	"tenants":   {summary: "create or list tenants", run: runTenants},
+	"export":    {summary: "export an account statement (json, csv, html, camt053, mt940)", run: runExport},
+	"reconcile": {summary: "compare account balances with their transactions (exit 3 on breaks)", run: runReconcile},
+}
+
+// exitBreaks is the exit status of a command that ran but found problems
+// that need an operator, as opposed to 1 for a failure to run.
+const exitBreaks = 3
+
+func main() {
+	os.Exit(run(context.Background(), os.Args[1:], os.Stdout, os.Stderr))
+}
+
+func run(ctx context.Context, args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 || args[0] == "-h" || args[0] == "--help" || args[0] == "help" {
+		usage(stderr)
+		return 2
+	}
+
+	cmd, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(stderr, "fintechctl: unknown command %q\n\n", args[0])
+		usage(stderr)
+		return 2
+	}
+
+	id := os.Getenv("FINTECH_TENANT")
+	if id == "" {
+		id = tenant.Default
+	}
+	if !tenant.Valid(id) {
+		fmt.Fprintf(stderr, "fintechctl: invalid FINTECH_TENANT %q\n", id)
+		return 2
+	}
+	ctx = tenant.WithID(ctx, id)
+
+	if err := cmd.run(ctx, args[1:], stdout); err != nil {
+		fmt.Fprintf(stderr, "fintechctl %s: %v\n", args[0], err)
+		if errors.Is(err, errBreaks) {
+			return exitBreaks
+		}
+		return 1
+	}
+	return 0
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "usage: fintechctl <command> [flags]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "commands:")
+
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(w, "  %-10s %s\n", name, commands[name].summary)
+	}
+}
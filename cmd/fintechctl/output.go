@@ -0,0 +1,60 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+)
+
+// Output formats shared by every command that prints records.
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+)
+
+// outputFlag registers -output on fs.
+func outputFlag(fs *flag.FlagSet) *string {
+	return fs.String("output", outputTable, "table or json")
+}
+
+// operatorFlag registers -operator on fs. Mutations are audited under this
+// name, which defaults to the login user.
+func operatorFlag(fs *flag.FlagSet) *string {
+	return fs.String("operator", os.Getenv("USER"), "operator name recorded in the audit trail")
+}
+
+// dryRunFlag registers -dry-run on fs. A dry run performs the change in a
+// database transaction and rolls it back, so every check runs.
+func dryRunFlag(fs *flag.FlagSet) *bool {
+	return fs.Bool("dry-run", false, "check the change and roll it back")
+}
+
+func checkOutput(format string) error {
+	if format != outputTable && format != outputJSON {
+		return fmt.Errorf("-output must be %s or %s", outputTable, outputJSON)
+	}
+	return nil
+}
+
+// render writes v as indented JSON, or hands a tabwriter to table.
+func render(w io.Writer, format string, v any, table func(tw *tabwriter.Writer)) error {
+	if format == outputJSON {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	table(tw)
+	return tw.Flush()
+}
+
+// dryRunNote marks table output of a rolled back change.
+func dryRunNote(w io.Writer, dryRun bool) {
+	if dryRun {
+		fmt.Fprintln(w, "dry run: nothing was changed")
+	}
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Andrew44Ashraf/fintech-service/internal/database"
+	"github.com/Andrew44Ashraf/fintech-service/internal/dto/responses"
+	"github.com/Andrew44Ashraf/fintech-service/internal/logging"
+	"github.com/Andrew44Ashraf/fintech-service/internal/repository"
+)
+
+// errBreaks is returned when a reconciliation report has breaks, so the
+// command exits with exitBreaks for scripts and cron to alert on.
+var errBreaks = errors.New("reconciliation has breaks")
+
+// runReconcile compares every account's balance with its journal and
+// prints the report, e.g.
+//
+//	fintechctl reconcile          # run a reconciliation now
+//	fintechctl reconcile -latest  # print the last stored report
+//	fintechctl reconcile -run 12  # print a stored report
+func runReconcile(ctx context.Context, args []string, stdout io.Writer) error {
+	fs := flag.NewFlagSet("reconcile", flag.ContinueOnError)
+	latest := fs.Bool("latest", false, "print the latest stored report instead of running")
+	runID := fs.Int("run", 0, "print the stored report with this ID instead of running")
+	output := outputFlag(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := checkOutput(*output); err != nil {
+		return err
+	}
+
+	db := database.Connect()
+	defer db.Close()
+
+	repo := repository.NewReconciliationRepository(db, logging.Discard())
+	var (
+		run *repository.ReconciliationRun
+		err error
+	)
+	switch {
+	case *runID > 0:
+		run, err = repo.GetRun(ctx, *runID)
+	case *latest:
+		run, err = repo.LatestRun(ctx)
+	default:
+		run, err = repo.Reconcile(ctx, repository.TriggerManual, time.Now())
+	}
+	if err != nil {
+		return err
+	}
+
+	if *output == outputJSON {
+		err = render(stdout, *output, toReconciliationOutput(run), nil)
+	} else {
+		printReconciliation(stdout, run)
+	}
+	if err != nil {
+		return err
+	}
+	if run.BreakCount > 0 {
+		return errBreaks
+	}
+	return nil
+}
+
+func printReconciliation(w io.Writer, run *repository.ReconciliationRun) {
+	fmt.Fprintf(w, "run %d (%s, %s): %d accounts checked, %d breaks\n",
+		run.ID, run.Trigger, run.RunDate.Format("2006-01-02"), run.AccountsChecked, run.BreakCount)
+	if len(run.Breaks) == 0 {
+		return
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "\nACCOUNT\tKIND\tLEDGER\tEXPECTED\tDIFFERENCE")
+	for _, b := range run.Breaks {
+		fmt.Fprintf(tw, "%d\t%s\t%.2f\t%.2f\t%.2f\n", b.AccountID, b.Kind, b.LedgerBalance, b.ExpectedBalance, b.Difference)
+	}
+	tw.Flush()
+}
+
+// toReconciliationOutput matches the JSON of GET /api/admin/reconciliation.
+func toReconciliationOutput(run *repository.ReconciliationRun) responses.ReconciliationRunResponse {
+	out := responses.ReconciliationRunResponse{
+		ID:              run.ID,
+		RunDate:         run.RunDate.Format("2006-01-02"),
+		Trigger:         run.Trigger,
+		AccountsChecked: run.AccountsChecked,
+		BreakCount:      run.BreakCount,
+		StartedAt:       run.StartedAt,
+		FinishedAt:      run.FinishedAt,
+		Breaks:          make([]responses.ReconciliationBreakItem, 0, len(run.Breaks)),
+	}
+	for _, b := range run.Breaks {
+		out.Breaks = append(out.Breaks, responses.ReconciliationBreakItem{
+			ID:              b.ID,
+			AccountID:       b.AccountPublicID,
+			Kind:            string(b.Kind),
+			LedgerBalance:   b.LedgerBalance,
+			ExpectedBalance: b.ExpectedBalance,
+			Difference:      b.Difference,
+			CreatedAt:       b.CreatedAt,
+		})
+	}
+	return out
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Andrew44Ashraf/fintech-service/internal/database"
+	"github.com/Andrew44Ashraf/fintech-service/internal/logging"
+	"github.com/Andrew44Ashraf/fintech-service/internal/repository"
+	"github.com/Andrew44Ashraf/fintech-service/internal/tenant"
+)
+
+type tenantOutput struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+	DryRun    bool      `json:"dry_run,omitempty"`
+}
+
+// runTenants registers and lists tenants, e.g.
+//
+//	fintechctl tenants create -id acme -name "Acme Ltd"
+//	fintechctl tenants list
+//
+// A new tenant has no API keys; create one with
+// FINTECH_TENANT=acme fintechctl apikeys create.
+func runTenants(ctx context.Context, args []string, stdout io.Writer) error {
+	if len(args) == 0 {
+		return errors.New("expected create or list")
+	}
+	sub, args := args[0], args[1:]
+
+	fs := flag.NewFlagSet("tenants "+sub, flag.ContinueOnError)
+	output := outputFlag(fs)
+	var (
+		id, name *string
+		dryRun   *bool
+	)
+	switch sub {
+	case "create":
+		id = fs.String("id", "", "tenant ID: lowercase letters, digits and hyphens (required)")
+		name = fs.String("name", "", "display name (required)")
+		dryRun = dryRunFlag(fs)
+	case "list":
+	default:
+		return fmt.Errorf("unknown subcommand %q: expected create or list", sub)
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := checkOutput(*output); err != nil {
+		return err
+	}
+
+	db := database.Connect()
+	defer db.Close()
+	repo := repository.NewTenantRepository(db, logging.Discard())
+
+	tenants, err := repo.ListTenants(ctx)
+	if err != nil {
+		return err
+	}
+
+	if sub == "create" {
+		switch {
+		case *id == "" || *name == "":
+			return errors.New("-id and -name are required")
+		case !tenant.Valid(*id):
+			return fmt.Errorf("-id must be 1 to %d lowercase letters, digits or hyphens", tenant.MaxIDLength)
+		}
+		out := tenantOutput{ID: *id, Name: *name, CreatedAt: time.Now().UTC(), DryRun: *dryRun}
+		if *dryRun {
+			for _, t := range tenants {
+				if t.ID == *id {
+					return repository.ErrTenantExists
+				}
+			}
+		} else {
+			t, err := repo.CreateTenant(ctx, *id, *name)
+			if err != nil {
+				return err
+			}
+			out.CreatedAt = t.CreatedAt
+		}
+		return render(stdout, *output, out, func(tw *tabwriter.Writer) {
+			fmt.Fprintln(tw, "ID\tNAME")
+			fmt.Fprintf(tw, "%s\t%s\n", out.ID, out.Name)
+			dryRunNote(tw, out.DryRun)
+		})
+	}
+
+	out := make([]tenantOutput, 0, len(tenants))
+	for _, t := range tenants {
+		out = append(out, tenantOutput{ID: t.ID, Name: t.Name, CreatedAt: t.CreatedAt})
+	}
+	return render(stdout, *output, out, func(tw *tabwriter.Writer) {
+		fmt.Fprintln(tw, "ID\tNAME\tCREATED")
+		for _, t := range out {
+			fmt.Fprintf(tw, "%s\t%s\t%s\n", t.ID, t.Name, t.CreatedAt.Format(time.RFC3339))
+		}
+	})
+}
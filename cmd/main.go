@@ -1,23 +1,279 @@
 package main
 
 import (
+	"context"
 	"database/sql"
+	"log/slog"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Andrew44Ashraf/fintech-service/internal/auth"
+	"github.com/Andrew44Ashraf/fintech-service/internal/cache"
 	"github.com/Andrew44Ashraf/fintech-service/internal/database"
+	"github.com/Andrew44Ashraf/fintech-service/internal/grpcapi"
+	"github.com/Andrew44Ashraf/fintech-service/internal/jobs"
+	"github.com/Andrew44Ashraf/fintech-service/internal/logging"
+	"github.com/Andrew44Ashraf/fintech-service/internal/middleware"
+	"github.com/Andrew44Ashraf/fintech-service/internal/ratelimit"
+	"github.com/Andrew44Ashraf/fintech-service/internal/reconciliation"
+	"github.com/Andrew44Ashraf/fintech-service/internal/repository"
 	"github.com/Andrew44Ashraf/fintech-service/internal/routes"
+	"github.com/Andrew44Ashraf/fintech-service/internal/screening"
 	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc"
 )
 
 func main() {
+	// Initialize logger (level from LOG_LEVEL)
+	logger := logging.NewFromEnv()
+	slog.SetDefault(logger)
+
 	// Initialize DB
 	db := database.Connect()
 	defer db.Close()
+	repository.SetRetryPolicy(retryPolicyFromEnv())
+
+	// Create router with structured access log instead of gin's default logger
+	router := gin.New()
+	router.Use(gin.Recovery(), middleware.RequestID(), middleware.AccessLog(logger))
+
+	withdrawalLimits := withdrawalLimitsFromEnv()
+	screener := newScreener(logger)
+
+	// Start background jobs, each run once per tenant
+	ctx, stop := context.WithCancel(context.Background())
+	defer stop()
+	reads := newReadRouter(ctx, db, logger)
+	tenants := repository.NewTenantRepository(db, logger)
+	// Stored statements are immutable, so the job builds them on the primary
+	go jobs.Schedule(ctx, logger, time.Hour,
+		jobs.PerTenant(tenants, jobs.NewMonthlyStatements(repository.NewStatementRepository(db, logger), logger)))
+
+	go jobs.Schedule(ctx, logger, time.Hour,
+		jobs.PerTenant(tenants, jobs.NewMonthlyFees(repository.NewFeeRepository(db, logger), logger)))
+
+	go jobs.Schedule(ctx, logger, time.Hour,
+		jobs.PerTenant(tenants, jobs.NewNightlyReconciliation(repository.NewReconciliationRepository(db, logger), logger)))
 
-	// Create router
-	router := gin.Default()
+	interestRepo := repository.NewInterestRepository(db, logger)
+	go jobs.Schedule(ctx, logger, time.Hour, jobs.PerTenant(tenants, jobs.NewInterestAccrual(interestRepo, logger)))
+	go jobs.Schedule(ctx, logger, time.Hour, jobs.PerTenant(tenants, jobs.NewInterestPosting(interestRepo, logger)))
+
+	balances := newBalanceCache(ctx, logger)
+	accountOpts := []repository.AccountOption{repository.WithAccountReads(reads)}
+	transactionOpts := []repository.TransactionOption{
+		repository.WithWithdrawalLimits(withdrawalLimits),
+		repository.WithScreener(screener),
+		repository.WithTransactionReads(reads),
+	}
+	if balances != nil {
+		accountOpts = append(accountOpts, repository.WithAccountCache(balances))
+		transactionOpts = append(transactionOpts, repository.WithTransactionCache(balances))
+	}
+
+	transactionRepo := repository.NewTransactionRepository(db, logger, transactionOpts...)
+	go jobs.Schedule(ctx, logger, time.Minute,
+		jobs.PerTenant(tenants, jobs.NewScheduledPayments(repository.NewScheduledPaymentRepository(db, logger, transactionRepo), logger)))
+
+	// Both APIs authenticate clients with the same keys, which also give
+	// the tenant a client acts for
+	keys := auth.KeyCheckers{
+		auth.NewStaticKeys(staticAPIKeys()...),
+		repository.NewAPIKeyRepository(db, logger),
+	}
+
+	// The gRPC API shares the repositories with the REST API. It has no
+	// consistency tokens, so its reads are only bounded by REPLICA_MAX_LAG.
+	accountRepo := repository.NewAccountRepository(db, logger, accountOpts...)
+	go serveGRPC(logger, grpcapi.NewServer(accountRepo, transactionRepo, logger, grpcapi.Config{
+		Keys: keys,
+	}))
 
 	// Setup routes
-	routes.SetupRoutes(router, db)
+	routes.SetupRoutes(router, db, logger, routes.Config{
+		Limiter:          newRateLimitStore(db, logger),
+		WithdrawalLimits: withdrawalLimits,
+		Screener:         screener,
+		Keys:             keys,
+		AdminToken:       os.Getenv("ADMIN_API_TOKEN"),
+		BankMatchRules:   bankMatchRules(logger),
+		Reads:            reads,
+		BalanceCache:     balances,
+	})
 
 	// Start server
 	router.Run(":8080")
-}
\ No newline at end of file
+}
+
+// newReadRouter routes reads to the replica at DB_REPLICA_HOST while it is
+// at most REPLICA_MAX_LAG (default 5s) behind the primary. Without a
+// replica every read goes to db.
+func newReadRouter(ctx context.Context, db *sql.DB, logger *slog.Logger) *database.Router {
+	maxLag, err := time.ParseDuration(os.Getenv("REPLICA_MAX_LAG"))
+	if err != nil || maxLag <= 0 {
+		maxLag = 5 * time.Second
+	}
+
+	reads := database.NewRouter(db, database.ConnectReplica(), maxLag, logger)
+	if reads.HasReplica() {
+		go reads.Watch(ctx, time.Second)
+	}
+	return reads
+}
+
+// newBalanceCache selects balance cache storage from BALANCE_CACHE:
+// "memory" keeps BALANCE_CACHE_SIZE (default 10000) balances per replica,
+// "memcached" shares them through the server at MEMCACHED_ADDR. Either is
+// kept current by database notifications. Unset turns caching off.
+func newBalanceCache(ctx context.Context, logger *slog.Logger) cache.Store {
+	var store cache.Store
+	switch kind := os.Getenv("BALANCE_CACHE"); kind {
+	case "":
+		return nil
+	case "memory":
+		size, err := strconv.Atoi(os.Getenv("BALANCE_CACHE_SIZE"))
+		if err != nil || size <= 0 {
+			size = 10000
+		}
+		store = cache.NewLRU(size)
+	case "memcached":
+		addr := os.Getenv("MEMCACHED_ADDR")
+		if addr == "" {
+			logger.Error("BALANCE_CACHE=memcached requires MEMCACHED_ADDR")
+			os.Exit(1)
+		}
+		store = cache.NewMemcached(addr, 10*time.Minute)
+	default:
+		logger.Error("unknown balance cache", "kind", kind)
+		os.Exit(1)
+	}
+
+	notified := cache.NewNotified(store, logger)
+	go func() {
+		if err := notified.Listen(ctx, database.DSN()); err != nil {
+			logger.Error("balance cache disabled", "error", err.Error())
+		}
+	}()
+	return notified
+}
+
+// staticAPIKeys reads the comma-separated keys of API_KEYS, or of
+// GRPC_API_KEYS, its name from before the REST API took keys. Entries are
+// "tenant:key", or a bare key for the default tenant.
+func staticAPIKeys() []string {
+	keys := os.Getenv("API_KEYS")
+	if keys == "" {
+		keys = os.Getenv("GRPC_API_KEYS")
+	}
+	return strings.Split(keys, ",")
+}
+
+// serveGRPC listens on GRPC_ADDR (default :9090). Clients authenticate with
+// a key from API_KEYS or one created with fintechctl apikeys.
+func serveGRPC(logger *slog.Logger, server *grpc.Server) {
+	addr := os.Getenv("GRPC_ADDR")
+	if addr == "" {
+		addr = ":9090"
+	}
+
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		logger.Error("failed to listen for gRPC", "addr", addr, "error", err.Error())
+		os.Exit(1)
+	}
+	if err := server.Serve(lis); err != nil {
+		logger.Error("gRPC server stopped", "error", err.Error())
+	}
+}
+
+// newRateLimitStore selects limiter storage from RATE_LIMIT_STORE. Use
+// "postgres" when running more than one replica. While the postgres store
+// is unreachable rate-limited requests fail with 503, unless
+// RATE_LIMIT_FAIL_OPEN=true lets them through unlimited.
+func newRateLimitStore(db *sql.DB, logger *slog.Logger) ratelimit.Store {
+	if os.Getenv("RATE_LIMIT_STORE") != "postgres" {
+		return ratelimit.NewMemoryStore()
+	}
+
+	store := ratelimit.NewPostgresStore(db)
+	go func() {
+		for range time.Tick(10 * time.Minute) {
+			if _, err := store.Purge(context.Background()); err != nil {
+				logger.Error("rate limit purge failed", "error", err.Error())
+			}
+		}
+	}()
+	if os.Getenv("RATE_LIMIT_FAIL_OPEN") == "true" {
+		return ratelimit.FailOpen(store, logger)
+	}
+	return store
+}
+
+// withdrawalLimitsFromEnv reads the default withdrawal limits. Unset or
+// invalid values mean unlimited.
+func withdrawalLimitsFromEnv() repository.WithdrawalLimits {
+	envFloat := func(key string) float64 {
+		v, _ := strconv.ParseFloat(os.Getenv(key), 64)
+		return v
+	}
+	count, _ := strconv.Atoi(os.Getenv("WITHDRAWAL_MAX_DAILY_COUNT"))
+
+	return repository.WithdrawalLimits{
+		MaxSingle:     envFloat("WITHDRAWAL_MAX_SINGLE"),
+		MaxDaily:      envFloat("WITHDRAWAL_MAX_DAILY"),
+		MaxMonthly:    envFloat("WITHDRAWAL_MAX_MONTHLY"),
+		MaxDailyCount: count,
+	}
+}
+
+// retryPolicyFromEnv reads how often transactions aborted by serialization
+// failures or deadlocks are retried. Unset or invalid values keep the
+// defaults.
+func retryPolicyFromEnv() repository.RetryPolicy {
+	p := repository.DefaultRetryPolicy
+	if n, err := strconv.Atoi(os.Getenv("TX_MAX_ATTEMPTS")); err == nil && n > 0 {
+		p.MaxAttempts = n
+	}
+	if d, err := time.ParseDuration(os.Getenv("TX_RETRY_BASE_DELAY")); err == nil && d >= 0 {
+		p.BaseDelay = d
+	}
+	if d, err := time.ParseDuration(os.Getenv("TX_RETRY_MAX_DELAY")); err == nil && d >= 0 {
+		p.MaxDelay = d
+	}
+	return p
+}
+
+// newScreener loads fraud screening rules from SCREENING_RULES_FILE.
+// Without a rules file every transaction is allowed.
+func newScreener(logger *slog.Logger) screening.Screener {
+	path := os.Getenv("SCREENING_RULES_FILE")
+	if path == "" {
+		return screening.AllowAll{}
+	}
+
+	engine, err := screening.LoadRules(path)
+	if err != nil {
+		logger.Error("failed to load screening rules", "path", path, "error", err.Error())
+		os.Exit(1)
+	}
+	return engine
+}
+
+// bankMatchRules loads bank file matching rules from BANK_MATCH_RULES_FILE.
+// Without a rules file the default rules apply.
+func bankMatchRules(logger *slog.Logger) []reconciliation.MatchRule {
+	path := os.Getenv("BANK_MATCH_RULES_FILE")
+	if path == "" {
+		return reconciliation.DefaultRules
+	}
+
+	rules, err := reconciliation.LoadRules(path)
+	if err != nil {
+		logger.Error("failed to load bank match rules", "path", path, "error", err.Error())
+		os.Exit(1)
+	}
+	return rules
+}
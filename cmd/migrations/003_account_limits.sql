@@ -0,0 +1,11 @@
+-- Per-account overrides of the configured withdrawal limits. NULL keeps the default.
+CREATE TABLE IF NOT EXISTS account_limits (
+    account_id INTEGER PRIMARY KEY REFERENCES accounts(id),
+    max_single_withdrawal DECIMAL(15,2) CHECK (max_single_withdrawal >= 0),
+    max_daily_withdrawal DECIMAL(15,2) CHECK (max_daily_withdrawal >= 0),
+    max_monthly_withdrawal DECIMAL(15,2) CHECK (max_monthly_withdrawal >= 0),
+    max_daily_withdrawals INTEGER CHECK (max_daily_withdrawals >= 0),
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_transactions_account_type_created ON transactions(account_id, type, created_at);
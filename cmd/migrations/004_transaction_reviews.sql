@@ -0,0 +1,15 @@
+ALTER TABLE transactions
+    ADD COLUMN status VARCHAR(20) NOT NULL DEFAULT 'posted'
+    CHECK (status IN ('posted', 'pending_review', 'rejected'));
+
+CREATE TABLE IF NOT EXISTS transaction_reviews (
+    transaction_id INTEGER PRIMARY KEY REFERENCES transactions(id),
+    reasons TEXT[] NOT NULL,
+    decision VARCHAR(10) CHECK (decision IN ('approved', 'rejected')),
+    decided_by VARCHAR(100),
+    note TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    decided_at TIMESTAMP
+);
+
+CREATE INDEX idx_transaction_reviews_pending ON transaction_reviews(created_at) WHERE decision IS NULL;
@@ -0,0 +1,23 @@
+CREATE TABLE IF NOT EXISTS statements (
+    id SERIAL PRIMARY KEY,
+    account_id INTEGER NOT NULL REFERENCES accounts(id),
+    period_start TIMESTAMP NOT NULL,
+    period_end TIMESTAMP NOT NULL,
+    opening_balance DECIMAL(15,2) NOT NULL,
+    closing_balance DECIMAL(15,2) NOT NULL,
+    payload JSONB NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (account_id, period_start, period_end),
+    CHECK (period_start < period_end)
+);
+
+-- Generated statements are immutable
+CREATE OR REPLACE FUNCTION forbid_statement_changes() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'statements are immutable';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER statements_immutable
+    BEFORE UPDATE OR DELETE ON statements
+    FOR EACH ROW EXECUTE FUNCTION forbid_statement_changes();
@@ -0,0 +1,36 @@
+-- Transfers are booked as a transfer_out leg on the source account and a
+-- transfer_in leg on the destination, each pointing at the other account.
+ALTER TABLE transactions ALTER COLUMN type TYPE VARCHAR(20);
+ALTER TABLE transactions DROP CONSTRAINT IF EXISTS transactions_type_check;
+ALTER TABLE transactions ADD CONSTRAINT transactions_type_check
+    CHECK (type IN ('deposit', 'withdrawal', 'transfer_in', 'transfer_out'));
+ALTER TABLE transactions ADD COLUMN counterparty_account_id INTEGER REFERENCES accounts(id);
+
+CREATE TABLE IF NOT EXISTS batches (
+    id SERIAL PRIMARY KEY,
+    mode VARCHAR(20) NOT NULL CHECK (mode IN ('all_or_nothing', 'best_effort')),
+    dry_run BOOLEAN NOT NULL DEFAULT FALSE,
+    status VARCHAR(20) NOT NULL DEFAULT 'processing'
+        CHECK (status IN ('processing', 'completed', 'partially_completed', 'failed')),
+    total_rows INTEGER NOT NULL,
+    succeeded_rows INTEGER NOT NULL DEFAULT 0,
+    failed_rows INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    completed_at TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS batch_rows (
+    batch_id INTEGER NOT NULL REFERENCES batches(id),
+    row_number INTEGER NOT NULL,
+    type VARCHAR(20) NOT NULL,
+    account_id INTEGER NOT NULL,
+    to_account_id INTEGER,
+    amount DECIMAL(15,2) NOT NULL,
+    reference VARCHAR(100),
+    status VARCHAR(20) NOT NULL
+        CHECK (status IN ('posted', 'held', 'failed', 'rolled_back', 'would_post', 'would_hold')),
+    transaction_id INTEGER REFERENCES transactions(id),
+    error_code VARCHAR(50),
+    error_message TEXT,
+    PRIMARY KEY (batch_id, row_number)
+);
@@ -0,0 +1,45 @@
+-- Standing orders. A payment with to_account_id is a transfer, without it a
+-- withdrawal. It repeats by cron_expr or by interval_count interval_units;
+-- with neither it runs once at start_at. due_at is the occurrence being
+-- executed and next_run_at when the worker should (re)try it; it is NULL
+-- once the payment has ended.
+CREATE TABLE IF NOT EXISTS scheduled_payments (
+    id SERIAL PRIMARY KEY,
+    account_id INTEGER NOT NULL REFERENCES accounts(id),
+    to_account_id INTEGER REFERENCES accounts(id),
+    amount DECIMAL(15,2) NOT NULL CHECK (amount > 0),
+    description VARCHAR(140),
+    cron_expr VARCHAR(100),
+    interval_unit VARCHAR(10) CHECK (interval_unit IN ('day', 'week', 'month')),
+    interval_count INTEGER CHECK (interval_count > 0),
+    start_at TIMESTAMP NOT NULL,
+    end_at TIMESTAMP,
+    due_at TIMESTAMP,
+    next_run_at TIMESTAMP,
+    on_insufficient_funds VARCHAR(10) NOT NULL DEFAULT 'skip'
+        CHECK (on_insufficient_funds IN ('skip', 'retry')),
+    max_retries INTEGER NOT NULL DEFAULT 3 CHECK (max_retries >= 0),
+    retry_count INTEGER NOT NULL DEFAULT 0,
+    status VARCHAR(10) NOT NULL DEFAULT 'active'
+        CHECK (status IN ('active', 'paused', 'completed', 'cancelled')),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CHECK (cron_expr IS NULL OR interval_unit IS NULL),
+    CHECK ((interval_unit IS NULL) = (interval_count IS NULL)),
+    CHECK (to_account_id IS NULL OR to_account_id <> account_id)
+);
+
+CREATE INDEX idx_scheduled_payments_due ON scheduled_payments(next_run_at) WHERE status = 'active';
+CREATE INDEX idx_scheduled_payments_account ON scheduled_payments(account_id);
+
+CREATE TABLE IF NOT EXISTS scheduled_payment_runs (
+    id SERIAL PRIMARY KEY,
+    scheduled_payment_id INTEGER NOT NULL REFERENCES scheduled_payments(id),
+    due_at TIMESTAMP NOT NULL,
+    attempt INTEGER NOT NULL,
+    outcome VARCHAR(10) NOT NULL CHECK (outcome IN ('posted', 'held', 'skipped', 'retrying', 'failed')),
+    transaction_id INTEGER REFERENCES transactions(id),
+    error_code VARCHAR(50),
+    run_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (scheduled_payment_id, due_at, attempt)
+);
@@ -0,0 +1,50 @@
+-- Account products carry the interest configuration. Rates are banded: each
+-- slice of the balance above min_balance earns that tier's APR (percent).
+CREATE TABLE IF NOT EXISTS account_products (
+    code VARCHAR(20) PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    day_count VARCHAR(10) NOT NULL DEFAULT 'ACT/365'
+        CHECK (day_count IN ('ACT/365', 'ACT/360', 'ACT/ACT', '30/360')),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS interest_rate_tiers (
+    product_code VARCHAR(20) NOT NULL REFERENCES account_products(code),
+    min_balance DECIMAL(15,2) NOT NULL CHECK (min_balance >= 0),
+    apr DECIMAL(7,4) NOT NULL CHECK (apr >= 0),
+    PRIMARY KEY (product_code, min_balance)
+);
+
+INSERT INTO account_products (code, name, day_count) VALUES
+    ('checking', 'Checking', 'ACT/365'),
+    ('savings', 'Savings', 'ACT/365')
+ON CONFLICT DO NOTHING;
+
+INSERT INTO interest_rate_tiers (product_code, min_balance, apr) VALUES
+    ('savings', 0, 1.0000),
+    ('savings', 10000, 2.0000),
+    ('savings', 50000, 2.5000)
+ON CONFLICT DO NOTHING;
+
+ALTER TABLE accounts ADD COLUMN product_code VARCHAR(20) NOT NULL DEFAULT 'checking'
+    REFERENCES account_products(code);
+
+ALTER TABLE transactions DROP CONSTRAINT IF EXISTS transactions_type_check;
+ALTER TABLE transactions ADD CONSTRAINT transactions_type_check
+    CHECK (type IN ('deposit', 'withdrawal', 'transfer_in', 'transfer_out', 'interest'));
+
+-- One row per account and day. Amounts keep full precision; posting rounds
+-- the sum to cents and leaves sub-cent totals for the next posting.
+CREATE TABLE IF NOT EXISTS interest_accruals (
+    account_id INTEGER NOT NULL REFERENCES accounts(id),
+    accrual_date DATE NOT NULL,
+    product_code VARCHAR(20) NOT NULL REFERENCES account_products(code),
+    balance DECIMAL(15,2) NOT NULL,
+    effective_apr DECIMAL(9,6) NOT NULL,
+    amount DECIMAL(20,10) NOT NULL CHECK (amount >= 0),
+    posted_transaction_id INTEGER REFERENCES transactions(id),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (account_id, accrual_date)
+);
+
+CREATE INDEX idx_interest_accruals_unposted ON interest_accruals(account_id) WHERE posted_transaction_id IS NULL;
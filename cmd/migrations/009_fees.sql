@@ -0,0 +1,91 @@
+-- Fee schedule per account product. Zero amounts disable a fee.
+CREATE TABLE IF NOT EXISTS fee_schedules (
+    product_code VARCHAR(20) PRIMARY KEY REFERENCES account_products(code),
+    withdrawal_fee DECIMAL(15,2) NOT NULL DEFAULT 0 CHECK (withdrawal_fee >= 0),
+    maintenance_fee DECIMAL(15,2) NOT NULL DEFAULT 0 CHECK (maintenance_fee >= 0),
+    low_balance_threshold DECIMAL(15,2) NOT NULL DEFAULT 0 CHECK (low_balance_threshold >= 0),
+    low_balance_fee DECIMAL(15,2) NOT NULL DEFAULT 0 CHECK (low_balance_fee >= 0),
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT INTO fee_schedules (product_code, withdrawal_fee, maintenance_fee, low_balance_threshold, low_balance_fee) VALUES
+    ('checking', 0, 0, 0, 0),
+    ('savings', 1.00, 0, 100.00, 2.00)
+ON CONFLICT DO NOTHING;
+
+-- Fees are booked as their own transactions. related_transaction_id points
+-- a withdrawal fee at its withdrawal and a reversal at the fee it refunds.
+ALTER TABLE transactions DROP CONSTRAINT IF EXISTS transactions_type_check;
+ALTER TABLE transactions ADD CONSTRAINT transactions_type_check
+    CHECK (type IN ('deposit', 'withdrawal', 'transfer_in', 'transfer_out', 'interest', 'fee', 'fee_reversal'));
+ALTER TABLE transactions ADD COLUMN fee_type VARCHAR(20)
+    CHECK (fee_type IN ('withdrawal', 'maintenance', 'low_balance'));
+ALTER TABLE transactions ADD COLUMN related_transaction_id INTEGER REFERENCES transactions(id);
+
+CREATE UNIQUE INDEX idx_transactions_fee_reversal ON transactions(related_transaction_id)
+    WHERE type = 'fee_reversal';
+
+CREATE TABLE IF NOT EXISTS fee_waivers (
+    id SERIAL PRIMARY KEY,
+    account_id INTEGER NOT NULL REFERENCES accounts(id),
+    fee_type VARCHAR(20) NOT NULL CHECK (fee_type IN ('withdrawal', 'maintenance', 'low_balance')),
+    starts_at TIMESTAMP NOT NULL,
+    ends_at TIMESTAMP,
+    reason TEXT NOT NULL,
+    granted_by VARCHAR(100) NOT NULL,
+    revoked_by VARCHAR(100),
+    revoked_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CHECK (ends_at IS NULL OR ends_at > starts_at)
+);
+
+CREATE INDEX idx_fee_waivers_account ON fee_waivers(account_id, fee_type) WHERE revoked_at IS NULL;
+
+-- One run per account and month: a rerun of the monthly job finds the row
+-- and charges nothing. Each fee due in the run is listed in
+-- monthly_fee_charges; a waived fee keeps the waiver instead of a
+-- transaction.
+CREATE TABLE IF NOT EXISTS monthly_fee_runs (
+    account_id INTEGER NOT NULL REFERENCES accounts(id),
+    period_start DATE NOT NULL,
+    lowest_balance DECIMAL(15,2) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (account_id, period_start)
+);
+
+CREATE TABLE IF NOT EXISTS monthly_fee_charges (
+    account_id INTEGER NOT NULL,
+    period_start DATE NOT NULL,
+    fee_type VARCHAR(20) NOT NULL CHECK (fee_type IN ('maintenance', 'low_balance')),
+    amount DECIMAL(15,2) NOT NULL,
+    transaction_id INTEGER REFERENCES transactions(id),
+    waiver_id INTEGER REFERENCES fee_waivers(id),
+    PRIMARY KEY (account_id, period_start, fee_type),
+    FOREIGN KEY (account_id, period_start) REFERENCES monthly_fee_runs(account_id, period_start)
+);
+
+-- Operator actions on fees. Rows are never updated or deleted.
+CREATE TABLE IF NOT EXISTS fee_audit_log (
+    id SERIAL PRIMARY KEY,
+    account_id INTEGER NOT NULL REFERENCES accounts(id),
+    action VARCHAR(20) NOT NULL CHECK (action IN ('waiver_granted', 'waiver_revoked', 'fee_reversed')),
+    fee_type VARCHAR(20),
+    waiver_id INTEGER REFERENCES fee_waivers(id),
+    transaction_id INTEGER REFERENCES transactions(id),
+    amount DECIMAL(15,2),
+    operator VARCHAR(100) NOT NULL,
+    reason TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_fee_audit_log_account ON fee_audit_log(account_id, created_at);
+
+CREATE OR REPLACE FUNCTION forbid_fee_audit_changes() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'fee audit log is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER fee_audit_log_immutable
+    BEFORE UPDATE OR DELETE ON fee_audit_log
+    FOR EACH ROW EXECUTE FUNCTION forbid_fee_audit_changes();
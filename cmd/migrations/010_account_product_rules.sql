@@ -0,0 +1,29 @@
+-- Product rules. Limits left NULL fall back to the service defaults; a
+-- per-account row in account_limits still overrides both. Products with an
+-- overdraft limit let the balance go that far below zero.
+ALTER TABLE account_products
+    ADD COLUMN allowed_transaction_types TEXT[] NOT NULL
+        DEFAULT '{deposit,withdrawal,transfer_in,transfer_out}',
+    ADD COLUMN min_balance DECIMAL(15,2) NOT NULL DEFAULT 0 CHECK (min_balance >= 0),
+    ADD COLUMN overdraft_limit DECIMAL(15,2) NOT NULL DEFAULT 0 CHECK (overdraft_limit >= 0),
+    ADD COLUMN max_single_withdrawal DECIMAL(15,2),
+    ADD COLUMN max_daily_withdrawal DECIMAL(15,2),
+    ADD COLUMN max_monthly_withdrawal DECIMAL(15,2),
+    ADD COLUMN max_daily_withdrawals INTEGER,
+    ADD COLUMN currency CHAR(3) NOT NULL DEFAULT 'USD';
+
+UPDATE account_products SET overdraft_limit = 250.00 WHERE code = 'checking';
+UPDATE account_products SET max_daily_withdrawals = 6 WHERE code = 'savings';
+
+INSERT INTO account_products (code, name, allowed_transaction_types, max_single_withdrawal, max_daily_withdrawal) VALUES
+    ('escrow', 'Escrow', '{deposit,transfer_in,transfer_out}', NULL, NULL),
+    ('wallet', 'Wallet', '{deposit,withdrawal,transfer_in,transfer_out}', 1000.00, 2000.00)
+ON CONFLICT DO NOTHING;
+
+-- Currency and overdraft are fixed when the account is opened, so the
+-- balance floor can be enforced by the database as well.
+ALTER TABLE accounts
+    ADD COLUMN currency CHAR(3) NOT NULL DEFAULT 'USD',
+    ADD COLUMN overdraft_limit DECIMAL(15,2) NOT NULL DEFAULT 0 CHECK (overdraft_limit >= 0);
+ALTER TABLE accounts DROP CONSTRAINT IF EXISTS accounts_balance_check;
+ALTER TABLE accounts ADD CONSTRAINT accounts_balance_check CHECK (balance >= -overdraft_limit);
@@ -0,0 +1,5 @@
+-- Point-in-time balances look up the last posted transaction at or before
+-- an instant. The (account_id, created_at, id) index serves that lookup
+-- and supersedes the account_id index.
+CREATE INDEX IF NOT EXISTS idx_transactions_account_created_id ON transactions(account_id, created_at, id);
+DROP INDEX IF EXISTS idx_transactions_account_id;
@@ -0,0 +1,51 @@
+-- The journal of an account is its opening balance plus its posted
+-- transactions. Existing accounts are backfilled from the current ledger,
+-- so drift from before this migration cannot be detected.
+ALTER TABLE accounts ADD COLUMN opening_balance DECIMAL(15,2) NOT NULL DEFAULT 0;
+
+UPDATE accounts a SET opening_balance = a.balance - COALESCE(
+    (SELECT SUM(CASE WHEN t.type IN ('deposit', 'transfer_in', 'interest', 'fee_reversal')
+                     THEN t.amount ELSE -t.amount END)
+     FROM transactions t
+     WHERE t.account_id = a.id AND t.status = 'posted'), 0);
+
+-- One row per reconciliation. Scheduled runs happen once per UTC day;
+-- operators may start manual runs at any time.
+CREATE TABLE IF NOT EXISTS reconciliation_runs (
+    id SERIAL PRIMARY KEY,
+    run_date DATE NOT NULL,
+    trigger VARCHAR(10) NOT NULL CHECK (trigger IN ('scheduled', 'manual')),
+    accounts_checked INTEGER NOT NULL DEFAULT 0,
+    break_count INTEGER NOT NULL DEFAULT 0,
+    started_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    finished_at TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_reconciliation_runs_scheduled
+    ON reconciliation_runs(run_date) WHERE trigger = 'scheduled';
+
+-- Ledger balance of every account as seen by the scheduled run of the day.
+CREATE TABLE IF NOT EXISTS balance_snapshots (
+    account_id INTEGER NOT NULL REFERENCES accounts(id),
+    snapshot_date DATE NOT NULL,
+    balance DECIMAL(15,2) NOT NULL,
+    journal_balance DECIMAL(15,2) NOT NULL,
+    last_transaction_id INTEGER REFERENCES transactions(id),
+    run_id INTEGER NOT NULL REFERENCES reconciliation_runs(id),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (account_id, snapshot_date)
+);
+
+CREATE TABLE IF NOT EXISTS reconciliation_breaks (
+    id SERIAL PRIMARY KEY,
+    run_id INTEGER NOT NULL REFERENCES reconciliation_runs(id),
+    account_id INTEGER NOT NULL REFERENCES accounts(id),
+    kind VARCHAR(30) NOT NULL CHECK (kind IN ('journal_mismatch', 'final_balance_mismatch')),
+    ledger_balance DECIMAL(15,2) NOT NULL,
+    expected_balance DECIMAL(15,2) NOT NULL,
+    difference DECIMAL(15,2) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_reconciliation_breaks_run ON reconciliation_breaks(run_id);
+CREATE INDEX IF NOT EXISTS idx_reconciliation_breaks_account ON reconciliation_breaks(account_id, created_at);
@@ -0,0 +1,44 @@
+-- Files of cash movements from the settlement bank. The checksum stops the
+-- same file from being imported twice.
+CREATE TABLE IF NOT EXISTS bank_statements (
+    id SERIAL PRIMARY KEY,
+    format VARCHAR(10) NOT NULL CHECK (format IN ('csv', 'camt053')),
+    file_name VARCHAR(255) NOT NULL DEFAULT '',
+    checksum CHAR(64) NOT NULL UNIQUE,
+    period_start DATE NOT NULL,
+    period_end DATE NOT NULL,
+    line_count INTEGER NOT NULL,
+    imported_by VARCHAR(100) NOT NULL,
+    imported_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CHECK (period_end >= period_start)
+);
+
+-- Amounts are signed: credits to the settlement account are positive.
+CREATE TABLE IF NOT EXISTS bank_statement_lines (
+    id SERIAL PRIMARY KEY,
+    statement_id INTEGER NOT NULL REFERENCES bank_statements(id),
+    line_no INTEGER NOT NULL,
+    reference VARCHAR(140) NOT NULL DEFAULT '',
+    amount DECIMAL(15,2) NOT NULL,
+    booking_date DATE NOT NULL,
+    value_date DATE NOT NULL,
+    description VARCHAR(500) NOT NULL DEFAULT '',
+    UNIQUE (statement_id, line_no)
+);
+
+-- A bank line and a transaction are each matched at most once. Unmatching
+-- keeps the row for the audit trail.
+CREATE TABLE IF NOT EXISTS bank_matches (
+    id SERIAL PRIMARY KEY,
+    line_id INTEGER NOT NULL REFERENCES bank_statement_lines(id),
+    transaction_id INTEGER NOT NULL REFERENCES transactions(id),
+    rule VARCHAR(50) NOT NULL,
+    matched_by VARCHAR(100) NOT NULL,
+    matched_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    unmatched_by VARCHAR(100),
+    unmatch_reason VARCHAR(500),
+    unmatched_at TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_bank_matches_line ON bank_matches(line_id) WHERE unmatched_at IS NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_bank_matches_transaction ON bank_matches(transaction_id) WHERE unmatched_at IS NULL;
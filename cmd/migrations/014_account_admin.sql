@@ -0,0 +1,54 @@
+-- Operators can freeze an account: it keeps its balance and history but
+-- accepts no postings until it is unfrozen.
+ALTER TABLE accounts DROP CONSTRAINT IF EXISTS accounts_status_check;
+ALTER TABLE accounts ADD CONSTRAINT accounts_status_check
+    CHECK (status IN ('active', 'frozen', 'closed'));
+
+-- Every status change with the operator's reason. Rows are never updated
+-- or deleted.
+CREATE TABLE IF NOT EXISTS account_status_changes (
+    id SERIAL PRIMARY KEY,
+    account_id INTEGER NOT NULL REFERENCES accounts(id),
+    from_status VARCHAR(10) NOT NULL,
+    to_status VARCHAR(10) NOT NULL,
+    operator VARCHAR(100) NOT NULL,
+    reason TEXT NOT NULL,
+    changed_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_account_status_changes_account ON account_status_changes(account_id, changed_at);
+
+-- Manual corrections. A reversal is an adjustment of the opposite sign
+-- whose related_transaction_id is the transaction it undoes; a transaction
+-- can be reversed once.
+ALTER TABLE transactions DROP CONSTRAINT IF EXISTS transactions_type_check;
+ALTER TABLE transactions ADD CONSTRAINT transactions_type_check
+    CHECK (type IN ('deposit', 'withdrawal', 'transfer_in', 'transfer_out', 'interest', 'fee', 'fee_reversal',
+                    'adjustment_credit', 'adjustment_debit'));
+
+CREATE UNIQUE INDEX idx_transactions_reversal ON transactions(related_transaction_id)
+    WHERE type IN ('adjustment_credit', 'adjustment_debit');
+
+CREATE TABLE IF NOT EXISTS adjustments (
+    transaction_id INTEGER PRIMARY KEY REFERENCES transactions(id),
+    account_id INTEGER NOT NULL REFERENCES accounts(id),
+    reversed_transaction_id INTEGER REFERENCES transactions(id),
+    operator VARCHAR(100) NOT NULL,
+    reason TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_adjustments_account ON adjustments(account_id, created_at);
+
+-- API keys for the gRPC API. Only the SHA-256 of a key is stored; prefix
+-- is its first characters so operators can tell keys apart.
+CREATE TABLE IF NOT EXISTS api_keys (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    prefix VARCHAR(12) NOT NULL,
+    key_hash BYTEA NOT NULL UNIQUE,
+    created_by VARCHAR(100) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    revoked_by VARCHAR(100),
+    revoked_at TIMESTAMP
+);
@@ -0,0 +1,31 @@
+-- version counts changes to an account for optimistic concurrency and
+-- ETags. The trigger bumps it on every update of the row, whichever code
+-- path writes it, and changes to the account's limit overrides bump it
+-- too.
+ALTER TABLE accounts ADD COLUMN IF NOT EXISTS version BIGINT NOT NULL DEFAULT 1;
+
+CREATE OR REPLACE FUNCTION bump_account_version() RETURNS trigger AS $$
+BEGIN
+    NEW.version := OLD.version + 1;
+    NEW.updated_at := CURRENT_TIMESTAMP;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS accounts_version ON accounts;
+CREATE TRIGGER accounts_version
+    BEFORE UPDATE ON accounts
+    FOR EACH ROW EXECUTE FUNCTION bump_account_version();
+
+CREATE OR REPLACE FUNCTION touch_account() RETURNS trigger AS $$
+BEGIN
+    UPDATE accounts SET version = version
+     WHERE id = CASE WHEN TG_OP = 'DELETE' THEN OLD.account_id ELSE NEW.account_id END;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS account_limits_version ON account_limits;
+CREATE TRIGGER account_limits_version
+    AFTER INSERT OR UPDATE OR DELETE ON account_limits
+    FOR EACH ROW EXECUTE FUNCTION touch_account();
@@ -0,0 +1,19 @@
+-- Integrators attach their own reference, a label and free-form JSON
+-- metadata to accounts and transactions. external_ref is a natural dedup
+-- key: unique across accounts, and per account across its transactions.
+-- Metadata is a JSON object of at most 4 KiB.
+ALTER TABLE accounts ADD COLUMN IF NOT EXISTS external_ref VARCHAR(64);
+ALTER TABLE accounts ADD COLUMN IF NOT EXISTS description VARCHAR(255);
+ALTER TABLE accounts ADD COLUMN IF NOT EXISTS metadata JSONB NOT NULL DEFAULT '{}'
+    CHECK (jsonb_typeof(metadata) = 'object' AND octet_length(metadata::text) <= 4096);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_accounts_external_ref ON accounts(external_ref)
+    WHERE external_ref IS NOT NULL;
+
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS external_ref VARCHAR(64);
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS description VARCHAR(255);
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS metadata JSONB NOT NULL DEFAULT '{}'
+    CHECK (jsonb_typeof(metadata) = 'object' AND octet_length(metadata::text) <= 4096);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_transactions_external_ref ON transactions(account_id, external_ref)
+    WHERE external_ref IS NOT NULL;
@@ -0,0 +1,33 @@
+-- Accounts and transactions get opaque public IDs (acc_/tx_ followed by
+-- the hex of a random UUID) and accounts an IBAN-style account number,
+-- so the SERIAL keys never leave the service. See internal/ids.
+ALTER TABLE accounts ADD COLUMN IF NOT EXISTS public_id VARCHAR(40) NOT NULL
+    DEFAULT 'acc_' || replace(gen_random_uuid()::text, '-', '');
+CREATE UNIQUE INDEX IF NOT EXISTS idx_accounts_public_id ON accounts(public_id);
+
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS public_id VARCHAR(40) NOT NULL
+    DEFAULT 'tx_' || replace(gen_random_uuid()::text, '-', '');
+CREATE UNIQUE INDEX IF NOT EXISTS idx_transactions_public_id ON transactions(public_id);
+
+-- new_account_number draws 16 random digits and prefixes them with FT and
+-- the ISO 13616 check digits: 98 minus the remainder mod 97 of the digits
+-- followed by FT00, with F = 15 and T = 29.
+CREATE OR REPLACE FUNCTION new_account_number() RETURNS VARCHAR AS $$
+DECLARE
+    bban TEXT;
+    n    VARCHAR;
+BEGIN
+    LOOP
+        bban := lpad(floor(random() * 1e16)::bigint::text, 16, '0');
+        n := 'FT' || lpad((98 - (bban || '152900')::numeric % 97)::text, 2, '0') || bban;
+        EXIT WHEN NOT EXISTS (SELECT 1 FROM accounts WHERE account_number = n);
+    END LOOP;
+    RETURN n;
+END;
+$$ LANGUAGE plpgsql;
+
+ALTER TABLE accounts ADD COLUMN IF NOT EXISTS account_number VARCHAR(34);
+UPDATE accounts SET account_number = new_account_number() WHERE account_number IS NULL;
+ALTER TABLE accounts ALTER COLUMN account_number SET DEFAULT new_account_number();
+ALTER TABLE accounts ALTER COLUMN account_number SET NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_accounts_account_number ON accounts(account_number);
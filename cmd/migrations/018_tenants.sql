@@ -0,0 +1,66 @@
+-- Tenants own accounts and everything recorded about them. Every tenant
+-- table has a tenant_id that defaults to the app.tenant_id setting, which
+-- the service sets on each connection to the tenant of the caller (see
+-- internal/database/tenant.go), and a row-level security policy that hides
+-- the rows of other tenants. FORCE applies the policies to the table owner
+-- too; only superusers and BYPASSRLS roles are exempt, so the service must
+-- not connect as one.
+CREATE TABLE IF NOT EXISTS tenants (
+    id VARCHAR(40) PRIMARY KEY CHECK (id ~ '^[a-z0-9][a-z0-9-]*$'),
+    name VARCHAR(100) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Data from before tenants belongs to the default tenant.
+INSERT INTO tenants (id, name) VALUES ('default', 'Default') ON CONFLICT (id) DO NOTHING;
+
+-- enable_tenant_isolation adds tenant_id to a table, assigning existing
+-- rows to the default tenant, and turns on its tenant policy. Tables added
+-- by later migrations call it as well unless they are global, like
+-- account_products or rate_limit_buckets.
+--
+-- Without a tenant the setting is empty: reads see nothing and inserts
+-- fail the reference to tenants.
+CREATE OR REPLACE FUNCTION enable_tenant_isolation(t regclass) RETURNS void AS $$
+BEGIN
+    EXECUTE format('ALTER TABLE %s ADD COLUMN IF NOT EXISTS tenant_id VARCHAR(40) NOT NULL DEFAULT %L REFERENCES tenants(id)', t, 'default');
+    EXECUTE format('ALTER TABLE %s ALTER COLUMN tenant_id SET DEFAULT current_setting(%L)', t, 'app.tenant_id');
+    EXECUTE format('ALTER TABLE %s ENABLE ROW LEVEL SECURITY', t);
+    EXECUTE format('ALTER TABLE %s FORCE ROW LEVEL SECURITY', t);
+    EXECUTE format('DROP POLICY IF EXISTS tenant_isolation ON %s', t);
+    EXECUTE format('CREATE POLICY tenant_isolation ON %s
+                        USING (tenant_id = current_setting(%L, true))
+                        WITH CHECK (tenant_id = current_setting(%L, true))', t, 'app.tenant_id', 'app.tenant_id');
+END;
+$$ LANGUAGE plpgsql;
+
+SELECT enable_tenant_isolation(t) FROM unnest(ARRAY[
+    'accounts', 'transactions', 'account_limits', 'transaction_reviews', 'statements',
+    'batches', 'batch_rows', 'scheduled_payments', 'scheduled_payment_runs',
+    'interest_accruals', 'fee_waivers', 'monthly_fee_runs', 'monthly_fee_charges', 'fee_audit_log',
+    'reconciliation_runs', 'balance_snapshots', 'reconciliation_breaks',
+    'bank_statements', 'bank_statement_lines', 'bank_matches',
+    'account_status_changes', 'adjustments'
+]::regclass[]) AS t;
+
+CREATE INDEX IF NOT EXISTS idx_accounts_tenant ON accounts(tenant_id);
+
+-- Natural keys are unique per tenant. public_id and account_number stay
+-- globally unique; new_account_number only sees the current tenant's
+-- numbers, but 16 random digits make a clash with another tenant's
+-- negligible, and the unique index would reject it.
+DROP INDEX IF EXISTS idx_accounts_external_ref;
+CREATE UNIQUE INDEX idx_accounts_external_ref ON accounts(tenant_id, external_ref)
+    WHERE external_ref IS NOT NULL;
+
+DROP INDEX IF EXISTS idx_reconciliation_runs_scheduled;
+CREATE UNIQUE INDEX idx_reconciliation_runs_scheduled
+    ON reconciliation_runs(tenant_id, run_date) WHERE trigger = 'scheduled';
+
+ALTER TABLE bank_statements DROP CONSTRAINT IF EXISTS bank_statements_checksum_key;
+ALTER TABLE bank_statements ADD CONSTRAINT bank_statements_checksum_key UNIQUE (tenant_id, checksum);
+
+-- API keys are looked up before the tenant is known, so they have no
+-- policy; the repository scopes listing and revoking to the tenant.
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS tenant_id VARCHAR(40) NOT NULL DEFAULT 'default' REFERENCES tenants(id);
+ALTER TABLE api_keys ALTER COLUMN tenant_id SET DEFAULT current_setting('app.tenant_id');
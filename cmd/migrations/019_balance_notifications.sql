@@ -0,0 +1,22 @@
+-- Committed balance changes are announced on the account_balances channel
+-- so that every service replica can update its balance cache, whichever
+-- code path or process made the change. Postgres delivers notifications
+-- only when the transaction commits. The account version orders them: a
+-- cache keeps the entry with the highest version.
+CREATE OR REPLACE FUNCTION notify_account_balance() RETURNS trigger AS $$
+BEGIN
+    PERFORM pg_notify('account_balances', json_build_object(
+        'tenant_id', NEW.tenant_id,
+        'account_id', NEW.id,
+        'balance', NEW.balance,
+        'version', NEW.version
+    )::text);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS accounts_balance_notify ON accounts;
+CREATE TRIGGER accounts_balance_notify
+    AFTER UPDATE ON accounts
+    FOR EACH ROW WHEN (OLD.balance IS DISTINCT FROM NEW.balance)
+    EXECUTE FUNCTION notify_account_balance();
@@ -0,0 +1,57 @@
+-- Incoming bank transfers are recorded as pending when the bank announces
+-- them and become posted or failed when they settle. Pending transactions
+-- show in history but do not change the balance until they are posted.
+ALTER TABLE transactions DROP CONSTRAINT IF EXISTS transactions_status_check;
+ALTER TABLE transactions ADD CONSTRAINT transactions_status_check
+    CHECK (status IN ('posted', 'pending_review', 'rejected', 'pending', 'failed'));
+
+ALTER TABLE transactions DROP CONSTRAINT IF EXISTS transactions_type_check;
+ALTER TABLE transactions ADD CONSTRAINT transactions_type_check
+    CHECK (type IN ('deposit', 'withdrawal', 'transfer_in', 'transfer_out', 'interest', 'fee', 'fee_reversal',
+                    'adjustment_credit', 'adjustment_debit', 'bank_transfer_in'));
+
+-- Products that take deposits take incoming bank transfers too.
+ALTER TABLE account_products ALTER COLUMN allowed_transaction_types
+    SET DEFAULT '{deposit,withdrawal,transfer_in,transfer_out,bank_transfer_in}';
+UPDATE account_products
+   SET allowed_transaction_types = array_append(allowed_transaction_types, 'bank_transfer_in')
+ WHERE 'deposit' = ANY(allowed_transaction_types)
+   AND NOT 'bank_transfer_in' = ANY(allowed_transaction_types);
+
+-- posted_at is when a transaction changed the balance and orders postings
+-- for past balances and statements; it is NULL until then. booking_date is
+-- its date. value_date is the date from which the amount counts, which the
+-- sending bank may set earlier or later than the booking date.
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS posted_at TIMESTAMP;
+UPDATE transactions SET posted_at = created_at WHERE status = 'posted' AND posted_at IS NULL;
+
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS booking_date DATE
+    GENERATED ALWAYS AS (posted_at::date) STORED;
+
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS value_date DATE;
+UPDATE transactions SET value_date = created_at::date WHERE value_date IS NULL;
+ALTER TABLE transactions ALTER COLUMN value_date SET DEFAULT CURRENT_DATE;
+ALTER TABLE transactions ALTER COLUMN value_date SET NOT NULL;
+
+-- The trigger stamps posted_at whichever code path posts a transaction,
+-- directly or on leaving pending or pending_review.
+CREATE OR REPLACE FUNCTION stamp_posted_at() RETURNS trigger AS $$
+BEGIN
+    IF NEW.status = 'posted' AND NEW.posted_at IS NULL THEN
+        NEW.posted_at := LOCALTIMESTAMP;
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS transactions_posted_at ON transactions;
+CREATE TRIGGER transactions_posted_at
+    BEFORE INSERT OR UPDATE OF status ON transactions
+    FOR EACH ROW EXECUTE FUNCTION stamp_posted_at();
+
+-- Point-in-time balances and statements look postings up by posted_at.
+-- History still lists transactions by created_at, see 011.
+CREATE INDEX IF NOT EXISTS idx_transactions_account_posted_id ON transactions(account_id, posted_at, id)
+    WHERE status = 'posted';
+
+CREATE INDEX IF NOT EXISTS idx_transactions_pending ON transactions(created_at) WHERE status = 'pending';
@@ -0,0 +1,5 @@
+-- Scheduled payments get opaque public IDs like accounts and transactions
+-- (see 017), so their SERIAL key never leaves the service either.
+ALTER TABLE scheduled_payments ADD COLUMN IF NOT EXISTS public_id VARCHAR(40) NOT NULL
+    DEFAULT 'sp_' || replace(gen_random_uuid()::text, '-', '');
+CREATE UNIQUE INDEX IF NOT EXISTS idx_scheduled_payments_public_id ON scheduled_payments(public_id);
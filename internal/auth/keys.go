@@ -0,0 +1,82 @@
+// Package auth checks the API keys clients of the REST and gRPC APIs
+// authenticate with. A key identifies its tenant.
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"errors"
+	"strings"
+
+	apperrors "github.com/Andrew44Ashraf/fintech-service/internal/errors"
+	"github.com/Andrew44Ashraf/fintech-service/internal/tenant"
+)
+
+// KeyChecker decides whether an API key may call the service and returns
+// the tenant the key belongs to.
+type KeyChecker interface {
+	CheckKey(ctx context.Context, key string) (string, error)
+}
+
+// StaticKeys accepts a fixed set of API keys. An empty set refuses every
+// call, so an API is off until keys are configured.
+type StaticKeys struct {
+	keys []staticKey
+}
+
+type staticKey struct {
+	hash   [sha256.Size]byte
+	tenant string
+}
+
+// NewStaticKeys accepts keys given as "tenant:key", or as a bare key for
+// tenant.Default.
+func NewStaticKeys(keys ...string) *StaticKeys {
+	s := &StaticKeys{}
+	for _, k := range keys {
+		id, key, ok := strings.Cut(k, ":")
+		if !ok {
+			id, key = tenant.Default, k
+		}
+		if key != "" && tenant.Valid(id) {
+			s.keys = append(s.keys, staticKey{hash: sha256.Sum256([]byte(key)), tenant: id})
+		}
+	}
+	return s
+}
+
+// CheckKey compares hashes in constant time and checks every key, so the
+// time taken does not reveal which key was close.
+func (s *StaticKeys) CheckKey(_ context.Context, key string) (string, error) {
+	if len(s.keys) == 0 {
+		return "", apperrors.ErrForbidden
+	}
+	got := sha256.Sum256([]byte(key))
+	var id string
+	for _, k := range s.keys {
+		if subtle.ConstantTimeCompare(got[:], k.hash[:]) == 1 {
+			id = k.tenant
+		}
+	}
+	if id == "" {
+		return "", apperrors.ErrForbidden
+	}
+	return id, nil
+}
+
+// KeyCheckers accepts a key that any of its checkers accepts.
+type KeyCheckers []KeyChecker
+
+func (k KeyCheckers) CheckKey(ctx context.Context, key string) (string, error) {
+	err := apperrors.ErrForbidden
+	for _, c := range k {
+		switch id, cerr := c.CheckKey(ctx, key); {
+		case cerr == nil:
+			return id, nil
+		case !errors.Is(cerr, apperrors.ErrForbidden):
+			err = cerr
+		}
+	}
+	return "", err
+}
@@ -0,0 +1,318 @@
+// Package batches parses and validates bulk posting files. Posting itself
+// is done by the repository so every row goes through the same checks as
+// the single-transaction endpoints.
+package batches
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	apperrors "github.com/Andrew44Ashraf/fintech-service/internal/errors"
+	"github.com/Andrew44Ashraf/fintech-service/internal/ids"
+)
+
+// Input formats
+const (
+	FormatCSV   = "csv"
+	FormatJSONL = "jsonl"
+)
+
+// Processing modes
+const (
+	// ModeAllOrNothing posts every row or none of them.
+	ModeAllOrNothing = "all_or_nothing"
+	// ModeBestEffort posts every row that can be posted and reports the rest.
+	ModeBestEffort = "best_effort"
+)
+
+// Instruction types
+const (
+	TypeDeposit    = "deposit"
+	TypeWithdrawal = "withdrawal"
+	TypeTransfer   = "transfer"
+)
+
+// MaxRows bounds the size of a single batch.
+const MaxRows = 1000
+
+// maxViolations caps the number of row errors reported for one file.
+const maxViolations = 50
+
+// Instruction is one row of a batch file. Row numbers start at 1 and do
+// not count the CSV header. Accounts are named by public ID or account
+// number; Resolve sets the internal IDs they refer to.
+type Instruction struct {
+	Row         int     `json:"-"`
+	Type        string  `json:"type"`
+	Account     string  `json:"account_id"`
+	ToAccount   string  `json:"to_account_id,omitempty"`
+	Amount      float64 `json:"amount"`
+	Reference   string  `json:"reference,omitempty"`
+	AccountID   int     `json:"-"`
+	ToAccountID int     `json:"-"`
+}
+
+// Parse reads a whole batch file and validates every row. Problems are
+// reported together as an *apperrors.ValidationError with fields named
+// after the row, e.g. "rows[3].amount".
+func Parse(r io.Reader, format string) ([]Instruction, error) {
+	var (
+		instructions []Instruction
+		verr         = &apperrors.ValidationError{}
+		err          error
+	)
+	switch format {
+	case FormatCSV:
+		instructions, verr.Fields, err = parseCSV(r)
+	case FormatJSONL:
+		instructions, err = parseJSONL(r)
+	default:
+		return nil, apperrors.Invalid("format", "oneof", "must be one of: csv jsonl")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case len(instructions) == 0:
+		return nil, apperrors.Invalid("file", "required", "contains no rows")
+	case len(instructions) > MaxRows:
+		return nil, apperrors.Invalid("file", "max", fmt.Sprintf("must not contain more than %d rows", MaxRows))
+	}
+
+	// Fields that could not be parsed are already reported
+	reported := map[string]bool{}
+	for _, f := range verr.Fields {
+		reported[f.Field] = true
+	}
+	for _, in := range instructions {
+		for _, f := range Validate(in) {
+			if !reported[f.Field] {
+				verr.Fields = append(verr.Fields, f)
+			}
+		}
+	}
+	if len(verr.Fields) > 0 {
+		if len(verr.Fields) > maxViolations {
+			verr.Fields = verr.Fields[:maxViolations]
+		}
+		return nil, verr
+	}
+	return instructions, nil
+}
+
+// Validate checks a single instruction without touching the database.
+func Validate(in Instruction) []apperrors.FieldViolation {
+	var out []apperrors.FieldViolation
+	add := func(field, rule, message string) {
+		out = append(out, apperrors.FieldViolation{
+			Field:   fmt.Sprintf("rows[%d].%s", in.Row, field),
+			Rule:    rule,
+			Message: message,
+		})
+	}
+
+	switch in.Type {
+	case TypeDeposit, TypeWithdrawal:
+		if in.ToAccount != "" {
+			add("to_account_id", "excluded", "is only allowed for transfers")
+		}
+	case TypeTransfer:
+		switch {
+		case in.ToAccount == "":
+			add("to_account_id", "required", "is required")
+		case !isAccountRef(in.ToAccount):
+			add("to_account_id", "account", "must be an account ID or account number")
+		case in.ToAccount == in.Account:
+			add("to_account_id", "nefield", "must differ from account_id")
+		}
+	default:
+		add("type", "oneof", "must be one of: deposit withdrawal transfer")
+	}
+
+	switch {
+	case in.Account == "":
+		add("account_id", "required", "is required")
+	case !isAccountRef(in.Account):
+		add("account_id", "account", "must be an account ID or account number")
+	}
+	switch {
+	case in.Amount <= 0 || math.IsNaN(in.Amount) || math.IsInf(in.Amount, 0):
+		add("amount", "gt", "must be greater than 0")
+	case math.Round(in.Amount*100) != in.Amount*100:
+		add("amount", "decimals", "must not have more than 2 decimal places")
+	}
+	if len(in.Reference) > 100 {
+		add("reference", "max", "must be at most 100 characters")
+	}
+	return out
+}
+
+func isAccountRef(ref string) bool {
+	_, isNumber := ids.ParseAccountNumber(ref)
+	return isNumber || ids.IsAccountID(ref)
+}
+
+// AccountRefs returns the accounts the instructions name, each once.
+func AccountRefs(instructions []Instruction) []string {
+	seen := map[string]bool{}
+	var refs []string
+	for _, in := range instructions {
+		for _, ref := range []string{in.Account, in.ToAccount} {
+			if ref != "" && !seen[ref] {
+				seen[ref] = true
+				refs = append(refs, ref)
+			}
+		}
+	}
+	return refs
+}
+
+// Resolve sets the internal account IDs of parsed instructions from
+// resolved, keyed by the references as written. Accounts that were not
+// found are reported together like Parse reports invalid rows.
+func Resolve(instructions []Instruction, resolved map[string]int) error {
+	verr := &apperrors.ValidationError{}
+	add := func(in Instruction, field, rule, message string) {
+		verr.Fields = append(verr.Fields, apperrors.FieldViolation{
+			Field:   fmt.Sprintf("rows[%d].%s", in.Row, field),
+			Rule:    rule,
+			Message: message,
+		})
+	}
+
+	for i := range instructions {
+		in := &instructions[i]
+		in.AccountID = resolved[in.Account]
+		if in.AccountID == 0 {
+			add(*in, "account_id", "exists", "is not an account")
+		}
+		if in.ToAccount == "" {
+			continue
+		}
+		in.ToAccountID = resolved[in.ToAccount]
+		switch {
+		case in.ToAccountID == 0:
+			add(*in, "to_account_id", "exists", "is not an account")
+		case in.ToAccountID == in.AccountID:
+			add(*in, "to_account_id", "nefield", "must differ from account_id")
+		}
+	}
+
+	if len(verr.Fields) > 0 {
+		if len(verr.Fields) > maxViolations {
+			verr.Fields = verr.Fields[:maxViolations]
+		}
+		return verr
+	}
+	return nil
+}
+
+var csvColumns = []string{"type", "account_id", "to_account_id", "amount", "reference"}
+
+// parseCSV returns the rows of a CSV file along with violations for
+// amounts that are not numbers; the file itself must be well-formed.
+func parseCSV(r io.Reader) ([]Instruction, []apperrors.FieldViolation, error) {
+	cr := csv.NewReader(r)
+	cr.TrimLeadingSpace = true
+	cr.FieldsPerRecord = -1
+
+	header, err := cr.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, nil, apperrors.Invalid("file", "required", "contains no rows")
+	}
+	if err != nil {
+		return nil, nil, apperrors.Invalid("file", "csv", "is not valid CSV")
+	}
+
+	index := map[string]int{}
+	for i, name := range header {
+		index[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))] = i
+	}
+	for _, required := range []string{"type", "account_id", "amount"} {
+		if _, ok := index[required]; !ok {
+			return nil, nil, apperrors.Invalid("file", "header", "header must contain the columns: "+strings.Join(csvColumns, ","))
+		}
+	}
+
+	var (
+		instructions []Instruction
+		violations   []apperrors.FieldViolation
+	)
+	for row := 1; ; row++ {
+		record, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, nil, apperrors.Invalid(fmt.Sprintf("rows[%d]", row), "csv", "is not valid CSV")
+		}
+		if len(instructions) >= MaxRows {
+			return nil, nil, apperrors.Invalid("file", "max", fmt.Sprintf("must not contain more than %d rows", MaxRows))
+		}
+
+		field := func(name string) string {
+			if i, ok := index[name]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+		number := func(name string, parse func(string) error) {
+			if v := field(name); v != "" {
+				if parse(v) != nil {
+					violations = append(violations, apperrors.FieldViolation{
+						Field: fmt.Sprintf("rows[%d].%s", row, name), Rule: "number", Message: "must be a number",
+					})
+				}
+			}
+		}
+
+		in := Instruction{
+			Row:       row,
+			Type:      strings.ToLower(field("type")),
+			Account:   field("account_id"),
+			ToAccount: field("to_account_id"),
+			Reference: field("reference"),
+		}
+		number("amount", func(v string) (err error) { in.Amount, err = strconv.ParseFloat(v, 64); return })
+		instructions = append(instructions, in)
+	}
+
+	return instructions, violations, nil
+}
+
+func parseJSONL(r io.Reader) ([]Instruction, error) {
+	sc := bufio.NewScanner(r)
+	var instructions []Instruction
+	for row := 0; sc.Scan(); {
+		line := bytes.TrimSpace(sc.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		row++
+		if len(instructions) >= MaxRows {
+			return nil, apperrors.Invalid("file", "max", fmt.Sprintf("must not contain more than %d rows", MaxRows))
+		}
+
+		dec := json.NewDecoder(bytes.NewReader(line))
+		dec.DisallowUnknownFields()
+		in := Instruction{Row: row}
+		if err := dec.Decode(&in); err != nil {
+			return nil, apperrors.Invalid(fmt.Sprintf("rows[%d]", row), "json", "is not a valid instruction object")
+		}
+		in.Type = strings.ToLower(in.Type)
+		instructions = append(instructions, in)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, apperrors.Invalid("file", "jsonl", "could not be read: lines must be shorter than 64KB")
+	}
+	return instructions, nil
+}
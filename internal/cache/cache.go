@@ -0,0 +1,32 @@
+// Package cache keeps account balances close to the service, with pluggable
+// storage: an in-process LRU, or memcached shared by replicas. Writers keep
+// entries current rather than expiring them, so every entry carries the
+// account version it was read at and older versions never replace newer
+// ones.
+package cache
+
+import (
+	"context"
+	"strconv"
+)
+
+// Entry is a cached balance and the account version it belongs to.
+type Entry struct {
+	Balance float64
+	Version int64
+}
+
+// Store keeps cached balances. Put must be atomic per key and keep the
+// entry with the highest version.
+type Store interface {
+	Get(ctx context.Context, key string) (Entry, bool, error)
+	Put(ctx context.Context, key string, e Entry) error
+	// Clear drops every entry, for when updates may have been missed.
+	Clear(ctx context.Context) error
+}
+
+// BalanceKey is the key of an account's balance. Account IDs are only
+// unique within a tenant's view, so the tenant is part of the key.
+func BalanceKey(tenantID string, accountID int) string {
+	return "balance:" + tenantID + ":" + strconv.Itoa(accountID)
+}
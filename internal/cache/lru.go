@@ -0,0 +1,78 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"sync"
+)
+
+// LRU keeps up to size entries in process, evicting the least recently
+// used. Entries are per replica; Notified keeps them current.
+type LRU struct {
+	mu      sync.Mutex
+	size    int
+	order   *list.List // front is most recently used
+	entries map[string]*list.Element
+}
+
+type lruItem struct {
+	key   string
+	entry Entry
+}
+
+func NewLRU(size int) *LRU {
+	if size < 1 {
+		size = 1
+	}
+	return &LRU{size: size, order: list.New(), entries: make(map[string]*list.Element)}
+}
+
+func (c *LRU) Get(_ context.Context, key string) (Entry, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.entries[key]
+	if !ok {
+		return Entry{}, false, nil
+	}
+	c.order.MoveToFront(el)
+	return el.Value.(*lruItem).entry, true, nil
+}
+
+func (c *LRU) Put(_ context.Context, key string, e Entry) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.entries[key]; ok {
+		item := el.Value.(*lruItem)
+		if item.entry.Version <= e.Version {
+			item.entry = e
+		}
+		c.order.MoveToFront(el)
+		return nil
+	}
+
+	c.entries[key] = c.order.PushFront(&lruItem{key: key, entry: e})
+	if c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*lruItem).key)
+	}
+	return nil
+}
+
+func (c *LRU) Clear(context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.order.Init()
+	c.entries = make(map[string]*list.Element)
+	return nil
+}
+
+// Len returns the number of entries.
+func (c *LRU) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
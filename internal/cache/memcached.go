@@ -0,0 +1,193 @@
+package cache
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// memcachedTimeout bounds a command when ctx has no deadline.
+	memcachedTimeout = 250 * time.Millisecond
+	// memcachedIdleConns is how many connections are kept open.
+	memcachedIdleConns = 8
+	// memcachedPutAttempts bounds the compare-and-swap loop of Put.
+	memcachedPutAttempts = 5
+)
+
+// errNotStored means a conditional store lost a race and may be retried.
+var errNotStored = errors.New("memcached: not stored")
+
+// Memcached keeps entries in a memcached server shared by replicas, using
+// compare-and-swap so concurrent writers keep the highest version. Entries
+// expire after ttl in case an update was lost. Clear flushes the whole
+// server, so it must be dedicated to the service.
+type Memcached struct {
+	addr string
+	ttl  time.Duration
+	idle chan *mcConn
+}
+
+type mcConn struct {
+	net.Conn
+	rw *bufio.ReadWriter
+}
+
+func NewMemcached(addr string, ttl time.Duration) *Memcached {
+	return &Memcached{addr: addr, ttl: ttl, idle: make(chan *mcConn, memcachedIdleConns)}
+}
+
+func (m *Memcached) Get(ctx context.Context, key string) (Entry, bool, error) {
+	var (
+		e  Entry
+		ok bool
+	)
+	err := m.do(ctx, func(c *mcConn) (err error) {
+		e, _, ok, err = c.gets(key)
+		return err
+	})
+	return e, ok, err
+}
+
+func (m *Memcached) Put(ctx context.Context, key string, e Entry) error {
+	return m.do(ctx, func(c *mcConn) error {
+		for range memcachedPutAttempts {
+			cur, cas, ok, err := c.gets(key)
+			switch {
+			case err != nil:
+				return err
+			case !ok:
+				err = c.store("add", key, e, m.ttl, 0)
+			case cur.Version > e.Version:
+				return nil
+			default:
+				err = c.store("cas", key, e, m.ttl, cas)
+			}
+			if !errors.Is(err, errNotStored) {
+				return err
+			}
+		}
+		return fmt.Errorf("memcached: %s changed %d times during put", key, memcachedPutAttempts)
+	})
+}
+
+func (m *Memcached) Clear(ctx context.Context) error {
+	return m.do(ctx, func(c *mcConn) error {
+		line, err := c.command("flush_all\r\n")
+		if err == nil && line != "OK" {
+			err = fmt.Errorf("memcached: flush_all: %s", line)
+		}
+		return err
+	})
+}
+
+// do runs fn on an idle or new connection. Connections that fail are
+// closed, as their protocol state is unknown.
+func (m *Memcached) do(ctx context.Context, fn func(*mcConn) error) error {
+	var c *mcConn
+	select {
+	case c = <-m.idle:
+	default:
+		var d net.Dialer
+		conn, err := d.DialContext(ctx, "tcp", m.addr)
+		if err != nil {
+			return fmt.Errorf("memcached: %w", err)
+		}
+		c = &mcConn{Conn: conn, rw: bufio.NewReadWriter(bufio.NewReader(conn), bufio.NewWriter(conn))}
+	}
+
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(memcachedTimeout)
+	}
+	c.SetDeadline(deadline)
+
+	if err := fn(c); err != nil {
+		c.Close()
+		return err
+	}
+	select {
+	case m.idle <- c:
+	default:
+		c.Close()
+	}
+	return nil
+}
+
+// command sends a request and returns the first line of the response.
+func (c *mcConn) command(req string) (string, error) {
+	if _, err := c.rw.WriteString(req); err != nil {
+		return "", err
+	}
+	if err := c.rw.Flush(); err != nil {
+		return "", err
+	}
+	return c.line()
+}
+
+func (c *mcConn) line() (string, error) {
+	line, err := c.rw.ReadString('\n')
+	return strings.TrimRight(line, "\r\n"), err
+}
+
+// gets reads an entry with its CAS unique.
+func (c *mcConn) gets(key string) (e Entry, cas uint64, ok bool, err error) {
+	line, err := c.command("gets " + key + "\r\n")
+	if err != nil || line == "END" {
+		return e, 0, false, err
+	}
+
+	// VALUE <key> <flags> <bytes> <cas unique>
+	fields := strings.Fields(line)
+	if len(fields) != 5 || fields[0] != "VALUE" {
+		return e, 0, false, fmt.Errorf("memcached: unexpected reply %q", line)
+	}
+	if cas, err = strconv.ParseUint(fields[4], 10, 64); err != nil {
+		return e, 0, false, fmt.Errorf("memcached: unexpected reply %q", line)
+	}
+	data, err := c.line()
+	if err != nil {
+		return e, 0, false, err
+	}
+	if end, err := c.line(); err != nil || end != "END" {
+		return e, 0, false, fmt.Errorf("memcached: unterminated reply to gets %s", key)
+	}
+
+	// Values are "<version> <balance>"
+	version, balance, _ := strings.Cut(data, " ")
+	e.Version, err = strconv.ParseInt(version, 10, 64)
+	if err == nil {
+		e.Balance, err = strconv.ParseFloat(balance, 64)
+	}
+	if err != nil {
+		return Entry{}, 0, false, fmt.Errorf("memcached: malformed entry %s: %q", key, data)
+	}
+	return e, cas, true, nil
+}
+
+// store runs add, or cas with the given unique. It returns errNotStored if
+// the key was added or changed by someone else.
+func (c *mcConn) store(verb, key string, e Entry, ttl time.Duration, cas uint64) error {
+	data := strconv.FormatInt(e.Version, 10) + " " + strconv.FormatFloat(e.Balance, 'f', -1, 64)
+	req := fmt.Sprintf("%s %s 0 %d %d", verb, key, int(ttl.Seconds()), len(data))
+	if verb == "cas" {
+		req += " " + strconv.FormatUint(cas, 10)
+	}
+
+	line, err := c.command(req + "\r\n" + data + "\r\n")
+	switch {
+	case err != nil:
+		return err
+	case line == "STORED":
+		return nil
+	case line == "NOT_STORED" || line == "EXISTS" || line == "NOT_FOUND":
+		return errNotStored
+	default:
+		return fmt.Errorf("memcached: %s %s: %s", verb, key, line)
+	}
+}
@@ -0,0 +1,119 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Channel is where the database announces committed balance changes, see
+// the accounts_balance_notify trigger.
+const Channel = "account_balances"
+
+// notification is the payload of a balance change.
+type notification struct {
+	TenantID  string  `json:"tenant_id"`
+	AccountID int     `json:"account_id"`
+	Balance   float64 `json:"balance"`
+	Version   int64   `json:"version"`
+}
+
+// Notified is a Store kept current by the database's balance change
+// notifications, so writes by other replicas, jobs and fintechctl reach it
+// too. It only serves entries while it is listening: missed notifications
+// cannot be told apart from none, so its entries are dropped whenever the
+// listening connection is lost.
+type Notified struct {
+	store  Store
+	logger *slog.Logger
+	live   atomic.Bool
+}
+
+func NewNotified(store Store, logger *slog.Logger) *Notified {
+	return &Notified{store: store, logger: logger}
+}
+
+// Get misses while n is not listening.
+func (n *Notified) Get(ctx context.Context, key string) (Entry, bool, error) {
+	if !n.live.Load() {
+		return Entry{}, false, nil
+	}
+	return n.store.Get(ctx, key)
+}
+
+func (n *Notified) Put(ctx context.Context, key string, e Entry) error {
+	return n.store.Put(ctx, key, e)
+}
+
+func (n *Notified) Clear(ctx context.Context) error {
+	return n.store.Clear(ctx)
+}
+
+// Listen receives notifications on a dedicated connection to dsn until ctx
+// is done, reconnecting when the connection is lost.
+func (n *Notified) Listen(ctx context.Context, dsn string) error {
+	l := pq.NewListener(dsn, time.Second, time.Minute, n.HandleEvent)
+	defer l.Close()
+
+	// Listen blocks until the server has acknowledged it, after which no
+	// commit is missed
+	if err := l.Listen(Channel); err != nil {
+		return fmt.Errorf("failed to listen for balance changes: %w", err)
+	}
+	n.live.Store(true)
+	n.logger.Info("balance cache listening for changes")
+
+	for {
+		select {
+		case <-ctx.Done():
+			n.live.Store(false)
+			return nil
+		case msg := <-l.Notify:
+			n.HandleNotification(ctx, msg)
+		}
+	}
+}
+
+// HandleEvent follows the state of the listening connection. Entries are
+// dropped when it is lost, as changes are not heard of until it is back.
+func (n *Notified) HandleEvent(event pq.ListenerEventType, err error) {
+	if event != pq.ListenerEventDisconnected {
+		return
+	}
+	n.live.Store(false)
+	n.clear(context.Background())
+	n.logger.Warn("balance cache stopped listening for changes", "error", fmt.Sprint(err))
+}
+
+// HandleNotification applies a balance change. A nil notification follows
+// a reconnect: changes made meanwhile are unknown, so entries are dropped
+// before n serves them again.
+func (n *Notified) HandleNotification(ctx context.Context, msg *pq.Notification) {
+	if msg == nil {
+		n.clear(ctx)
+		n.live.Store(true)
+		n.logger.Info("balance cache listening for changes again")
+		return
+	}
+
+	var c notification
+	if err := json.Unmarshal([]byte(msg.Extra), &c); err != nil {
+		n.logger.Error("malformed balance change", "payload", msg.Extra, "error", err.Error())
+		return
+	}
+	key := BalanceKey(c.TenantID, c.AccountID)
+	if err := n.store.Put(ctx, key, Entry{Balance: c.Balance, Version: c.Version}); err != nil {
+		n.logger.Error("failed to apply balance change", "key", key, "error", err.Error())
+	}
+}
+
+func (n *Notified) clear(ctx context.Context) {
+	if err := n.store.Clear(ctx); err != nil {
+		n.logger.Error("failed to clear balance cache", "error", err.Error())
+	}
+}
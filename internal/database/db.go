@@ -6,26 +6,93 @@ import (
 	"log"
 	"os"
 
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 )
 
 var DB *sql.DB
 
-func InitDB() {
-	connStr := fmt.Sprintf(
+// DSN builds the connection string from the DB_* environment variables.
+// DB_HOST defaults to "db", the compose service name.
+func DSN() string {
+	host := os.Getenv("DB_HOST")
+	if host == "" {
+		host = "db"
+	}
+	return hostDSN(host)
+}
+
+// ReplicaDSN builds the connection string of the read replica at
+// DB_REPLICA_HOST, which shares the primary's credentials. It is empty if
+// no replica is configured.
+func ReplicaDSN() string {
+	host := os.Getenv("DB_REPLICA_HOST")
+	if host == "" {
+		return ""
+	}
+	return hostDSN(host)
+}
+
+func hostDSN(host string) string {
+	return fmt.Sprintf(
 		"host=%s user=%s password=%s dbname=%s sslmode=disable",
-		"db", os.Getenv("DB_USER"), os.Getenv("DB_PASSWORD"), os.Getenv("DB_NAME"),
+		host, os.Getenv("DB_USER"), os.Getenv("DB_PASSWORD"), os.Getenv("DB_NAME"),
 	)
+}
+
+// Open returns a handle to a Postgres database whose connections are
+// scoped to the tenant of each statement's context, see WithTenants.
+func Open(dsn string) (*sql.DB, error) {
+	connector, err := pq.NewConnector(dsn)
+	if err != nil {
+		return nil, err
+	}
+	return sql.OpenDB(WithTenants(connector)), nil
+}
 
-	var err error
-	DB, err = sql.Open("postgres", connStr)
+// Connect opens and verifies a connection to the service database
+func Connect() *sql.DB {
+	db, err := Open(DSN())
 	if err != nil {
 		log.Fatal("Failed to connect to database:", err)
 	}
 
-	if err = DB.Ping(); err != nil {
+	if err = db.Ping(); err != nil {
 		log.Fatal("Database is unreachable:", err)
 	}
 
+	// Superusers and BYPASSRLS roles are not subject to row-level
+	// security, so tenants would see each other's data.
+	var bypass bool
+	err = db.QueryRow("SELECT rolsuper OR rolbypassrls FROM pg_roles WHERE rolname = current_user").Scan(&bypass)
+	if err == nil && bypass {
+		log.Println("WARNING: the database role bypasses row-level security; tenant isolation is not enforced")
+	}
+
 	log.Println("Connected to the database successfully")
+	return db
+}
+
+// ConnectReplica opens the read replica, or returns nil if none is
+// configured. Unlike Connect it does not fail when the replica is
+// unreachable: reads go to the primary until it is back.
+func ConnectReplica() *sql.DB {
+	dsn := ReplicaDSN()
+	if dsn == "" {
+		return nil
+	}
+	db, err := Open(dsn)
+	if err != nil {
+		log.Fatal("Invalid replica configuration:", err)
+	}
+
+	if err = db.Ping(); err != nil {
+		log.Println("WARNING: the read replica is unreachable, reading from the primary:", err)
+	} else {
+		log.Println("Connected to the read replica successfully")
+	}
+	return db
+}
+
+func InitDB() {
+	DB = Connect()
 }
@@ -0,0 +1,210 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"expvar"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// LSN is a position in the primary's write-ahead log, written as two hex
+// numbers, e.g. 16/B374D848. Clients use it as a consistency token: a
+// replica that has replayed up to the LSN returned by a write has the
+// write.
+type LSN uint64
+
+// ParseLSN parses the text form of a pg_lsn.
+func ParseLSN(s string) (LSN, error) {
+	hi, lo, ok := strings.Cut(s, "/")
+	if ok {
+		h, herr := strconv.ParseUint(hi, 16, 32)
+		l, lerr := strconv.ParseUint(lo, 16, 32)
+		if herr == nil && lerr == nil {
+			return LSN(h<<32 | l), nil
+		}
+	}
+	return 0, fmt.Errorf("invalid LSN %q", s)
+}
+
+func (l LSN) String() string {
+	return fmt.Sprintf("%X/%X", uint64(l)>>32, uint32(l))
+}
+
+type routeKey int
+
+const (
+	tokenKey routeKey = iota
+	primaryKey
+)
+
+// WithToken asks reads made with ctx to see every write the primary had
+// made at lsn, for read-your-writes consistency.
+func WithToken(ctx context.Context, lsn LSN) context.Context {
+	return context.WithValue(ctx, tokenKey, lsn)
+}
+
+// WithPrimary sends reads made with ctx to the primary, for requests that
+// write as well as read.
+func WithPrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryKey, true)
+}
+
+// Consistent reports whether reads made with ctx must see particular
+// writes, having been made WithPrimary or WithToken. Caches that are
+// updated asynchronously cannot serve them.
+func Consistent(ctx context.Context) bool {
+	_, token := ctx.Value(tokenKey).(LSN)
+	primary, _ := ctx.Value(primaryKey).(bool)
+	return token || primary
+}
+
+// ReadMetrics counts where Router sent reads, published by expvar as
+// database_reads: "replica", or the reason the primary served them:
+// "requested" (WithPrimary), "unavailable" (replica down or unchecked),
+// "lagging" (past the staleness bound) or "token" (token not replayed yet).
+var ReadMetrics = expvar.NewMap("database_reads")
+
+// Router sends read-only queries to a replica while its data is at most
+// maxLag old, and to the primary otherwise. Watch keeps its view of the
+// replica current; until the first check succeeds every read goes to the
+// primary.
+type Router struct {
+	primary *sql.DB
+	replica *sql.DB
+	maxLag  time.Duration
+	logger  *slog.Logger
+
+	mu    sync.RWMutex
+	state replicaState
+}
+
+// replicaState is the outcome of the last replica check.
+type replicaState struct {
+	replayed LSN
+	lag      time.Duration
+	checked  time.Time
+	err      error
+}
+
+// NewRouter returns a router over primary and replica. A nil replica
+// sends every read to the primary.
+func NewRouter(primary, replica *sql.DB, maxLag time.Duration, logger *slog.Logger) *Router {
+	return &Router{primary: primary, replica: replica, maxLag: maxLag, logger: logger}
+}
+
+// HasReplica reports whether a replica is configured.
+func (r *Router) HasReplica() bool {
+	return r.replica != nil
+}
+
+// Reader returns the database a read-only query made with ctx runs on.
+// The replica qualifies when its lag at the last check plus the time
+// since is within maxLag, and it has replayed the token of ctx, if any.
+func (r *Router) Reader(ctx context.Context) *sql.DB {
+	if r.replica == nil {
+		return r.primary
+	}
+	if primary, _ := ctx.Value(primaryKey).(bool); primary {
+		ReadMetrics.Add("requested", 1)
+		return r.primary
+	}
+
+	r.mu.RLock()
+	s := r.state
+	r.mu.RUnlock()
+
+	reason := ""
+	switch token, _ := ctx.Value(tokenKey).(LSN); {
+	case s.err != nil || s.checked.IsZero():
+		reason = "unavailable"
+	case s.lag+time.Since(s.checked) > r.maxLag:
+		reason = "lagging"
+	case token > s.replayed:
+		reason = "token"
+	}
+	if reason != "" {
+		ReadMetrics.Add(reason, 1)
+		return r.primary
+	}
+	ReadMetrics.Add("replica", 1)
+	return r.replica
+}
+
+// Token returns the primary's current WAL position. Reads made with
+// WithToken(ctx, token) see every write committed before the call.
+func (r *Router) Token(ctx context.Context) (LSN, error) {
+	var s string
+	if err := r.primary.QueryRowContext(ctx, "SELECT pg_current_wal_lsn()::text").Scan(&s); err != nil {
+		return 0, fmt.Errorf("failed to read WAL position: %w", err)
+	}
+	return ParseLSN(s)
+}
+
+// Check measures how far the replica is behind the primary. A replica
+// that has replayed the primary's current position has no lag; otherwise
+// the lag is the age of the last transaction it replayed.
+func (r *Router) Check(ctx context.Context) error {
+	if r.replica == nil {
+		return nil
+	}
+
+	s := replicaState{checked: time.Now()}
+	primary, err := r.Token(ctx)
+	if err == nil {
+		var (
+			replayed string
+			age      float64
+		)
+		// A server that is not in recovery, e.g. the primary itself in
+		// development, has nothing to replay
+		err = r.replica.QueryRowContext(ctx,
+			`SELECT COALESCE(pg_last_wal_replay_lsn(), pg_current_wal_lsn())::text,
+			        COALESCE(EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp()), 0)`,
+		).Scan(&replayed, &age)
+		if err == nil {
+			s.replayed, err = ParseLSN(replayed)
+		}
+		if err == nil && s.replayed < primary {
+			s.lag = time.Duration(age * float64(time.Second))
+		}
+	}
+	if err != nil {
+		s.err = fmt.Errorf("failed to check replica: %w", err)
+	}
+
+	r.mu.Lock()
+	prev := r.state
+	r.state = s
+	r.mu.Unlock()
+
+	switch {
+	case s.err != nil && prev.err == nil:
+		r.logger.Warn("replica unavailable, reading from the primary", "error", s.err.Error())
+	case s.err == nil && prev.err != nil:
+		r.logger.Info("replica available again")
+	}
+	return s.err
+}
+
+// Watch checks the replica every interval until ctx is done.
+func (r *Router) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		checkCtx, cancel := context.WithTimeout(ctx, interval)
+		r.Check(checkCtx)
+		cancel()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
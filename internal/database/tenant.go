@@ -0,0 +1,220 @@
+package database
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+
+	"github.com/Andrew44Ashraf/fintech-service/internal/tenant"
+)
+
+// TenantSetting is the session setting the row-level security policies of
+// tenant tables compare tenant_id with, see cmd/migrations/018_tenants.sql.
+const TenantSetting = "app.tenant_id"
+
+const setTenantQuery = "SELECT set_config('" + TenantSetting + "', $1, false)"
+
+// WithTenants wraps a connector so that every statement runs with
+// TenantSetting set to the tenant of its context. Statements whose context
+// has no tenant run with an empty setting and see no tenant data.
+//
+// The setting is only sent when a connection changes tenant. It is
+// session-wide, so a rolled back transaction may undo it; the connection
+// then sends it again before its next statement.
+func WithTenants(c driver.Connector) driver.Connector {
+	return &tenantConnector{Connector: c}
+}
+
+type tenantConnector struct {
+	driver.Connector
+}
+
+func (c *tenantConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &tenantConn{Conn: conn}, nil
+}
+
+// tenantConn tracks the tenant its session is set to. database/sql uses a
+// connection from one goroutine at a time, so it needs no locking.
+type tenantConn struct {
+	driver.Conn
+	tenant string
+	known  bool
+}
+
+// setTenant points the session at the tenant of ctx.
+func (c *tenantConn) setTenant(ctx context.Context) error {
+	id := tenant.FromContext(ctx)
+	if c.known && c.tenant == id {
+		return nil
+	}
+	execer, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return errors.New("database: driver cannot set the tenant")
+	}
+	c.known = false
+	if _, err := execer.ExecContext(ctx, setTenantQuery, []driver.NamedValue{{Ordinal: 1, Value: id}}); err != nil {
+		return err
+	}
+	c.tenant, c.known = id, true
+	return nil
+}
+
+func (c *tenantConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	queryer, ok := c.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	if err := c.setTenant(ctx); err != nil {
+		return nil, err
+	}
+	return queryer.QueryContext(ctx, query, args)
+}
+
+func (c *tenantConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	execer, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	if err := c.setTenant(ctx); err != nil {
+		return nil, err
+	}
+	return execer.ExecContext(ctx, query, args)
+}
+
+func (c *tenantConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	if err := c.setTenant(ctx); err != nil {
+		return nil, err
+	}
+	var (
+		stmt driver.Stmt
+		err  error
+	)
+	if p, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		stmt, err = p.PrepareContext(ctx, query)
+	} else {
+		stmt, err = c.Conn.Prepare(query)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &tenantStmt{Stmt: stmt, conn: c}, nil
+}
+
+func (c *tenantConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if err := c.setTenant(ctx); err != nil {
+		return nil, err
+	}
+	var (
+		tx  driver.Tx
+		err error
+	)
+	if b, ok := c.Conn.(driver.ConnBeginTx); ok {
+		tx, err = b.BeginTx(ctx, opts)
+	} else if opts.Isolation != driver.IsolationLevel(0) || opts.ReadOnly {
+		return nil, errors.New("database: driver does not support transaction options")
+	} else {
+		tx, err = c.Conn.Begin()
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &tenantTx{Tx: tx, conn: c}, nil
+}
+
+func (c *tenantConn) Ping(ctx context.Context) error {
+	if p, ok := c.Conn.(driver.Pinger); ok {
+		return p.Ping(ctx)
+	}
+	return nil
+}
+
+func (c *tenantConn) ResetSession(ctx context.Context) error {
+	if r, ok := c.Conn.(driver.SessionResetter); ok {
+		return r.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (c *tenantConn) IsValid() bool {
+	if v, ok := c.Conn.(driver.Validator); ok {
+		return v.IsValid()
+	}
+	return true
+}
+
+func (c *tenantConn) CheckNamedValue(nv *driver.NamedValue) error {
+	if n, ok := c.Conn.(driver.NamedValueChecker); ok {
+		return n.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
+
+// tenantTx forgets the session's tenant when the transaction does not
+// commit, since rolling back undoes a set_config made inside it.
+type tenantTx struct {
+	driver.Tx
+	conn *tenantConn
+}
+
+func (t *tenantTx) Commit() error {
+	err := t.Tx.Commit()
+	if err != nil {
+		t.conn.known = false
+	}
+	return err
+}
+
+func (t *tenantTx) Rollback() error {
+	t.conn.known = false
+	return t.Tx.Rollback()
+}
+
+// tenantStmt sets the tenant again before each execution, as a statement
+// may outlive the context it was prepared with.
+type tenantStmt struct {
+	driver.Stmt
+	conn *tenantConn
+}
+
+func (s *tenantStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	if err := s.conn.setTenant(ctx); err != nil {
+		return nil, err
+	}
+	if e, ok := s.Stmt.(driver.StmtExecContext); ok {
+		return e.ExecContext(ctx, args)
+	}
+	values, err := namedValues(args)
+	if err != nil {
+		return nil, err
+	}
+	return s.Stmt.Exec(values)
+}
+
+func (s *tenantStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	if err := s.conn.setTenant(ctx); err != nil {
+		return nil, err
+	}
+	if q, ok := s.Stmt.(driver.StmtQueryContext); ok {
+		return q.QueryContext(ctx, args)
+	}
+	values, err := namedValues(args)
+	if err != nil {
+		return nil, err
+	}
+	return s.Stmt.Query(values)
+}
+
+func namedValues(args []driver.NamedValue) ([]driver.Value, error) {
+	values := make([]driver.Value, len(args))
+	for i, a := range args {
+		if a.Name != "" {
+			return nil, errors.New("database: driver does not support named parameters")
+		}
+		values[i] = a.Value
+	}
+	return values, nil
+}
@@ -1,5 +1,27 @@
 package requests
 
+import "time"
+
 type OpenAccountRequest struct {
+    ProductCode    string  `json:"product_code" validate:"omitempty,max=20"`
     InitialBalance float64 `json:"initial_balance" validate:"gte=0"`
-}
\ No newline at end of file
+    Details
+}
+
+type BalancesAsOfRequest struct {
+    AsOf       time.Time `json:"as_of" validate:"required"`
+    AccountIDs []string  `json:"account_ids" validate:"required,min=1,max=1000,dive,required"`
+}
+
+type ChangeAccountStatusRequest struct {
+    Status   string `json:"status" validate:"required,oneof=active frozen closed"`
+    Operator string `json:"operator" validate:"required,max=100"`
+    Reason   string `json:"reason" validate:"required,max=500"`
+}
+
+type AccountLimitsRequest struct {
+    MaxSingleWithdrawal  *float64 `json:"max_single_withdrawal" validate:"omitempty,gte=0"`
+    MaxDailyWithdrawal   *float64 `json:"max_daily_withdrawal" validate:"omitempty,gte=0"`
+    MaxMonthlyWithdrawal *float64 `json:"max_monthly_withdrawal" validate:"omitempty,gte=0"`
+    MaxDailyWithdrawals  *int     `json:"max_daily_withdrawals" validate:"omitempty,gte=0"`
+}
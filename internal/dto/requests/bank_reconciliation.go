@@ -0,0 +1,7 @@
+package requests
+
+type BankMatchRequest struct {
+    LineID        int    `json:"line_id" validate:"required,gt=0"`
+    TransactionID string `json:"transaction_id" validate:"required"`
+    Operator      string `json:"operator" validate:"required,max=100"`
+}
@@ -0,0 +1,11 @@
+package requests
+
+import "encoding/json"
+
+// Details are the integrator-supplied reference, description and metadata
+// accepted when opening an account and on deposits and withdrawals.
+type Details struct {
+    ExternalRef string          `json:"external_ref" validate:"omitempty,max=64"`
+    Description string          `json:"description" validate:"omitempty,max=255"`
+    Metadata    json.RawMessage `json:"metadata" validate:"omitempty,max=4096"`
+}
\ No newline at end of file
@@ -0,0 +1,16 @@
+package requests
+
+import "time"
+
+type CreateFeeWaiverRequest struct {
+    FeeType  string     `json:"fee_type" validate:"required,oneof=withdrawal maintenance low_balance"`
+    StartsAt *time.Time `json:"starts_at"`
+    EndsAt   *time.Time `json:"ends_at"`
+    Reason   string     `json:"reason" validate:"required,max=500"`
+    Operator string     `json:"operator" validate:"required,max=100"`
+}
+
+type FeeActionRequest struct {
+    Operator string `json:"operator" validate:"required,max=100"`
+    Reason   string `json:"reason" validate:"required,max=500"`
+}
@@ -0,0 +1,9 @@
+package requests
+
+// IncomingTransferRequest records a transfer announced by another bank.
+// ValueDate is YYYY-MM-DD and defaults to today.
+type IncomingTransferRequest struct {
+    Amount    float64 `json:"amount" validate:"required,gt=0"`
+    ValueDate string  `json:"value_date" validate:"omitempty,datetime=2006-01-02"`
+    Details
+}
@@ -0,0 +1,6 @@
+package requests
+
+type ReviewDecisionRequest struct {
+    Operator string `json:"operator" validate:"required,max=100"`
+    Note     string `json:"note" validate:"max=500"`
+}
@@ -0,0 +1,25 @@
+package requests
+
+import "time"
+
+type CreateScheduledPaymentRequest struct {
+    ToAccountID         string     `json:"to_account_id" validate:"max=64"`
+    Amount              float64    `json:"amount" validate:"required,gt=0"`
+    Description         string     `json:"description" validate:"max=140"`
+    Cron                string     `json:"cron" validate:"max=100"`
+    Every               int        `json:"every" validate:"gte=0,lte=366"`
+    Unit                string     `json:"unit" validate:"omitempty,oneof=day week month"`
+    StartAt             time.Time  `json:"start_at" validate:"required"`
+    EndAt               *time.Time `json:"end_at"`
+    OnInsufficientFunds string     `json:"on_insufficient_funds" validate:"omitempty,oneof=skip retry"`
+    MaxRetries          int        `json:"max_retries" validate:"gte=0,lte=10"`
+}
+
+type UpdateScheduledPaymentRequest struct {
+    Amount              *float64   `json:"amount" validate:"omitempty,gt=0"`
+    Description         *string    `json:"description" validate:"omitempty,max=140"`
+    EndAt               *time.Time `json:"end_at"`
+    Status              *string    `json:"status" validate:"omitempty,oneof=active paused"`
+    OnInsufficientFunds *string    `json:"on_insufficient_funds" validate:"omitempty,oneof=skip retry"`
+    MaxRetries          *int       `json:"max_retries" validate:"omitempty,gte=0,lte=10"`
+}
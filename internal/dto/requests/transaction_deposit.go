@@ -4,6 +4,7 @@ import "github.com/go-playground/validator/v10"
 
 type DepositRequest struct {
     Amount float64 `json:"amount" validate:"required,gt=0"`
+    Details
 }
 
 func (r *DepositRequest) Validate() error {
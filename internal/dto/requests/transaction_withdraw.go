@@ -2,4 +2,5 @@ package requests
 
 type WithdrawRequest struct {
     Amount float64 `json:"amount" validate:"required,gt=0"`
+    Details
 }
\ No newline at end of file
@@ -1,9 +1,66 @@
 package responses
 
+import "time"
+
 type AccountResponse struct {
-    AccountID int `json:"account_id"`
+    AccountID     string `json:"account_id"`
+    AccountNumber string `json:"account_number"`
+    ProductCode   string `json:"product_code,omitempty"`
 }
 
 type BalanceResponse struct {
-    Balance float64 `json:"balance"`
-}
\ No newline at end of file
+    Balance float64    `json:"balance"`
+    AsOf    *time.Time `json:"as_of,omitempty"`
+}
+
+type AccountBalance struct {
+    AccountID string  `json:"account_id"`
+    Balance   float64 `json:"balance"`
+}
+
+type BalancesAsOfResponse struct {
+    AsOf              time.Time        `json:"as_of"`
+    Balances          []AccountBalance `json:"balances"`
+    MissingAccountIDs []string         `json:"missing_account_ids,omitempty"`
+}
+
+type ProductResponse struct {
+    Code                 string   `json:"code"`
+    Name                 string   `json:"name"`
+    AllowedTypes         []string `json:"allowed_transaction_types"`
+    MinBalance           float64  `json:"min_balance"`
+    OverdraftLimit       float64  `json:"overdraft_limit"`
+    MaxSingleWithdrawal  float64  `json:"max_single_withdrawal,omitempty"`
+    MaxDailyWithdrawal   float64  `json:"max_daily_withdrawal,omitempty"`
+    MaxMonthlyWithdrawal float64  `json:"max_monthly_withdrawal,omitempty"`
+    MaxDailyWithdrawals  int      `json:"max_daily_withdrawals,omitempty"`
+    Currency             string   `json:"currency"`
+}
+
+type ProductListResponse struct {
+    Products []ProductResponse `json:"products"`
+}
+
+// AccountLimits are an account's own withdrawal limits; absent fields fall
+// back to the product's.
+type AccountLimits struct {
+    MaxSingleWithdrawal  *float64 `json:"max_single_withdrawal,omitempty"`
+    MaxDailyWithdrawal   *float64 `json:"max_daily_withdrawal,omitempty"`
+    MaxMonthlyWithdrawal *float64 `json:"max_monthly_withdrawal,omitempty"`
+    MaxDailyWithdrawals  *int     `json:"max_daily_withdrawals,omitempty"`
+}
+
+type AccountDetailResponse struct {
+    AccountID      string        `json:"account_id"`
+    AccountNumber  string        `json:"account_number"`
+    ProductCode    string        `json:"product_code"`
+    Status         string        `json:"status"`
+    Balance        float64       `json:"balance"`
+    Currency       string        `json:"currency"`
+    OverdraftLimit float64       `json:"overdraft_limit"`
+    Limits         AccountLimits `json:"limits"`
+    Version        int64         `json:"version"`
+    Details
+    CreatedAt      time.Time     `json:"created_at"`
+    UpdatedAt      time.Time     `json:"updated_at"`
+}
@@ -0,0 +1,55 @@
+package responses
+
+import "time"
+
+type BankStatementResponse struct {
+    ID          int       `json:"id"`
+    Format      string    `json:"format"`
+    FileName    string    `json:"file_name,omitempty"`
+    PeriodStart string    `json:"period_start"`
+    PeriodEnd   string    `json:"period_end"`
+    LineCount   int       `json:"line_count"`
+    ImportedBy  string    `json:"imported_by"`
+    ImportedAt  time.Time `json:"imported_at"`
+}
+
+type BankImportResponse struct {
+    Statement BankStatementResponse `json:"statement"`
+    Matched   int                   `json:"matched"`
+}
+
+type BankLineItem struct {
+    ID          int     `json:"id"`
+    LineNo      int     `json:"line_no"`
+    Reference   string  `json:"reference"`
+    Amount      float64 `json:"amount"`
+    BookingDate string  `json:"booking_date"`
+    ValueDate   string  `json:"value_date"`
+    Description string  `json:"description,omitempty"`
+}
+
+type BankTransactionItem struct {
+    TransactionID string    `json:"transaction_id"`
+    Amount        float64   `json:"amount"`
+    Date          time.Time `json:"date"`
+}
+
+type BankMatchItem struct {
+    ID          int                 `json:"id"`
+    Rule        string              `json:"rule"`
+    MatchedBy   string              `json:"matched_by"`
+    MatchedAt   time.Time           `json:"matched_at"`
+    Line        BankLineItem        `json:"line"`
+    Transaction BankTransactionItem `json:"transaction"`
+}
+
+type BankReconciliationResponse struct {
+    Statement       BankStatementResponse `json:"statement"`
+    Matched         []BankMatchItem       `json:"matched"`
+    UnmatchedOurs   []BankTransactionItem `json:"unmatched_ours"`
+    UnmatchedTheirs []BankLineItem        `json:"unmatched_theirs"`
+}
+
+type BankMatchResponse struct {
+    ID int `json:"id"`
+}
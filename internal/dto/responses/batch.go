@@ -0,0 +1,29 @@
+package responses
+
+import "time"
+
+type BatchRowResult struct {
+    Row           int     `json:"row"`
+    Type          string  `json:"type"`
+    AccountID     string  `json:"account_id"`
+    ToAccountID   string  `json:"to_account_id,omitempty"`
+    Amount        float64 `json:"amount"`
+    Reference     string  `json:"reference,omitempty"`
+    Status        string  `json:"status"`
+    TransactionID string  `json:"transaction_id,omitempty"`
+    ErrorCode     string  `json:"error_code,omitempty"`
+    Error         string  `json:"error,omitempty"`
+}
+
+type BatchResponse struct {
+    ID            int              `json:"id"`
+    Mode          string           `json:"mode"`
+    DryRun        bool             `json:"dry_run"`
+    Status        string           `json:"status"`
+    TotalRows     int              `json:"total_rows"`
+    SucceededRows int              `json:"succeeded_rows"`
+    FailedRows    int              `json:"failed_rows"`
+    CreatedAt     time.Time        `json:"created_at"`
+    CompletedAt   *time.Time       `json:"completed_at,omitempty"`
+    Rows          []BatchRowResult `json:"rows"`
+}
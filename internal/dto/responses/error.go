@@ -1,9 +1,66 @@
 package responses
 
-type ErrorResponse struct {
-    Error string `json:"error"`
+import (
+    "errors"
+
+    apperrors "github.com/Andrew44Ashraf/fintech-service/internal/errors"
+)
+
+// ProblemContentType is the media type of Problem bodies (RFC 7807).
+const ProblemContentType = "application/problem+json"
+
+// Problem is an RFC 7807 problem details body extended with a stable code.
+type Problem struct {
+    Type      string          `json:"type"`
+    Title     string          `json:"title"`
+    Status    int             `json:"status"`
+    Detail    string          `json:"detail,omitempty"`
+    Instance  string          `json:"instance,omitempty"`
+    Code      apperrors.Code  `json:"code"`
+    RequestID string          `json:"request_id,omitempty"`
+    Errors    []FieldProblem  `json:"errors,omitempty"`
+
+    // Set for limit_exceeded problems
+    Limit     string          `json:"limit,omitempty"`
+    Remaining *float64        `json:"remaining,omitempty"`
 }
 
-func NewErrorResponse(err string) ErrorResponse {
-    return ErrorResponse{Error: err}
-}
\ No newline at end of file
+// FieldProblem describes one invalid request field.
+type FieldProblem struct {
+    Field   string `json:"field"`
+    Rule    string `json:"rule"`
+    Message string `json:"message"`
+}
+
+// NewProblem builds the problem body for err from the error catalog.
+// Details of server errors are never exposed.
+func NewProblem(err error, instance, requestID string) Problem {
+    entry := apperrors.Lookup(err)
+    p := Problem{
+        Type:      "/problems/" + string(entry.Code),
+        Title:     entry.Title,
+        Status:    entry.Status,
+        Instance:  instance,
+        Code:      entry.Code,
+        RequestID: requestID,
+    }
+    if entry.Client() {
+        p.Detail = err.Error()
+    }
+
+    var verr *apperrors.ValidationError
+    if errors.As(err, &verr) {
+        p.Detail = "one or more fields are invalid"
+        for _, f := range verr.Fields {
+            p.Errors = append(p.Errors, FieldProblem{Field: f.Field, Rule: f.Rule, Message: f.Message})
+        }
+    }
+
+    var lerr *apperrors.LimitExceededError
+    if errors.As(err, &lerr) {
+        remaining := lerr.Remaining
+        p.Limit = lerr.Limit
+        p.Remaining = &remaining
+    }
+    return p
+}
@@ -0,0 +1,43 @@
+package responses
+
+import "time"
+
+type FeeWaiverResponse struct {
+    ID        int        `json:"id"`
+    AccountID string     `json:"account_id"`
+    FeeType   string     `json:"fee_type"`
+    StartsAt  time.Time  `json:"starts_at"`
+    EndsAt    *time.Time `json:"ends_at,omitempty"`
+    Reason    string     `json:"reason"`
+    GrantedBy string     `json:"granted_by"`
+    RevokedBy string     `json:"revoked_by,omitempty"`
+    RevokedAt *time.Time `json:"revoked_at,omitempty"`
+    CreatedAt time.Time  `json:"created_at"`
+}
+
+type FeeWaiverListResponse struct {
+    Waivers []FeeWaiverResponse `json:"waivers"`
+}
+
+type FeeReversalResponse struct {
+    TransactionID         string `json:"transaction_id"`
+    ReversedTransactionID string `json:"reversed_transaction_id"`
+}
+
+type FeeAuditItem struct {
+    ID            int       `json:"id"`
+    Action        string    `json:"action"`
+    FeeType       string    `json:"fee_type,omitempty"`
+    WaiverID      int       `json:"waiver_id,omitempty"`
+    TransactionID string    `json:"transaction_id,omitempty"`
+    Amount        float64   `json:"amount,omitempty"`
+    Operator      string    `json:"operator"`
+    Reason        string    `json:"reason"`
+    CreatedAt     time.Time `json:"created_at"`
+}
+
+type FeeAuditListResponse struct {
+    Entries []FeeAuditItem `json:"entries"`
+    Limit   int            `json:"limit"`
+    Offset  int            `json:"offset"`
+}
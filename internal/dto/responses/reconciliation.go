@@ -0,0 +1,24 @@
+package responses
+
+import "time"
+
+type ReconciliationBreakItem struct {
+    ID              int       `json:"id"`
+    AccountID       string    `json:"account_id"`
+    Kind            string    `json:"kind"`
+    LedgerBalance   float64   `json:"ledger_balance"`
+    ExpectedBalance float64   `json:"expected_balance"`
+    Difference      float64   `json:"difference"`
+    CreatedAt       time.Time `json:"created_at"`
+}
+
+type ReconciliationRunResponse struct {
+    ID              int                       `json:"id"`
+    RunDate         string                    `json:"run_date"`
+    Trigger         string                    `json:"trigger"`
+    AccountsChecked int                       `json:"accounts_checked"`
+    BreakCount      int                       `json:"break_count"`
+    StartedAt       time.Time                 `json:"started_at"`
+    FinishedAt      *time.Time                `json:"finished_at,omitempty"`
+    Breaks          []ReconciliationBreakItem `json:"breaks"`
+}
@@ -0,0 +1,42 @@
+package responses
+
+import "time"
+
+type ScheduledPaymentResponse struct {
+    ID                  string     `json:"id"`
+    AccountID           string     `json:"account_id"`
+    ToAccountID         string     `json:"to_account_id,omitempty"`
+    Amount              float64    `json:"amount"`
+    Description         string     `json:"description,omitempty"`
+    Cron                string     `json:"cron,omitempty"`
+    Every               int        `json:"every,omitempty"`
+    Unit                string     `json:"unit,omitempty"`
+    StartAt             time.Time  `json:"start_at"`
+    EndAt               *time.Time `json:"end_at,omitempty"`
+    NextRunAt           *time.Time `json:"next_run_at,omitempty"`
+    OnInsufficientFunds string     `json:"on_insufficient_funds"`
+    MaxRetries          int        `json:"max_retries"`
+    RetryCount          int        `json:"retry_count"`
+    Status              string     `json:"status"`
+    CreatedAt           time.Time  `json:"created_at"`
+    UpdatedAt           time.Time  `json:"updated_at"`
+}
+
+type ScheduledPaymentListResponse struct {
+    ScheduledPayments []ScheduledPaymentResponse `json:"scheduled_payments"`
+}
+
+type ScheduledPaymentRunItem struct {
+    DueAt         time.Time `json:"due_at"`
+    Attempt       int       `json:"attempt"`
+    Outcome       string    `json:"outcome"`
+    TransactionID string    `json:"transaction_id,omitempty"`
+    ErrorCode     string    `json:"error_code,omitempty"`
+    RunAt         time.Time `json:"run_at"`
+}
+
+type ScheduledPaymentRunListResponse struct {
+    Runs   []ScheduledPaymentRunItem `json:"runs"`
+    Limit  int                       `json:"limit"`
+    Offset int                       `json:"offset"`
+}
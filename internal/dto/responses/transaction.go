@@ -1,15 +1,56 @@
 package responses
 
-import "time"
+import (
+    "encoding/json"
+    "time"
+)
 
 type TransactionResponse struct {
-    ID        int       `json:"id"`
-    Amount    float64   `json:"amount"`
-    Type      string    `json:"type"`
-    Timestamp time.Time `json:"timestamp"`
-    NewBalance float64  `json:"new_balance,omitempty"`
+    TransactionID string  `json:"transaction_id,omitempty"`
+    Status        string  `json:"status"`
+    NewBalance    float64 `json:"new_balance,omitempty"`
+    Message       string  `json:"message,omitempty"`
 }
 
-type ErrorResponse struct {
-    Error string `json:"error"`
+type TransactionItem struct {
+    ID           string    `json:"id"`
+    Amount       float64   `json:"amount"`
+    Type         string    `json:"type"`
+    Status       string    `json:"status"`
+    Timestamp    time.Time `json:"timestamp"`
+    FinalBalance float64   `json:"final_balance"`
+    // Dates as YYYY-MM-DD; booking_date is omitted until the transaction
+    // is posted
+    ValueDate   string `json:"value_date"`
+    BookingDate string `json:"booking_date,omitempty"`
+    Details
+}
+
+type TransactionListResponse struct {
+    Transactions []TransactionItem `json:"transactions"`
+    Limit        int               `json:"limit"`
+    Offset       int               `json:"offset"`
+}
+
+type ReviewItem struct {
+    TransactionID string    `json:"transaction_id"`
+    AccountID     string    `json:"account_id"`
+    Amount        float64   `json:"amount"`
+    Type          string    `json:"type"`
+    Reasons       []string  `json:"reasons"`
+    CreatedAt     time.Time `json:"created_at"`
+}
+
+type ReviewListResponse struct {
+    Reviews []ReviewItem `json:"reviews"`
+    Limit   int          `json:"limit"`
+    Offset  int          `json:"offset"`
+}
+
+// Details are an account's or transaction's external reference,
+// description and metadata, omitted when unset.
+type Details struct {
+    ExternalRef string          `json:"external_ref,omitempty"`
+    Description string          `json:"description,omitempty"`
+    Metadata    json.RawMessage `json:"metadata,omitempty"`
 }
\ No newline at end of file
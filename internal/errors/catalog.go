@@ -0,0 +1,132 @@
+package errors
+
+import (
+	"errors"
+	"net/http"
+)
+
+// Code is a stable, machine-readable error identifier. Codes are part of the
+// public API: never rename one, add a new code instead.
+type Code string
+
+const (
+	CodeInvalidRequest            Code = "invalid_request"
+	CodeValidationFailed          Code = "validation_failed"
+	CodeInvalidAmount             Code = "invalid_amount"
+	CodeInvalidTransaction        Code = "invalid_transaction"
+	CodeAccountNotFound           Code = "account_not_found"
+	CodeAccountClosed             Code = "account_closed"
+	CodeAccountAlreadyClosed      Code = "account_already_closed"
+	CodeAccountFrozen             Code = "account_frozen"
+	CodeAccountHasBalance         Code = "account_has_balance"
+	CodeInvalidAccountState       Code = "invalid_account_state"
+	CodeInsufficientFunds         Code = "insufficient_funds"
+	CodeLimitExceeded             Code = "limit_exceeded"
+	CodeTransactionDenied         Code = "transaction_denied"
+	CodeTransactionNotFound       Code = "transaction_not_found"
+	CodeTransactionNotAllowed     Code = "transaction_not_allowed"
+	CodeCurrencyMismatch          Code = "currency_mismatch"
+	CodeInvalidTransactionState   Code = "invalid_transaction_state"
+	CodeDuplicateExternalRef      Code = "duplicate_external_ref"
+	CodeBatchNotFound             Code = "batch_not_found"
+	CodeScheduledPaymentNotFound  Code = "scheduled_payment_not_found"
+	CodeScheduledPaymentEnded     Code = "scheduled_payment_ended"
+	CodeFeeWaiverNotFound         Code = "fee_waiver_not_found"
+	CodeFeeWaiverRevoked          Code = "fee_waiver_revoked"
+	CodeFeeAlreadyReversed        Code = "fee_already_reversed"
+	CodeReconciliationRunNotFound Code = "reconciliation_run_not_found"
+	CodeBankStatementNotFound     Code = "bank_statement_not_found"
+	CodeBankStatementDuplicate    Code = "bank_statement_duplicate"
+	CodeBankLineNotFound          Code = "bank_line_not_found"
+	CodeBankMatchNotFound         Code = "bank_match_not_found"
+	CodeAlreadyMatched            Code = "already_matched"
+	CodeTenantExists              Code = "tenant_exists"
+	CodeAPIKeyNotFound            Code = "api_key_not_found"
+	CodeAlreadyReversed           Code = "already_reversed"
+	CodeUnauthorized              Code = "unauthorized"
+	CodeForbidden                 Code = "forbidden"
+	CodePreconditionRequired      Code = "precondition_required"
+	CodeVersionMismatch           Code = "version_mismatch"
+	CodeRateLimited               Code = "rate_limited"
+	CodeRateLimitUnavailable      Code = "rate_limit_unavailable"
+	CodeContention                Code = "contention"
+	CodeInternal                  Code = "internal_error"
+)
+
+// Entry describes how an error is presented to API clients.
+type Entry struct {
+	Code   Code
+	Status int
+	Title  string
+}
+
+// Client reports whether the entry describes a client error whose message
+// is safe to expose.
+func (e Entry) Client() bool {
+	return e.Status < http.StatusInternalServerError
+}
+
+var internalEntry = Entry{CodeInternal, http.StatusInternalServerError, "Internal server error"}
+
+// catalog maps every known sentinel to its entry. Order matters only when an
+// error wraps several sentinels: the first match wins.
+var catalog = []struct {
+	err   error
+	entry Entry
+}{
+	{ErrInvalidRequest, Entry{CodeInvalidRequest, http.StatusBadRequest, "Malformed request"}},
+	{ErrNegativeAmount, Entry{CodeInvalidAmount, http.StatusBadRequest, "Invalid amount"}},
+	{ErrNegativeBalance, Entry{CodeInvalidAmount, http.StatusBadRequest, "Invalid amount"}},
+	{ErrInvalidTransaction, Entry{CodeInvalidTransaction, http.StatusBadRequest, "Invalid transaction"}},
+	{ErrAccountNotFound, Entry{CodeAccountNotFound, http.StatusNotFound, "Account not found"}},
+	{ErrAccountClosed, Entry{CodeAccountClosed, http.StatusConflict, "Account is closed"}},
+	{ErrAccountAlreadyClosed, Entry{CodeAccountAlreadyClosed, http.StatusConflict, "Account is already closed"}},
+	{ErrAccountFrozen, Entry{CodeAccountFrozen, http.StatusConflict, "Account is frozen"}},
+	{ErrAccountHasBalance, Entry{CodeAccountHasBalance, http.StatusConflict, "Account balance is not zero"}},
+	{ErrInvalidAccountState, Entry{CodeInvalidAccountState, http.StatusConflict, "Invalid account state"}},
+	{ErrInsufficientFunds, Entry{CodeInsufficientFunds, http.StatusUnprocessableEntity, "Insufficient funds"}},
+	{ErrLimitExceeded, Entry{CodeLimitExceeded, http.StatusUnprocessableEntity, "Transaction limit exceeded"}},
+	{ErrTransactionDenied, Entry{CodeTransactionDenied, http.StatusUnprocessableEntity, "Transaction declined"}},
+	{ErrTransactionNotFound, Entry{CodeTransactionNotFound, http.StatusNotFound, "Transaction not found"}},
+	{ErrTransactionNotAllowed, Entry{CodeTransactionNotAllowed, http.StatusUnprocessableEntity, "Transaction not allowed for this product"}},
+	{ErrCurrencyMismatch, Entry{CodeCurrencyMismatch, http.StatusUnprocessableEntity, "Currency mismatch"}},
+	{ErrInvalidTransactionState, Entry{CodeInvalidTransactionState, http.StatusConflict, "Invalid transaction state"}},
+	{ErrDuplicateExternalRef, Entry{CodeDuplicateExternalRef, http.StatusConflict, "Duplicate external reference"}},
+	{ErrBatchNotFound, Entry{CodeBatchNotFound, http.StatusNotFound, "Batch not found"}},
+	{ErrScheduledPaymentNotFound, Entry{CodeScheduledPaymentNotFound, http.StatusNotFound, "Scheduled payment not found"}},
+	{ErrScheduledPaymentEnded, Entry{CodeScheduledPaymentEnded, http.StatusConflict, "Scheduled payment has ended"}},
+	{ErrFeeWaiverNotFound, Entry{CodeFeeWaiverNotFound, http.StatusNotFound, "Fee waiver not found"}},
+	{ErrFeeWaiverRevoked, Entry{CodeFeeWaiverRevoked, http.StatusConflict, "Fee waiver already revoked"}},
+	{ErrFeeAlreadyReversed, Entry{CodeFeeAlreadyReversed, http.StatusConflict, "Fee already reversed"}},
+	{ErrReconciliationRunNotFound, Entry{CodeReconciliationRunNotFound, http.StatusNotFound, "Reconciliation run not found"}},
+	{ErrBankStatementNotFound, Entry{CodeBankStatementNotFound, http.StatusNotFound, "Bank statement not found"}},
+	{ErrBankStatementDuplicate, Entry{CodeBankStatementDuplicate, http.StatusConflict, "Bank statement already imported"}},
+	{ErrBankLineNotFound, Entry{CodeBankLineNotFound, http.StatusNotFound, "Bank statement line not found"}},
+	{ErrBankMatchNotFound, Entry{CodeBankMatchNotFound, http.StatusNotFound, "Bank match not found"}},
+	{ErrAlreadyMatched, Entry{CodeAlreadyMatched, http.StatusConflict, "Already matched"}},
+	{ErrTenantExists, Entry{CodeTenantExists, http.StatusConflict, "Tenant already exists"}},
+	{ErrAPIKeyNotFound, Entry{CodeAPIKeyNotFound, http.StatusNotFound, "API key not found"}},
+	{ErrAlreadyReversed, Entry{CodeAlreadyReversed, http.StatusConflict, "Transaction already reversed"}},
+	{ErrUnauthorized, Entry{CodeUnauthorized, http.StatusUnauthorized, "Unauthorized"}},
+	{ErrForbidden, Entry{CodeForbidden, http.StatusForbidden, "Forbidden"}},
+	{ErrPreconditionRequired, Entry{CodePreconditionRequired, http.StatusPreconditionRequired, "Precondition required"}},
+	{ErrVersionMismatch, Entry{CodeVersionMismatch, http.StatusPreconditionFailed, "Precondition failed"}},
+	{ErrRateLimited, Entry{CodeRateLimited, http.StatusTooManyRequests, "Too many requests"}},
+	{ErrRateLimitUnavailable, Entry{CodeRateLimitUnavailable, http.StatusServiceUnavailable, "Rate limiter unavailable"}},
+	{ErrContention, Entry{CodeContention, http.StatusServiceUnavailable, "Too many concurrent updates"}},
+}
+
+// Lookup returns the catalog entry for err. Unknown errors map to
+// internal_error.
+func Lookup(err error) Entry {
+	var verr *ValidationError
+	if errors.As(err, &verr) {
+		return Entry{CodeValidationFailed, http.StatusBadRequest, "Request validation failed"}
+	}
+	for _, c := range catalog {
+		if errors.Is(err, c.err) {
+			return c.entry
+		}
+	}
+	return internalEntry
+}
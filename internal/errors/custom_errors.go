@@ -4,7 +4,48 @@ import "errors"
 
 // Predefined errors for standardization
 var (
-	ErrAccountNotFound    = errors.New("account not found")
-	ErrInsufficientFunds  = errors.New("insufficient funds")
-	ErrInvalidTransaction = errors.New("invalid transaction")
+	ErrAccountNotFound       = errors.New("account not found")
+	ErrAccountClosed         = errors.New("account is closed")
+	ErrAccountAlreadyClosed  = errors.New("account is already closed")
+	ErrAccountFrozen         = errors.New("account is frozen")
+	ErrAccountHasBalance     = errors.New("account balance must be zero to close it")
+	ErrInvalidAccountState   = errors.New("account is not in a state that allows this operation")
+	ErrInsufficientFunds     = errors.New("insufficient funds")
+	ErrInvalidTransaction    = errors.New("invalid transaction")
+	ErrNegativeAmount        = errors.New("amount must be positive")
+	ErrNegativeBalance       = errors.New("balance cannot be negative")
+	ErrInvalidRequest        = errors.New("malformed request")
+	ErrRateLimited           = errors.New("rate limit exceeded")
+	ErrRateLimitUnavailable  = errors.New("rate limiter unavailable")
+	ErrContention            = errors.New("too many concurrent updates, try again")
+	ErrTransactionDenied     = errors.New("transaction declined")
+	ErrTransactionNotFound   = errors.New("transaction not found")
+	ErrTransactionNotAllowed = errors.New("transaction type is not allowed for this account product")
+	ErrCurrencyMismatch      = errors.New("accounts hold different currencies")
+	ErrDuplicateExternalRef  = errors.New("external_ref has already been used")
+	ErrUnauthorized          = errors.New("missing or invalid credentials")
+	ErrForbidden             = errors.New("operation not permitted")
+	ErrPreconditionRequired  = errors.New("an If-Match header is required")
+	ErrVersionMismatch       = errors.New("resource has changed since it was read")
+	ErrBatchNotFound         = errors.New("batch not found")
+
+	ErrScheduledPaymentNotFound = errors.New("scheduled payment not found")
+	ErrScheduledPaymentEnded    = errors.New("scheduled payment has been completed or cancelled")
+
+	ErrFeeWaiverNotFound  = errors.New("fee waiver not found")
+	ErrFeeWaiverRevoked   = errors.New("fee waiver has already been revoked")
+	ErrFeeAlreadyReversed = errors.New("fee has already been reversed")
+
+	ErrReconciliationRunNotFound = errors.New("reconciliation run not found")
+	ErrBankStatementNotFound     = errors.New("bank statement not found")
+	ErrBankStatementDuplicate    = errors.New("bank statement has already been imported")
+	ErrBankLineNotFound          = errors.New("bank statement line not found")
+	ErrBankMatchNotFound         = errors.New("bank match not found or already unmatched")
+	ErrAlreadyMatched            = errors.New("bank line or transaction is already matched")
+
+	ErrTenantExists    = errors.New("tenant already exists")
+	ErrAPIKeyNotFound  = errors.New("api key not found or already revoked")
+	ErrAlreadyReversed = errors.New("transaction has already been reversed")
+
+	ErrInvalidTransactionState = errors.New("transaction is not in a state that allows this operation")
 )
@@ -0,0 +1,33 @@
+package errors
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrLimitExceeded is matched by every *LimitExceededError.
+var ErrLimitExceeded = errors.New("limit exceeded")
+
+// Limit kinds reported by LimitExceededError.
+const (
+	LimitSingleWithdrawal  = "single_withdrawal"
+	LimitDailyWithdrawal   = "daily_withdrawal_amount"
+	LimitMonthlyWithdrawal = "monthly_withdrawal_amount"
+	LimitDailyWithdrawals  = "daily_withdrawal_count"
+)
+
+// LimitExceededError reports which limit a transaction hit and how much of
+// it is still available.
+type LimitExceededError struct {
+	Limit     string
+	Max       float64
+	Remaining float64
+}
+
+func (e *LimitExceededError) Error() string {
+	return fmt.Sprintf("%s limit of %.2f exceeded, remaining allowance %.2f", e.Limit, e.Max, e.Remaining)
+}
+
+func (e *LimitExceededError) Is(target error) bool {
+	return target == ErrLimitExceeded
+}
@@ -0,0 +1,78 @@
+package errors
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// FieldViolation describes a single invalid request field.
+type FieldViolation struct {
+	Field   string
+	Rule    string
+	Message string
+}
+
+// ValidationError carries field-level details for a rejected request.
+type ValidationError struct {
+	Fields []FieldViolation
+}
+
+func (e *ValidationError) Error() string {
+	parts := make([]string, 0, len(e.Fields))
+	for _, f := range e.Fields {
+		parts = append(parts, f.Field+": "+f.Message)
+	}
+	return "validation failed: " + strings.Join(parts, "; ")
+}
+
+// Invalid returns a validation error for a single field.
+func Invalid(field, rule, message string) error {
+	return &ValidationError{Fields: []FieldViolation{{Field: field, Rule: rule, Message: message}}}
+}
+
+// FromValidator converts validator output into a ValidationError so raw
+// validator messages never reach clients. Other errors are returned as is.
+func FromValidator(err error) error {
+	var verrs validator.ValidationErrors
+	if !errors.As(err, &verrs) {
+		return err
+	}
+
+	out := &ValidationError{}
+	for _, fe := range verrs {
+		out.Fields = append(out.Fields, FieldViolation{
+			Field:   fe.Field(),
+			Rule:    fe.Tag(),
+			Message: describeRule(fe),
+		})
+	}
+	return out
+}
+
+func describeRule(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "gt":
+		return fmt.Sprintf("must be greater than %s", fe.Param())
+	case "gte":
+		return fmt.Sprintf("must be at least %s", fe.Param())
+	case "lt":
+		return fmt.Sprintf("must be less than %s", fe.Param())
+	case "lte":
+		return fmt.Sprintf("must be at most %s", fe.Param())
+	case "max":
+		return fmt.Sprintf("must be at most %s", fe.Param())
+	case "min":
+		return fmt.Sprintf("must be at least %s", fe.Param())
+	case "oneof":
+		return fmt.Sprintf("must be one of: %s", fe.Param())
+	case "datetime":
+		return fmt.Sprintf("must be a date in the layout %s", fe.Param())
+	default:
+		return "is invalid"
+	}
+}
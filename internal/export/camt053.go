@@ -0,0 +1,225 @@
+package export
+
+import (
+	"encoding/xml"
+	"io"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/Andrew44Ashraf/fintech-service/internal/statements"
+)
+
+const camt053Namespace = "urn:iso:std:iso:20022:tech:xsd:camt.053.001.08"
+
+const (
+	isoDate     = "2006-01-02"
+	isoDateTime = "2006-01-02T15:04:05Z"
+)
+
+type camtDocument struct {
+	XMLName xml.Name      `xml:"Document"`
+	Xmlns   string        `xml:"xmlns,attr"`
+	Stmt    camtStatement `xml:"BkToCstmrStmt"`
+}
+
+type camtStatement struct {
+	GrpHdr camtGroupHeader   `xml:"GrpHdr"`
+	Stmt   camtAccountReport `xml:"Stmt"`
+}
+
+type camtGroupHeader struct {
+	MsgID   string `xml:"MsgId"`
+	CreDtTm string `xml:"CreDtTm"`
+}
+
+type camtAccountReport struct {
+	ID       string        `xml:"Id"`
+	CreDtTm  string        `xml:"CreDtTm"`
+	FrToDt   camtPeriod    `xml:"FrToDt"`
+	Acct     camtAccount   `xml:"Acct"`
+	Bal      []camtBalance `xml:"Bal"`
+	TxsSummy camtTxSummary `xml:"TxsSummry"`
+	Ntry     []camtEntry   `xml:"Ntry"`
+}
+
+type camtPeriod struct {
+	FrDtTm string `xml:"FrDtTm"`
+	ToDtTm string `xml:"ToDtTm"`
+}
+
+type camtAccount struct {
+	ID  camtAccountID `xml:"Id"`
+	Ccy string        `xml:"Ccy"`
+}
+
+type camtAccountID struct {
+	Othr struct {
+		ID string `xml:"Id"`
+	} `xml:"Othr"`
+}
+
+type camtAmount struct {
+	Ccy   string `xml:"Ccy,attr"`
+	Value string `xml:",chardata"`
+}
+
+type camtCode struct {
+	Cd string `xml:"Cd"`
+}
+
+type camtDate struct {
+	Dt string `xml:"Dt"`
+}
+
+type camtBalance struct {
+	Tp struct {
+		CdOrPrtry camtCode `xml:"CdOrPrtry"`
+	} `xml:"Tp"`
+	Amt       camtAmount `xml:"Amt"`
+	CdtDbtInd string     `xml:"CdtDbtInd"`
+	Dt        camtDate   `xml:"Dt"`
+}
+
+type camtTxSummary struct {
+	TtlNtries struct {
+		NbOfNtries string `xml:"NbOfNtries"`
+		Sum        string `xml:"Sum"`
+	} `xml:"TtlNtries"`
+	TtlCdtNtries camtCountAndSum `xml:"TtlCdtNtries"`
+	TtlDbtNtries camtCountAndSum `xml:"TtlDbtNtries"`
+}
+
+type camtCountAndSum struct {
+	NbOfNtries string `xml:"NbOfNtries"`
+	Sum        string `xml:"Sum"`
+}
+
+type camtEntry struct {
+	NtryRef     string      `xml:"NtryRef"`
+	Amt         camtAmount  `xml:"Amt"`
+	CdtDbtInd   string      `xml:"CdtDbtInd"`
+	Sts         camtCode    `xml:"Sts"`
+	BookgDt     camtDate    `xml:"BookgDt"`
+	ValDt       camtDate    `xml:"ValDt"`
+	AcctSvcrRef string      `xml:"AcctSvcrRef"`
+	BkTxCd      camtTxCode  `xml:"BkTxCd"`
+	NtryDtls    camtDetails `xml:"NtryDtls"`
+	AddtlInf    string      `xml:"AddtlNtryInf"`
+}
+
+type camtTxCode struct {
+	Domn struct {
+		Cd   string `xml:"Cd"`
+		Fmly struct {
+			Cd        string `xml:"Cd"`
+			SubFmlyCd string `xml:"SubFmlyCd"`
+		} `xml:"Fmly"`
+	} `xml:"Domn"`
+}
+
+type camtDetails struct {
+	TxDtls struct {
+		Refs struct {
+			AcctSvcrRef string `xml:"AcctSvcrRef"`
+			EndToEndID  string `xml:"EndToEndId"`
+		} `xml:"Refs"`
+	} `xml:"TxDtls"`
+}
+
+// Camt053 writes s as an ISO 20022 camt.053.001.08 bank-to-customer
+// statement with booked opening (OPBD) and closing (CLBD) balances.
+func Camt053(w io.Writer, s *statements.Statement, now time.Time) error {
+	ccy := currency(s)
+	created := now.UTC().Format(isoDateTime)
+
+	report := camtAccountReport{
+		ID:      statementID(s),
+		CreDtTm: created,
+		FrToDt: camtPeriod{
+			FrDtTm: s.From.UTC().Format(isoDateTime),
+			ToDtTm: s.To.UTC().Add(-time.Second).Format(isoDateTime),
+		},
+		Acct: camtAccount{Ccy: ccy},
+		Bal: []camtBalance{
+			camtBalanceOf("OPBD", s.OpeningBalance, ccy, s.From),
+			camtBalanceOf("CLBD", s.ClosingBalance, ccy, lastDay(s)),
+		},
+	}
+	report.Acct.ID.Othr.ID = strconv.Itoa(s.AccountID)
+
+	var (
+		credits, debits            int
+		creditSum, debitSum, total float64
+	)
+	for _, l := range s.Lines {
+		ref := strconv.Itoa(l.TransactionID)
+		e := camtEntry{
+			NtryRef:     ref,
+			Amt:         camtAmount{Ccy: ccy, Value: formatAmount(l.Amount)},
+			CdtDbtInd:   creditDebit(l.Credit()),
+			Sts:         camtCode{Cd: "BOOK"},
+			BookgDt:     camtDate{Dt: l.Date.UTC().Format(isoDate)},
+			ValDt:       camtDate{Dt: l.ValueDay().Format(isoDate)},
+			AcctSvcrRef: ref,
+			AddtlInf:    l.Type,
+		}
+		e.BkTxCd.Domn.Cd, e.BkTxCd.Domn.Fmly.Cd, e.BkTxCd.Domn.Fmly.SubFmlyCd = bankTxCode(l.Type)
+		e.NtryDtls.TxDtls.Refs.AcctSvcrRef = ref
+		e.NtryDtls.TxDtls.Refs.EndToEndID = "NOTPROVIDED"
+		report.Ntry = append(report.Ntry, e)
+
+		total += l.Amount
+		if l.Credit() {
+			credits++
+			creditSum += l.Amount
+		} else {
+			debits++
+			debitSum += l.Amount
+		}
+	}
+	report.TxsSummy.TtlNtries.NbOfNtries = strconv.Itoa(len(s.Lines))
+	report.TxsSummy.TtlNtries.Sum = formatAmount(total)
+	report.TxsSummy.TtlCdtNtries = camtCountAndSum{strconv.Itoa(credits), formatAmount(creditSum)}
+	report.TxsSummy.TtlDbtNtries = camtCountAndSum{strconv.Itoa(debits), formatAmount(debitSum)}
+
+	doc := camtDocument{
+		Xmlns: camt053Namespace,
+		Stmt: camtStatement{
+			GrpHdr: camtGroupHeader{MsgID: "STMT-" + statementID(s), CreDtTm: created},
+			Stmt:   report,
+		},
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+func camtBalanceOf(code string, balance float64, ccy string, date time.Time) camtBalance {
+	b := camtBalance{
+		Amt:       camtAmount{Ccy: ccy, Value: formatAmount(math.Abs(balance))},
+		CdtDbtInd: creditDebit(balance >= 0),
+		Dt:        camtDate{Dt: date.UTC().Format(isoDate)},
+	}
+	b.Tp.CdOrPrtry.Cd = code
+	return b
+}
+
+func creditDebit(credit bool) string {
+	if credit {
+		return "CRDT"
+	}
+	return "DBIT"
+}
+
+func formatAmount(v float64) string {
+	return strconv.FormatFloat(v, 'f', 2, 64)
+}
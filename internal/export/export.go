@@ -0,0 +1,102 @@
+// Package export renders account statements in bank statement interchange
+// formats: ISO 20022 camt.053 and SWIFT MT940.
+package export
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/Andrew44Ashraf/fintech-service/internal/statements"
+)
+
+// Supported formats
+const (
+	FormatCamt053 = "camt053"
+	FormatMT940   = "mt940"
+)
+
+// DefaultCurrency is used for statements that don't carry a currency.
+const DefaultCurrency = "USD"
+
+// Supports reports whether format is an export format.
+func Supports(format string) bool {
+	return format == FormatCamt053 || format == FormatMT940
+}
+
+// ContentType returns the media type for format.
+func ContentType(format string) string {
+	if format == FormatMT940 {
+		return "text/plain; charset=utf-8"
+	}
+	return "application/xml; charset=utf-8"
+}
+
+// FileExtension returns the conventional file extension for format.
+func FileExtension(format string) string {
+	if format == FormatMT940 {
+		return "sta"
+	}
+	return "xml"
+}
+
+// Render writes s to w in the given export format. now stamps the message
+// creation time.
+func Render(w io.Writer, s *statements.Statement, format string, now time.Time) error {
+	switch format {
+	case FormatCamt053:
+		return Camt053(w, s, now)
+	case FormatMT940:
+		return MT940(w, s)
+	default:
+		return fmt.Errorf("unsupported export format %q", format)
+	}
+}
+
+func currency(s *statements.Statement) string {
+	if s.Currency != "" {
+		return s.Currency
+	}
+	return DefaultCurrency
+}
+
+// statementID identifies a statement in both formats, e.g. "42-20260301".
+func statementID(s *statements.Statement) string {
+	return fmt.Sprintf("%d-%s", s.AccountID, s.From.Format("20060102"))
+}
+
+// lastDay is the last day covered by the half-open statement period.
+func lastDay(s *statements.Statement) time.Time {
+	return s.To.AddDate(0, 0, -1)
+}
+
+// bankTxCode maps transaction types to ISO 20022 bank transaction codes
+// (domain, family, sub-family).
+func bankTxCode(txType string) (string, string, string) {
+	switch txType {
+	case "deposit":
+		return "PMNT", "CNTR", "CDPT"
+	case "withdrawal":
+		return "PMNT", "CNTR", "CWDL"
+	case "bank_transfer_in":
+		return "PMNT", "RCDT", "OTHR"
+	case "interest":
+		return "ACMT", "MCOP", "INTR"
+	default:
+		return "PMNT", "MCOP", "OTHR"
+	}
+}
+
+// swiftTxCode maps transaction types to MT940 transaction type codes.
+func swiftTxCode(txType string) string {
+	switch txType {
+	case "transfer_in", "transfer_out", "bank_transfer_in":
+		return "NTRF"
+	case "interest":
+		return "NINT"
+	case "fee":
+		return "NCHG"
+	default:
+		return "NMSC"
+	}
+}
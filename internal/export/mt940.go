@@ -0,0 +1,74 @@
+package export
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Andrew44Ashraf/fintech-service/internal/statements"
+)
+
+// mt940Line is the MT940 line separator.
+const mt940Line = "\r\n"
+
+// MT940 writes s as the text block of a SWIFT MT940 customer statement:
+// :60F: opening and :62F: closing booked balances and one :61:/:86: pair
+// per entry. Our transaction ID is used as the servicing institution's
+// reference.
+func MT940(w io.Writer, s *statements.Statement) error {
+	ccy := currency(s)
+
+	var b strings.Builder
+	tag := func(name, value string) {
+		b.WriteString(":" + name + ":" + value + mt940Line)
+	}
+
+	tag("20", truncate("STMT"+statementID(s), 16))
+	tag("25", strconv.Itoa(s.AccountID))
+	tag("28C", s.From.Format("0601")+"/1")
+	tag("60F", mt940Balance(s.OpeningBalance, s.From, ccy))
+
+	for _, l := range s.Lines {
+		tag("61", fmt.Sprintf("%s%s%s%s%sNONREF//%s",
+			l.ValueDay().Format("060102"), // value date
+			l.Date.UTC().Format("0102"),   // entry date
+			mt940Mark(l.Credit()),
+			mt940Amount(l.Amount),
+			swiftTxCode(l.Type),
+			truncate(strconv.Itoa(l.TransactionID), 16),
+		))
+		tag("86", truncate(l.Type, 65))
+	}
+
+	tag("62F", mt940Balance(s.ClosingBalance, lastDay(s), ccy))
+	b.WriteString("-" + mt940Line)
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func mt940Balance(balance float64, date time.Time, ccy string) string {
+	return mt940Mark(balance >= 0) + date.UTC().Format("060102") + ccy + mt940Amount(math.Abs(balance))
+}
+
+func mt940Mark(credit bool) string {
+	if credit {
+		return "C"
+	}
+	return "D"
+}
+
+// mt940Amount formats amounts with a decimal comma, as SWIFT requires.
+func mt940Amount(v float64) string {
+	return strings.Replace(strconv.FormatFloat(v, 'f', 2, 64), ".", ",", 1)
+}
+
+func truncate(s string, n int) string {
+	if len(s) > n {
+		return s[:n]
+	}
+	return s
+}
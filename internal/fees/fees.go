@@ -0,0 +1,54 @@
+// Package fees describes the fee schedule of an account product and works
+// out which fees are due.
+package fees
+
+// Type identifies what a fee is charged for.
+type Type string
+
+const (
+	// Withdrawal is charged on every posted withdrawal.
+	Withdrawal Type = "withdrawal"
+	// Maintenance is charged once a month.
+	Maintenance Type = "maintenance"
+	// LowBalance is charged once a month when the balance fell below the
+	// product's threshold at any point during the month.
+	LowBalance Type = "low_balance"
+)
+
+// Valid reports whether t is a known fee type.
+func (t Type) Valid() bool {
+	switch t {
+	case Withdrawal, Maintenance, LowBalance:
+		return true
+	}
+	return false
+}
+
+// Schedule is the fee configuration of an account product. Zero amounts
+// mean the fee is not charged.
+type Schedule struct {
+	ProductCode         string
+	WithdrawalFee       float64
+	MaintenanceFee      float64
+	LowBalanceThreshold float64
+	LowBalanceFee       float64
+}
+
+// Charge is one fee that is due.
+type Charge struct {
+	Type   Type
+	Amount float64
+}
+
+// Monthly returns the fees due for a month in which the balance never went
+// below lowestBalance.
+func (s Schedule) Monthly(lowestBalance float64) []Charge {
+	var charges []Charge
+	if s.MaintenanceFee > 0 {
+		charges = append(charges, Charge{Type: Maintenance, Amount: s.MaintenanceFee})
+	}
+	if s.LowBalanceFee > 0 && lowestBalance < s.LowBalanceThreshold {
+		charges = append(charges, Charge{Type: LowBalance, Amount: s.LowBalanceFee})
+	}
+	return charges
+}
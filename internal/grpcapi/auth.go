@@ -0,0 +1,93 @@
+package grpcapi
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+
+	"github.com/Andrew44Ashraf/fintech-service/internal/auth"
+	apperrors "github.com/Andrew44Ashraf/fintech-service/internal/errors"
+	"github.com/Andrew44Ashraf/fintech-service/internal/logging"
+	"github.com/Andrew44Ashraf/fintech-service/internal/tenant"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// Metadata keys read from incoming calls. gRPC metadata keys are lower case.
+const (
+	APIKeyMetadata    = "x-api-key"
+	RequestIDMetadata = "x-request-id"
+)
+
+// maxRequestIDLen matches the REST middleware.
+const maxRequestIDLen = 128
+
+// authenticate attaches the caller's request ID to ctx, checks its API key
+// and attaches the key's tenant.
+func authenticate(ctx context.Context, keys auth.KeyChecker) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	id := first(md, RequestIDMetadata)
+	if id == "" || len(id) > maxRequestIDLen {
+		id = newRequestID()
+	}
+	ctx = logging.WithRequestID(ctx, id)
+
+	key := first(md, APIKeyMetadata)
+	if key == "" {
+		return ctx, apperrors.ErrUnauthorized
+	}
+	id, err := keys.CheckKey(ctx, key)
+	if err != nil {
+		return ctx, err
+	}
+	return tenant.WithID(ctx, id), nil
+}
+
+// UnaryAuth rejects unary calls without a valid API key.
+func UnaryAuth(keys auth.KeyChecker, logger *slog.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		ctx, err := authenticate(ctx, keys)
+		if err != nil {
+			return nil, fail(ctx, logger, info.FullMethod, err)
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamAuth rejects streaming calls without a valid API key.
+func StreamAuth(keys auth.KeyChecker, logger *slog.Logger) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := authenticate(ss.Context(), keys)
+		if err != nil {
+			return fail(ctx, logger, info.FullMethod, err)
+		}
+		return handler(srv, &contextStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+// contextStream overrides the context of a server stream.
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextStream) Context() context.Context {
+	return s.ctx
+}
+
+func first(md metadata.MD, key string) string {
+	if v := md.Get(key); len(v) > 0 {
+		return v[0]
+	}
+	return ""
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "unknown"
+	}
+	return hex.EncodeToString(b)
+}
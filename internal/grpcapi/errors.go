@@ -0,0 +1,84 @@
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	apperrors "github.com/Andrew44Ashraf/fintech-service/internal/errors"
+	"github.com/Andrew44Ashraf/fintech-service/internal/logging"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
+)
+
+// ErrorDomain is the ErrorInfo domain of every error the service returns.
+// The ErrorInfo reason is the catalog code, the same one the REST API puts
+// in problem responses.
+const ErrorDomain = "fintech-service"
+
+// codeForStatus maps the catalog's HTTP statuses onto gRPC codes.
+var codeForStatus = map[int]codes.Code{
+	http.StatusBadRequest:           codes.InvalidArgument,
+	http.StatusUnauthorized:         codes.Unauthenticated,
+	http.StatusForbidden:            codes.PermissionDenied,
+	http.StatusNotFound:             codes.NotFound,
+	http.StatusConflict:             codes.FailedPrecondition,
+	http.StatusPreconditionFailed:   codes.FailedPrecondition,
+	http.StatusPreconditionRequired: codes.FailedPrecondition,
+	http.StatusUnprocessableEntity:  codes.FailedPrecondition,
+	http.StatusTooManyRequests:      codes.ResourceExhausted,
+	http.StatusServiceUnavailable:   codes.Aborted,
+}
+
+// Status converts err into a gRPC status using the central error catalog.
+// Messages of internal errors are replaced by the catalog title so they
+// never reach clients.
+func Status(err error) *status.Status {
+	entry := apperrors.Lookup(err)
+	code, ok := codeForStatus[entry.Status]
+	if !ok {
+		code = codes.Internal
+	}
+
+	msg := entry.Title
+	if entry.Client() {
+		msg = err.Error()
+	}
+
+	details := []protoadapt.MessageV1{&errdetails.ErrorInfo{Reason: string(entry.Code), Domain: ErrorDomain}}
+	var verr *apperrors.ValidationError
+	if errors.As(err, &verr) {
+		br := &errdetails.BadRequest{}
+		for _, f := range verr.Fields {
+			br.FieldViolations = append(br.FieldViolations, &errdetails.BadRequest_FieldViolation{
+				Field:       f.Field,
+				Description: f.Message,
+			})
+		}
+		details = append(details, br)
+	}
+
+	st := status.New(code, msg)
+	if withDetails, err := st.WithDetails(details...); err == nil {
+		st = withDetails
+	}
+	return st
+}
+
+// fail logs err the way handlers.respondError does and returns it as a
+// gRPC status error.
+func fail(ctx context.Context, logger *slog.Logger, op string, err error) error {
+	st := Status(err)
+	entry := apperrors.Lookup(err)
+
+	log := logging.FromContext(ctx, logger)
+	if !entry.Client() {
+		log.Error(op+" failed", logging.KeyOp, op, "code", entry.Code, logging.KeyError, err.Error())
+	} else {
+		log.Debug(op+" rejected", logging.KeyOp, op, "code", entry.Code, logging.KeyError, err.Error())
+	}
+	return st.Err()
+}
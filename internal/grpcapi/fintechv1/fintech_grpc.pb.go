@@ -0,0 +1,392 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             v4.25.3
+// source: fintech/v1/fintech.proto
+
+package fintechv1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+const (
+	FintechService_OpenAccount_FullMethodName      = "/fintech.v1.FintechService/OpenAccount"
+	FintechService_GetBalance_FullMethodName       = "/fintech.v1.FintechService/GetBalance"
+	FintechService_Deposit_FullMethodName          = "/fintech.v1.FintechService/Deposit"
+	FintechService_Withdraw_FullMethodName         = "/fintech.v1.FintechService/Withdraw"
+	FintechService_Transfer_FullMethodName         = "/fintech.v1.FintechService/Transfer"
+	FintechService_ListTransactions_FullMethodName = "/fintech.v1.FintechService/ListTransactions"
+	FintechService_WatchAccount_FullMethodName     = "/fintech.v1.FintechService/WatchAccount"
+)
+
+// FintechServiceClient is the client API for FintechService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type FintechServiceClient interface {
+	OpenAccount(ctx context.Context, in *OpenAccountRequest, opts ...grpc.CallOption) (*Account, error)
+	GetBalance(ctx context.Context, in *GetBalanceRequest, opts ...grpc.CallOption) (*Balance, error)
+	Deposit(ctx context.Context, in *DepositRequest, opts ...grpc.CallOption) (*TransactionResult, error)
+	Withdraw(ctx context.Context, in *WithdrawRequest, opts ...grpc.CallOption) (*TransactionResult, error)
+	Transfer(ctx context.Context, in *TransferRequest, opts ...grpc.CallOption) (*TransactionResult, error)
+	// ListTransactions streams an account's history, newest first.
+	ListTransactions(ctx context.Context, in *ListTransactionsRequest, opts ...grpc.CallOption) (FintechService_ListTransactionsClient, error)
+	// WatchAccount sends the current balance, then every change to it until
+	// the client cancels.
+	WatchAccount(ctx context.Context, in *WatchAccountRequest, opts ...grpc.CallOption) (FintechService_WatchAccountClient, error)
+}
+
+type fintechServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewFintechServiceClient(cc grpc.ClientConnInterface) FintechServiceClient {
+	return &fintechServiceClient{cc}
+}
+
+func (c *fintechServiceClient) OpenAccount(ctx context.Context, in *OpenAccountRequest, opts ...grpc.CallOption) (*Account, error) {
+	out := new(Account)
+	err := c.cc.Invoke(ctx, FintechService_OpenAccount_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *fintechServiceClient) GetBalance(ctx context.Context, in *GetBalanceRequest, opts ...grpc.CallOption) (*Balance, error) {
+	out := new(Balance)
+	err := c.cc.Invoke(ctx, FintechService_GetBalance_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *fintechServiceClient) Deposit(ctx context.Context, in *DepositRequest, opts ...grpc.CallOption) (*TransactionResult, error) {
+	out := new(TransactionResult)
+	err := c.cc.Invoke(ctx, FintechService_Deposit_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *fintechServiceClient) Withdraw(ctx context.Context, in *WithdrawRequest, opts ...grpc.CallOption) (*TransactionResult, error) {
+	out := new(TransactionResult)
+	err := c.cc.Invoke(ctx, FintechService_Withdraw_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *fintechServiceClient) Transfer(ctx context.Context, in *TransferRequest, opts ...grpc.CallOption) (*TransactionResult, error) {
+	out := new(TransactionResult)
+	err := c.cc.Invoke(ctx, FintechService_Transfer_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *fintechServiceClient) ListTransactions(ctx context.Context, in *ListTransactionsRequest, opts ...grpc.CallOption) (FintechService_ListTransactionsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &FintechService_ServiceDesc.Streams[0], FintechService_ListTransactions_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &fintechServiceListTransactionsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type FintechService_ListTransactionsClient interface {
+	Recv() (*Transaction, error)
+	grpc.ClientStream
+}
+
+type fintechServiceListTransactionsClient struct {
+	grpc.ClientStream
+}
+
+func (x *fintechServiceListTransactionsClient) Recv() (*Transaction, error) {
+	m := new(Transaction)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *fintechServiceClient) WatchAccount(ctx context.Context, in *WatchAccountRequest, opts ...grpc.CallOption) (FintechService_WatchAccountClient, error) {
+	stream, err := c.cc.NewStream(ctx, &FintechService_ServiceDesc.Streams[1], FintechService_WatchAccount_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &fintechServiceWatchAccountClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type FintechService_WatchAccountClient interface {
+	Recv() (*Balance, error)
+	grpc.ClientStream
+}
+
+type fintechServiceWatchAccountClient struct {
+	grpc.ClientStream
+}
+
+func (x *fintechServiceWatchAccountClient) Recv() (*Balance, error) {
+	m := new(Balance)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// FintechServiceServer is the server API for FintechService service.
+// All implementations must embed UnimplementedFintechServiceServer
+// for forward compatibility
+type FintechServiceServer interface {
+	OpenAccount(context.Context, *OpenAccountRequest) (*Account, error)
+	GetBalance(context.Context, *GetBalanceRequest) (*Balance, error)
+	Deposit(context.Context, *DepositRequest) (*TransactionResult, error)
+	Withdraw(context.Context, *WithdrawRequest) (*TransactionResult, error)
+	Transfer(context.Context, *TransferRequest) (*TransactionResult, error)
+	// ListTransactions streams an account's history, newest first.
+	ListTransactions(*ListTransactionsRequest, FintechService_ListTransactionsServer) error
+	// WatchAccount sends the current balance, then every change to it until
+	// the client cancels.
+	WatchAccount(*WatchAccountRequest, FintechService_WatchAccountServer) error
+	mustEmbedUnimplementedFintechServiceServer()
+}
+
+// UnimplementedFintechServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedFintechServiceServer struct {
+}
+
+func (UnimplementedFintechServiceServer) OpenAccount(context.Context, *OpenAccountRequest) (*Account, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method OpenAccount not implemented")
+}
+func (UnimplementedFintechServiceServer) GetBalance(context.Context, *GetBalanceRequest) (*Balance, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetBalance not implemented")
+}
+func (UnimplementedFintechServiceServer) Deposit(context.Context, *DepositRequest) (*TransactionResult, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Deposit not implemented")
+}
+func (UnimplementedFintechServiceServer) Withdraw(context.Context, *WithdrawRequest) (*TransactionResult, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Withdraw not implemented")
+}
+func (UnimplementedFintechServiceServer) Transfer(context.Context, *TransferRequest) (*TransactionResult, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Transfer not implemented")
+}
+func (UnimplementedFintechServiceServer) ListTransactions(*ListTransactionsRequest, FintechService_ListTransactionsServer) error {
+	return status.Errorf(codes.Unimplemented, "method ListTransactions not implemented")
+}
+func (UnimplementedFintechServiceServer) WatchAccount(*WatchAccountRequest, FintechService_WatchAccountServer) error {
+	return status.Errorf(codes.Unimplemented, "method WatchAccount not implemented")
+}
+func (UnimplementedFintechServiceServer) mustEmbedUnimplementedFintechServiceServer() {}
+
+// UnsafeFintechServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to FintechServiceServer will
+// result in compilation errors.
+type UnsafeFintechServiceServer interface {
+	mustEmbedUnimplementedFintechServiceServer()
+}
+
+func RegisterFintechServiceServer(s grpc.ServiceRegistrar, srv FintechServiceServer) {
+	s.RegisterService(&FintechService_ServiceDesc, srv)
+}
+
+func _FintechService_OpenAccount_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(OpenAccountRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FintechServiceServer).OpenAccount(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FintechService_OpenAccount_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FintechServiceServer).OpenAccount(ctx, req.(*OpenAccountRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FintechService_GetBalance_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetBalanceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FintechServiceServer).GetBalance(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FintechService_GetBalance_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FintechServiceServer).GetBalance(ctx, req.(*GetBalanceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FintechService_Deposit_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DepositRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FintechServiceServer).Deposit(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FintechService_Deposit_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FintechServiceServer).Deposit(ctx, req.(*DepositRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FintechService_Withdraw_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(WithdrawRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FintechServiceServer).Withdraw(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FintechService_Withdraw_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FintechServiceServer).Withdraw(ctx, req.(*WithdrawRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FintechService_Transfer_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TransferRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FintechServiceServer).Transfer(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FintechService_Transfer_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FintechServiceServer).Transfer(ctx, req.(*TransferRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FintechService_ListTransactions_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ListTransactionsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(FintechServiceServer).ListTransactions(m, &fintechServiceListTransactionsServer{stream})
+}
+
+type FintechService_ListTransactionsServer interface {
+	Send(*Transaction) error
+	grpc.ServerStream
+}
+
+type fintechServiceListTransactionsServer struct {
+	grpc.ServerStream
+}
+
+func (x *fintechServiceListTransactionsServer) Send(m *Transaction) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _FintechService_WatchAccount_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchAccountRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(FintechServiceServer).WatchAccount(m, &fintechServiceWatchAccountServer{stream})
+}
+
+type FintechService_WatchAccountServer interface {
+	Send(*Balance) error
+	grpc.ServerStream
+}
+
+type fintechServiceWatchAccountServer struct {
+	grpc.ServerStream
+}
+
+func (x *fintechServiceWatchAccountServer) Send(m *Balance) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+// FintechService_ServiceDesc is the grpc.ServiceDesc for FintechService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var FintechService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "fintech.v1.FintechService",
+	HandlerType: (*FintechServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "OpenAccount",
+			Handler:    _FintechService_OpenAccount_Handler,
+		},
+		{
+			MethodName: "GetBalance",
+			Handler:    _FintechService_GetBalance_Handler,
+		},
+		{
+			MethodName: "Deposit",
+			Handler:    _FintechService_Deposit_Handler,
+		},
+		{
+			MethodName: "Withdraw",
+			Handler:    _FintechService_Withdraw_Handler,
+		},
+		{
+			MethodName: "Transfer",
+			Handler:    _FintechService_Transfer_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "ListTransactions",
+			Handler:       _FintechService_ListTransactions_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "WatchAccount",
+			Handler:       _FintechService_WatchAccount_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "fintech/v1/fintech.proto",
+}
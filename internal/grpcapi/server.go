@@ -0,0 +1,357 @@
+// Package grpcapi serves the gRPC API defined in proto/fintech/v1. It sits
+// on the same repositories and error catalog as the REST handlers.
+package grpcapi
+
+//go:generate protoc -I ../../proto --go_out=../.. --go_opt=module=github.com/Andrew44Ashraf/fintech-service --go-grpc_out=../.. --go-grpc_opt=module=github.com/Andrew44Ashraf/fintech-service fintech/v1/fintech.proto
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/Andrew44Ashraf/fintech-service/internal/auth"
+	"github.com/Andrew44Ashraf/fintech-service/internal/database"
+	apperrors "github.com/Andrew44Ashraf/fintech-service/internal/errors"
+	"github.com/Andrew44Ashraf/fintech-service/internal/grpcapi/fintechv1"
+	"github.com/Andrew44Ashraf/fintech-service/internal/logging"
+	"github.com/Andrew44Ashraf/fintech-service/internal/repository"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	// streamPageSize is how many transactions ListTransactions reads per
+	// query.
+	streamPageSize = 100
+	// defaultWatchInterval is how often WatchAccount checks for balance
+	// changes.
+	defaultWatchInterval = time.Second
+)
+
+// Config carries the settings the gRPC server is built with.
+type Config struct {
+	// Keys authenticate callers and give the tenant they act for.
+	Keys auth.KeyChecker
+	// WatchInterval is how often WatchAccount polls the balance. Zero means
+	// once a second.
+	WatchInterval time.Duration
+}
+
+// NewServer returns a gRPC server with the fintech service and the auth
+// interceptors registered.
+func NewServer(
+	accounts *repository.AccountRepository,
+	transactions *repository.TransactionRepository,
+	logger *slog.Logger,
+	cfg Config,
+) *grpc.Server {
+	s := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(UnaryAuth(cfg.Keys, logger)),
+		grpc.ChainStreamInterceptor(StreamAuth(cfg.Keys, logger)),
+	)
+	fintechv1.RegisterFintechServiceServer(s, NewService(accounts, transactions, logger, cfg.WatchInterval))
+	return s
+}
+
+// Service implements fintechv1.FintechServiceServer.
+type Service struct {
+	fintechv1.UnimplementedFintechServiceServer
+
+	accounts      *repository.AccountRepository
+	transactions  *repository.TransactionRepository
+	logger        *slog.Logger
+	watchInterval time.Duration
+}
+
+func NewService(
+	accounts *repository.AccountRepository,
+	transactions *repository.TransactionRepository,
+	logger *slog.Logger,
+	watchInterval time.Duration,
+) *Service {
+	if watchInterval <= 0 {
+		watchInterval = defaultWatchInterval
+	}
+	return &Service{
+		accounts:      accounts,
+		transactions:  transactions,
+		logger:        logger,
+		watchInterval: watchInterval,
+	}
+}
+
+func (s *Service) OpenAccount(ctx context.Context, req *fintechv1.OpenAccountRequest) (*fintechv1.Account, error) {
+	switch {
+	case len(req.ProductCode) > 20:
+		return nil, fail(ctx, s.logger, "open_account", apperrors.Invalid("product_code", "max", "must be at most 20 characters"))
+	case req.InitialBalance < 0:
+		return nil, fail(ctx, s.logger, "open_account", apperrors.Invalid("initial_balance", "gte", "must be at least 0"))
+	}
+
+	productCode := req.ProductCode
+	if productCode == "" {
+		productCode = repository.DefaultProduct
+	}
+	account, err := s.accounts.CreateAccount(ctx, productCode, req.InitialBalance, toDetails(req.Details))
+	if err != nil {
+		return nil, fail(ctx, s.logger, "open_account", err)
+	}
+
+	return &fintechv1.Account{
+		ProductCode:   account.ProductCode,
+		Balance:       account.Balance,
+		PublicId:      account.PublicID,
+		AccountNumber: account.Number,
+	}, nil
+}
+
+func (s *Service) GetBalance(ctx context.Context, req *fintechv1.GetBalanceRequest) (*fintechv1.Balance, error) {
+	accountID, err := s.resolveAccount(ctx, "account_id", req.AccountId)
+	if err != nil {
+		return nil, fail(ctx, s.logger, "get_balance", err)
+	}
+
+	if req.AsOf != nil {
+		if err := req.AsOf.CheckValid(); err != nil {
+			return nil, fail(ctx, s.logger, "get_balance", apperrors.Invalid("as_of", "datetime", "must be a valid timestamp"))
+		}
+		asOf := req.AsOf.AsTime()
+		if asOf.After(time.Now()) {
+			return nil, fail(ctx, s.logger, "get_balance", apperrors.Invalid("as_of", "lte", "must not be in the future"))
+		}
+		balance, err := s.accounts.GetBalanceAsOf(ctx, accountID, asOf)
+		if err != nil {
+			return nil, fail(ctx, s.logger, "get_balance", err)
+		}
+		return &fintechv1.Balance{AccountId: req.AccountId, Balance: balance, AsOf: req.AsOf}, nil
+	}
+
+	balance, err := s.accounts.GetAccountBalance(ctx, accountID)
+	if err != nil {
+		return nil, fail(ctx, s.logger, "get_balance", err)
+	}
+	return &fintechv1.Balance{AccountId: req.AccountId, Balance: balance, AsOf: timestamppb.Now()}, nil
+}
+
+func (s *Service) Deposit(ctx context.Context, req *fintechv1.DepositRequest) (*fintechv1.TransactionResult, error) {
+	err := positive(req.Amount)
+	var accountID int
+	if err == nil {
+		accountID, err = s.resolveAccount(ctx, "account_id", req.AccountId)
+	}
+	if err != nil {
+		return nil, fail(ctx, s.logger, "deposit", err)
+	}
+
+	txID, err := s.transactions.CreateDeposit(ctx, accountID, req.Amount, toDetails(req.Details))
+	return s.result(ctx, "deposit", accountID, txID, err)
+}
+
+func (s *Service) Withdraw(ctx context.Context, req *fintechv1.WithdrawRequest) (*fintechv1.TransactionResult, error) {
+	err := positive(req.Amount)
+	var accountID int
+	if err == nil {
+		accountID, err = s.resolveAccount(ctx, "account_id", req.AccountId)
+	}
+	if err != nil {
+		return nil, fail(ctx, s.logger, "withdrawal", err)
+	}
+
+	txID, err := s.transactions.CreateWithdrawal(ctx, accountID, req.Amount, toDetails(req.Details))
+	return s.result(ctx, "withdrawal", accountID, txID, err)
+}
+
+func (s *Service) Transfer(ctx context.Context, req *fintechv1.TransferRequest) (*fintechv1.TransactionResult, error) {
+	if err := positive(req.Amount); err != nil {
+		return nil, fail(ctx, s.logger, "transfer", err)
+	}
+	fromID, err := s.resolveAccount(ctx, "from_account_id", req.FromAccountId)
+	if err != nil {
+		return nil, fail(ctx, s.logger, "transfer", err)
+	}
+	toID, err := s.resolveAccount(ctx, "to_account_id", req.ToAccountId)
+	if err != nil {
+		return nil, fail(ctx, s.logger, "transfer", err)
+	}
+
+	txID, err := s.transactions.CreateTransfer(ctx, fromID, toID, req.Amount)
+	return s.result(ctx, "transfer", fromID, txID, err)
+}
+
+// result builds the response of a posting. Held transactions are not an
+// error, the same as the 202 of the REST API.
+func (s *Service) result(ctx context.Context, op string, accountID, txID int, err error) (*fintechv1.TransactionResult, error) {
+	held := errors.Is(err, repository.ErrPendingReview)
+	if err != nil && !held {
+		return nil, fail(ctx, s.logger, op, err)
+	}
+
+	// A replica may not have the posting yet
+	primary := database.WithPrimary(ctx)
+	publicID, err := s.transactions.PublicID(primary, txID)
+	if err != nil {
+		s.logLookupError(ctx, "failed to get transaction ID", op, accountID, txID, err)
+	}
+	if held {
+		return &fintechv1.TransactionResult{TransactionId: publicID, Status: repository.StatusPendingReview}, nil
+	}
+
+	balance, err := s.accounts.GetAccountBalance(primary, accountID)
+	if err != nil {
+		s.logLookupError(ctx, "failed to get updated balance", op, accountID, txID, err)
+		balance = 0
+	}
+	return &fintechv1.TransactionResult{
+		TransactionId: publicID,
+		Status:        repository.StatusPosted,
+		NewBalance:    balance,
+	}, nil
+}
+
+// logLookupError logs a failed read after a posting. The posting has been
+// committed, so the response goes out without the value.
+func (s *Service) logLookupError(ctx context.Context, msg, op string, accountID, txID int, err error) {
+	logging.FromContext(ctx, s.logger).Error(msg,
+		logging.KeyOp, op,
+		logging.KeyAccountID, accountID,
+		logging.KeyTxID, txID,
+		logging.KeyError, err.Error(),
+	)
+}
+
+// ListTransactions pages through the history with the same query as the
+// REST endpoint. Transactions posted while the stream runs shift the pages,
+// so a long stream can repeat an entry; clients dedup on the ID.
+func (s *Service) ListTransactions(req *fintechv1.ListTransactionsRequest, stream fintechv1.FintechService_ListTransactionsServer) error {
+	ctx := stream.Context()
+	if req.Limit < 0 {
+		return fail(ctx, s.logger, "list_transactions", apperrors.Invalid("limit", "gte", "must be at least 0"))
+	}
+	accountID, err := s.resolveAccount(ctx, "account_id", req.AccountId)
+	if err != nil {
+		return fail(ctx, s.logger, "list_transactions", err)
+	}
+	account, err := s.accounts.GetAccount(ctx, accountID)
+	if err != nil {
+		return fail(ctx, s.logger, "list_transactions", err)
+	}
+
+	sent := 0
+	for offset := 0; ; offset += streamPageSize {
+		pageSize := streamPageSize
+		if req.Limit > 0 {
+			pageSize = min(pageSize, int(req.Limit)-sent)
+		}
+		page, err := s.transactions.GetTransactions(ctx, accountID, pageSize, offset, req.ExternalRef)
+		if err != nil {
+			return fail(ctx, s.logger, "list_transactions", err)
+		}
+		for _, t := range page {
+			if err := stream.Send(toTransaction(t, account.PublicID)); err != nil {
+				return err
+			}
+		}
+		sent += len(page)
+		if len(page) < pageSize || (req.Limit > 0 && sent >= int(req.Limit)) {
+			return nil
+		}
+	}
+}
+
+// WatchAccount polls the balance and sends it whenever it changes.
+func (s *Service) WatchAccount(req *fintechv1.WatchAccountRequest, stream fintechv1.FintechService_WatchAccountServer) error {
+	ctx := stream.Context()
+	accountID, err := s.resolveAccount(ctx, "account_id", req.AccountId)
+	if err != nil {
+		return fail(ctx, s.logger, "watch_account", err)
+	}
+
+	last, err := s.accounts.GetAccountBalance(ctx, accountID)
+	if err != nil {
+		return fail(ctx, s.logger, "watch_account", err)
+	}
+	if err := stream.Send(&fintechv1.Balance{AccountId: req.AccountId, Balance: last, AsOf: timestamppb.Now()}); err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(s.watchInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		balance, err := s.accounts.GetAccountBalance(ctx, accountID)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fail(ctx, s.logger, "watch_account", err)
+		}
+		if balance == last {
+			continue
+		}
+		last = balance
+		if err := stream.Send(&fintechv1.Balance{AccountId: req.AccountId, Balance: balance, AsOf: timestamppb.Now()}); err != nil {
+			return err
+		}
+	}
+}
+
+func toTransaction(t repository.Transaction, accountPublicID string) *fintechv1.Transaction {
+	return &fintechv1.Transaction{
+		AccountId:    accountPublicID,
+		Amount:       t.Amount,
+		Type:         t.Type,
+		Status:       t.Status,
+		FinalBalance: t.FinalBalance,
+		CreatedAt:    timestamppb.New(t.CreatedAt),
+		Details:      fromDetails(t.Details),
+		PublicId:     t.PublicID,
+	}
+}
+
+// toDetails converts optional request details. The repository validates
+// them.
+func toDetails(d *fintechv1.Details) repository.Details {
+	if d == nil {
+		return repository.Details{}
+	}
+	details := repository.Details{ExternalRef: d.ExternalRef, Description: d.Description}
+	if d.Metadata != "" {
+		details.Metadata = json.RawMessage(d.Metadata)
+	}
+	return details
+}
+
+func fromDetails(d repository.Details) *fintechv1.Details {
+	if d.ExternalRef == "" && d.Description == "" && len(d.Metadata) == 0 {
+		return nil
+	}
+	return &fintechv1.Details{ExternalRef: d.ExternalRef, Description: d.Description, Metadata: string(d.Metadata)}
+}
+
+// resolveAccount returns the internal ID of the account a request field
+// names by public ID or account number.
+func (s *Service) resolveAccount(ctx context.Context, field, ref string) (int, error) {
+	if ref == "" {
+		return 0, apperrors.Invalid(field, "required", "is required")
+	}
+	accountID, err := s.accounts.ResolveAccount(ctx, ref)
+	var verr *apperrors.ValidationError
+	if errors.As(err, &verr) {
+		return 0, apperrors.Invalid(field, "account", "must be an account ID or account number")
+	}
+	return accountID, err
+}
+
+func positive(amount float64) error {
+	if amount <= 0 {
+		return apperrors.Invalid("amount", "gt", "must be greater than 0")
+	}
+	return nil
+}
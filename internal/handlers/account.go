@@ -1,98 +1,392 @@
 package handlers
 
 import (
-    "context"
-    "github.com/Andrew44Ashraf/fintech-service/internal/dtos/responses"
-    "github.com/Andrew44Ashraf/fintech-service/internal/repository"
-    "github.com/gin-gonic/gin"
-    "log"
-    "net/http"
-    "strconv"
-    "time"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/Andrew44Ashraf/fintech-service/internal/dto/requests"
+	"github.com/Andrew44Ashraf/fintech-service/internal/dto/responses"
+	apperrors "github.com/Andrew44Ashraf/fintech-service/internal/errors"
+	"github.com/Andrew44Ashraf/fintech-service/internal/repository"
+	"github.com/gin-gonic/gin"
 )
 
 type AccountHandler struct {
-    accountRepo *repository.AccountRepository
+	accountRepo *repository.AccountRepository
+	logger      *slog.Logger
 }
 
-func NewAccountHandler(repo *repository.AccountRepository) *AccountHandler {
-    return &AccountHandler{accountRepo: repo}
+func NewAccountHandler(repo *repository.AccountRepository, logger *slog.Logger) *AccountHandler {
+	return &AccountHandler{accountRepo: repo, logger: logger}
 }
 
 // OpenAccount godoc
 // @Summary Create a new account
-// @Description Opens a new account with optional initial balance
+// @Description Opens a new account of the given product (default checking) with optional initial balance
 // @Tags accounts
 // @Accept json
 // @Produce json
-// @Param request body requests.OpenAccountRequest false "Optional initial balance"
+// @Param request body requests.OpenAccountRequest false "Optional product code, initial balance, external reference, description and metadata"
 // @Success 200 {object} responses.AccountResponse
-// @Failure 400 {object} responses.ErrorResponse
-// @Failure 500 {object} responses.ErrorResponse
+// @Failure 400 {object} responses.Problem
+// @Failure 409 {object} responses.Problem
+// @Failure 500 {object} responses.Problem
 // @Router /accounts [post]
 func (h *AccountHandler) OpenAccount(c *gin.Context) {
-    ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
-    defer cancel()
-
-    var req requests.OpenAccountRequest
-    if err := c.ShouldBindJSON(&req); err != nil && err != io.EOF {
-        c.JSON(http.StatusBadRequest, responses.NewErrorResponse("invalid request"))
-        return
-    }
-
-    accountID, err := h.accountRepo.CreateAccount(ctx, req.InitialBalance)
-    if err != nil {
-        log.Printf("OpenAccount failed: %v", err)
-        
-        switch {
-        case errors.Is(err, repository.ErrNegativeBalance):
-            c.JSON(http.StatusBadRequest, responses.NewErrorResponse("initial balance cannot be negative"))
-        default:
-            c.JSON(http.StatusInternalServerError, responses.NewErrorResponse("failed to create account"))
-        }
-        return
-    }
-
-    c.JSON(http.StatusOK, responses.AccountResponse{
-        AccountID: accountID,
-    })
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	var req requests.OpenAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		respondError(c, h.logger, "open_account", fmt.Errorf("%w: %v", apperrors.ErrInvalidRequest, err))
+		return
+	}
+	if err := validateRequest(req); err != nil {
+		respondError(c, h.logger, "open_account", err)
+		return
+	}
+
+	productCode := req.ProductCode
+	if productCode == "" {
+		productCode = repository.DefaultProduct
+	}
+	account, err := h.accountRepo.CreateAccount(ctx, productCode, req.InitialBalance, toDetails(req.Details))
+	if err != nil {
+		respondError(c, h.logger, "open_account", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, responses.AccountResponse{
+		AccountID:     account.PublicID,
+		AccountNumber: account.Number,
+		ProductCode:   account.ProductCode,
+	})
+}
+
+// ListProducts godoc
+// @Summary List account products
+// @Description Returns the products accounts can be opened with and the rules each one enforces
+// @Tags accounts
+// @Produce json
+// @Success 200 {object} responses.ProductListResponse
+// @Failure 500 {object} responses.Problem
+// @Router /products [get]
+func (h *AccountHandler) ListProducts(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 3*time.Second)
+	defer cancel()
+
+	products, err := h.accountRepo.ListProducts(ctx)
+	if err != nil {
+		respondError(c, h.logger, "list_products", err)
+		return
+	}
+
+	items := make([]responses.ProductResponse, 0, len(products))
+	for _, p := range products {
+		items = append(items, responses.ProductResponse{
+			Code:                 p.Code,
+			Name:                 p.Name,
+			AllowedTypes:         p.AllowedTypes,
+			MinBalance:           p.MinBalance,
+			OverdraftLimit:       p.OverdraftLimit,
+			MaxSingleWithdrawal:  p.Limits.MaxSingle,
+			MaxDailyWithdrawal:   p.Limits.MaxDaily,
+			MaxMonthlyWithdrawal: p.Limits.MaxMonthly,
+			MaxDailyWithdrawals:  p.Limits.MaxDailyCount,
+			Currency:             p.Currency,
+		})
+	}
+	c.JSON(http.StatusOK, responses.ProductListResponse{Products: items})
 }
 
 // GetBalance godoc
 // @Summary Get account balance
+// @Description Returns the current balance, or the balance at the instant as_of (RFC 3339). The current balance carries an ETag for conditional polling.
 // @Tags accounts
-// @Param id path int true "Account ID"
+// @Param id path string true "Account ID or account number"
+// @Param as_of query string false "Point in time, e.g. 2026-03-31T23:59:59Z"
+// @Param If-None-Match header string false "ETag of a previous response; not used with as_of"
 // @Success 200 {object} responses.BalanceResponse
-// @Failure 400 {object} responses.ErrorResponse
-// @Failure 404 {object} responses.ErrorResponse
-// @Failure 500 {object} responses.ErrorResponse
+// @Success 304 "Not modified"
+// @Failure 400 {object} responses.Problem
+// @Failure 404 {object} responses.Problem
+// @Failure 500 {object} responses.Problem
 // @Router /accounts/{id}/balance [get]
 func (h *AccountHandler) GetBalance(c *gin.Context) {
-    ctx, cancel := context.WithTimeout(c.Request.Context(), 3*time.Second)
-    defer cancel()
-
-    accountID, err := strconv.Atoi(c.Param("id"))
-    if err != nil {
-        c.JSON(http.StatusBadRequest, responses.NewErrorResponse("invalid account ID"))
-        return
-    }
-
-    balance, err := h.accountRepo.GetAccountBalance(ctx, accountID)
-    if err != nil {
-        log.Printf("GetBalance failed: %v", err)
-        
-        switch {
-        case errors.Is(err, repository.ErrAccountNotFound):
-            c.JSON(http.StatusNotFound, responses.NewErrorResponse("account not found"))
-        case errors.Is(err, repository.ErrAccountAlreadyClosed):
-            c.JSON(http.StatusGone, responses.NewErrorResponse("account is closed"))
-        default:
-            c.JSON(http.StatusInternalServerError, responses.NewErrorResponse("failed to get balance"))
-        }
-        return
-    }
-
-    c.JSON(http.StatusOK, responses.BalanceResponse{
-        Balance: balance,
-    })
-}
\ No newline at end of file
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 3*time.Second)
+	defer cancel()
+
+	accountID, err := parseAccountID(c)
+	if err != nil {
+		respondError(c, h.logger, "get_balance", err)
+		return
+	}
+
+	if v := c.Query("as_of"); v != "" {
+		asOf, err := parseAsOf(v, time.Now())
+		if err != nil {
+			respondError(c, h.logger, "get_balance", err)
+			return
+		}
+		balance, err := h.accountRepo.GetBalanceAsOf(ctx, accountID, asOf)
+		if err != nil {
+			respondError(c, h.logger, "get_balance", err)
+			return
+		}
+		c.JSON(http.StatusOK, responses.BalanceResponse{Balance: balance, AsOf: &asOf})
+		return
+	}
+
+	balance, version, err := h.accountRepo.GetBalanceVersion(ctx, accountID)
+	if err != nil {
+		respondError(c, h.logger, "get_balance", err)
+		return
+	}
+	if notModified(c, version) {
+		return
+	}
+
+	c.JSON(http.StatusOK, responses.BalanceResponse{
+		Balance: balance,
+	})
+}
+
+// GetAccount godoc
+// @Summary Get an account
+// @Description Returns the account with its status and limits. The ETag header carries the account version for If-Match on updates.
+// @Tags accounts
+// @Produce json
+// @Param id path string true "Account ID or account number"
+// @Param If-None-Match header string false "ETag of a previous response"
+// @Success 200 {object} responses.AccountDetailResponse
+// @Success 304 "Not modified"
+// @Failure 400 {object} responses.Problem
+// @Failure 404 {object} responses.Problem
+// @Failure 500 {object} responses.Problem
+// @Router /accounts/{id} [get]
+func (h *AccountHandler) GetAccount(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 3*time.Second)
+	defer cancel()
+
+	accountID, err := parseAccountID(c)
+	if err != nil {
+		respondError(c, h.logger, "get_account", err)
+		return
+	}
+	account, err := h.accountRepo.GetAccount(ctx, accountID)
+	if err != nil {
+		respondError(c, h.logger, "get_account", err)
+		return
+	}
+	if notModified(c, account.Version) {
+		return
+	}
+	c.JSON(http.StatusOK, toAccountDetail(account))
+}
+
+// ChangeStatus godoc
+// @Summary Freeze, unfreeze or close an account
+// @Description Requires If-Match with the account's ETag; a stale ETag is rejected with 412.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path string true "Account ID or account number"
+// @Param If-Match header string true "ETag of the account"
+// @Param body body requests.ChangeAccountStatusRequest true "New status, operator and reason"
+// @Success 200 {object} responses.AccountDetailResponse
+// @Failure 400 {object} responses.Problem
+// @Failure 404 {object} responses.Problem
+// @Failure 409 {object} responses.Problem
+// @Failure 412 {object} responses.Problem
+// @Failure 428 {object} responses.Problem
+// @Router /admin/accounts/{id} [patch]
+func (h *AccountHandler) ChangeStatus(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	var req requests.ChangeAccountStatusRequest
+	accountID, version, ok := h.bindUpdate(c, "change_account_status", &req)
+	if !ok {
+		return
+	}
+	if _, err := h.accountRepo.ChangeAccountStatus(ctx, accountID, version, req.Status, req.Operator, req.Reason, false); err != nil {
+		respondError(c, h.logger, "change_account_status", err)
+		return
+	}
+	h.respondAccount(ctx, c, "change_account_status", accountID)
+}
+
+// SetLimits godoc
+// @Summary Set an account's withdrawal limits
+// @Description Replaces the account's own limits; omitted limits fall back to the product's. Requires If-Match with the account's ETag.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path string true "Account ID or account number"
+// @Param If-Match header string true "ETag of the account"
+// @Param body body requests.AccountLimitsRequest true "Limits"
+// @Success 200 {object} responses.AccountDetailResponse
+// @Failure 400 {object} responses.Problem
+// @Failure 404 {object} responses.Problem
+// @Failure 409 {object} responses.Problem
+// @Failure 412 {object} responses.Problem
+// @Failure 428 {object} responses.Problem
+// @Router /admin/accounts/{id}/limits [put]
+func (h *AccountHandler) SetLimits(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	var req requests.AccountLimitsRequest
+	accountID, version, ok := h.bindUpdate(c, "set_account_limits", &req)
+	if !ok {
+		return
+	}
+	_, err := h.accountRepo.SetAccountLimits(ctx, accountID, version, repository.AccountLimits{
+		MaxSingle:     req.MaxSingleWithdrawal,
+		MaxDaily:      req.MaxDailyWithdrawal,
+		MaxMonthly:    req.MaxMonthlyWithdrawal,
+		MaxDailyCount: req.MaxDailyWithdrawals,
+	})
+	if err != nil {
+		respondError(c, h.logger, "set_account_limits", err)
+		return
+	}
+	h.respondAccount(ctx, c, "set_account_limits", accountID)
+}
+
+// bindUpdate reads the account ID, the If-Match version and the body of an
+// account update, responding with the error when one of them is invalid.
+func (h *AccountHandler) bindUpdate(c *gin.Context, op string, req any) (int, int64, bool) {
+	accountID, err := parseAccountID(c)
+	if err != nil {
+		respondError(c, h.logger, op, err)
+		return 0, 0, false
+	}
+	version, err := ifMatch(c)
+	if err != nil {
+		respondError(c, h.logger, op, err)
+		return 0, 0, false
+	}
+	if err := c.ShouldBindJSON(req); err != nil {
+		respondError(c, h.logger, op, fmt.Errorf("%w: %v", apperrors.ErrInvalidRequest, err))
+		return 0, 0, false
+	}
+	if err := validateRequest(req); err != nil {
+		respondError(c, h.logger, op, err)
+		return 0, 0, false
+	}
+	return accountID, version, true
+}
+
+// respondAccount answers an update with the account as it is now.
+func (h *AccountHandler) respondAccount(ctx context.Context, c *gin.Context, op string, accountID int) {
+	account, err := h.accountRepo.GetAccount(ctx, accountID)
+	if err != nil {
+		respondError(c, h.logger, op, err)
+		return
+	}
+	c.Header("ETag", etag(account.Version))
+	c.JSON(http.StatusOK, toAccountDetail(account))
+}
+
+func toAccountDetail(a *repository.Account) responses.AccountDetailResponse {
+	return responses.AccountDetailResponse{
+		AccountID:      a.PublicID,
+		AccountNumber:  a.Number,
+		ProductCode:    a.ProductCode,
+		Status:         a.Status,
+		Balance:        a.Balance,
+		Currency:       a.Currency,
+		OverdraftLimit: a.OverdraftLimit,
+		Limits: responses.AccountLimits{
+			MaxSingleWithdrawal:  a.Limits.MaxSingle,
+			MaxDailyWithdrawal:   a.Limits.MaxDaily,
+			MaxMonthlyWithdrawal: a.Limits.MaxMonthly,
+			MaxDailyWithdrawals:  a.Limits.MaxDailyCount,
+		},
+		Version:   a.Version,
+		Details:   fromDetails(a.Details),
+		CreatedAt: a.CreatedAt,
+		UpdatedAt: a.UpdatedAt,
+	}
+}
+
+// BalancesAsOf godoc
+// @Summary Get the balances of many accounts at one instant
+// @Description For month-end reporting. Accounts are named by account ID or account number and are listed as named. Accounts that do not exist or were opened after as_of are listed in missing_account_ids.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param body body requests.BalancesAsOfRequest true "Instant and accounts"
+// @Success 200 {object} responses.BalancesAsOfResponse
+// @Failure 400 {object} responses.Problem
+// @Failure 500 {object} responses.Problem
+// @Router /admin/balances [post]
+func (h *AccountHandler) BalancesAsOf(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	var req requests.BalancesAsOfRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, h.logger, "balances_as_of", fmt.Errorf("%w: %v", apperrors.ErrInvalidRequest, err))
+		return
+	}
+	if err := validateRequest(req); err != nil {
+		respondError(c, h.logger, "balances_as_of", err)
+		return
+	}
+	asOf := req.AsOf.UTC()
+	if asOf.After(time.Now()) {
+		respondError(c, h.logger, "balances_as_of", apperrors.Invalid("as_of", "lte", "must not be in the future"))
+		return
+	}
+
+	resolved, err := h.accountRepo.ResolveAccounts(ctx, req.AccountIDs)
+	if err != nil {
+		respondError(c, h.logger, "balances_as_of", err)
+		return
+	}
+	accountIDs := make([]int, 0, len(resolved))
+	for _, id := range resolved {
+		accountIDs = append(accountIDs, id)
+	}
+
+	balances, err := h.accountRepo.BalancesAsOf(ctx, accountIDs, asOf)
+	if err != nil {
+		respondError(c, h.logger, "balances_as_of", err)
+		return
+	}
+
+	resp := responses.BalancesAsOfResponse{AsOf: asOf, Balances: make([]responses.AccountBalance, 0, len(balances))}
+	seen := make(map[string]bool, len(req.AccountIDs))
+	for _, ref := range req.AccountIDs {
+		if seen[ref] {
+			continue
+		}
+		seen[ref] = true
+		if balance, ok := balances[resolved[ref]]; ok {
+			resp.Balances = append(resp.Balances, responses.AccountBalance{AccountID: ref, Balance: balance})
+		} else {
+			resp.MissingAccountIDs = append(resp.MissingAccountIDs, ref)
+		}
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// parseAsOf reads an as_of timestamp. Balances in the future are not known
+// yet, so those are rejected.
+func parseAsOf(v string, now time.Time) (time.Time, error) {
+	asOf, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		return time.Time{}, apperrors.Invalid("as_of", "datetime", "must be an RFC 3339 timestamp")
+	}
+	if asOf.After(now) {
+		return time.Time{}, apperrors.Invalid("as_of", "lte", "must not be in the future")
+	}
+	return asOf.UTC(), nil
+}
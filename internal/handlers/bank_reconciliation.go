@@ -0,0 +1,350 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Andrew44Ashraf/fintech-service/internal/dto/requests"
+	"github.com/Andrew44Ashraf/fintech-service/internal/dto/responses"
+	apperrors "github.com/Andrew44Ashraf/fintech-service/internal/errors"
+	"github.com/Andrew44Ashraf/fintech-service/internal/reconciliation"
+	"github.com/Andrew44Ashraf/fintech-service/internal/repository"
+	"github.com/gin-gonic/gin"
+)
+
+// maxBankFileSize bounds uploaded bank files.
+const maxBankFileSize = 20 << 20
+
+// BankReconciliationHandler serves the import of settlement bank files and
+// the matching of their lines with our transactions.
+type BankReconciliationHandler struct {
+	repo            *repository.BankReconciliationRepository
+	transactionRepo *repository.TransactionRepository
+	rules           []reconciliation.MatchRule
+	logger          *slog.Logger
+}
+
+func NewBankReconciliationHandler(repo *repository.BankReconciliationRepository, transactionRepo *repository.TransactionRepository, rules []reconciliation.MatchRule, logger *slog.Logger) *BankReconciliationHandler {
+	if len(rules) == 0 {
+		rules = reconciliation.DefaultRules
+	}
+	return &BankReconciliationHandler{repo: repo, transactionRepo: transactionRepo, rules: rules, logger: logger}
+}
+
+// Import godoc
+// @Summary Import a settlement bank file
+// @Description Accepts a CSV or camt.053 file, either as the request body or as the multipart field "file", stores its lines and matches them automatically
+// @Tags admin
+// @Accept text/csv,application/xml,multipart/form-data
+// @Produce json
+// @Param operator query string true "Who imports the file"
+// @Param format query string false "csv or camt053 (default: from the content type or file name)"
+// @Success 201 {object} responses.BankImportResponse
+// @Failure 400 {object} responses.Problem
+// @Failure 409 {object} responses.Problem
+// @Failure 500 {object} responses.Problem
+// @Router /admin/bank-statements [post]
+func (h *BankReconciliationHandler) Import(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 60*time.Second)
+	defer cancel()
+
+	operator := strings.TrimSpace(c.Query("operator"))
+	if operator == "" || len(operator) > 100 {
+		respondError(c, h.logger, "import_bank_statement", apperrors.Invalid("operator", "required", "is required and at most 100 characters"))
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxBankFileSize)
+	file, fileName, format, err := bankFile(c)
+	if err != nil {
+		respondError(c, h.logger, "import_bank_statement", err)
+		return
+	}
+
+	st, err := reconciliation.Parse(bytes.NewReader(file), format)
+	if err != nil {
+		respondError(c, h.logger, "import_bank_statement", err)
+		return
+	}
+
+	sum := sha256.Sum256(file)
+	rec, err := h.repo.ImportStatement(ctx, st, fileName, hex.EncodeToString(sum[:]), operator)
+	if err != nil {
+		respondError(c, h.logger, "import_bank_statement", err)
+		return
+	}
+
+	matched, err := h.repo.AutoMatch(ctx, rec.ID, h.rules)
+	if err != nil {
+		respondError(c, h.logger, "import_bank_statement", err)
+		return
+	}
+
+	c.Header("Location", fmt.Sprintf("/api/admin/bank-statements/%d", rec.ID))
+	c.JSON(http.StatusCreated, responses.BankImportResponse{Statement: toBankStatementResponse(rec), Matched: matched})
+}
+
+// Get godoc
+// @Summary Get the reconciliation of a bank file
+// @Description Returns the matched pairs, our unmatched transactions in the file's period and the bank's unmatched lines
+// @Tags admin
+// @Produce json
+// @Param id path int true "Bank statement ID"
+// @Success 200 {object} responses.BankReconciliationResponse
+// @Failure 400 {object} responses.Problem
+// @Failure 404 {object} responses.Problem
+// @Failure 500 {object} responses.Problem
+// @Router /admin/bank-statements/{id} [get]
+func (h *BankReconciliationHandler) Get(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	id, err := parseBankStatementID(c)
+	if err != nil {
+		respondError(c, h.logger, "get_bank_reconciliation", err)
+		return
+	}
+
+	rec, err := h.repo.Reconciliation(ctx, id)
+	if err != nil {
+		respondError(c, h.logger, "get_bank_reconciliation", err)
+		return
+	}
+	c.JSON(http.StatusOK, toBankReconciliationResponse(rec))
+}
+
+// AutoMatch godoc
+// @Summary Rerun automatic matching for a bank file
+// @Tags admin
+// @Produce json
+// @Param id path int true "Bank statement ID"
+// @Success 200 {object} responses.BankReconciliationResponse
+// @Failure 400 {object} responses.Problem
+// @Failure 404 {object} responses.Problem
+// @Failure 500 {object} responses.Problem
+// @Router /admin/bank-statements/{id}/auto-match [post]
+func (h *BankReconciliationHandler) AutoMatch(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 60*time.Second)
+	defer cancel()
+
+	id, err := parseBankStatementID(c)
+	if err != nil {
+		respondError(c, h.logger, "bank_auto_match", err)
+		return
+	}
+
+	if _, err := h.repo.AutoMatch(ctx, id, h.rules); err != nil {
+		respondError(c, h.logger, "bank_auto_match", err)
+		return
+	}
+	rec, err := h.repo.Reconciliation(ctx, id)
+	if err != nil {
+		respondError(c, h.logger, "bank_auto_match", err)
+		return
+	}
+	c.JSON(http.StatusOK, toBankReconciliationResponse(rec))
+}
+
+// Match godoc
+// @Summary Match a bank line with a transaction by hand
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param body body requests.BankMatchRequest true "Line, transaction and operator"
+// @Success 201 {object} responses.BankMatchResponse
+// @Failure 400 {object} responses.Problem
+// @Failure 404 {object} responses.Problem
+// @Failure 409 {object} responses.Problem
+// @Failure 500 {object} responses.Problem
+// @Router /admin/bank-matches [post]
+func (h *BankReconciliationHandler) Match(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	var req requests.BankMatchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, h.logger, "bank_match", fmt.Errorf("%w: %v", apperrors.ErrInvalidRequest, err))
+		return
+	}
+	if err := validateRequest(req); err != nil {
+		respondError(c, h.logger, "bank_match", err)
+		return
+	}
+
+	txID, err := h.transactionRepo.ResolveTransaction(ctx, req.TransactionID)
+	var verr *apperrors.ValidationError
+	if errors.As(err, &verr) {
+		err = apperrors.Invalid("transaction_id", "transaction", "must be a transaction ID")
+	}
+	if err != nil {
+		respondError(c, h.logger, "bank_match", err)
+		return
+	}
+
+	id, err := h.repo.Match(ctx, req.LineID, txID, req.Operator)
+	if err != nil {
+		respondError(c, h.logger, "bank_match", err)
+		return
+	}
+	c.JSON(http.StatusCreated, responses.BankMatchResponse{ID: id})
+}
+
+// Unmatch godoc
+// @Summary Undo a bank match
+// @Tags admin
+// @Accept json
+// @Param id path int true "Bank match ID"
+// @Param body body requests.FeeActionRequest true "Operator and reason"
+// @Success 204
+// @Failure 400 {object} responses.Problem
+// @Failure 404 {object} responses.Problem
+// @Failure 500 {object} responses.Problem
+// @Router /admin/bank-matches/{id}/unmatch [post]
+func (h *BankReconciliationHandler) Unmatch(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		respondError(c, h.logger, "bank_unmatch", apperrors.Invalid("id", "int", "must be a positive integer"))
+		return
+	}
+	var req requests.FeeActionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, h.logger, "bank_unmatch", fmt.Errorf("%w: %v", apperrors.ErrInvalidRequest, err))
+		return
+	}
+	if err := validateRequest(req); err != nil {
+		respondError(c, h.logger, "bank_unmatch", err)
+		return
+	}
+
+	if err := h.repo.Unmatch(ctx, id, req.Operator, req.Reason); err != nil {
+		respondError(c, h.logger, "bank_unmatch", err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func parseBankStatementID(c *gin.Context) (int, error) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		return 0, apperrors.Invalid("id", "int", "must be a positive integer")
+	}
+	return id, nil
+}
+
+// bankFile reads the uploaded bank file and returns it with its file name
+// and format. The format comes from the format query parameter, the file
+// name or the content type, in that order.
+func bankFile(c *gin.Context) ([]byte, string, string, error) {
+	format := c.Query("format")
+	fileName := c.Query("file_name")
+	mediaType, _, _ := mime.ParseMediaType(c.ContentType())
+
+	var body io.ReadCloser = c.Request.Body
+	if mediaType == "multipart/form-data" {
+		header, err := c.FormFile("file")
+		if err != nil {
+			if tooLarge(err) {
+				return nil, "", "", apperrors.Invalid("file", "max", "must not be larger than 20 MB")
+			}
+			return nil, "", "", apperrors.Invalid("file", "required", "is required")
+		}
+		f, err := header.Open()
+		if err != nil {
+			return nil, "", "", fmt.Errorf("failed to open upload: %w", err)
+		}
+		body = f
+		fileName = header.Filename
+	}
+	defer body.Close()
+
+	if format == "" {
+		switch ext := strings.ToLower(filepath.Ext(fileName)); {
+		case ext == ".csv" || mediaType == "text/csv":
+			format = reconciliation.FormatCSV
+		case ext == ".xml" || mediaType == "application/xml" || mediaType == "text/xml":
+			format = reconciliation.FormatCamt053
+		}
+	}
+	if !reconciliation.SupportsFormat(format) {
+		return nil, "", "", apperrors.Invalid("format", "oneof", "must be one of: csv camt053")
+	}
+
+	data, err := io.ReadAll(body)
+	switch {
+	case tooLarge(err):
+		return nil, "", "", apperrors.Invalid("file", "max", "must not be larger than 20 MB")
+	case err != nil:
+		return nil, "", "", fmt.Errorf("%w: %v", apperrors.ErrInvalidRequest, err)
+	}
+	return data, filepath.Base(fileName), format, nil
+}
+
+func toBankStatementResponse(rec *repository.BankStatementRecord) responses.BankStatementResponse {
+	return responses.BankStatementResponse{
+		ID:          rec.ID,
+		Format:      rec.Format,
+		FileName:    rec.FileName,
+		PeriodStart: rec.PeriodStart.Format(dateLayout),
+		PeriodEnd:   rec.PeriodEnd.Format(dateLayout),
+		LineCount:   rec.LineCount,
+		ImportedBy:  rec.ImportedBy,
+		ImportedAt:  rec.ImportedAt,
+	}
+}
+
+func toBankLineItem(l repository.BankStatementLine) responses.BankLineItem {
+	return responses.BankLineItem{
+		ID:          l.ID,
+		LineNo:      l.LineNo,
+		Reference:   l.Reference,
+		Amount:      l.Amount,
+		BookingDate: l.BookingDate.Format(dateLayout),
+		ValueDate:   l.ValueDate.Format(dateLayout),
+		Description: l.Description,
+	}
+}
+
+func toBankTransactionItem(c reconciliation.Candidate) responses.BankTransactionItem {
+	return responses.BankTransactionItem{TransactionID: c.PublicID, Amount: c.Amount, Date: c.Date}
+}
+
+func toBankReconciliationResponse(rec *repository.BankReconciliation) responses.BankReconciliationResponse {
+	resp := responses.BankReconciliationResponse{
+		Statement:       toBankStatementResponse(&rec.Statement),
+		Matched:         make([]responses.BankMatchItem, 0, len(rec.Matched)),
+		UnmatchedOurs:   make([]responses.BankTransactionItem, 0, len(rec.UnmatchedOurs)),
+		UnmatchedTheirs: make([]responses.BankLineItem, 0, len(rec.UnmatchedTheirs)),
+	}
+	for _, m := range rec.Matched {
+		resp.Matched = append(resp.Matched, responses.BankMatchItem{
+			ID:          m.ID,
+			Rule:        m.Rule,
+			MatchedBy:   m.MatchedBy,
+			MatchedAt:   m.MatchedAt,
+			Line:        toBankLineItem(m.Line),
+			Transaction: toBankTransactionItem(m.Transaction),
+		})
+	}
+	for _, c := range rec.UnmatchedOurs {
+		resp.UnmatchedOurs = append(resp.UnmatchedOurs, toBankTransactionItem(c))
+	}
+	for _, l := range rec.UnmatchedTheirs {
+		resp.UnmatchedTheirs = append(resp.UnmatchedTheirs, toBankLineItem(l))
+	}
+	return resp
+}
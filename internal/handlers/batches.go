@@ -0,0 +1,215 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Andrew44Ashraf/fintech-service/internal/batches"
+	"github.com/Andrew44Ashraf/fintech-service/internal/dto/responses"
+	apperrors "github.com/Andrew44Ashraf/fintech-service/internal/errors"
+	"github.com/Andrew44Ashraf/fintech-service/internal/repository"
+	"github.com/gin-gonic/gin"
+)
+
+// maxBatchFileSize bounds uploaded batch files.
+const maxBatchFileSize = 5 << 20
+
+// BatchHandler serves bulk posting of deposits, withdrawals and transfers.
+type BatchHandler struct {
+	batchRepo   *repository.BatchRepository
+	accountRepo *repository.AccountRepository
+	logger      *slog.Logger
+}
+
+func NewBatchHandler(batchRepo *repository.BatchRepository, accountRepo *repository.AccountRepository, logger *slog.Logger) *BatchHandler {
+	return &BatchHandler{batchRepo: batchRepo, accountRepo: accountRepo, logger: logger}
+}
+
+// CreateBatch godoc
+// @Summary Post a batch of transactions
+// @Description Accepts a CSV (columns type, account_id, to_account_id, amount, reference) or JSON-lines file, either as the request body or as the multipart field "file". Accounts are given by account ID or account number. The whole file is validated, and its accounts looked up, before anything is posted.
+// @Tags admin
+// @Accept text/csv,application/x-ndjson,multipart/form-data
+// @Produce json
+// @Param mode query string false "all_or_nothing (default) or best_effort"
+// @Param dry_run query bool false "Validate and simulate without posting"
+// @Param format query string false "csv or jsonl (default: from the content type or file name)"
+// @Success 201 {object} responses.BatchResponse
+// @Failure 400 {object} responses.Problem
+// @Failure 401 {object} responses.Problem
+// @Failure 500 {object} responses.Problem
+// @Router /batches [post]
+func (h *BatchHandler) CreateBatch(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 60*time.Second)
+	defer cancel()
+
+	mode := c.DefaultQuery("mode", batches.ModeAllOrNothing)
+	if mode != batches.ModeAllOrNothing && mode != batches.ModeBestEffort {
+		respondError(c, h.logger, "create_batch", apperrors.Invalid("mode", "oneof", "must be one of: all_or_nothing best_effort"))
+		return
+	}
+	dryRun, err := strconv.ParseBool(c.DefaultQuery("dry_run", "false"))
+	if err != nil {
+		respondError(c, h.logger, "create_batch", apperrors.Invalid("dry_run", "boolean", "must be true or false"))
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxBatchFileSize)
+	file, format, err := batchFile(c)
+	if err != nil {
+		respondError(c, h.logger, "create_batch", err)
+		return
+	}
+
+	instructions, err := batches.Parse(bytes.NewReader(file), format)
+	if err != nil {
+		respondError(c, h.logger, "create_batch", err)
+		return
+	}
+	resolved, err := h.accountRepo.ResolveAccounts(ctx, batches.AccountRefs(instructions))
+	if err != nil {
+		respondError(c, h.logger, "create_batch", err)
+		return
+	}
+	if err := batches.Resolve(instructions, resolved); err != nil {
+		respondError(c, h.logger, "create_batch", err)
+		return
+	}
+
+	batch, err := h.batchRepo.Process(ctx, mode, dryRun, instructions)
+	if err != nil {
+		respondError(c, h.logger, "create_batch", err)
+		return
+	}
+
+	c.Header("Location", fmt.Sprintf("/api/batches/%d", batch.ID))
+	c.JSON(http.StatusCreated, toBatchResponse(batch))
+}
+
+// GetBatch godoc
+// @Summary Get a batch and its per-row results
+// @Tags admin
+// @Produce json
+// @Param id path int true "Batch ID"
+// @Success 200 {object} responses.BatchResponse
+// @Failure 400 {object} responses.Problem
+// @Failure 401 {object} responses.Problem
+// @Failure 404 {object} responses.Problem
+// @Failure 500 {object} responses.Problem
+// @Router /batches/{id} [get]
+func (h *BatchHandler) GetBatch(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		respondError(c, h.logger, "get_batch", apperrors.Invalid("id", "int", "must be a positive integer"))
+		return
+	}
+
+	batch, err := h.batchRepo.GetBatch(ctx, id)
+	if err != nil {
+		respondError(c, h.logger, "get_batch", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, toBatchResponse(batch))
+}
+
+// batchFile reads the uploaded file and returns it with its format. The
+// format comes from the format query parameter, the multipart file name or
+// the content type, in that order.
+func batchFile(c *gin.Context) ([]byte, string, error) {
+	format := c.Query("format")
+	mediaType, _, _ := mime.ParseMediaType(c.ContentType())
+
+	var body io.ReadCloser = c.Request.Body
+	if mediaType == "multipart/form-data" {
+		header, err := c.FormFile("file")
+		if err != nil {
+			if tooLarge(err) {
+				return nil, "", apperrors.Invalid("file", "max", "must not be larger than 5 MB")
+			}
+			return nil, "", apperrors.Invalid("file", "required", "is required")
+		}
+		f, err := header.Open()
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to open upload: %w", err)
+		}
+		body = f
+		if format == "" {
+			format = strings.TrimPrefix(strings.ToLower(filepath.Ext(header.Filename)), ".")
+		}
+	}
+
+	if format == "" {
+		switch mediaType {
+		case "text/csv":
+			format = batches.FormatCSV
+		case "application/x-ndjson", "application/jsonl", "application/x-jsonlines":
+			format = batches.FormatJSONL
+		}
+	}
+	if format == "ndjson" {
+		format = batches.FormatJSONL
+	}
+	defer body.Close()
+	if format != batches.FormatCSV && format != batches.FormatJSONL {
+		return nil, "", apperrors.Invalid("format", "oneof", "must be one of: csv jsonl")
+	}
+
+	data, err := io.ReadAll(body)
+	switch {
+	case tooLarge(err):
+		return nil, "", apperrors.Invalid("file", "max", "must not be larger than 5 MB")
+	case err != nil:
+		return nil, "", fmt.Errorf("%w: %v", apperrors.ErrInvalidRequest, err)
+	}
+	return data, format, nil
+}
+
+func tooLarge(err error) bool {
+	var maxErr *http.MaxBytesError
+	return errors.As(err, &maxErr)
+}
+
+func toBatchResponse(b *repository.Batch) responses.BatchResponse {
+	rows := make([]responses.BatchRowResult, 0, len(b.Rows))
+	for _, row := range b.Rows {
+		rows = append(rows, responses.BatchRowResult{
+			Row:           row.Row,
+			Type:          row.Type,
+			AccountID:     row.AccountPublicID,
+			ToAccountID:   row.ToAccountPublicID,
+			Amount:        row.Amount,
+			Reference:     row.Reference,
+			Status:        row.Status,
+			TransactionID: row.TransactionPublicID,
+			ErrorCode:     row.ErrorCode,
+			Error:         row.ErrorMessage,
+		})
+	}
+
+	return responses.BatchResponse{
+		ID:            b.ID,
+		Mode:          b.Mode,
+		DryRun:        b.DryRun,
+		Status:        b.Status,
+		TotalRows:     b.TotalRows,
+		SucceededRows: b.SucceededRows,
+		FailedRows:    b.FailedRows,
+		CreatedAt:     b.CreatedAt,
+		CompletedAt:   b.CompletedAt,
+		Rows:          rows,
+	}
+}
@@ -0,0 +1,25 @@
+package handlers
+
+import (
+	"github.com/Andrew44Ashraf/fintech-service/internal/dto/requests"
+	"github.com/Andrew44Ashraf/fintech-service/internal/dto/responses"
+	"github.com/Andrew44Ashraf/fintech-service/internal/repository"
+)
+
+// toDetails converts request details; the repository checks the metadata
+// is a JSON object.
+func toDetails(d requests.Details) repository.Details {
+	return repository.Details{
+		ExternalRef: d.ExternalRef,
+		Description: d.Description,
+		Metadata:    d.Metadata,
+	}
+}
+
+func fromDetails(d repository.Details) responses.Details {
+	return responses.Details{
+		ExternalRef: d.ExternalRef,
+		Description: d.Description,
+		Metadata:    d.Metadata,
+	}
+}
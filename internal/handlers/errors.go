@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"log/slog"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/Andrew44Ashraf/fintech-service/internal/dto/responses"
+	apperrors "github.com/Andrew44Ashraf/fintech-service/internal/errors"
+	"github.com/Andrew44Ashraf/fintech-service/internal/logging"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+// validate is shared by all handlers; it reports fields by their JSON name.
+var validate = newValidator()
+
+func newValidator() *validator.Validate {
+	v := validator.New()
+	v.RegisterTagNameFunc(func(f reflect.StructField) string {
+		name := strings.SplitN(f.Tag.Get("json"), ",", 2)[0]
+		if name == "-" || name == "" {
+			return f.Name
+		}
+		return name
+	})
+	return v
+}
+
+// validateRequest runs struct validation and converts failures into a
+// catalog validation error.
+func validateRequest(req any) error {
+	if err := validate.Struct(req); err != nil {
+		return apperrors.FromValidator(err)
+	}
+	return nil
+}
+
+// respondError writes err as an application/problem+json response using the
+// central error catalog.
+func respondError(c *gin.Context, logger *slog.Logger, op string, err error) {
+	ctx := c.Request.Context()
+	p := responses.NewProblem(err, c.Request.URL.Path, logging.RequestID(ctx))
+
+	log := logging.FromContext(ctx, logger)
+	if p.Status >= http.StatusInternalServerError {
+		log.Error(op+" failed", logging.KeyOp, op, "code", p.Code, logging.KeyError, err.Error())
+	} else {
+		log.Debug(op+" rejected", logging.KeyOp, op, "code", p.Code, logging.KeyError, err.Error())
+	}
+
+	c.Header("Content-Type", responses.ProblemContentType)
+	c.AbortWithStatusJSON(p.Status, p)
+}
@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	apperrors "github.com/Andrew44Ashraf/fintech-service/internal/errors"
+	"github.com/gin-gonic/gin"
+)
+
+// etag is the entity tag of an account version.
+func etag(version int64) string {
+	return `"` + strconv.FormatInt(version, 10) + `"`
+}
+
+// ifMatch reads the account version a mutating request was made against.
+// The header is required; "*" matches any version and is returned as 0.
+func ifMatch(c *gin.Context) (int64, error) {
+	v := strings.TrimSpace(c.GetHeader("If-Match"))
+	switch v {
+	case "":
+		return 0, apperrors.ErrPreconditionRequired
+	case "*":
+		return 0, nil
+	}
+	version, err := strconv.ParseInt(strings.Trim(strings.TrimPrefix(v, "W/"), `"`), 10, 64)
+	if err != nil || version <= 0 {
+		// Not a tag this service issued, so it cannot match
+		return 0, apperrors.ErrVersionMismatch
+	}
+	return version, nil
+}
+
+// notModified sets the ETag of the current version and, when the
+// If-None-Match header lists it, answers 304 Not Modified. It reports
+// whether the response has been written.
+func notModified(c *gin.Context, version int64) bool {
+	tag := etag(version)
+	c.Header("ETag", tag)
+	for _, v := range strings.Split(c.GetHeader("If-None-Match"), ",") {
+		v = strings.TrimPrefix(strings.TrimSpace(v), "W/")
+		if v == tag || v == "*" {
+			c.Status(http.StatusNotModified)
+			c.Writer.WriteHeaderNow()
+			return true
+		}
+	}
+	return false
+}
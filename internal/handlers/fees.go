@@ -0,0 +1,278 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Andrew44Ashraf/fintech-service/internal/dto/requests"
+	"github.com/Andrew44Ashraf/fintech-service/internal/dto/responses"
+	apperrors "github.com/Andrew44Ashraf/fintech-service/internal/errors"
+	"github.com/Andrew44Ashraf/fintech-service/internal/fees"
+	"github.com/Andrew44Ashraf/fintech-service/internal/logging"
+	"github.com/Andrew44Ashraf/fintech-service/internal/repository"
+	"github.com/gin-gonic/gin"
+)
+
+// FeeHandler serves the operator endpoints for fee waivers and reversals.
+// Every change is written to the fee audit log.
+type FeeHandler struct {
+	feeRepo         *repository.FeeRepository
+	transactionRepo *repository.TransactionRepository
+	logger          *slog.Logger
+}
+
+func NewFeeHandler(feeRepo *repository.FeeRepository, transactionRepo *repository.TransactionRepository, logger *slog.Logger) *FeeHandler {
+	return &FeeHandler{feeRepo: feeRepo, transactionRepo: transactionRepo, logger: logger}
+}
+
+// GrantWaiver godoc
+// @Summary Waive a fee for an account
+// @Description Exempts the account from one fee type from starts_at (default now) until ends_at or revocation
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path string true "Account ID or account number"
+// @Param body body requests.CreateFeeWaiverRequest true "Waiver"
+// @Success 201 {object} responses.FeeWaiverResponse
+// @Failure 400 {object} responses.Problem
+// @Failure 404 {object} responses.Problem
+// @Failure 409 {object} responses.Problem
+// @Failure 500 {object} responses.Problem
+// @Router /admin/accounts/{id}/fee-waivers [post]
+func (h *FeeHandler) GrantWaiver(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	accountID, err := parseAccountID(c)
+	if err != nil {
+		respondError(c, h.logger, "grant_fee_waiver", err)
+		return
+	}
+
+	var req requests.CreateFeeWaiverRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, h.logger, "grant_fee_waiver", fmt.Errorf("%w: %v", apperrors.ErrInvalidRequest, err))
+		return
+	}
+	if err := validateRequest(req); err != nil {
+		respondError(c, h.logger, "grant_fee_waiver", err)
+		return
+	}
+
+	w := &repository.FeeWaiver{
+		AccountID: accountID,
+		FeeType:   fees.Type(req.FeeType),
+		StartsAt:  time.Now().UTC(),
+		EndsAt:    req.EndsAt,
+		Reason:    req.Reason,
+		GrantedBy: req.Operator,
+	}
+	if req.StartsAt != nil {
+		w.StartsAt = req.StartsAt.UTC()
+	}
+	if err := h.feeRepo.GrantWaiver(ctx, w); err != nil {
+		respondError(c, h.logger, "grant_fee_waiver", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, toFeeWaiverResponse(w))
+}
+
+// ListWaivers godoc
+// @Summary List an account's fee waivers
+// @Tags admin
+// @Produce json
+// @Param id path string true "Account ID or account number"
+// @Success 200 {object} responses.FeeWaiverListResponse
+// @Failure 400 {object} responses.Problem
+// @Failure 500 {object} responses.Problem
+// @Router /admin/accounts/{id}/fee-waivers [get]
+func (h *FeeHandler) ListWaivers(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	accountID, err := parseAccountID(c)
+	if err != nil {
+		respondError(c, h.logger, "list_fee_waivers", err)
+		return
+	}
+
+	waivers, err := h.feeRepo.ListWaivers(ctx, accountID)
+	if err != nil {
+		respondError(c, h.logger, "list_fee_waivers", err)
+		return
+	}
+
+	items := make([]responses.FeeWaiverResponse, 0, len(waivers))
+	for i := range waivers {
+		items = append(items, toFeeWaiverResponse(&waivers[i]))
+	}
+	c.JSON(http.StatusOK, responses.FeeWaiverListResponse{Waivers: items})
+}
+
+// RevokeWaiver godoc
+// @Summary Revoke a fee waiver
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path int true "Fee waiver ID"
+// @Param body body requests.FeeActionRequest true "Operator and reason"
+// @Success 204
+// @Failure 400 {object} responses.Problem
+// @Failure 404 {object} responses.Problem
+// @Failure 409 {object} responses.Problem
+// @Failure 500 {object} responses.Problem
+// @Router /admin/fee-waivers/{id}/revoke [post]
+func (h *FeeHandler) RevokeWaiver(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		respondError(c, h.logger, "revoke_fee_waiver", apperrors.Invalid("id", "int", "must be a positive integer"))
+		return
+	}
+	req, ok := h.bindAction(c, "revoke_fee_waiver")
+	if !ok {
+		return
+	}
+
+	if err := h.feeRepo.RevokeWaiver(ctx, id, req.Operator, req.Reason); err != nil {
+		respondError(c, h.logger, "revoke_fee_waiver", err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// ReverseFee godoc
+// @Summary Reverse a charged fee
+// @Description Refunds the fee with a fee_reversal transaction. A fee can be reversed once.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path string true "Fee transaction ID"
+// @Param body body requests.FeeActionRequest true "Operator and reason"
+// @Success 201 {object} responses.FeeReversalResponse
+// @Failure 400 {object} responses.Problem
+// @Failure 404 {object} responses.Problem
+// @Failure 409 {object} responses.Problem
+// @Failure 500 {object} responses.Problem
+// @Router /admin/fees/{id}/reverse [post]
+func (h *FeeHandler) ReverseFee(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	feeTxID, err := h.transactionRepo.ResolveTransaction(ctx, c.Param("id"))
+	if err != nil {
+		respondError(c, h.logger, "reverse_fee", err)
+		return
+	}
+	req, ok := h.bindAction(c, "reverse_fee")
+	if !ok {
+		return
+	}
+
+	txID, err := h.feeRepo.ReverseFee(ctx, feeTxID, req.Operator, req.Reason)
+	if err != nil {
+		respondError(c, h.logger, "reverse_fee", err)
+		return
+	}
+
+	// The reversal is posted, so a failed lookup only drops its ID
+	publicID, err := h.transactionRepo.PublicID(ctx, txID)
+	if err != nil {
+		logging.FromContext(ctx, h.logger).Error("failed to get transaction ID",
+			logging.KeyOp, "reverse_fee",
+			logging.KeyTxID, txID,
+			logging.KeyError, err.Error(),
+		)
+	}
+	c.JSON(http.StatusCreated, responses.FeeReversalResponse{TransactionID: publicID, ReversedTransactionID: c.Param("id")})
+}
+
+// ListAudit godoc
+// @Summary List an account's fee audit log
+// @Tags admin
+// @Produce json
+// @Param id path string true "Account ID or account number"
+// @Param limit query int false "Page size (default 10, max 100)"
+// @Param offset query int false "Number of records to skip"
+// @Success 200 {object} responses.FeeAuditListResponse
+// @Failure 400 {object} responses.Problem
+// @Failure 500 {object} responses.Problem
+// @Router /admin/accounts/{id}/fee-audit [get]
+func (h *FeeHandler) ListAudit(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	accountID, err := parseAccountID(c)
+	if err != nil {
+		respondError(c, h.logger, "list_fee_audit", err)
+		return
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultPageSize)))
+	if err != nil || limit <= 0 || limit > maxPageSize {
+		respondError(c, h.logger, "list_fee_audit", apperrors.Invalid("limit", "range", fmt.Sprintf("must be between 1 and %d", maxPageSize)))
+		return
+	}
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		respondError(c, h.logger, "list_fee_audit", apperrors.Invalid("offset", "gte", "must be at least 0"))
+		return
+	}
+
+	entries, err := h.feeRepo.ListFeeAudit(ctx, accountID, limit, offset)
+	if err != nil {
+		respondError(c, h.logger, "list_fee_audit", err)
+		return
+	}
+
+	items := make([]responses.FeeAuditItem, 0, len(entries))
+	for _, e := range entries {
+		items = append(items, responses.FeeAuditItem{
+			ID:            e.ID,
+			Action:        e.Action,
+			FeeType:       e.FeeType,
+			WaiverID:      e.WaiverID,
+			TransactionID: e.TransactionPublicID,
+			Amount:        e.Amount,
+			Operator:      e.Operator,
+			Reason:        e.Reason,
+			CreatedAt:     e.CreatedAt,
+		})
+	}
+	c.JSON(http.StatusOK, responses.FeeAuditListResponse{Entries: items, Limit: limit, Offset: offset})
+}
+
+// bindAction reads the operator's reason.
+func (h *FeeHandler) bindAction(c *gin.Context, op string) (requests.FeeActionRequest, bool) {
+	var req requests.FeeActionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, h.logger, op, fmt.Errorf("%w: %v", apperrors.ErrInvalidRequest, err))
+		return req, false
+	}
+	if err := validateRequest(req); err != nil {
+		respondError(c, h.logger, op, err)
+		return req, false
+	}
+	return req, true
+}
+
+func toFeeWaiverResponse(w *repository.FeeWaiver) responses.FeeWaiverResponse {
+	return responses.FeeWaiverResponse{
+		ID:        w.ID,
+		AccountID: w.AccountPublicID,
+		FeeType:   string(w.FeeType),
+		StartsAt:  w.StartsAt,
+		EndsAt:    w.EndsAt,
+		Reason:    w.Reason,
+		GrantedBy: w.GrantedBy,
+		RevokedBy: w.RevokedBy,
+		RevokedAt: w.RevokedAt,
+		CreatedAt: w.CreatedAt,
+	}
+}
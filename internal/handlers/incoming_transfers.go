@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/Andrew44Ashraf/fintech-service/internal/dto/requests"
+	"github.com/Andrew44Ashraf/fintech-service/internal/dto/responses"
+	apperrors "github.com/Andrew44Ashraf/fintech-service/internal/errors"
+	"github.com/Andrew44Ashraf/fintech-service/internal/logging"
+	"github.com/Andrew44Ashraf/fintech-service/internal/repository"
+	"github.com/gin-gonic/gin"
+)
+
+// IncomingTransferHandler serves the operator endpoints that record
+// transfers announced by other banks and settle them.
+type IncomingTransferHandler struct {
+	transactionRepo *repository.TransactionRepository
+	logger          *slog.Logger
+}
+
+func NewIncomingTransferHandler(transactionRepo *repository.TransactionRepository, logger *slog.Logger) *IncomingTransferHandler {
+	return &IncomingTransferHandler{transactionRepo: transactionRepo, logger: logger}
+}
+
+// Record godoc
+// @Summary Record an incoming bank transfer
+// @Description Records the transfer as pending: it shows in history but does not change the balance until posted
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path string true "Account ID or account number"
+// @Param body body requests.IncomingTransferRequest true "Incoming transfer"
+// @Success 202 {object} responses.TransactionResponse
+// @Failure 400 {object} responses.Problem
+// @Failure 404 {object} responses.Problem
+// @Failure 409 {object} responses.Problem
+// @Failure 500 {object} responses.Problem
+// @Router /admin/accounts/{id}/incoming-transfers [post]
+func (h *IncomingTransferHandler) Record(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	accountID, err := parseAccountID(c)
+	if err != nil {
+		respondError(c, h.logger, "incoming_transfer", err)
+		return
+	}
+
+	var req requests.IncomingTransferRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, h.logger, "incoming_transfer", fmt.Errorf("%w: %v", apperrors.ErrInvalidRequest, err))
+		return
+	}
+	if err := validateRequest(req); err != nil {
+		respondError(c, h.logger, "incoming_transfer", err)
+		return
+	}
+	var valueDate time.Time
+	if req.ValueDate != "" {
+		valueDate, _ = time.Parse(dateLayout, req.ValueDate) // checked by validateRequest
+	}
+
+	txID, err := h.transactionRepo.CreateIncomingTransfer(ctx, accountID, req.Amount, valueDate, toDetails(req.Details))
+	if err != nil {
+		respondError(c, h.logger, "incoming_transfer", err)
+		return
+	}
+
+	// The transfer is recorded, so a failed lookup only drops the ID
+	publicID, err := h.transactionRepo.PublicID(ctx, txID)
+	if err != nil {
+		logging.FromContext(ctx, h.logger).Error("failed to get transaction ID",
+			logging.KeyOp, "incoming_transfer",
+			logging.KeyAccountID, accountID,
+			logging.KeyTxID, txID,
+			logging.KeyError, err.Error(),
+		)
+	}
+	c.JSON(http.StatusAccepted, responses.TransactionResponse{
+		TransactionID: publicID,
+		Status:        repository.StatusPending,
+		Message:       "Incoming transfer recorded as pending",
+	})
+}
+
+// Post godoc
+// @Summary Post a settled incoming transfer
+// @Description Credits the pending transfer to the account. Its booking date is today; the value date is kept.
+// @Tags admin
+// @Produce json
+// @Param id path string true "Transaction ID"
+// @Success 204
+// @Failure 400 {object} responses.Problem
+// @Failure 404 {object} responses.Problem
+// @Failure 409 {object} responses.Problem
+// @Failure 500 {object} responses.Problem
+// @Router /admin/incoming-transfers/{id}/post [post]
+func (h *IncomingTransferHandler) Post(c *gin.Context) {
+	h.settle(c, "post_pending", h.transactionRepo.PostPendingTransaction)
+}
+
+// Fail godoc
+// @Summary Fail an incoming transfer that did not settle
+// @Description Marks the pending transfer as failed. The balance is untouched.
+// @Tags admin
+// @Produce json
+// @Param id path string true "Transaction ID"
+// @Success 204
+// @Failure 400 {object} responses.Problem
+// @Failure 404 {object} responses.Problem
+// @Failure 409 {object} responses.Problem
+// @Failure 500 {object} responses.Problem
+// @Router /admin/incoming-transfers/{id}/fail [post]
+func (h *IncomingTransferHandler) Fail(c *gin.Context) {
+	h.settle(c, "fail_pending", h.transactionRepo.FailPendingTransaction)
+}
+
+func (h *IncomingTransferHandler) settle(c *gin.Context, op string, apply func(ctx context.Context, txID int) error) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	txID, err := h.transactionRepo.ResolveTransaction(ctx, c.Param("id"))
+	if err != nil {
+		respondError(c, h.logger, op, err)
+		return
+	}
+
+	if err := apply(ctx, txID); err != nil {
+		respondError(c, h.logger, op, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
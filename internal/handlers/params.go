@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strconv"
+	"time"
+
+	apperrors "github.com/Andrew44Ashraf/fintech-service/internal/errors"
+	"github.com/gin-gonic/gin"
+)
+
+// accountIDKey holds the internal ID ResolveAccount found for :id.
+const accountIDKey = "account_id"
+
+// AccountResolver finds the internal ID of an account a client refers to
+// by public ID or account number.
+type AccountResolver interface {
+	ResolveAccount(ctx context.Context, ref string) (int, error)
+}
+
+// ResolveAccount resolves the :id path parameter, a public account ID or
+// account number, to the internal account ID the handlers work with.
+// Routes with an account in the path use it, so the internal ID is never
+// accepted from clients.
+func ResolveAccount(accounts AccountResolver, logger *slog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 3*time.Second)
+		defer cancel()
+
+		accountID, err := accounts.ResolveAccount(ctx, c.Param("id"))
+		if err != nil {
+			respondError(c, logger, "resolve_account", err)
+			return
+		}
+		c.Set(accountIDKey, accountID)
+		c.Next()
+	}
+}
+
+// resolveAccountField resolves an account a client names in a request
+// field, reporting a malformed reference against that field.
+func resolveAccountField(ctx context.Context, accounts AccountResolver, field, ref string) (int, error) {
+	accountID, err := accounts.ResolveAccount(ctx, ref)
+	var verr *apperrors.ValidationError
+	if errors.As(err, &verr) {
+		return 0, apperrors.Invalid(field, "account", "must be an account ID or account number")
+	}
+	return accountID, err
+}
+
+// parseAccountID returns the account ID ResolveAccount found. Without
+// ResolveAccount in the chain, as when a handler is called directly, the
+// :id path parameter is read as the internal ID.
+func parseAccountID(c *gin.Context) (int, error) {
+	if accountID := c.GetInt(accountIDKey); accountID > 0 {
+		return accountID, nil
+	}
+	accountID, err := strconv.Atoi(c.Param("id"))
+	if err != nil || accountID <= 0 {
+		return 0, apperrors.Invalid("id", "int", "must be a positive integer")
+	}
+	return accountID, nil
+}
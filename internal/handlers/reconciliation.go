@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Andrew44Ashraf/fintech-service/internal/dto/responses"
+	apperrors "github.com/Andrew44Ashraf/fintech-service/internal/errors"
+	"github.com/Andrew44Ashraf/fintech-service/internal/repository"
+	"github.com/gin-gonic/gin"
+)
+
+// ReconciliationHandler serves the ledger-vs-journal reconciliation report.
+type ReconciliationHandler struct {
+	repo   *repository.ReconciliationRepository
+	logger *slog.Logger
+}
+
+func NewReconciliationHandler(repo *repository.ReconciliationRepository, logger *slog.Logger) *ReconciliationHandler {
+	return &ReconciliationHandler{repo: repo, logger: logger}
+}
+
+// Latest godoc
+// @Summary Get the latest reconciliation report
+// @Tags admin
+// @Produce json
+// @Success 200 {object} responses.ReconciliationRunResponse
+// @Failure 404 {object} responses.Problem
+// @Failure 500 {object} responses.Problem
+// @Router /admin/reconciliation [get]
+func (h *ReconciliationHandler) Latest(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	run, err := h.repo.LatestRun(ctx)
+	if err != nil {
+		respondError(c, h.logger, "get_reconciliation", err)
+		return
+	}
+	c.JSON(http.StatusOK, toReconciliationResponse(run))
+}
+
+// Get godoc
+// @Summary Get a reconciliation report
+// @Tags admin
+// @Produce json
+// @Param id path int true "Reconciliation run ID"
+// @Success 200 {object} responses.ReconciliationRunResponse
+// @Failure 400 {object} responses.Problem
+// @Failure 404 {object} responses.Problem
+// @Failure 500 {object} responses.Problem
+// @Router /admin/reconciliation/{id} [get]
+func (h *ReconciliationHandler) Get(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		respondError(c, h.logger, "get_reconciliation", apperrors.Invalid("id", "int", "must be a positive integer"))
+		return
+	}
+
+	run, err := h.repo.GetRun(ctx, id)
+	if err != nil {
+		respondError(c, h.logger, "get_reconciliation", err)
+		return
+	}
+	c.JSON(http.StatusOK, toReconciliationResponse(run))
+}
+
+// Run godoc
+// @Summary Reconcile all accounts now
+// @Description Starts a manual reconciliation run and returns its report
+// @Tags admin
+// @Produce json
+// @Success 201 {object} responses.ReconciliationRunResponse
+// @Failure 500 {object} responses.Problem
+// @Router /admin/reconciliation [post]
+func (h *ReconciliationHandler) Run(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), time.Minute)
+	defer cancel()
+
+	run, err := h.repo.Reconcile(ctx, repository.TriggerManual, time.Now())
+	if err != nil {
+		respondError(c, h.logger, "reconcile", err)
+		return
+	}
+	c.JSON(http.StatusCreated, toReconciliationResponse(run))
+}
+
+func toReconciliationResponse(run *repository.ReconciliationRun) responses.ReconciliationRunResponse {
+	resp := responses.ReconciliationRunResponse{
+		ID:              run.ID,
+		RunDate:         run.RunDate.Format(dateLayout),
+		Trigger:         run.Trigger,
+		AccountsChecked: run.AccountsChecked,
+		BreakCount:      run.BreakCount,
+		StartedAt:       run.StartedAt,
+		FinishedAt:      run.FinishedAt,
+		Breaks:          make([]responses.ReconciliationBreakItem, 0, len(run.Breaks)),
+	}
+	for _, b := range run.Breaks {
+		resp.Breaks = append(resp.Breaks, responses.ReconciliationBreakItem{
+			ID:              b.ID,
+			AccountID:       b.AccountPublicID,
+			Kind:            string(b.Kind),
+			LedgerBalance:   b.LedgerBalance,
+			ExpectedBalance: b.ExpectedBalance,
+			Difference:      b.Difference,
+			CreatedAt:       b.CreatedAt,
+		})
+	}
+	return resp
+}
@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Andrew44Ashraf/fintech-service/internal/dto/requests"
+	"github.com/Andrew44Ashraf/fintech-service/internal/dto/responses"
+	apperrors "github.com/Andrew44Ashraf/fintech-service/internal/errors"
+	"github.com/Andrew44Ashraf/fintech-service/internal/repository"
+	"github.com/gin-gonic/gin"
+)
+
+// ReviewHandler serves the operator queue of transactions held by screening.
+type ReviewHandler struct {
+	transactionRepo *repository.TransactionRepository
+	logger          *slog.Logger
+}
+
+func NewReviewHandler(transactionRepo *repository.TransactionRepository, logger *slog.Logger) *ReviewHandler {
+	return &ReviewHandler{transactionRepo: transactionRepo, logger: logger}
+}
+
+// ListPending godoc
+// @Summary List transactions awaiting review
+// @Tags admin
+// @Produce json
+// @Param limit query int false "Page size (default 10, max 100)"
+// @Param offset query int false "Number of records to skip"
+// @Success 200 {object} responses.ReviewListResponse
+// @Failure 400 {object} responses.Problem
+// @Failure 401 {object} responses.Problem
+// @Failure 500 {object} responses.Problem
+// @Router /admin/reviews [get]
+func (h *ReviewHandler) ListPending(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultPageSize)))
+	if err != nil || limit <= 0 || limit > maxPageSize {
+		respondError(c, h.logger, "list_reviews", apperrors.Invalid("limit", "range", fmt.Sprintf("must be between 1 and %d", maxPageSize)))
+		return
+	}
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		respondError(c, h.logger, "list_reviews", apperrors.Invalid("offset", "gte", "must be at least 0"))
+		return
+	}
+
+	reviews, err := h.transactionRepo.ListPendingReviews(ctx, limit, offset)
+	if err != nil {
+		respondError(c, h.logger, "list_reviews", err)
+		return
+	}
+
+	items := make([]responses.ReviewItem, 0, len(reviews))
+	for _, rv := range reviews {
+		items = append(items, responses.ReviewItem{
+			TransactionID: rv.TransactionPublicID,
+			AccountID:     rv.AccountPublicID,
+			Amount:        rv.Amount,
+			Type:          rv.Type,
+			Reasons:       rv.Reasons,
+			CreatedAt:     rv.CreatedAt,
+		})
+	}
+
+	c.JSON(http.StatusOK, responses.ReviewListResponse{
+		Reviews: items,
+		Limit:   limit,
+		Offset:  offset,
+	})
+}
+
+// Approve godoc
+// @Summary Approve a held transaction
+// @Description Posts the transaction, re-checking account status and funds
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path string true "Transaction ID"
+// @Param body body requests.ReviewDecisionRequest true "Operator decision"
+// @Success 204
+// @Failure 400 {object} responses.Problem
+// @Failure 404 {object} responses.Problem
+// @Failure 409 {object} responses.Problem
+// @Failure 422 {object} responses.Problem
+// @Failure 500 {object} responses.Problem
+// @Router /admin/reviews/{id}/approve [post]
+func (h *ReviewHandler) Approve(c *gin.Context) {
+	h.decide(c, "approve_review", h.transactionRepo.ApproveTransaction)
+}
+
+// Reject godoc
+// @Summary Reject a held transaction
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path string true "Transaction ID"
+// @Param body body requests.ReviewDecisionRequest true "Operator decision"
+// @Success 204
+// @Failure 400 {object} responses.Problem
+// @Failure 404 {object} responses.Problem
+// @Failure 409 {object} responses.Problem
+// @Failure 500 {object} responses.Problem
+// @Router /admin/reviews/{id}/reject [post]
+func (h *ReviewHandler) Reject(c *gin.Context) {
+	h.decide(c, "reject_review", h.transactionRepo.RejectTransaction)
+}
+
+func (h *ReviewHandler) decide(c *gin.Context, op string, apply func(ctx context.Context, txID int, operator, note string) error) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	txID, err := h.transactionRepo.ResolveTransaction(ctx, c.Param("id"))
+	if err != nil {
+		respondError(c, h.logger, op, err)
+		return
+	}
+
+	var req requests.ReviewDecisionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, h.logger, op, fmt.Errorf("%w: %v", apperrors.ErrInvalidRequest, err))
+		return
+	}
+	if err := validateRequest(req); err != nil {
+		respondError(c, h.logger, op, err)
+		return
+	}
+
+	if err := apply(ctx, txID, req.Operator, req.Note); err != nil {
+		respondError(c, h.logger, op, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,301 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Andrew44Ashraf/fintech-service/internal/dto/requests"
+	"github.com/Andrew44Ashraf/fintech-service/internal/dto/responses"
+	apperrors "github.com/Andrew44Ashraf/fintech-service/internal/errors"
+	"github.com/Andrew44Ashraf/fintech-service/internal/recurrence"
+	"github.com/Andrew44Ashraf/fintech-service/internal/repository"
+	"github.com/gin-gonic/gin"
+)
+
+// ScheduledPaymentHandler manages standing orders.
+type ScheduledPaymentHandler struct {
+	scheduleRepo *repository.ScheduledPaymentRepository
+	accounts     AccountResolver
+	logger       *slog.Logger
+}
+
+func NewScheduledPaymentHandler(scheduleRepo *repository.ScheduledPaymentRepository, accounts AccountResolver, logger *slog.Logger) *ScheduledPaymentHandler {
+	return &ScheduledPaymentHandler{scheduleRepo: scheduleRepo, accounts: accounts, logger: logger}
+}
+
+// Create godoc
+// @Summary Create a scheduled payment
+// @Description Schedules a transfer to to_account_id (an account ID or account number), or a withdrawal when it is omitted. Repeats by cron (5 fields, UTC) or every N day/week/month from start_at; with neither it runs once at start_at.
+// @Tags scheduled-payments
+// @Accept json
+// @Produce json
+// @Param id path string true "Paying account ID or account number"
+// @Param body body requests.CreateScheduledPaymentRequest true "Schedule"
+// @Success 201 {object} responses.ScheduledPaymentResponse
+// @Failure 400 {object} responses.Problem
+// @Failure 404 {object} responses.Problem
+// @Failure 409 {object} responses.Problem
+// @Failure 500 {object} responses.Problem
+// @Router /accounts/{id}/scheduled-payments [post]
+func (h *ScheduledPaymentHandler) Create(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	accountID, err := parseAccountID(c)
+	if err != nil {
+		respondError(c, h.logger, "create_scheduled_payment", err)
+		return
+	}
+
+	var req requests.CreateScheduledPaymentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, h.logger, "create_scheduled_payment", fmt.Errorf("%w: %v", apperrors.ErrInvalidRequest, err))
+		return
+	}
+	if err := validateRequest(req); err != nil {
+		respondError(c, h.logger, "create_scheduled_payment", err)
+		return
+	}
+
+	var toAccountID int
+	if req.ToAccountID != "" {
+		toAccountID, err = resolveAccountField(ctx, h.accounts, "to_account_id", req.ToAccountID)
+		if err != nil {
+			respondError(c, h.logger, "create_scheduled_payment", err)
+			return
+		}
+	}
+
+	p := &repository.ScheduledPayment{
+		AccountID:           accountID,
+		ToAccountID:         toAccountID,
+		Amount:              req.Amount,
+		Description:         req.Description,
+		Rule:                recurrence.Rule{Cron: req.Cron, Every: req.Every, Unit: req.Unit},
+		StartAt:             req.StartAt.UTC(),
+		EndAt:               req.EndAt,
+		OnInsufficientFunds: req.OnInsufficientFunds,
+		MaxRetries:          req.MaxRetries,
+	}
+	if err := h.scheduleRepo.CreateScheduledPayment(ctx, p, time.Now()); err != nil {
+		respondError(c, h.logger, "create_scheduled_payment", err)
+		return
+	}
+
+	c.Header("Location", "/api/scheduled-payments/"+p.PublicID)
+	c.JSON(http.StatusCreated, toScheduledPaymentResponse(p))
+}
+
+// List godoc
+// @Summary List an account's scheduled payments
+// @Tags scheduled-payments
+// @Produce json
+// @Param id path string true "Account ID or account number"
+// @Success 200 {object} responses.ScheduledPaymentListResponse
+// @Failure 400 {object} responses.Problem
+// @Failure 500 {object} responses.Problem
+// @Router /accounts/{id}/scheduled-payments [get]
+func (h *ScheduledPaymentHandler) List(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	accountID, err := parseAccountID(c)
+	if err != nil {
+		respondError(c, h.logger, "list_scheduled_payments", err)
+		return
+	}
+
+	payments, err := h.scheduleRepo.ListScheduledPayments(ctx, accountID)
+	if err != nil {
+		respondError(c, h.logger, "list_scheduled_payments", err)
+		return
+	}
+
+	items := make([]responses.ScheduledPaymentResponse, 0, len(payments))
+	for i := range payments {
+		items = append(items, toScheduledPaymentResponse(&payments[i]))
+	}
+	c.JSON(http.StatusOK, responses.ScheduledPaymentListResponse{ScheduledPayments: items})
+}
+
+// Get godoc
+// @Summary Get a scheduled payment
+// @Tags scheduled-payments
+// @Produce json
+// @Param id path string true "Scheduled payment ID"
+// @Success 200 {object} responses.ScheduledPaymentResponse
+// @Failure 400 {object} responses.Problem
+// @Failure 404 {object} responses.Problem
+// @Failure 500 {object} responses.Problem
+// @Router /scheduled-payments/{id} [get]
+func (h *ScheduledPaymentHandler) Get(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	id, err := h.scheduleRepo.ResolveScheduledPayment(ctx, c.Param("id"))
+	if err != nil {
+		respondError(c, h.logger, "get_scheduled_payment", err)
+		return
+	}
+
+	p, err := h.scheduleRepo.GetScheduledPayment(ctx, id)
+	if err != nil {
+		respondError(c, h.logger, "get_scheduled_payment", err)
+		return
+	}
+	c.JSON(http.StatusOK, toScheduledPaymentResponse(p))
+}
+
+// Update godoc
+// @Summary Update a scheduled payment
+// @Description Changes amount, description, end date or retry policy, or pauses (status=paused) and resumes (status=active) the payment. Occurrences missed while paused are skipped.
+// @Tags scheduled-payments
+// @Accept json
+// @Produce json
+// @Param id path string true "Scheduled payment ID"
+// @Param body body requests.UpdateScheduledPaymentRequest true "Changes"
+// @Success 200 {object} responses.ScheduledPaymentResponse
+// @Failure 400 {object} responses.Problem
+// @Failure 404 {object} responses.Problem
+// @Failure 409 {object} responses.Problem
+// @Failure 500 {object} responses.Problem
+// @Router /scheduled-payments/{id} [patch]
+func (h *ScheduledPaymentHandler) Update(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	id, err := h.scheduleRepo.ResolveScheduledPayment(ctx, c.Param("id"))
+	if err != nil {
+		respondError(c, h.logger, "update_scheduled_payment", err)
+		return
+	}
+
+	var req requests.UpdateScheduledPaymentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, h.logger, "update_scheduled_payment", fmt.Errorf("%w: %v", apperrors.ErrInvalidRequest, err))
+		return
+	}
+	if err := validateRequest(req); err != nil {
+		respondError(c, h.logger, "update_scheduled_payment", err)
+		return
+	}
+
+	p, err := h.scheduleRepo.UpdateScheduledPayment(ctx, id, repository.ScheduledPaymentUpdate{
+		Amount:              req.Amount,
+		Description:         req.Description,
+		EndAt:               req.EndAt,
+		Status:              req.Status,
+		OnInsufficientFunds: req.OnInsufficientFunds,
+		MaxRetries:          req.MaxRetries,
+	}, time.Now())
+	if err != nil {
+		respondError(c, h.logger, "update_scheduled_payment", err)
+		return
+	}
+	c.JSON(http.StatusOK, toScheduledPaymentResponse(p))
+}
+
+// Cancel godoc
+// @Summary Cancel a scheduled payment
+// @Tags scheduled-payments
+// @Param id path string true "Scheduled payment ID"
+// @Success 204
+// @Failure 400 {object} responses.Problem
+// @Failure 404 {object} responses.Problem
+// @Failure 409 {object} responses.Problem
+// @Failure 500 {object} responses.Problem
+// @Router /scheduled-payments/{id} [delete]
+func (h *ScheduledPaymentHandler) Cancel(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	id, err := h.scheduleRepo.ResolveScheduledPayment(ctx, c.Param("id"))
+	if err != nil {
+		respondError(c, h.logger, "cancel_scheduled_payment", err)
+		return
+	}
+
+	if err := h.scheduleRepo.CancelScheduledPayment(ctx, id); err != nil {
+		respondError(c, h.logger, "cancel_scheduled_payment", err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// ListRuns godoc
+// @Summary List the runs of a scheduled payment
+// @Tags scheduled-payments
+// @Produce json
+// @Param id path string true "Scheduled payment ID"
+// @Param limit query int false "Page size (default 10, max 100)"
+// @Param offset query int false "Number of records to skip"
+// @Success 200 {object} responses.ScheduledPaymentRunListResponse
+// @Failure 400 {object} responses.Problem
+// @Failure 404 {object} responses.Problem
+// @Failure 500 {object} responses.Problem
+// @Router /scheduled-payments/{id}/runs [get]
+func (h *ScheduledPaymentHandler) ListRuns(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	id, err := h.scheduleRepo.ResolveScheduledPayment(ctx, c.Param("id"))
+	if err != nil {
+		respondError(c, h.logger, "list_scheduled_payment_runs", err)
+		return
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultPageSize)))
+	if err != nil || limit <= 0 || limit > maxPageSize {
+		respondError(c, h.logger, "list_scheduled_payment_runs", apperrors.Invalid("limit", "range", fmt.Sprintf("must be between 1 and %d", maxPageSize)))
+		return
+	}
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		respondError(c, h.logger, "list_scheduled_payment_runs", apperrors.Invalid("offset", "gte", "must be at least 0"))
+		return
+	}
+
+	runs, err := h.scheduleRepo.ListRuns(ctx, id, limit, offset)
+	if err != nil {
+		respondError(c, h.logger, "list_scheduled_payment_runs", err)
+		return
+	}
+
+	items := make([]responses.ScheduledPaymentRunItem, 0, len(runs))
+	for _, run := range runs {
+		items = append(items, responses.ScheduledPaymentRunItem{
+			DueAt:         run.DueAt,
+			Attempt:       run.Attempt,
+			Outcome:       run.Outcome,
+			TransactionID: run.TransactionPublicID,
+			ErrorCode:     run.ErrorCode,
+			RunAt:         run.RunAt,
+		})
+	}
+	c.JSON(http.StatusOK, responses.ScheduledPaymentRunListResponse{Runs: items, Limit: limit, Offset: offset})
+}
+
+func toScheduledPaymentResponse(p *repository.ScheduledPayment) responses.ScheduledPaymentResponse {
+	return responses.ScheduledPaymentResponse{
+		ID:                  p.PublicID,
+		AccountID:           p.AccountPublicID,
+		ToAccountID:         p.ToAccountPublicID,
+		Amount:              p.Amount,
+		Description:         p.Description,
+		Cron:                p.Rule.Cron,
+		Every:               p.Rule.Every,
+		Unit:                p.Rule.Unit,
+		StartAt:             p.StartAt,
+		EndAt:               p.EndAt,
+		NextRunAt:           p.NextRunAt,
+		OnInsufficientFunds: p.OnInsufficientFunds,
+		MaxRetries:          p.MaxRetries,
+		RetryCount:          p.RetryCount,
+		Status:              p.Status,
+		CreatedAt:           p.CreatedAt,
+		UpdatedAt:           p.UpdatedAt,
+	}
+}
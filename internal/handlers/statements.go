@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	apperrors "github.com/Andrew44Ashraf/fintech-service/internal/errors"
+	"github.com/Andrew44Ashraf/fintech-service/internal/export"
+	"github.com/Andrew44Ashraf/fintech-service/internal/repository"
+	"github.com/Andrew44Ashraf/fintech-service/internal/statements"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	dateLayout         = "2006-01-02"
+	maxStatementPeriod = 366 * 24 * time.Hour
+)
+
+type StatementHandler struct {
+	statementRepo *repository.StatementRepository
+	logger        *slog.Logger
+}
+
+func NewStatementHandler(statementRepo *repository.StatementRepository, logger *slog.Logger) *StatementHandler {
+	return &StatementHandler{statementRepo: statementRepo, logger: logger}
+}
+
+// GetStatement godoc
+// @Summary Get an account statement
+// @Description Opening and closing balance, totals by type and every posted transaction with its running balance. Closed months are served from pre-generated statements.
+// @Tags accounts
+// @Produce json,text/csv,text/html,application/xml,text/plain
+// @Param id path string true "Account ID or account number"
+// @Param from query string false "First day, YYYY-MM-DD (default: first day of current month)"
+// @Param to query string false "Last day, inclusive, YYYY-MM-DD (default: today)"
+// @Param format query string false "json, csv, html, camt053 (ISO 20022) or mt940 (SWIFT) (default json)"
+// @Success 200 {object} statements.Statement
+// @Failure 400 {object} responses.Problem
+// @Failure 404 {object} responses.Problem
+// @Failure 500 {object} responses.Problem
+// @Router /accounts/{id}/statements [get]
+func (h *StatementHandler) GetStatement(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	accountID, err := parseAccountID(c)
+	if err != nil {
+		respondError(c, h.logger, "get_statement", err)
+		return
+	}
+
+	from, to, err := parsePeriod(c, time.Now())
+	if err != nil {
+		respondError(c, h.logger, "get_statement", err)
+		return
+	}
+
+	format := c.DefaultQuery("format", statements.FormatJSON)
+	if !statements.Supports(format) && !export.Supports(format) {
+		respondError(c, h.logger, "get_statement", apperrors.Invalid("format", "oneof", "must be one of: json csv html camt053 mt940"))
+		return
+	}
+
+	stmt, err := h.statementRepo.GetStoredStatement(ctx, accountID, from, to)
+	if err == nil && stmt == nil {
+		stmt, err = h.statementRepo.BuildStatement(ctx, accountID, from, to)
+	}
+	if err != nil {
+		respondError(c, h.logger, "get_statement", err)
+		return
+	}
+
+	var (
+		buf         bytes.Buffer
+		contentType string
+		extension   = format
+	)
+	if export.Supports(format) {
+		err = export.Render(&buf, stmt, format, time.Now())
+		contentType, extension = export.ContentType(format), export.FileExtension(format)
+	} else {
+		err = statements.Render(&buf, stmt, format)
+		contentType = statements.ContentType(format)
+	}
+	if err != nil {
+		respondError(c, h.logger, "get_statement", err)
+		return
+	}
+
+	if format != statements.FormatJSON {
+		c.Header("Content-Disposition", fmt.Sprintf(`inline; filename="statement-%d-%s.%s"`, accountID, from.Format(dateLayout), extension))
+	}
+	c.Data(http.StatusOK, contentType, buf.Bytes())
+}
+
+// parsePeriod reads the from/to query parameters as UTC dates and returns
+// the half-open interval [from, to+1 day).
+func parsePeriod(c *gin.Context, now time.Time) (time.Time, time.Time, error) {
+	now = now.UTC()
+	from := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+	var err error
+	if v := c.Query("from"); v != "" {
+		if from, err = time.Parse(dateLayout, v); err != nil {
+			return time.Time{}, time.Time{}, apperrors.Invalid("from", "date", "must be a date in YYYY-MM-DD format")
+		}
+	}
+	if v := c.Query("to"); v != "" {
+		if to, err = time.Parse(dateLayout, v); err != nil {
+			return time.Time{}, time.Time{}, apperrors.Invalid("to", "date", "must be a date in YYYY-MM-DD format")
+		}
+	}
+
+	end := to.AddDate(0, 0, 1)
+	switch {
+	case !from.Before(end):
+		return time.Time{}, time.Time{}, apperrors.Invalid("to", "gtefield", "must not be before from")
+	case end.Sub(from) > maxStatementPeriod:
+		return time.Time{}, time.Time{}, apperrors.Invalid("to", "max", "period must not exceed 366 days")
+	}
+	return from, end, nil
+}
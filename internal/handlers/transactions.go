@@ -2,29 +2,41 @@ package handlers
 
 import (
 	"context"
-	"github.com/Andrew44Ashraf/fintech-service/internal/dtos/requests"
-	"github.com/Andrew44Ashraf/fintech-service/internal/dtos/responses"
-	"github.com/Andrew44Ashraf/fintech-service/internal/repository"
-	"github.com/gin-gonic/gin"
-	"github.com/go-playground/validator/v10"
-	"log"
+	"errors"
+	"fmt"
+	"log/slog"
 	"net/http"
 	"strconv"
 	"time"
+
+	"github.com/Andrew44Ashraf/fintech-service/internal/dto/requests"
+	"github.com/Andrew44Ashraf/fintech-service/internal/dto/responses"
+	apperrors "github.com/Andrew44Ashraf/fintech-service/internal/errors"
+	"github.com/Andrew44Ashraf/fintech-service/internal/logging"
+	"github.com/Andrew44Ashraf/fintech-service/internal/repository"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultPageSize = 10
+	maxPageSize     = 100
 )
 
 type TransactionHandler struct {
-	transactionRepo repository.TransactionRepository
-	accountRepo     repository.AccountRepository
+	transactionRepo *repository.TransactionRepository
+	accountRepo     *repository.AccountRepository
+	logger          *slog.Logger
 }
 
 func NewTransactionHandler(
-	transactionRepo repository.TransactionRepository,
-	accountRepo repository.AccountRepository,
+	transactionRepo *repository.TransactionRepository,
+	accountRepo *repository.AccountRepository,
+	logger *slog.Logger,
 ) *TransactionHandler {
 	return &TransactionHandler{
 		transactionRepo: transactionRepo,
 		accountRepo:     accountRepo,
+		logger:          logger,
 	}
 }
 
@@ -34,61 +46,65 @@ func NewTransactionHandler(
 // @Tags transactions
 // @Accept json
 // @Produce json
-// @Param id path int true "Account ID"
+// @Param id path string true "Account ID or account number"
 // @Param body body requests.DepositRequest true "Deposit amount"
 // @Success 200 {object} responses.TransactionResponse
-// @Failure 400 {object} responses.ErrorResponse
-// @Failure 404 {object} responses.ErrorResponse
-// @Failure 500 {object} responses.ErrorResponse
+// @Success 202 {object} responses.TransactionResponse "Held for review"
+// @Failure 400 {object} responses.Problem
+// @Failure 404 {object} responses.Problem
+// @Failure 409 {object} responses.Problem
+// @Failure 500 {object} responses.Problem
 // @Router /accounts/{id}/deposit [post]
 func (h *TransactionHandler) Deposit(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
 	defer cancel()
 
-	accountID, err := strconv.Atoi(c.Param("id"))
+	accountID, err := parseAccountID(c)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, responses.NewErrorResponse("invalid account ID"))
+		respondError(c, h.logger, "deposit", err)
 		return
 	}
 
 	var req requests.DepositRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		log.Printf("Deposit: invalid input - %v", err)
-		c.JSON(http.StatusBadRequest, responses.NewErrorResponse("invalid request body"))
+		respondError(c, h.logger, "deposit", fmt.Errorf("%w: %v", apperrors.ErrInvalidRequest, err))
 		return
 	}
-
-	validate := validator.New()
-	if err := validate.Struct(req); err != nil {
-		c.JSON(http.StatusBadRequest, responses.NewErrorResponse(err.Error()))
+	if err := validateRequest(req); err != nil {
+		respondError(c, h.logger, "deposit", err)
 		return
 	}
 
 	// Process deposit
-	txID, err := h.transactionRepo.CreateDeposit(ctx, accountID, req.Amount)
+	txID, err := h.transactionRepo.CreateDeposit(ctx, accountID, req.Amount, toDetails(req.Details))
+	if errors.Is(err, repository.ErrPendingReview) {
+		c.JSON(http.StatusAccepted, responses.TransactionResponse{
+			TransactionID: h.publicID(ctx, "deposit", accountID, txID),
+			Status:        repository.StatusPendingReview,
+			Message:       "Deposit held for review",
+		})
+		return
+	}
 	if err != nil {
-		log.Printf("Deposit failed: %v", err)
-		
-		switch {
-		case errors.Is(err, repository.ErrAccountNotFound):
-			c.JSON(http.StatusNotFound, responses.NewErrorResponse("account not found"))
-		case errors.Is(err, repository.ErrAccountClosed):
-			c.JSON(http.StatusForbidden, responses.NewErrorResponse("account is closed"))
-		default:
-			c.JSON(http.StatusInternalServerError, responses.NewErrorResponse("failed to process deposit"))
-		}
+		respondError(c, h.logger, "deposit", err)
 		return
 	}
 
 	// Get updated balance
 	balance, err := h.accountRepo.GetAccountBalance(ctx, accountID)
 	if err != nil {
-		log.Printf("Deposit: failed to get updated balance: %v", err)
+		logging.FromContext(ctx, h.logger).Error("failed to get updated balance",
+			logging.KeyOp, "deposit",
+			logging.KeyAccountID, accountID,
+			logging.KeyTxID, txID,
+			logging.KeyError, err.Error(),
+		)
 		balance = 0 // Continue response without balance
 	}
 
 	c.JSON(http.StatusOK, responses.TransactionResponse{
-		TransactionID: txID,
+		TransactionID: h.publicID(ctx, "deposit", accountID, txID),
+		Status:        repository.StatusPosted,
 		NewBalance:    balance,
 		Message:       "Deposit processed successfully",
 	})
@@ -100,65 +116,154 @@ func (h *TransactionHandler) Deposit(c *gin.Context) {
 // @Tags transactions
 // @Accept json
 // @Produce json
-// @Param id path int true "Account ID"
+// @Param id path string true "Account ID or account number"
 // @Param body body requests.WithdrawRequest true "Withdrawal amount"
 // @Success 200 {object} responses.TransactionResponse
-// @Failure 400 {object} responses.ErrorResponse
-// @Failure 403 {object} responses.ErrorResponse
-// @Failure 404 {object} responses.ErrorResponse
-// @Failure 500 {object} responses.ErrorResponse
+// @Success 202 {object} responses.TransactionResponse "Held for review"
+// @Failure 400 {object} responses.Problem
+// @Failure 404 {object} responses.Problem
+// @Failure 409 {object} responses.Problem
+// @Failure 422 {object} responses.Problem
+// @Failure 500 {object} responses.Problem
 // @Router /accounts/{id}/withdraw [post]
 func (h *TransactionHandler) Withdraw(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
 	defer cancel()
 
-	accountID, err := strconv.Atoi(c.Param("id"))
+	accountID, err := parseAccountID(c)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, responses.NewErrorResponse("invalid account ID"))
+		respondError(c, h.logger, "withdrawal", err)
 		return
 	}
 
 	var req requests.WithdrawRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		log.Printf("Withdraw: invalid input - %v", err)
-		c.JSON(http.StatusBadRequest, responses.NewErrorResponse("invalid request body"))
+		respondError(c, h.logger, "withdrawal", fmt.Errorf("%w: %v", apperrors.ErrInvalidRequest, err))
 		return
 	}
-
-	validate := validator.New()
-	if err := validate.Struct(req); err != nil {
-		c.JSON(http.StatusBadRequest, responses.NewErrorResponse(err.Error()))
+	if err := validateRequest(req); err != nil {
+		respondError(c, h.logger, "withdrawal", err)
 		return
 	}
 
 	// Process withdrawal
-	txID, err := h.transactionRepo.CreateWithdrawal(ctx, accountID, req.Amount)
+	txID, err := h.transactionRepo.CreateWithdrawal(ctx, accountID, req.Amount, toDetails(req.Details))
+	if errors.Is(err, repository.ErrPendingReview) {
+		c.JSON(http.StatusAccepted, responses.TransactionResponse{
+			TransactionID: h.publicID(ctx, "withdrawal", accountID, txID),
+			Status:        repository.StatusPendingReview,
+			Message:       "Withdrawal held for review",
+		})
+		return
+	}
 	if err != nil {
-		log.Printf("Withdraw failed: %v", err)
-		
-		switch {
-		case errors.Is(err, repository.ErrAccountNotFound):
-			c.JSON(http.StatusNotFound, responses.NewErrorResponse("account not found"))
-		case errors.Is(err, repository.ErrAccountClosed):
-			c.JSON(http.StatusForbidden, responses.NewErrorResponse("account is closed"))
-		case errors.Is(err, repository.ErrInsufficientFunds):
-			c.JSON(http.StatusBadRequest, responses.NewErrorResponse("insufficient funds"))
-		default:
-			c.JSON(http.StatusInternalServerError, responses.NewErrorResponse("failed to process withdrawal"))
-		}
+		respondError(c, h.logger, "withdrawal", err)
 		return
 	}
 
 	// Get updated balance
 	balance, err := h.accountRepo.GetAccountBalance(ctx, accountID)
 	if err != nil {
-		log.Printf("Withdraw: failed to get updated balance: %v", err)
+		logging.FromContext(ctx, h.logger).Error("failed to get updated balance",
+			logging.KeyOp, "withdrawal",
+			logging.KeyAccountID, accountID,
+			logging.KeyTxID, txID,
+			logging.KeyError, err.Error(),
+		)
 		balance = 0 // Continue response without balance
 	}
 
 	c.JSON(http.StatusOK, responses.TransactionResponse{
-		TransactionID: txID,
+		TransactionID: h.publicID(ctx, "withdrawal", accountID, txID),
+		Status:        repository.StatusPosted,
 		NewBalance:    balance,
 		Message:       "Withdrawal processed successfully",
 	})
-}
\ No newline at end of file
+}
+
+// GetTransactions godoc
+// @Summary List account transactions
+// @Description Returns the transaction history of an account, newest first
+// @Tags transactions
+// @Produce json
+// @Param id path string true "Account ID or account number"
+// @Param limit query int false "Page size (default 10, max 100)"
+// @Param offset query int false "Number of records to skip"
+// @Param external_ref query string false "Only the transaction with this external reference"
+// @Success 200 {object} responses.TransactionListResponse
+// @Failure 400 {object} responses.Problem
+// @Failure 500 {object} responses.Problem
+// @Router /accounts/{id}/transactions [get]
+func (h *TransactionHandler) GetTransactions(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	accountID, err := parseAccountID(c)
+	if err != nil {
+		respondError(c, h.logger, "list_transactions", err)
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultPageSize)))
+	if err != nil || limit <= 0 || limit > maxPageSize {
+		respondError(c, h.logger, "list_transactions", apperrors.Invalid("limit", "range", fmt.Sprintf("must be between 1 and %d", maxPageSize)))
+		return
+	}
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		respondError(c, h.logger, "list_transactions", apperrors.Invalid("offset", "gte", "must be at least 0"))
+		return
+	}
+
+	externalRef := c.Query("external_ref")
+	if len(externalRef) > repository.MaxExternalRefLength {
+		respondError(c, h.logger, "list_transactions", apperrors.Invalid("external_ref", "max", fmt.Sprintf("must be at most %d characters", repository.MaxExternalRefLength)))
+		return
+	}
+
+	transactions, err := h.transactionRepo.GetTransactions(ctx, accountID, limit, offset, externalRef)
+	if err != nil {
+		respondError(c, h.logger, "list_transactions", err)
+		return
+	}
+
+	items := make([]responses.TransactionItem, 0, len(transactions))
+	for _, t := range transactions {
+		item := responses.TransactionItem{
+			ID:           t.PublicID,
+			Amount:       t.Amount,
+			Type:         t.Type,
+			Status:       t.Status,
+			Timestamp:    t.CreatedAt,
+			FinalBalance: t.FinalBalance,
+			ValueDate:    t.ValueDate.Format(dateLayout),
+			Details:      fromDetails(t.Details),
+		}
+		if t.BookingDate != nil {
+			item.BookingDate = t.BookingDate.Format(dateLayout)
+		}
+		items = append(items, item)
+	}
+
+	c.JSON(http.StatusOK, responses.TransactionListResponse{
+		Transactions: items,
+		Limit:        limit,
+		Offset:       offset,
+	})
+}
+
+// publicID looks up the public ID of a transaction just posted. The
+// posting is committed, so a failed lookup is logged and the response goes
+// out without the ID.
+func (h *TransactionHandler) publicID(ctx context.Context, op string, accountID, txID int) string {
+	publicID, err := h.transactionRepo.PublicID(ctx, txID)
+	if err != nil {
+		logging.FromContext(ctx, h.logger).Error("failed to get transaction ID",
+			logging.KeyOp, op,
+			logging.KeyAccountID, accountID,
+			logging.KeyTxID, txID,
+			logging.KeyError, err.Error(),
+		)
+	}
+	return publicID
+}
@@ -0,0 +1,118 @@
+// Package ids describes the identifiers accounts, transactions and
+// scheduled payments are known by outside the service: opaque public IDs
+// and IBAN-style account numbers. The database generates both; this package recognises and
+// checks them so lookups can tell the forms apart.
+package ids
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Public ID prefixes. A public ID is the prefix followed by 32 lowercase
+// hex digits of a random UUID.
+const (
+	AccountPrefix          = "acc_"
+	TransactionPrefix      = "tx_"
+	ScheduledPaymentPrefix = "sp_"
+)
+
+// Account numbers are CountryCode, two check digits and BBANLength digits,
+// e.g. FT25 1234 5678 9012 3456. "FT" is not an ISO 3166 country, so the
+// numbers can't be mistaken for real IBANs.
+const (
+	CountryCode = "FT"
+	BBANLength  = 16
+)
+
+const hexLength = 32
+
+// IsAccountID reports whether s is a well-formed public account ID.
+func IsAccountID(s string) bool {
+	return isPublicID(s, AccountPrefix)
+}
+
+// IsTransactionID reports whether s is a well-formed public transaction ID.
+func IsTransactionID(s string) bool {
+	return isPublicID(s, TransactionPrefix)
+}
+
+// IsScheduledPaymentID reports whether s is a well-formed public scheduled
+// payment ID.
+func IsScheduledPaymentID(s string) bool {
+	return isPublicID(s, ScheduledPaymentPrefix)
+}
+
+func isPublicID(s, prefix string) bool {
+	hex, ok := strings.CutPrefix(s, prefix)
+	if !ok || len(hex) != hexLength {
+		return false
+	}
+	for _, r := range hex {
+		if !('0' <= r && r <= '9' || 'a' <= r && r <= 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// AccountNumber returns the account number of a BBAN of BBANLength digits
+// with its check digits, computed as for an IBAN (ISO 13616, mod 97).
+func AccountNumber(bban string) string {
+	check := 98 - mod97(bban+CountryCode+"00")
+	return CountryCode + twoDigits(check) + bban
+}
+
+// ParseAccountNumber normalises s, which may be grouped with spaces and in
+// any case, and reports whether it is an account number with valid check
+// digits.
+func ParseAccountNumber(s string) (string, bool) {
+	n := strings.ToUpper(strings.ReplaceAll(s, " ", ""))
+	if len(n) != len(CountryCode)+2+BBANLength || !strings.HasPrefix(n, CountryCode) {
+		return "", false
+	}
+	for _, r := range n[len(CountryCode):] {
+		if r < '0' || r > '9' {
+			return "", false
+		}
+	}
+	bban, check := n[len(CountryCode)+2:], n[len(CountryCode):len(CountryCode)+2]
+	if mod97(bban+CountryCode+check) != 1 {
+		return "", false
+	}
+	return n, true
+}
+
+// FormatAccountNumber groups an account number in fours for display.
+func FormatAccountNumber(n string) string {
+	var b strings.Builder
+	for i := 0; i < len(n); i += 4 {
+		if i > 0 {
+			b.WriteByte(' ')
+		}
+		b.WriteString(n[i:min(i+4, len(n))])
+	}
+	return b.String()
+}
+
+// mod97 is the remainder of the number s spells when letters count as
+// 10 (A) to 35 (Z).
+func mod97(s string) int {
+	r := 0
+	for _, c := range s {
+		switch {
+		case '0' <= c && c <= '9':
+			r = (r*10 + int(c-'0')) % 97
+		case 'A' <= c && c <= 'Z':
+			r = (r*100 + int(c-'A') + 10) % 97
+		}
+	}
+	return r
+}
+
+func twoDigits(n int) string {
+	if n < 10 {
+		return "0" + strconv.Itoa(n)
+	}
+	return strconv.Itoa(n)
+}
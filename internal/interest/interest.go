@@ -0,0 +1,138 @@
+// Package interest computes daily interest on savings balances from a
+// product's tiered rates and day-count convention.
+package interest
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// DayCount is a day-count convention: it decides which fraction of a year
+// one calendar day represents.
+type DayCount string
+
+const (
+	// Actual365 counts every day as 1/365 of a year.
+	Actual365 DayCount = "ACT/365"
+	// Actual360 counts every day as 1/360 of a year.
+	Actual360 DayCount = "ACT/360"
+	// ActualActual counts every day as 1/365 or 1/366 depending on the year.
+	ActualActual DayCount = "ACT/ACT"
+	// Thirty360 treats every month as 30 days: the 31st accrues nothing and
+	// the last day of February accrues the missing days.
+	Thirty360 DayCount = "30/360"
+)
+
+// Valid reports whether d is a supported convention.
+func (d DayCount) Valid() bool {
+	switch d {
+	case Actual365, Actual360, ActualActual, Thirty360:
+		return true
+	}
+	return false
+}
+
+// DayFraction returns the year fraction accrued on date.
+func (d DayCount) DayFraction(date time.Time) float64 {
+	switch d {
+	case Actual360:
+		return 1.0 / 360
+	case ActualActual:
+		return 1.0 / float64(daysInYear(date.Year()))
+	case Thirty360:
+		day := date.Day()
+		last := lastDayOfMonth(date)
+		switch {
+		case day == 31:
+			return 0
+		case date.Month() == time.February && day == last:
+			return float64(30-last+1) / 360
+		default:
+			return 1.0 / 360
+		}
+	default:
+		return 1.0 / 365
+	}
+}
+
+// Tier is one balance band. The APR is a percentage, e.g. 2.5 for 2.5%.
+type Tier struct {
+	MinBalance float64
+	APR        float64
+}
+
+// Product is the interest configuration of an account product.
+type Product struct {
+	Code     string
+	DayCount DayCount
+	Tiers    []Tier
+}
+
+// Bearing reports whether the product pays interest at all.
+func (p Product) Bearing() bool {
+	for _, t := range p.Tiers {
+		if t.APR > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate checks the tiers and the day-count convention.
+func (p Product) Validate() error {
+	if !p.DayCount.Valid() {
+		return fmt.Errorf("product %s: unsupported day count %q", p.Code, p.DayCount)
+	}
+	for _, t := range p.Tiers {
+		if t.MinBalance < 0 || t.APR < 0 {
+			return fmt.Errorf("product %s: tiers must not be negative", p.Code)
+		}
+	}
+	return nil
+}
+
+// Daily returns the interest earned by an end-of-day balance on date.
+//
+// Tiers are banded: each slice of the balance earns the rate of its band,
+// so crossing a threshold never changes the rate on money below it. With
+// tiers at 0 (1%) and 10,000 (2%), a 15,000 balance earns 1% on the first
+// 10,000 and 2% on the remaining 5,000. The result is not rounded.
+func (p Product) Daily(balance float64, date time.Time) float64 {
+	if balance <= 0 || len(p.Tiers) == 0 {
+		return 0
+	}
+
+	tiers := append([]Tier(nil), p.Tiers...)
+	sort.Slice(tiers, func(i, j int) bool { return tiers[i].MinBalance < tiers[j].MinBalance })
+
+	var annual float64
+	for i, t := range tiers {
+		if balance <= t.MinBalance {
+			break
+		}
+		top := balance
+		if i+1 < len(tiers) && tiers[i+1].MinBalance < balance {
+			top = tiers[i+1].MinBalance
+		}
+		annual += (top - t.MinBalance) * t.APR / 100
+	}
+	return annual * p.DayCount.DayFraction(date)
+}
+
+// EffectiveAPR returns the blended rate a balance earns, for reporting.
+func (p Product) EffectiveAPR(balance float64, date time.Time) float64 {
+	fraction := p.DayCount.DayFraction(date)
+	if balance <= 0 || fraction == 0 {
+		return 0
+	}
+	return p.Daily(balance, date) / fraction / balance * 100
+}
+
+func daysInYear(year int) int {
+	return time.Date(year, 12, 31, 0, 0, 0, 0, time.UTC).YearDay()
+}
+
+func lastDayOfMonth(date time.Time) int {
+	return time.Date(date.Year(), date.Month()+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
@@ -0,0 +1,99 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/Andrew44Ashraf/fintech-service/internal/logging"
+	"github.com/Andrew44Ashraf/fintech-service/internal/repository"
+)
+
+// accrualLookback is how many closed days each accrual run covers, so a
+// few days of downtime are caught up without manual backfill.
+const accrualLookback = 7
+
+// InterestAccrual records daily interest for the last closed days (UTC).
+// Days that already have an accrual are skipped.
+type InterestAccrual struct {
+	repo   *repository.InterestRepository
+	logger *slog.Logger
+}
+
+func NewInterestAccrual(repo *repository.InterestRepository, logger *slog.Logger) *InterestAccrual {
+	return &InterestAccrual{repo: repo, logger: logger}
+}
+
+func (j *InterestAccrual) Name() string { return "interest_accrual" }
+
+func (j *InterestAccrual) Run(ctx context.Context, now time.Time) error {
+	products, err := j.repo.Products(ctx)
+	if err != nil {
+		return err
+	}
+
+	now = now.UTC()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	for day := today.AddDate(0, 0, -accrualLookback); day.Before(today); day = day.AddDate(0, 0, 1) {
+		written, err := j.repo.AccrueInterest(ctx, day, products)
+		if err != nil {
+			return fmt.Errorf("accrual for %s: %w", day.Format("2006-01-02"), err)
+		}
+		if written > 0 {
+			j.logger.Info("interest accrued",
+				logging.KeyOp, j.Name(),
+				"accrual_date", day.Format("2006-01-02"),
+				"accounts", written,
+			)
+		}
+	}
+	return nil
+}
+
+// InterestPosting credits the interest accrued up to the end of the last
+// closed calendar month (UTC). Accruals are marked posted in the same
+// transaction, so reruns find nothing left to post.
+type InterestPosting struct {
+	repo   *repository.InterestRepository
+	logger *slog.Logger
+}
+
+func NewInterestPosting(repo *repository.InterestRepository, logger *slog.Logger) *InterestPosting {
+	return &InterestPosting{repo: repo, logger: logger}
+}
+
+func (j *InterestPosting) Name() string { return "interest_posting" }
+
+func (j *InterestPosting) Run(ctx context.Context, now time.Time) error {
+	_, before := LastClosedMonth(now)
+
+	ids, err := j.repo.AccountsWithUnpostedInterest(ctx, before)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	posted := 0
+	for _, id := range ids {
+		txID, err := j.repo.PostInterest(ctx, id, before)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("account %d: %w", id, err))
+			continue
+		}
+		if txID != 0 {
+			posted++
+		}
+	}
+
+	if posted > 0 || len(errs) > 0 {
+		j.logger.Info("interest posted",
+			logging.KeyOp, j.Name(),
+			"period_end", before.Format("2006-01-02"),
+			"posted", posted,
+			"failed", len(errs),
+		)
+	}
+	return errors.Join(errs...)
+}
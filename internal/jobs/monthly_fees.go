@@ -0,0 +1,57 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/Andrew44Ashraf/fintech-service/internal/logging"
+	"github.com/Andrew44Ashraf/fintech-service/internal/repository"
+)
+
+// MonthlyFees charges the maintenance and low-balance fees of the last
+// closed calendar month (UTC). Each account's run is recorded with its
+// charges, so reruns charge nothing twice.
+type MonthlyFees struct {
+	repo   *repository.FeeRepository
+	logger *slog.Logger
+}
+
+func NewMonthlyFees(repo *repository.FeeRepository, logger *slog.Logger) *MonthlyFees {
+	return &MonthlyFees{repo: repo, logger: logger}
+}
+
+func (j *MonthlyFees) Name() string { return "monthly_fees" }
+
+func (j *MonthlyFees) Run(ctx context.Context, now time.Time) error {
+	from, to := LastClosedMonth(now)
+
+	ids, err := j.repo.AccountsDueMonthlyFees(ctx, from, to)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	charged := 0
+	for _, id := range ids {
+		n, err := j.repo.ApplyMonthlyFees(ctx, id, from, to)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("account %d: %w", id, err))
+			continue
+		}
+		charged += n
+	}
+
+	if len(ids) > 0 {
+		j.logger.Info("monthly fees applied",
+			logging.KeyOp, j.Name(),
+			"period_start", from.Format("2006-01-02"),
+			"accounts", len(ids),
+			"charged", charged,
+			"failed", len(errs),
+		)
+	}
+	return errors.Join(errs...)
+}
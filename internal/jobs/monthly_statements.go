@@ -0,0 +1,68 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/Andrew44Ashraf/fintech-service/internal/logging"
+	"github.com/Andrew44Ashraf/fintech-service/internal/repository"
+)
+
+// MonthlyStatements stores the statement of the last closed calendar month
+// (UTC) for every account that does not have one yet.
+type MonthlyStatements struct {
+	repo   *repository.StatementRepository
+	logger *slog.Logger
+}
+
+func NewMonthlyStatements(repo *repository.StatementRepository, logger *slog.Logger) *MonthlyStatements {
+	return &MonthlyStatements{repo: repo, logger: logger}
+}
+
+func (j *MonthlyStatements) Name() string { return "monthly_statements" }
+
+func (j *MonthlyStatements) Run(ctx context.Context, now time.Time) error {
+	from, to := LastClosedMonth(now)
+
+	ids, err := j.repo.AccountsMissingStatement(ctx, from, to)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	created := 0
+	for _, id := range ids {
+		stmt, err := j.repo.BuildStatement(ctx, id, from, to)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("account %d: %w", id, err))
+			continue
+		}
+		ok, err := j.repo.SaveStatement(ctx, stmt)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("account %d: %w", id, err))
+			continue
+		}
+		if ok {
+			created++
+		}
+	}
+
+	j.logger.Info("monthly statements generated",
+		logging.KeyOp, j.Name(),
+		"period_start", from.Format("2006-01-02"),
+		"created", created,
+		"failed", len(errs),
+	)
+	return errors.Join(errs...)
+}
+
+// LastClosedMonth returns [first day of previous month, first day of this
+// month) in UTC.
+func LastClosedMonth(now time.Time) (time.Time, time.Time) {
+	now = now.UTC()
+	to := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	return to.AddDate(0, -1, 0), to
+}
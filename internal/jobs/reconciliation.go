@@ -0,0 +1,40 @@
+package jobs
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/Andrew44Ashraf/fintech-service/internal/logging"
+	"github.com/Andrew44Ashraf/fintech-service/internal/repository"
+)
+
+// NightlyReconciliation snapshots every account's balance and compares it
+// with its journal once per UTC day. Breaks are recorded and logged; they
+// do not fail the job.
+type NightlyReconciliation struct {
+	repo   *repository.ReconciliationRepository
+	logger *slog.Logger
+}
+
+func NewNightlyReconciliation(repo *repository.ReconciliationRepository, logger *slog.Logger) *NightlyReconciliation {
+	return &NightlyReconciliation{repo: repo, logger: logger}
+}
+
+func (j *NightlyReconciliation) Name() string { return "nightly_reconciliation" }
+
+func (j *NightlyReconciliation) Run(ctx context.Context, now time.Time) error {
+	run, err := j.repo.Reconcile(ctx, repository.TriggerScheduled, now)
+	if err != nil || run == nil {
+		return err
+	}
+
+	j.logger.Info("balances reconciled",
+		logging.KeyOp, j.Name(),
+		"run_id", run.ID,
+		"run_date", run.RunDate.Format("2006-01-02"),
+		"accounts_checked", run.AccountsChecked,
+		"breaks", run.BreakCount,
+	)
+	return nil
+}
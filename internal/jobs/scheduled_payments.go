@@ -0,0 +1,48 @@
+package jobs
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/Andrew44Ashraf/fintech-service/internal/logging"
+	"github.com/Andrew44Ashraf/fintech-service/internal/repository"
+)
+
+// maxPaymentsPerRun bounds one run so a large backlog can't starve the
+// scheduler; the rest is picked up on the next tick.
+const maxPaymentsPerRun = 500
+
+// ScheduledPayments executes due standing orders. It is safe to run on
+// every replica: payments are claimed with FOR UPDATE SKIP LOCKED.
+type ScheduledPayments struct {
+	repo   *repository.ScheduledPaymentRepository
+	logger *slog.Logger
+}
+
+func NewScheduledPayments(repo *repository.ScheduledPaymentRepository, logger *slog.Logger) *ScheduledPayments {
+	return &ScheduledPayments{repo: repo, logger: logger}
+}
+
+func (j *ScheduledPayments) Name() string { return "scheduled_payments" }
+
+func (j *ScheduledPayments) Run(ctx context.Context, now time.Time) error {
+	executed := 0
+	defer func() {
+		if executed > 0 {
+			j.logger.Info("scheduled payments executed", logging.KeyOp, j.Name(), "executed", executed)
+		}
+	}()
+
+	for executed < maxPaymentsPerRun {
+		ran, err := j.repo.RunNext(ctx, now)
+		if err != nil {
+			return err
+		}
+		if !ran {
+			return nil
+		}
+		executed++
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+// Package jobs contains background jobs and a minimal scheduler to run them.
+// Jobs must be idempotent: every replica runs them, and a run may be
+// repeated after a crash.
+package jobs
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/Andrew44Ashraf/fintech-service/internal/logging"
+)
+
+// Job is a unit of periodic background work.
+type Job interface {
+	Name() string
+	Run(ctx context.Context, now time.Time) error
+}
+
+// Schedule runs job immediately and then every interval until ctx is done.
+func Schedule(ctx context.Context, logger *slog.Logger, interval time.Duration, job Job) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		RunOnce(ctx, logger, job, time.Now())
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce runs job and logs the outcome.
+func RunOnce(ctx context.Context, logger *slog.Logger, job Job, now time.Time) error {
+	start := time.Now()
+	err := job.Run(ctx, now)
+
+	log := logger.With(logging.KeyOp, job.Name(), logging.KeyLatency, time.Since(start))
+	if err != nil {
+		log.Error("job failed", logging.KeyOutcome, logging.OutcomeError, logging.KeyError, err.Error())
+		return err
+	}
+	log.Info("job finished", logging.KeyOutcome, logging.OutcomeSuccess)
+	return nil
+}
@@ -0,0 +1,40 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Andrew44Ashraf/fintech-service/internal/repository"
+	"github.com/Andrew44Ashraf/fintech-service/internal/tenant"
+)
+
+// PerTenant runs job once for every tenant, with the tenant in its
+// context; without one a job would see no data. A failure for one tenant
+// does not stop the others.
+func PerTenant(tenants *repository.TenantRepository, job Job) Job {
+	return &perTenant{tenants: tenants, job: job}
+}
+
+type perTenant struct {
+	tenants *repository.TenantRepository
+	job     Job
+}
+
+func (j *perTenant) Name() string { return j.job.Name() }
+
+func (j *perTenant) Run(ctx context.Context, now time.Time) error {
+	tenants, err := j.tenants.ListTenants(ctx)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, t := range tenants {
+		if err := j.job.Run(tenant.WithID(ctx, t.ID), now); err != nil {
+			errs = append(errs, fmt.Errorf("tenant %s: %w", t.ID, err))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,117 @@
+// Package logging builds the service's structured JSON logger and carries
+// per-request correlation data through context.Context.
+package logging
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/Andrew44Ashraf/fintech-service/internal/tenant"
+)
+
+// Standard attribute keys. Every log line about money movement should use
+// these so that entries can be correlated across handlers and repositories.
+const (
+	KeyRequestID = "request_id"
+	KeyTenantID  = "tenant_id"
+	KeyAccountID = "account_id"
+	KeyTxID      = "tx_id"
+	KeyOp        = "op"
+	KeyOutcome   = "outcome"
+	KeyLatency   = "latency"
+	KeyError     = "error"
+)
+
+// Outcome values for KeyOutcome.
+const (
+	OutcomeSuccess  = "success"
+	OutcomeRejected = "rejected"
+	OutcomeHeld     = "held"
+	OutcomeError    = "error"
+)
+
+// redactedKeys lists attribute keys whose values must never reach the logs.
+var redactedKeys = map[string]bool{
+	"authorization":  true,
+	"cookie":         true,
+	"password":       true,
+	"api_key":        true,
+	"x-api-key":      true,
+	"email":          true,
+	"phone":          true,
+	"iban":           true,
+	"account_number": true,
+}
+
+const redacted = "[REDACTED]"
+
+type ctxKey struct{}
+
+// New returns a JSON logger writing to w at the given level
+// ("debug", "info", "warn" or "error").
+func New(w io.Writer, level string) *slog.Logger {
+	return slog.New(slog.NewJSONHandler(w, &slog.HandlerOptions{
+		Level:       ParseLevel(level),
+		ReplaceAttr: redact,
+	}))
+}
+
+// NewFromEnv returns a logger writing to stdout at the level set by LOG_LEVEL.
+func NewFromEnv() *slog.Logger {
+	return New(os.Stdout, os.Getenv("LOG_LEVEL"))
+}
+
+// ParseLevel maps a level name to an slog.Level, defaulting to info.
+func ParseLevel(level string) slog.Level {
+	switch strings.ToLower(strings.TrimSpace(level)) {
+	case "debug":
+		return slog.LevelDebug
+	case "warn", "warning":
+		return slog.LevelWarn
+	case "error":
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
+
+// Discard returns a logger that drops everything. Useful in tests.
+func Discard() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+// WithRequestID stores the request ID in ctx.
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, ctxKey{}, requestID)
+}
+
+// RequestID returns the request ID stored in ctx, or "" if there is none.
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(ctxKey{}).(string)
+	return id
+}
+
+// FromContext returns base annotated with the request ID and tenant carried
+// by ctx.
+func FromContext(ctx context.Context, base *slog.Logger) *slog.Logger {
+	if base == nil {
+		base = slog.Default()
+	}
+	if id := RequestID(ctx); id != "" {
+		base = base.With(KeyRequestID, id)
+	}
+	if id := tenant.FromContext(ctx); id != "" {
+		base = base.With(KeyTenantID, id)
+	}
+	return base
+}
+
+func redact(_ []string, a slog.Attr) slog.Attr {
+	if redactedKeys[strings.ToLower(a.Key)] {
+		return slog.String(a.Key, redacted)
+	}
+	return a
+}
@@ -0,0 +1,35 @@
+package middleware
+
+import (
+	"crypto/subtle"
+
+	apperrors "github.com/Andrew44Ashraf/fintech-service/internal/errors"
+	"github.com/gin-gonic/gin"
+)
+
+// AdminTokenHeader carries the operator token for /api/admin routes.
+const AdminTokenHeader = "X-Admin-Token"
+
+// AdminAuth guards operator endpoints with a shared token. When no token is
+// configured every request is refused, so admin routes are off by default.
+func AdminAuth(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		got := c.GetHeader(AdminTokenHeader)
+
+		var err error
+		switch {
+		case token == "":
+			err = apperrors.ErrForbidden
+		case got == "":
+			err = apperrors.ErrUnauthorized
+		case subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1:
+			err = apperrors.ErrForbidden
+		}
+		if err != nil {
+			abortWithProblem(c, err)
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,96 @@
+package middleware
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/Andrew44Ashraf/fintech-service/internal/database"
+	apperrors "github.com/Andrew44Ashraf/fintech-service/internal/errors"
+	"github.com/Andrew44Ashraf/fintech-service/internal/logging"
+	"github.com/gin-gonic/gin"
+)
+
+// ConsistencyHeader carries the token of a write, returned by successful
+// writes and sent back on reads that must see them.
+const ConsistencyHeader = "X-Consistency-Token"
+
+// Consistency gives clients read-your-writes consistency when reads go to
+// a replica. Writes run on the primary, reads included, and successful
+// ones return the primary's WAL position as a token. A read sending a
+// token is served by the replica only once it has replayed that far, and
+// by the primary until then. Without a replica it does nothing.
+func Consistency(router *database.Router, logger *slog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if router == nil || !router.HasReplica() {
+			c.Next()
+			return
+		}
+
+		ctx := c.Request.Context()
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			if token := c.GetHeader(ConsistencyHeader); token != "" {
+				lsn, err := database.ParseLSN(token)
+				if err != nil {
+					abortWithProblem(c, apperrors.Invalid(ConsistencyHeader, "token", "must be a token from a previous response"))
+					return
+				}
+				ctx = database.WithToken(ctx, lsn)
+			}
+			c.Request = c.Request.WithContext(ctx)
+			c.Next()
+			return
+		}
+
+		c.Request = c.Request.WithContext(database.WithPrimary(ctx))
+		w := &tokenWriter{ResponseWriter: c.Writer, c: c, router: router, logger: logger}
+		c.Writer = w
+		c.Next()
+		// Responses without a body, e.g. 204, are written after the
+		// middleware returns
+		w.stamp()
+	}
+}
+
+// tokenWriter sets the consistency token header of a successful write
+// before the response goes out, when the write has committed.
+type tokenWriter struct {
+	gin.ResponseWriter
+	c       *gin.Context
+	router  *database.Router
+	logger  *slog.Logger
+	stamped bool
+}
+
+func (w *tokenWriter) stamp() {
+	if w.stamped || w.Written() {
+		return
+	}
+	w.stamped = true
+	if w.Status() >= http.StatusBadRequest {
+		return
+	}
+
+	ctx := w.c.Request.Context()
+	lsn, err := w.router.Token(ctx)
+	if err != nil {
+		logging.FromContext(ctx, w.logger).Error("failed to get consistency token", logging.KeyError, err.Error())
+		return
+	}
+	w.Header().Set(ConsistencyHeader, lsn.String())
+}
+
+func (w *tokenWriter) WriteHeaderNow() {
+	w.stamp()
+	w.ResponseWriter.WriteHeaderNow()
+}
+
+func (w *tokenWriter) Write(data []byte) (int, error) {
+	w.stamp()
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *tokenWriter) WriteString(s string) (int, error) {
+	w.stamp()
+	return w.ResponseWriter.WriteString(s)
+}
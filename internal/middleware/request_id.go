@@ -0,0 +1,41 @@
+// Package middleware holds gin middleware shared by all routes.
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+
+	"github.com/Andrew44Ashraf/fintech-service/internal/logging"
+	"github.com/gin-gonic/gin"
+)
+
+// RequestIDHeader is the header used to propagate request IDs.
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLen bounds client-supplied IDs so they can't bloat the logs.
+const maxRequestIDLen = 128
+
+// RequestID accepts an incoming X-Request-ID or generates one, stores it in
+// the request context and echoes it back in the response.
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(RequestIDHeader)
+		if id == "" || len(id) > maxRequestIDLen {
+			id = newRequestID()
+		}
+
+		c.Set(logging.KeyRequestID, id)
+		c.Request = c.Request.WithContext(logging.WithRequestID(c.Request.Context(), id))
+		c.Header(RequestIDHeader, id)
+
+		c.Next()
+	}
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "unknown"
+	}
+	return hex.EncodeToString(b)
+}
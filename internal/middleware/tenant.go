@@ -0,0 +1,70 @@
+package middleware
+
+import (
+	"errors"
+	"log/slog"
+
+	"github.com/Andrew44Ashraf/fintech-service/internal/auth"
+	"github.com/Andrew44Ashraf/fintech-service/internal/dto/responses"
+	apperrors "github.com/Andrew44Ashraf/fintech-service/internal/errors"
+	"github.com/Andrew44Ashraf/fintech-service/internal/logging"
+	"github.com/Andrew44Ashraf/fintech-service/internal/tenant"
+	"github.com/gin-gonic/gin"
+)
+
+// TenantHeader names the tenant an operator request acts for.
+const TenantHeader = "X-Tenant-ID"
+
+// APIKeyAuth authenticates clients by their X-API-Key and runs the request
+// for the key's tenant. Nil keys turn authentication off: every request
+// then acts for tenant.Default, as in a single-tenant deployment.
+func APIKeyAuth(keys auth.KeyChecker, logger *slog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+		if keys == nil {
+			c.Request = c.Request.WithContext(tenant.WithID(ctx, tenant.Default))
+			c.Next()
+			return
+		}
+
+		key := c.GetHeader(APIKeyHeader)
+		if key == "" {
+			abortWithProblem(c, apperrors.ErrUnauthorized)
+			return
+		}
+		id, err := keys.CheckKey(ctx, key)
+		if err != nil {
+			if !errors.Is(err, apperrors.ErrForbidden) {
+				logging.FromContext(ctx, logger).Error("api key check failed", logging.KeyError, err.Error())
+			}
+			abortWithProblem(c, err)
+			return
+		}
+
+		c.Request = c.Request.WithContext(tenant.WithID(ctx, id))
+		c.Next()
+	}
+}
+
+// AdminTenant runs an operator request for the tenant in its X-Tenant-ID
+// header. The operator token is not tied to a tenant, so the header is
+// required.
+func AdminTenant() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(TenantHeader)
+		if !tenant.Valid(id) {
+			abortWithProblem(c, apperrors.Invalid(TenantHeader, "tenant", "must name a tenant"))
+			return
+		}
+
+		c.Request = c.Request.WithContext(tenant.WithID(c.Request.Context(), id))
+		c.Next()
+	}
+}
+
+// abortWithProblem ends the request with the problem body for err.
+func abortWithProblem(c *gin.Context, err error) {
+	p := responses.NewProblem(err, c.Request.URL.Path, logging.RequestID(c.Request.Context()))
+	c.Header("Content-Type", responses.ProblemContentType)
+	c.AbortWithStatusJSON(p.Status, p)
+}
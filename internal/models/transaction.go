@@ -7,10 +7,40 @@ import (
 type TransactionType string
 
 const (
-	Deposit    TransactionType = "deposit"
-	Withdrawal TransactionType = "withdrawal"
+	Deposit     TransactionType = "deposit"
+	Withdrawal  TransactionType = "withdrawal"
+	TransferIn  TransactionType = "transfer_in"
+	TransferOut TransactionType = "transfer_out"
+	Interest    TransactionType = "interest"
+	Fee         TransactionType = "fee"
+	FeeReversal TransactionType = "fee_reversal"
+
+	AdjustmentCredit TransactionType = "adjustment_credit"
+	AdjustmentDebit  TransactionType = "adjustment_debit"
+
+	// BankTransferIn is an incoming transfer from another bank, recorded
+	// as pending until it settles
+	BankTransferIn TransactionType = "bank_transfer_in"
 )
 
+// IsCredit reports whether transactions of this type add to the balance
+func (t TransactionType) IsCredit() bool {
+	switch t {
+	case Deposit, TransferIn, Interest, FeeReversal, AdjustmentCredit, BankTransferIn:
+		return true
+	default:
+		return false
+	}
+}
+
+// Signed returns amount with the sign of its effect on the balance
+func (t TransactionType) Signed(amount float64) float64 {
+	if t.IsCredit() {
+		return amount
+	}
+	return -amount
+}
+
 // Transaction represents the database model
 type Transaction struct {
 	ID        int             `json:"id" gorm:"primaryKey"`
@@ -0,0 +1,36 @@
+package ratelimit
+
+import (
+	"context"
+	"log/slog"
+)
+
+// failOpen lets requests through while its store is failing.
+type failOpen struct {
+	store  Store
+	logger *slog.Logger
+}
+
+// FailOpen wraps store so that a failing Take allows the request, reporting
+// a full budget, and a failing Refund is only logged. Without it a store
+// outage rejects every rate-limited request, which keeps brute-force
+// protection at the cost of availability.
+func FailOpen(store Store, logger *slog.Logger) Store {
+	return failOpen{store: store, logger: logger}
+}
+
+func (s failOpen) Take(ctx context.Context, key string, limit Limit) (Result, error) {
+	res, err := s.store.Take(ctx, key, limit)
+	if err != nil {
+		s.logger.Error("rate limiter unavailable, allowing request", "key", key, "error", err.Error())
+		return Result{Allowed: true, Limit: limit.Burst, Remaining: limit.Burst}, nil
+	}
+	return res, nil
+}
+
+func (s failOpen) Refund(ctx context.Context, key string, limit Limit) error {
+	if err := s.store.Refund(ctx, key, limit); err != nil {
+		s.logger.Error("rate limiter refund failed", "key", key, "error", err.Error())
+	}
+	return nil
+}
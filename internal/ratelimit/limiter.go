@@ -0,0 +1,62 @@
+// Package ratelimit implements token-bucket rate limiting with pluggable
+// bucket storage, so limits can be kept in process or shared by replicas
+// through Postgres.
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"time"
+)
+
+// Limit is a token-bucket budget: Burst tokens, refilled at Rate per second.
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// PerMinute returns a limit allowing n requests per minute with bursts of burst.
+func PerMinute(n, burst int) Limit {
+	return Limit{Rate: float64(n) / 60, Burst: burst}
+}
+
+// Result is the outcome of taking a token from a bucket.
+type Result struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	RetryAfter time.Duration // time until a token is available; zero when allowed
+	ResetAfter time.Duration // time until the bucket is full again
+}
+
+// Store keeps bucket state. Take must be atomic per key. Refund returns a
+// token taken by an earlier Take, for when a request is rejected by another
+// budget after this one allowed it.
+type Store interface {
+	Take(ctx context.Context, key string, limit Limit) (Result, error)
+	Refund(ctx context.Context, key string, limit Limit) error
+}
+
+// take applies the token-bucket algorithm to a bucket last updated at last
+// holding tokens, and returns the outcome and the new token count.
+func take(tokens float64, last, now time.Time, limit Limit) (Result, float64) {
+	burst := float64(limit.Burst)
+	if elapsed := now.Sub(last).Seconds(); elapsed > 0 {
+		tokens = math.Min(burst, tokens+elapsed*limit.Rate)
+	}
+
+	res := Result{Limit: limit.Burst}
+	if tokens >= 1 {
+		tokens--
+		res.Allowed = true
+	} else {
+		res.RetryAfter = secondsToDuration((1 - tokens) / limit.Rate)
+	}
+	res.Remaining = int(math.Floor(tokens))
+	res.ResetAfter = secondsToDuration((burst - tokens) / limit.Rate)
+	return res, tokens
+}
+
+func secondsToDuration(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
@@ -0,0 +1,83 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// sweepInterval is how often idle buckets are dropped from memory.
+const sweepInterval = time.Minute
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+	full    time.Time // when the bucket refills completely and can be forgotten
+}
+
+// MemoryStore keeps buckets in process. Limits are per replica.
+type MemoryStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{buckets: make(map[string]*bucket), now: time.Now}
+}
+
+// NewMemoryStoreWithClock returns a MemoryStore using now as its clock.
+func NewMemoryStoreWithClock(now func() time.Time) *MemoryStore {
+	s := NewMemoryStore()
+	s.now = now
+	return s
+}
+
+func (s *MemoryStore) Take(_ context.Context, key string, limit Limit) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.sweep(now)
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(limit.Burst), updated: now}
+		s.buckets[key] = b
+	}
+
+	res, tokens := take(b.tokens, b.updated, now, limit)
+	b.tokens = tokens
+	b.updated = now
+	b.full = now.Add(res.ResetAfter)
+	return res, nil
+}
+
+func (s *MemoryStore) Refund(_ context.Context, key string, limit Limit) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b, ok := s.buckets[key]
+	if !ok {
+		return nil // swept, so already full
+	}
+	b.tokens = math.Min(float64(limit.Burst), b.tokens+1)
+	b.full = b.updated.Add(secondsToDuration((float64(limit.Burst) - b.tokens) / limit.Rate))
+	return nil
+}
+
+// sweep removes buckets that have refilled completely, since a missing
+// bucket behaves exactly like a full one.
+func (s *MemoryStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < sweepInterval {
+		return
+	}
+	s.lastSweep = now
+	for key, b := range s.buckets {
+		if !now.Before(b.full) {
+			delete(s.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package ratelimit
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// PostgresStore keeps buckets in the rate_limit_buckets table so every
+// replica shares the same budget. The bucket row is locked for the duration
+// of a Take and the database clock is used to avoid skew between replicas.
+type PostgresStore struct {
+	db *sql.DB
+}
+
+func NewPostgresStore(db *sql.DB) *PostgresStore {
+	return &PostgresStore{db: db}
+}
+
+func (s *PostgresStore) Take(ctx context.Context, key string, limit Limit) (Result, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return Result{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx,
+		`INSERT INTO rate_limit_buckets (key, tokens, updated_at)
+		 VALUES ($1, $2, clock_timestamp())
+		 ON CONFLICT (key) DO NOTHING`,
+		key, limit.Burst,
+	)
+	if err != nil {
+		return Result{}, fmt.Errorf("failed to create bucket: %w", err)
+	}
+
+	var (
+		tokens  float64
+		updated time.Time
+		now     time.Time
+	)
+	err = tx.QueryRowContext(ctx,
+		`SELECT tokens, updated_at, clock_timestamp()
+		 FROM rate_limit_buckets WHERE key = $1 FOR UPDATE`,
+		key,
+	).Scan(&tokens, &updated, &now)
+	if err != nil {
+		return Result{}, fmt.Errorf("failed to load bucket: %w", err)
+	}
+
+	res, tokens := take(tokens, updated, now, limit)
+
+	_, err = tx.ExecContext(ctx,
+		`UPDATE rate_limit_buckets
+		 SET tokens = $1, updated_at = $2, expires_at = $3
+		 WHERE key = $4`,
+		tokens, now, now.Add(res.ResetAfter), key,
+	)
+	if err != nil {
+		return Result{}, fmt.Errorf("failed to update bucket: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return Result{}, fmt.Errorf("transaction commit failed: %w", err)
+	}
+	return res, nil
+}
+
+func (s *PostgresStore) Refund(ctx context.Context, key string, limit Limit) error {
+	_, err := s.db.ExecContext(ctx,
+		"UPDATE rate_limit_buckets SET tokens = LEAST(tokens + 1, $1) WHERE key = $2",
+		limit.Burst, key,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to refund bucket: %w", err)
+	}
+	return nil
+}
+
+// Purge deletes buckets that have refilled completely.
+func (s *PostgresStore) Purge(ctx context.Context) (int64, error) {
+	res, err := s.db.ExecContext(ctx,
+		"DELETE FROM rate_limit_buckets WHERE expires_at < clock_timestamp()",
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge buckets: %w", err)
+	}
+	return res.RowsAffected()
+}
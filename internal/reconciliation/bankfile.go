@@ -0,0 +1,255 @@
+package reconciliation
+
+import (
+	"encoding/csv"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	apperrors "github.com/Andrew44Ashraf/fintech-service/internal/errors"
+)
+
+// Bank file formats accepted by Parse.
+const (
+	FormatCSV     = "csv"
+	FormatCamt053 = "camt053"
+)
+
+const isoDate = "2006-01-02"
+
+// BankLine is one cash movement reported by the settlement bank. Amount is
+// signed: credits to the settlement account are positive.
+type BankLine struct {
+	LineNo      int
+	Reference   string
+	Amount      float64
+	BookingDate time.Time
+	ValueDate   time.Time
+	Description string
+}
+
+// BankStatement is a parsed bank file. The period covers the booking dates
+// of its lines, both ends inclusive.
+type BankStatement struct {
+	Format      string
+	PeriodStart time.Time
+	PeriodEnd   time.Time
+	Lines       []BankLine
+}
+
+// SupportsFormat reports whether Parse understands format.
+func SupportsFormat(format string) bool {
+	return format == FormatCSV || format == FormatCamt053
+}
+
+// Parse reads a bank file in the given format. Problems with the file are
+// returned as validation errors of the file field.
+func Parse(r io.Reader, format string) (*BankStatement, error) {
+	var (
+		st  *BankStatement
+		err error
+	)
+	switch format {
+	case FormatCSV:
+		st, err = ParseCSV(r)
+	case FormatCamt053:
+		st, err = ParseCamt053(r)
+	default:
+		return nil, apperrors.Invalid("format", "oneof", "must be one of: csv camt053")
+	}
+	if err != nil {
+		return nil, apperrors.Invalid("file", "format", err.Error())
+	}
+	if len(st.Lines) == 0 {
+		return nil, apperrors.Invalid("file", "required", "contains no booked lines")
+	}
+	st.Format = format
+	st.setPeriod()
+	return st, nil
+}
+
+// setPeriod widens the stated period, if any, to cover every line.
+func (s *BankStatement) setPeriod() {
+	for _, l := range s.Lines {
+		if s.PeriodStart.IsZero() || l.BookingDate.Before(s.PeriodStart) {
+			s.PeriodStart = l.BookingDate
+		}
+		if s.PeriodEnd.IsZero() || l.BookingDate.After(s.PeriodEnd) {
+			s.PeriodEnd = l.BookingDate
+		}
+	}
+}
+
+// ParseCSV reads a CSV bank file with a header row. Columns are found by
+// name: booking_date, amount and reference are required; value_date,
+// description and credit_debit are optional. Without credit_debit the
+// amount is signed; with it (C/D or CRDT/DBIT) the amount is unsigned.
+func ParseCSV(r io.Reader) (*BankStatement, error) {
+	cr := csv.NewReader(r)
+	cr.TrimLeadingSpace = true
+
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV header: %w", err)
+	}
+	col := make(map[string]int, len(header))
+	for i, name := range header {
+		col[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range []string{"booking_date", "amount", "reference"} {
+		if _, ok := col[name]; !ok {
+			return nil, fmt.Errorf("CSV header is missing the %s column", name)
+		}
+	}
+	field := func(rec []string, name string) string {
+		if i, ok := col[name]; ok && i < len(rec) {
+			return strings.TrimSpace(rec[i])
+		}
+		return ""
+	}
+
+	st := &BankStatement{}
+	for n := 1; ; n++ {
+		rec, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+
+		l := BankLine{LineNo: n, Reference: field(rec, "reference"), Description: field(rec, "description")}
+		if l.BookingDate, err = time.Parse(isoDate, field(rec, "booking_date")); err != nil {
+			return nil, fmt.Errorf("line %d: booking_date must be YYYY-MM-DD", n)
+		}
+		l.ValueDate = l.BookingDate
+		if v := field(rec, "value_date"); v != "" {
+			if l.ValueDate, err = time.Parse(isoDate, v); err != nil {
+				return nil, fmt.Errorf("line %d: value_date must be YYYY-MM-DD", n)
+			}
+		}
+		if l.Amount, err = strconv.ParseFloat(field(rec, "amount"), 64); err != nil {
+			return nil, fmt.Errorf("line %d: invalid amount", n)
+		}
+		if v := field(rec, "credit_debit"); v != "" {
+			if l.Amount, err = signed(l.Amount, v); err != nil {
+				return nil, fmt.Errorf("line %d: %w", n, err)
+			}
+		}
+		st.Lines = append(st.Lines, l)
+	}
+	return st, nil
+}
+
+func signed(amount float64, indicator string) (float64, error) {
+	if amount < 0 {
+		return 0, errors.New("amount must be unsigned when credit_debit is given")
+	}
+	switch strings.ToUpper(indicator) {
+	case "C", "CRDT":
+		return amount, nil
+	case "D", "DBIT":
+		return -amount, nil
+	}
+	return 0, fmt.Errorf("unknown credit/debit indicator %q", indicator)
+}
+
+type camtDocument struct {
+	Stmts []struct {
+		FrToDt struct {
+			FrDtTm string `xml:"FrDtTm"`
+			ToDtTm string `xml:"ToDtTm"`
+		} `xml:"FrToDt"`
+		Ntry []struct {
+			Amt         string     `xml:"Amt"`
+			CdtDbtInd   string     `xml:"CdtDbtInd"`
+			Sts         camtStatus `xml:"Sts"`
+			BookgDt     camtDate   `xml:"BookgDt"`
+			ValDt       camtDate   `xml:"ValDt"`
+			AcctSvcrRef string     `xml:"AcctSvcrRef"`
+			EndToEndID  string     `xml:"NtryDtls>TxDtls>Refs>EndToEndId"`
+			AddtlInf    string     `xml:"AddtlNtryInf"`
+		} `xml:"Ntry"`
+	} `xml:"BkToCstmrStmt>Stmt"`
+}
+
+// camtStatus is <Sts><Cd>BOOK</Cd></Sts> from version 08 on and
+// <Sts>BOOK</Sts> before.
+type camtStatus struct {
+	Cd   string `xml:"Cd"`
+	Text string `xml:",chardata"`
+}
+
+func (s camtStatus) code() string {
+	if s.Cd != "" {
+		return strings.TrimSpace(s.Cd)
+	}
+	return strings.TrimSpace(s.Text)
+}
+
+type camtDate struct {
+	Dt   string `xml:"Dt"`
+	DtTm string `xml:"DtTm"`
+}
+
+func (d camtDate) date() (time.Time, error) {
+	if d.Dt != "" {
+		return time.Parse(isoDate, d.Dt)
+	}
+	if len(d.DtTm) >= len(isoDate) {
+		return time.Parse(isoDate, d.DtTm[:len(isoDate)])
+	}
+	return time.Time{}, errors.New("missing date")
+}
+
+// ParseCamt053 reads the booked entries of an ISO 20022 camt.053 file. The
+// reference is the end-to-end ID when the bank provides one, otherwise the
+// bank's own reference.
+func ParseCamt053(r io.Reader) (*BankStatement, error) {
+	var doc camtDocument
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("failed to parse camt.053: %w", err)
+	}
+
+	st := &BankStatement{}
+	n := 0
+	for _, s := range doc.Stmts {
+		if from, err := (camtDate{DtTm: s.FrToDt.FrDtTm}).date(); err == nil && (st.PeriodStart.IsZero() || from.Before(st.PeriodStart)) {
+			st.PeriodStart = from
+		}
+		if to, err := (camtDate{DtTm: s.FrToDt.ToDtTm}).date(); err == nil && to.After(st.PeriodEnd) {
+			st.PeriodEnd = to
+		}
+		for _, e := range s.Ntry {
+			n++
+			if status := e.Sts.code(); status != "" && status != "BOOK" {
+				continue
+			}
+
+			l := BankLine{LineNo: n, Reference: e.AcctSvcrRef, Description: e.AddtlInf}
+			if ref := strings.TrimSpace(e.EndToEndID); ref != "" && ref != "NOTPROVIDED" {
+				l.Reference = ref
+			}
+			var err error
+			if l.BookingDate, err = e.BookgDt.date(); err != nil {
+				return nil, fmt.Errorf("entry %d: booking date: %w", n, err)
+			}
+			if l.ValueDate, err = e.ValDt.date(); err != nil {
+				l.ValueDate = l.BookingDate
+			}
+			amount, err := strconv.ParseFloat(strings.TrimSpace(e.Amt), 64)
+			if err != nil {
+				return nil, fmt.Errorf("entry %d: invalid amount", n)
+			}
+			if l.Amount, err = signed(amount, e.CdtDbtInd); err != nil {
+				return nil, fmt.Errorf("entry %d: %w", n, err)
+			}
+			st.Lines = append(st.Lines, l)
+		}
+	}
+	return st, nil
+}
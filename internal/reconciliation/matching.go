@@ -0,0 +1,188 @@
+package reconciliation
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// MatchRule is one pass of automatic matching as written in the rules
+// file. A bank line matches a transaction when the amounts agree within
+// AmountTolerance, the dates lie at most DateWindowDays apart and, with
+// MatchReference, the references are equal.
+type MatchRule struct {
+	Name            string  `yaml:"name"`
+	MatchReference  bool    `yaml:"match_reference"`
+	DateWindowDays  int     `yaml:"date_window_days"`
+	AmountTolerance float64 `yaml:"amount_tolerance"`
+}
+
+func (r MatchRule) validate() error {
+	switch {
+	case r.Name == "":
+		return errors.New("name is required")
+	case r.Name == ManualRule:
+		return fmt.Errorf("name %q is reserved", ManualRule)
+	case r.DateWindowDays < 0:
+		return errors.New("date_window_days must not be negative")
+	case r.AmountTolerance < 0:
+		return errors.New("amount_tolerance must not be negative")
+	}
+	return nil
+}
+
+// ManualRule is recorded as the rule of operator matches.
+const ManualRule = "manual"
+
+// DefaultRules match on reference first and then, for lines without a
+// usable reference, on the exact amount booked on the same or next day.
+var DefaultRules = []MatchRule{
+	{Name: "reference", MatchReference: true, DateWindowDays: 5},
+	{Name: "amount_and_date", DateWindowDays: 1},
+}
+
+type rulesFile struct {
+	Rules []MatchRule `yaml:"rules"`
+}
+
+// ValidateRules checks a rule list.
+func ValidateRules(rules []MatchRule) error {
+	if len(rules) == 0 {
+		return errors.New("at least one rule is required")
+	}
+	for i, r := range rules {
+		if err := r.validate(); err != nil {
+			return fmt.Errorf("rule %d (%s): %w", i, r.Name, err)
+		}
+	}
+	return nil
+}
+
+// LoadRules reads a YAML matching rules file.
+func LoadRules(path string) ([]MatchRule, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read rules file: %w", err)
+	}
+
+	var f rulesFile
+	if err := yaml.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("failed to parse rules file: %w", err)
+	}
+	if err := ValidateRules(f.Rules); err != nil {
+		return nil, err
+	}
+	return f.Rules, nil
+}
+
+// MaxWindow is the widest date window of the rules, in days.
+func MaxWindow(rules []MatchRule) int {
+	window := 0
+	for _, r := range rules {
+		window = max(window, r.DateWindowDays)
+	}
+	return window
+}
+
+// Candidate is one of our transactions that may appear in a bank file.
+// Amount is signed the same way as bank lines. PublicID is the ID clients
+// see.
+type Candidate struct {
+	TransactionID int
+	PublicID      string
+	Amount        float64
+	Date          time.Time
+	Reference     string
+}
+
+// LineRef identifies a bank line to match; ID is the stored line ID.
+type LineRef struct {
+	ID   int
+	Line BankLine
+}
+
+// Pair is an automatic match.
+type Pair struct {
+	LineID        int
+	TransactionID int
+	Rule          string
+}
+
+// Match pairs lines with candidates one to one, applying rules in order.
+// Within a rule each line, in order, takes the matching candidate closest
+// in date, then the lowest transaction ID. Anything left over is for an
+// operator to match by hand.
+func Match(lines []LineRef, candidates []Candidate, rules []MatchRule) []Pair {
+	var (
+		pairs     []Pair
+		lineTaken = make([]bool, len(lines))
+		candTaken = make([]bool, len(candidates))
+	)
+	for _, rule := range rules {
+		for i, l := range lines {
+			if lineTaken[i] {
+				continue
+			}
+			best := -1
+			for j, c := range candidates {
+				if candTaken[j] || !rule.matches(l.Line, c) {
+					continue
+				}
+				if best < 0 || closer(l.Line, c, candidates[best]) {
+					best = j
+				}
+			}
+			if best < 0 {
+				continue
+			}
+			lineTaken[i], candTaken[best] = true, true
+			pairs = append(pairs, Pair{LineID: l.ID, TransactionID: candidates[best].TransactionID, Rule: rule.Name})
+		}
+	}
+	slices.SortFunc(pairs, func(a, b Pair) int { return a.LineID - b.LineID })
+	return pairs
+}
+
+func (r MatchRule) matches(l BankLine, c Candidate) bool {
+	if math.Abs(l.Amount-c.Amount) > r.AmountTolerance+1e-9 {
+		return false
+	}
+	if dayDistance(l.BookingDate, c.Date) > r.DateWindowDays {
+		return false
+	}
+	if r.MatchReference {
+		ref := normalizeRef(l.Reference)
+		return ref != "" && ref == normalizeRef(c.Reference)
+	}
+	return true
+}
+
+func closer(l BankLine, a, b Candidate) bool {
+	da, db := dayDistance(l.BookingDate, a.Date), dayDistance(l.BookingDate, b.Date)
+	if da != db {
+		return da < db
+	}
+	return a.TransactionID < b.TransactionID
+}
+
+// dayDistance is the number of calendar days (UTC) between a and b.
+func dayDistance(a, b time.Time) int {
+	a, b = a.UTC(), b.UTC()
+	da := time.Date(a.Year(), a.Month(), a.Day(), 0, 0, 0, 0, time.UTC)
+	db := time.Date(b.Year(), b.Month(), b.Day(), 0, 0, 0, 0, time.UTC)
+	days := int(da.Sub(db).Hours() / 24)
+	if days < 0 {
+		return -days
+	}
+	return days
+}
+
+func normalizeRef(ref string) string {
+	return strings.ToUpper(strings.TrimSpace(ref))
+}
@@ -0,0 +1,55 @@
+// Package reconciliation compares the balance stored on an account (the
+// ledger) with the balance implied by its transactions (the journal).
+package reconciliation
+
+import "math"
+
+// Kind identifies what a break disagrees with.
+type Kind string
+
+const (
+	// JournalMismatch means the ledger differs from the opening balance
+	// plus all posted transactions.
+	JournalMismatch Kind = "journal_mismatch"
+	// FinalBalanceMismatch means the ledger differs from the final_balance
+	// recorded on the last posted transaction.
+	FinalBalanceMismatch Kind = "final_balance_mismatch"
+)
+
+// Account is what is known about one account at reconciliation time.
+type Account struct {
+	ID      int
+	Ledger  float64
+	Journal float64
+	// LastFinalBalance is nil when the account has no posted transaction.
+	LastFinalBalance *float64
+}
+
+// Break is one disagreement found for an account.
+type Break struct {
+	AccountID int
+	Kind      Kind
+	Ledger    float64
+	Expected  float64
+}
+
+// Difference is the ledger less the expected balance.
+func (b Break) Difference() float64 {
+	return round(b.Ledger - b.Expected)
+}
+
+// Check returns the breaks of one account. Balances are compared in cents.
+func Check(a Account) []Break {
+	var breaks []Break
+	if round(a.Ledger) != round(a.Journal) {
+		breaks = append(breaks, Break{AccountID: a.ID, Kind: JournalMismatch, Ledger: a.Ledger, Expected: a.Journal})
+	}
+	if a.LastFinalBalance != nil && round(a.Ledger) != round(*a.LastFinalBalance) {
+		breaks = append(breaks, Break{AccountID: a.ID, Kind: FinalBalanceMismatch, Ledger: a.Ledger, Expected: *a.LastFinalBalance})
+	}
+	return breaks
+}
+
+func round(v float64) float64 {
+	return math.Round(v*100) / 100
+}
@@ -0,0 +1,125 @@
+// Package recurrence computes occurrences of scheduled payments, either
+// from a cron expression or from a fixed interval anchored at the start.
+// All times are UTC.
+package recurrence
+
+import (
+	"fmt"
+	"time"
+
+	apperrors "github.com/Andrew44Ashraf/fintech-service/internal/errors"
+)
+
+// Interval units
+const (
+	UnitDay   = "day"
+	UnitWeek  = "week"
+	UnitMonth = "month"
+)
+
+// Rule describes when a payment repeats. Set either Cron or Every and
+// Unit; the zero Rule is a one-off payment at the start time.
+type Rule struct {
+	Cron  string
+	Every int
+	Unit  string
+}
+
+// OneOff reports whether the rule never repeats.
+func (r Rule) OneOff() bool {
+	return r.Cron == "" && r.Every == 0
+}
+
+// Validate reports an invalid rule as an apperrors validation error.
+func (r Rule) Validate() error {
+	switch {
+	case r.Cron != "" && (r.Every != 0 || r.Unit != ""):
+		return apperrors.Invalid("cron", "excluded_with", "cannot be combined with every/unit")
+	case r.Cron != "":
+		if _, err := ParseCron(r.Cron); err != nil {
+			return apperrors.Invalid("cron", "cron", err.Error())
+		}
+	case r.Every < 0:
+		return apperrors.Invalid("every", "gt", "must be greater than 0")
+	case r.Every > 0 || r.Unit != "":
+		if r.Every == 0 {
+			return apperrors.Invalid("every", "required_with", "is required with unit")
+		}
+		if r.Unit != UnitDay && r.Unit != UnitWeek && r.Unit != UnitMonth {
+			return apperrors.Invalid("unit", "oneof", "must be one of: day week month")
+		}
+	}
+	return nil
+}
+
+// Next returns the first occurrence at or after start that is strictly
+// after after, or the zero time if there is none.
+//
+// Monthly intervals keep the start day, clamped to the end of shorter
+// months: a payment starting on the 31st runs on Feb 28 (or 29).
+func (r Rule) Next(start, after time.Time) (time.Time, error) {
+	start, after = start.UTC(), after.UTC()
+
+	switch {
+	case r.Cron != "":
+		c, err := ParseCron(r.Cron)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if start.After(after) {
+			after = start.Add(-time.Nanosecond)
+		}
+		return c.Next(after), nil
+
+	case r.OneOff():
+		if start.After(after) {
+			return start, nil
+		}
+		return time.Time{}, nil
+	}
+
+	if r.Every <= 0 {
+		return time.Time{}, fmt.Errorf("invalid interval %d", r.Every)
+	}
+	if start.After(after) {
+		return start, nil
+	}
+
+	// Estimate the number of periods elapsed, step back one to be safe and
+	// walk forward to the first occurrence after after.
+	var k int
+	switch r.Unit {
+	case UnitDay:
+		k = int(after.Sub(start).Hours()/24) / r.Every
+	case UnitWeek:
+		k = int(after.Sub(start).Hours()/(24*7)) / r.Every
+	case UnitMonth:
+		k = ((after.Year()-start.Year())*12 + int(after.Month()-start.Month())) / r.Every
+	default:
+		return time.Time{}, fmt.Errorf("invalid interval unit %q", r.Unit)
+	}
+	for k = max(k-1, 0); ; k++ {
+		if occ := r.occurrence(start, k); occ.After(after) {
+			return occ, nil
+		}
+	}
+}
+
+// occurrence returns the k-th occurrence of an interval rule (k = 0 is start).
+func (r Rule) occurrence(start time.Time, k int) time.Time {
+	switch r.Unit {
+	case UnitWeek:
+		return start.AddDate(0, 0, 7*k*r.Every)
+	case UnitMonth:
+		return addMonths(start, k*r.Every)
+	default:
+		return start.AddDate(0, 0, k*r.Every)
+	}
+}
+
+// addMonths adds n months to t, clamping the day to the target month.
+func addMonths(t time.Time, n int) time.Time {
+	first := time.Date(t.Year(), t.Month()+time.Month(n), 1, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+	lastDay := first.AddDate(0, 1, -1).Day()
+	return first.AddDate(0, 0, min(t.Day(), lastDay)-1)
+}
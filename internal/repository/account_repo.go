@@ -1,18 +1,328 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
-	"fintech-service/internal/models"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/Andrew44Ashraf/fintech-service/internal/cache"
+	apperrors "github.com/Andrew44Ashraf/fintech-service/internal/errors"
+	"github.com/Andrew44Ashraf/fintech-service/internal/ids"
+	"github.com/Andrew44Ashraf/fintech-service/internal/logging"
+	"github.com/lib/pq"
+)
+
+// Account errors are the catalog sentinels so handlers can map them to
+// stable API error codes.
+var (
+	ErrAccountNotFound      = apperrors.ErrAccountNotFound
+	ErrAccountClosed        = apperrors.ErrAccountClosed
+	ErrAccountAlreadyClosed = apperrors.ErrAccountAlreadyClosed
+	ErrAccountFrozen        = apperrors.ErrAccountFrozen
+	ErrAccountHasBalance    = apperrors.ErrAccountHasBalance
+	ErrInvalidAccountState  = apperrors.ErrInvalidAccountState
+	ErrVersionMismatch      = apperrors.ErrVersionMismatch
+	ErrNegativeBalance      = apperrors.ErrNegativeBalance
+	ErrInsufficientFunds    = apperrors.ErrInsufficientFunds
 )
 
-func CreateAccount(db *sql.DB) (int, error) {
-	var id int
-	err := db.QueryRow("INSERT INTO accounts (balance) VALUES (0) RETURNING id").Scan(&id)
-	return id, err
+type AccountRepository struct {
+	db       *sql.DB
+	logger   *slog.Logger
+	reads    ReadRouter
+	balances cache.Store
+}
+
+func NewAccountRepository(db *sql.DB, logger *slog.Logger, opts ...AccountOption) *AccountRepository {
+	r := &AccountRepository{db: db, logger: logger}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// CreateAccount opens a new active account of the given product with the
+// given initial balance. An empty product code opens a DefaultProduct
+// account. The account takes its currency and overdraft from the product;
+// the database assigns its public ID and account number.
+func (r *AccountRepository) CreateAccount(ctx context.Context, productCode string, initialBalance float64, details Details) (*Account, error) {
+	return r.createAccount(ctx, productCode, initialBalance, details, false)
+}
+
+// CheckCreateAccount opens the account as CreateAccount does and rolls it
+// back, so it fails exactly when CreateAccount would. The returned account
+// has no ID or number.
+func (r *AccountRepository) CheckCreateAccount(ctx context.Context, productCode string, initialBalance float64, details Details) (*Account, error) {
+	return r.createAccount(ctx, productCode, initialBalance, details, true)
+}
+
+func (r *AccountRepository) createAccount(ctx context.Context, productCode string, initialBalance float64, details Details, dryRun bool) (*Account, error) {
+	if initialBalance < 0 {
+		return nil, ErrNegativeBalance
+	}
+	if err := details.validate(); err != nil {
+		return nil, err
+	}
+	if productCode == "" {
+		productCode = DefaultProduct
+	}
+	start := time.Now()
+
+	var a *Account
+	err := withTx(ctx, r.db, r.logger, "open_account", nil, func(tx *sql.Tx) error {
+		product, err := getProduct(ctx, tx, productCode)
+		if err != nil {
+			return err
+		}
+		if initialBalance < product.MinBalance {
+			return apperrors.Invalid("initial_balance", "gte",
+				fmt.Sprintf("must be at least %.2f for %s accounts", product.MinBalance, product.Code))
+		}
+
+		a = &Account{
+			ProductCode:    product.Code,
+			Status:         AccountActive,
+			Balance:        initialBalance,
+			Currency:       product.Currency,
+			OverdraftLimit: product.OverdraftLimit,
+			Details:        details,
+		}
+		err = tx.QueryRowContext(ctx,
+			`INSERT INTO accounts (balance, opening_balance, product_code, currency, overdraft_limit,
+			                       external_ref, description, metadata)
+			 VALUES ($1, $1, $2, $3, $4, NULLIF($5, ''), NULLIF($6, ''), COALESCE($7::jsonb, '{}'))
+			 RETURNING id, public_id, account_number, version, created_at, updated_at`,
+			initialBalance, product.Code, product.Currency, product.OverdraftLimit,
+			details.ExternalRef, details.Description, details.metadata(),
+		).Scan(&a.ID, &a.PublicID, &a.Number, &a.Version, &a.CreatedAt, &a.UpdatedAt)
+		if isUniqueViolation(err, "idx_accounts_external_ref") {
+			return fmt.Errorf("%w: an account has external_ref %q", ErrDuplicateExternalRef, details.ExternalRef)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to create account: %w", err)
+		}
+
+		if dryRun {
+			return errRollback
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if dryRun {
+		a.ID, a.PublicID, a.Number = 0, "", ""
+		return a, nil
+	}
+
+	logging.FromContext(ctx, r.logger).Info("account opened",
+		logging.KeyOp, "open_account",
+		logging.KeyAccountID, a.ID,
+		"product_code", productCode,
+		logging.KeyOutcome, logging.OutcomeSuccess,
+		logging.KeyLatency, time.Since(start),
+	)
+
+	return a, nil
+}
+
+// Account is an account's current state. Version increases with every
+// change to the account, balance included. ID is the internal key; clients
+// know the account by PublicID and Number.
+type Account struct {
+	ID             int
+	PublicID       string
+	Number         string
+	ProductCode    string
+	Status         string
+	Balance        float64
+	Currency       string
+	OverdraftLimit float64
+	Version        int64
+	Details        Details
+	// Limits are the account's own withdrawal limit overrides.
+	Limits    AccountLimits
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// GetAccount returns an account's current state.
+func (r *AccountRepository) GetAccount(ctx context.Context, accountID int) (*Account, error) {
+	var (
+		a                      = &Account{ID: accountID}
+		single, daily, monthly sql.NullFloat64
+		count                  sql.NullInt64
+		metadata               []byte
+	)
+	err := r.reader(ctx).QueryRowContext(ctx,
+		`SELECT a.public_id, a.account_number, a.product_code, a.status, a.balance, a.currency, a.overdraft_limit,
+		        a.version, a.created_at, a.updated_at,
+		        COALESCE(a.external_ref, ''), COALESCE(a.description, ''), a.metadata,
+		        l.max_single_withdrawal, l.max_daily_withdrawal, l.max_monthly_withdrawal, l.max_daily_withdrawals
+		 FROM accounts a
+		 LEFT JOIN account_limits l ON l.account_id = a.id
+		 WHERE a.id = $1`,
+		accountID,
+	).Scan(&a.PublicID, &a.Number, &a.ProductCode, &a.Status, &a.Balance, &a.Currency, &a.OverdraftLimit, &a.Version, &a.CreatedAt, &a.UpdatedAt,
+		&a.Details.ExternalRef, &a.Details.Description, &metadata,
+		&single, &daily, &monthly, &count)
+
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return nil, ErrAccountNotFound
+	case err != nil:
+		return nil, fmt.Errorf("failed to get account: %w", err)
+	}
+	a.Details.Metadata = scanMetadata(metadata)
+	a.Limits = AccountLimits{
+		MaxSingle:     nullFloat(single),
+		MaxDaily:      nullFloat(daily),
+		MaxMonthly:    nullFloat(monthly),
+		MaxDailyCount: nullInt(count),
+	}
+	return a, nil
+}
+
+// ResolveAccount returns the internal ID of the account a client refers to
+// by public ID or account number.
+func (r *AccountRepository) ResolveAccount(ctx context.Context, ref string) (int, error) {
+	column := "public_id"
+	if !ids.IsAccountID(ref) {
+		number, ok := ids.ParseAccountNumber(ref)
+		if !ok {
+			return 0, apperrors.Invalid("id", "account", "must be an account ID or account number")
+		}
+		column, ref = "account_number", number
+	}
+
+	var accountID int
+	err := r.reader(ctx).QueryRowContext(ctx,
+		"SELECT id FROM accounts WHERE "+column+" = $1",
+		ref,
+	).Scan(&accountID)
+
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return 0, ErrAccountNotFound
+	case err != nil:
+		return 0, fmt.Errorf("failed to resolve account: %w", err)
+	}
+	return accountID, nil
+}
+
+// ResolveAccounts resolves many references at once, as ResolveAccount
+// does one, keyed by the reference as given. References to no account are
+// left out.
+func (r *AccountRepository) ResolveAccounts(ctx context.Context, refs []string) (map[string]int, error) {
+	var publicIDs, numbers []string
+	for _, ref := range refs {
+		if ids.IsAccountID(ref) {
+			publicIDs = append(publicIDs, ref)
+		} else if number, ok := ids.ParseAccountNumber(ref); ok {
+			numbers = append(numbers, number)
+		} else {
+			return nil, apperrors.Invalid("account_ids", "account", fmt.Sprintf("%q is not an account ID or account number", ref))
+		}
+	}
+
+	rows, err := r.reader(ctx).QueryContext(ctx,
+		"SELECT id, public_id, account_number FROM accounts WHERE public_id = ANY($1) OR account_number = ANY($2)",
+		pq.Array(publicIDs), pq.Array(numbers),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve accounts: %w", err)
+	}
+	defer rows.Close()
+
+	found := make(map[string]int, len(refs))
+	for rows.Next() {
+		var (
+			id               int
+			publicID, number string
+		)
+		if err := rows.Scan(&id, &publicID, &number); err != nil {
+			return nil, fmt.Errorf("failed to resolve accounts: %w", err)
+		}
+		found[publicID], found[number] = id, id
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	resolved := make(map[string]int, len(refs))
+	for _, ref := range refs {
+		key := ref
+		if number, ok := ids.ParseAccountNumber(ref); ok {
+			key = number
+		}
+		if id, ok := found[key]; ok {
+			resolved[ref] = id
+		}
+	}
+	return resolved, nil
 }
 
-func GetAccountBalance(db *sql.DB, accountID int) (float64, error) {
+// GetBalanceVersion returns the current balance with the account version,
+// for conditional requests. With a cache it is served from the cache like
+// GetAccountBalance.
+func (r *AccountRepository) GetBalanceVersion(ctx context.Context, accountID int) (float64, int64, error) {
+	if r.balances != nil {
+		e, err := r.cachedBalance(ctx, accountID)
+		return e.Balance, e.Version, err
+	}
+	return r.balanceVersion(ctx, accountID)
+}
+
+func (r *AccountRepository) balanceVersion(ctx context.Context, accountID int) (float64, int64, error) {
+	var (
+		balance float64
+		version int64
+	)
+	err := r.reader(ctx).QueryRowContext(ctx,
+		"SELECT balance, version FROM accounts WHERE id = $1",
+		accountID,
+	).Scan(&balance, &version)
+
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return 0, 0, ErrAccountNotFound
+	case err != nil:
+		return 0, 0, fmt.Errorf("failed to get balance: %w", err)
+	}
+	return balance, version, nil
+}
+
+// checkVersion compares an account's version with the one the caller read.
+// Zero means the caller did not ask for a check.
+func checkVersion(current, expected int64) error {
+	if expected != 0 && current != expected {
+		return ErrVersionMismatch
+	}
+	return nil
+}
+
+// GetAccountBalance returns the current balance of an account
+func (r *AccountRepository) GetAccountBalance(ctx context.Context, accountID int) (float64, error) {
+	if r.balances != nil {
+		e, err := r.cachedBalance(ctx, accountID)
+		return e.Balance, err
+	}
+
 	var balance float64
-	err := db.QueryRow("SELECT balance FROM accounts WHERE id = $1", accountID).Scan(&balance)
-	return balance, err
+	err := r.reader(ctx).QueryRowContext(ctx,
+		"SELECT balance FROM accounts WHERE id = $1",
+		accountID,
+	).Scan(&balance)
+
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return 0, ErrAccountNotFound
+	case err != nil:
+		return 0, fmt.Errorf("failed to get balance: %w", err)
+	}
+
+	return balance, nil
 }
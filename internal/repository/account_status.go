@@ -0,0 +1,133 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	apperrors "github.com/Andrew44Ashraf/fintech-service/internal/errors"
+	"github.com/Andrew44Ashraf/fintech-service/internal/logging"
+)
+
+// Account statuses. Frozen accounts keep their balance and history but
+// accept no postings; closed accounts are final.
+const (
+	AccountActive = "active"
+	AccountFrozen = "frozen"
+	AccountClosed = "closed"
+)
+
+// StatusChange is an operator's change of an account's status.
+type StatusChange struct {
+	ID        int
+	AccountID int
+	From      string
+	To        string
+	Operator  string
+	Reason    string
+	ChangedAt time.Time
+	// Version is the account version after the change.
+	Version int64
+}
+
+// inactiveAccountError returns the error for posting to accounts with the
+// given statuses, or nil when all of them are active.
+func inactiveAccountError(statuses ...string) error {
+	for _, status := range statuses {
+		switch status {
+		case AccountActive:
+		case AccountFrozen:
+			return ErrAccountFrozen
+		default:
+			return ErrAccountClosed
+		}
+	}
+	return nil
+}
+
+// ChangeAccountStatus freezes, unfreezes or closes an account and records
+// the change with the operator's reason. Only a zero balance can be
+// closed. A non-zero ifVersion must match the account's version. With
+// dryRun the change is checked and rolled back.
+func (r *AccountRepository) ChangeAccountStatus(ctx context.Context, accountID int, ifVersion int64, to, operator, reason string, dryRun bool) (*StatusChange, error) {
+	if to != AccountActive && to != AccountFrozen && to != AccountClosed {
+		return nil, apperrors.Invalid("status", "oneof", "must be one of active, frozen or closed")
+	}
+	if err := requireAudit(operator, reason); err != nil {
+		return nil, err
+	}
+
+	var change *StatusChange
+	err := withTx(ctx, r.db, r.logger, "change_account_status", nil, func(tx *sql.Tx) error {
+		var (
+			from    string
+			balance float64
+			version int64
+		)
+		err := tx.QueryRowContext(ctx,
+			"SELECT status, balance, version FROM accounts WHERE id = $1 FOR UPDATE",
+			accountID,
+		).Scan(&from, &balance, &version)
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return ErrAccountNotFound
+		case err != nil:
+			return fmt.Errorf("account verification failed: %w", err)
+		}
+		if err := checkVersion(version, ifVersion); err != nil {
+			return err
+		}
+		switch {
+		case from == AccountClosed && to == AccountClosed:
+			return ErrAccountAlreadyClosed
+		case from == AccountClosed:
+			return ErrAccountClosed
+		case from == to:
+			return fmt.Errorf("%w: account is already %s", ErrInvalidAccountState, to)
+		case to == AccountClosed && balance != 0:
+			return ErrAccountHasBalance
+		}
+
+		change = &StatusChange{AccountID: accountID, From: from, To: to, Operator: operator, Reason: reason}
+		err = tx.QueryRowContext(ctx,
+			"UPDATE accounts SET status = $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2 RETURNING version",
+			to, accountID,
+		).Scan(&change.Version)
+		if err != nil {
+			return fmt.Errorf("failed to update account status: %w", err)
+		}
+
+		err = tx.QueryRowContext(ctx,
+			`INSERT INTO account_status_changes (account_id, from_status, to_status, operator, reason)
+			 VALUES ($1, $2, $3, $4, $5)
+			 RETURNING id, changed_at`,
+			accountID, from, to, operator, reason,
+		).Scan(&change.ID, &change.ChangedAt)
+		if err != nil {
+			return fmt.Errorf("failed to record status change: %w", err)
+		}
+
+		if dryRun {
+			return errRollback
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if dryRun {
+		return change, nil
+	}
+
+	logging.FromContext(ctx, r.logger).Info("account status changed",
+		logging.KeyOp, "change_account_status",
+		logging.KeyAccountID, accountID,
+		"from", change.From,
+		"to", to,
+		"operator", operator,
+		logging.KeyOutcome, logging.OutcomeSuccess,
+	)
+	return change, nil
+}
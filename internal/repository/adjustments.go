@@ -0,0 +1,241 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"time"
+
+	apperrors "github.com/Andrew44Ashraf/fintech-service/internal/errors"
+	"github.com/Andrew44Ashraf/fintech-service/internal/models"
+)
+
+var ErrAlreadyReversed = apperrors.ErrAlreadyReversed
+
+// reversibleTypes are the transaction types ReverseTransaction accepts.
+// Fees have their own reversal, and a transfer is undone by a transfer
+// back so both accounts see it.
+var reversibleTypes = map[models.TransactionType]bool{
+	models.Deposit:          true,
+	models.Withdrawal:       true,
+	models.Interest:         true,
+	models.AdjustmentCredit: true,
+	models.AdjustmentDebit:  true,
+	models.BankTransferIn:   true,
+}
+
+// Adjustment is a manual correction posted by an operator.
+type Adjustment struct {
+	TransactionID int
+	AccountID     int
+	Type          models.TransactionType
+	Amount        float64
+	FinalBalance  float64
+	// ReversedTransactionID is set when the adjustment reverses a
+	// transaction.
+	ReversedTransactionID int
+	Operator              string
+	Reason                string
+	CreatedAt             time.Time
+	DryRun                bool
+}
+
+// Signed returns the adjustment's effect on the balance.
+func (a *Adjustment) Signed() float64 {
+	return a.Type.Signed(a.Amount)
+}
+
+// PostAdjustment credits (amount > 0) or debits (amount < 0) an account
+// outside the product rules, limits and screening. Debits may use the
+// overdraft but no more. Frozen accounts can be adjusted, closed ones
+// cannot. With dryRun nothing is committed.
+func (r *TransactionRepository) PostAdjustment(ctx context.Context, accountID int, amount float64, operator, reason string, dryRun bool) (adj *Adjustment, err error) {
+	start := time.Now()
+	defer func() { logAdjustment(ctx, r.logger, "adjustment", accountID, adj, dryRun, start, err) }()
+
+	if amount == 0 || math.IsNaN(amount) || math.IsInf(amount, 0) {
+		return nil, apperrors.Invalid("amount", "ne", "must not be zero")
+	}
+	if err := requireAudit(operator, reason); err != nil {
+		return nil, err
+	}
+
+	var balances []accountBalance
+	err = withTx(ctx, r.db, r.logger, "adjustment", nil, func(tx *sql.Tx) error {
+		var err error
+		balances = nil
+		if adj, err = postAdjustment(ctx, tx, accountID, amount, 0, operator, reason); err != nil {
+			return err
+		}
+		if err := finishAdjustment(adj, dryRun); err != nil {
+			return err
+		}
+		balances, err = readBalances(ctx, tx, r.balances, []int{accountID})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	cacheBalances(ctx, r.logger, r.balances, balances)
+	return adj, nil
+}
+
+// ReverseTransaction undoes a posted transaction with an adjustment of the
+// opposite sign. A transaction can be reversed once. With dryRun nothing
+// is committed.
+func (r *TransactionRepository) ReverseTransaction(ctx context.Context, txID int, operator, reason string, dryRun bool) (adj *Adjustment, err error) {
+	start := time.Now()
+	var accountID int
+	defer func() { logAdjustment(ctx, r.logger, "reversal", accountID, adj, dryRun, start, err) }()
+
+	if err := requireAudit(operator, reason); err != nil {
+		return nil, err
+	}
+
+	var balances []accountBalance
+	err = withTx(ctx, r.db, r.logger, "reversal", nil, func(tx *sql.Tx) error {
+		var (
+			amount         float64
+			txType, status string
+		)
+		balances = nil
+		err := tx.QueryRowContext(ctx,
+			"SELECT account_id, amount, type, status FROM transactions WHERE id = $1 FOR UPDATE",
+			txID,
+		).Scan(&accountID, &amount, &txType, &status)
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return ErrTransactionNotFound
+		case err != nil:
+			return fmt.Errorf("failed to load transaction: %w", err)
+		case !reversibleTypes[models.TransactionType(txType)]:
+			return fmt.Errorf("%w: %s transactions cannot be reversed", ErrInvalidTransaction, txType)
+		case status != StatusPosted:
+			return ErrInvalidTransactionState
+		}
+
+		// Checked after the lock so a concurrent reversal is seen
+		var reversed bool
+		err = tx.QueryRowContext(ctx,
+			`SELECT EXISTS (SELECT 1 FROM transactions
+			                WHERE related_transaction_id = $1 AND type IN ('adjustment_credit', 'adjustment_debit'))`,
+			txID,
+		).Scan(&reversed)
+		switch {
+		case err != nil:
+			return fmt.Errorf("failed to check reversals: %w", err)
+		case reversed:
+			return ErrAlreadyReversed
+		}
+
+		signed := models.TransactionType(txType).Signed(amount)
+		if adj, err = postAdjustment(ctx, tx, accountID, -signed, txID, operator, reason); err != nil {
+			return err
+		}
+		if err := finishAdjustment(adj, dryRun); err != nil {
+			return err
+		}
+		balances, err = readBalances(ctx, tx, r.balances, []int{accountID})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	cacheBalances(ctx, r.logger, r.balances, balances)
+	return adj, nil
+}
+
+// postAdjustment moves the balance by amount and books the adjustment.
+func postAdjustment(ctx context.Context, tx *sql.Tx, accountID int, amount float64, reversedTxID int, operator, reason string) (*Adjustment, error) {
+	var (
+		balance, overdraft float64
+		status             string
+	)
+	err := tx.QueryRowContext(ctx,
+		"SELECT balance, status, overdraft_limit FROM accounts WHERE id = $1 FOR UPDATE",
+		accountID,
+	).Scan(&balance, &status, &overdraft)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return nil, ErrAccountNotFound
+	case err != nil:
+		return nil, fmt.Errorf("account verification failed: %w", err)
+	case status == AccountClosed:
+		return nil, ErrAccountClosed
+	case balance+amount < -overdraft:
+		return nil, ErrInsufficientFunds
+	}
+
+	adj := &Adjustment{
+		AccountID:             accountID,
+		Type:                  models.AdjustmentCredit,
+		Amount:                math.Abs(amount),
+		ReversedTransactionID: reversedTxID,
+		Operator:              operator,
+		Reason:                reason,
+	}
+	if amount < 0 {
+		adj.Type = models.AdjustmentDebit
+	}
+
+	err = tx.QueryRowContext(ctx,
+		"UPDATE accounts SET balance = balance + $1 WHERE id = $2 RETURNING balance",
+		amount, accountID,
+	).Scan(&adj.FinalBalance)
+	if err != nil {
+		return nil, fmt.Errorf("balance update failed: %w", err)
+	}
+
+	err = tx.QueryRowContext(ctx,
+		`INSERT INTO transactions (account_id, amount, type, final_balance, related_transaction_id)
+		 VALUES ($1, $2, $3, $4, NULLIF($5, 0))
+		 RETURNING id, created_at`,
+		accountID, adj.Amount, string(adj.Type), adj.FinalBalance, reversedTxID,
+	).Scan(&adj.TransactionID, &adj.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create transaction: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx,
+		`INSERT INTO adjustments (transaction_id, account_id, reversed_transaction_id, operator, reason)
+		 VALUES ($1, $2, NULLIF($3, 0), $4, $5)`,
+		adj.TransactionID, accountID, reversedTxID, operator, reason,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to record adjustment: %w", err)
+	}
+	return adj, nil
+}
+
+// finishAdjustment marks a dry run and returns errRollback for it, so the
+// adjustment is not committed.
+func finishAdjustment(adj *Adjustment, dryRun bool) error {
+	adj.DryRun = dryRun
+	if dryRun {
+		return errRollback
+	}
+	return nil
+}
+
+// logAdjustment logs the outcome of an adjustment. Successful dry runs
+// changed nothing and are not logged.
+func logAdjustment(ctx context.Context, logger *slog.Logger, op string, accountID int, adj *Adjustment, dryRun bool, start time.Time, err error) {
+	if err != nil {
+		logOutcome(ctx, logger, op, accountID, 0, start, err)
+	} else if !dryRun {
+		logOutcome(ctx, logger, op, accountID, adj.TransactionID, start, nil)
+	}
+}
+
+func requireAudit(operator, reason string) error {
+	switch {
+	case operator == "":
+		return apperrors.Invalid("operator", "required", "is required")
+	case reason == "":
+		return apperrors.Invalid("reason", "required", "is required")
+	}
+	return nil
+}
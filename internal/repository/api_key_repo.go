@@ -0,0 +1,166 @@
+package repository
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	apperrors "github.com/Andrew44Ashraf/fintech-service/internal/errors"
+	"github.com/Andrew44Ashraf/fintech-service/internal/logging"
+	"github.com/Andrew44Ashraf/fintech-service/internal/tenant"
+)
+
+var ErrAPIKeyNotFound = apperrors.ErrAPIKeyNotFound
+
+// apiKeyPrefix starts every generated key so leaked keys are easy to find
+// in code and logs.
+const apiKeyPrefix = "fk_"
+
+// APIKey describes a stored key. The key itself is only known when it is
+// created. Callers that authenticate with it act for TenantID.
+type APIKey struct {
+	ID        int
+	TenantID  string
+	Name      string
+	Prefix    string
+	CreatedBy string
+	CreatedAt time.Time
+	RevokedBy string
+	RevokedAt *time.Time
+}
+
+type APIKeyRepository struct {
+	db     *sql.DB
+	logger *slog.Logger
+}
+
+func NewAPIKeyRepository(db *sql.DB, logger *slog.Logger) *APIKeyRepository {
+	return &APIKeyRepository{db: db, logger: logger}
+}
+
+// CreateKey generates a key for the tenant of ctx and stores its hash. The
+// returned secret is not stored and cannot be shown again.
+func (r *APIKeyRepository) CreateKey(ctx context.Context, name, operator string) (*APIKey, string, error) {
+	tenantID := tenant.FromContext(ctx)
+	switch {
+	case tenantID == "":
+		return nil, "", apperrors.Invalid("tenant", "required", "is required")
+	case name == "":
+		return nil, "", apperrors.Invalid("name", "required", "is required")
+	case operator == "":
+		return nil, "", apperrors.Invalid("operator", "required", "is required")
+	}
+
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return nil, "", fmt.Errorf("failed to generate api key: %w", err)
+	}
+	secret := apiKeyPrefix + hex.EncodeToString(b)
+	hash := sha256.Sum256([]byte(secret))
+
+	key := &APIKey{TenantID: tenantID, Name: name, Prefix: secret[:len(apiKeyPrefix)+8], CreatedBy: operator}
+	err := r.db.QueryRowContext(ctx,
+		`INSERT INTO api_keys (name, prefix, key_hash, created_by, tenant_id)
+		 VALUES ($1, $2, $3, $4, $5)
+		 RETURNING id, created_at`,
+		name, key.Prefix, hash[:], operator, tenantID,
+	).Scan(&key.ID, &key.CreatedAt)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to create api key: %w", err)
+	}
+
+	logging.FromContext(ctx, r.logger).Info("api key created",
+		logging.KeyOp, "create_api_key",
+		"api_key_id", key.ID,
+		"operator", operator,
+		logging.KeyOutcome, logging.OutcomeSuccess,
+	)
+	return key, secret, nil
+}
+
+// ListKeys returns the keys of the tenant of ctx, revoked ones included,
+// newest first.
+func (r *APIKeyRepository) ListKeys(ctx context.Context) ([]APIKey, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT id, tenant_id, name, prefix, created_by, created_at, COALESCE(revoked_by, ''), revoked_at
+		 FROM api_keys
+		 WHERE tenant_id = $1
+		 ORDER BY id DESC`,
+		tenant.FromContext(ctx),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query api keys: %w", err)
+	}
+	defer rows.Close()
+
+	var keys []APIKey
+	for rows.Next() {
+		var k APIKey
+		if err := rows.Scan(&k.ID, &k.TenantID, &k.Name, &k.Prefix, &k.CreatedBy, &k.CreatedAt, &k.RevokedBy, &k.RevokedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan api key: %w", err)
+		}
+		keys = append(keys, k)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+	return keys, nil
+}
+
+// RevokeKey stops a key of the tenant of ctx from working. Revocation
+// cannot be undone.
+func (r *APIKeyRepository) RevokeKey(ctx context.Context, id int, operator string) error {
+	if operator == "" {
+		return apperrors.Invalid("operator", "required", "is required")
+	}
+
+	res, err := r.db.ExecContext(ctx,
+		`UPDATE api_keys SET revoked_by = $1, revoked_at = CURRENT_TIMESTAMP
+		 WHERE id = $2 AND tenant_id = $3 AND revoked_at IS NULL`,
+		operator, id, tenant.FromContext(ctx),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to revoke api key: %w", err)
+	}
+	n, err := res.RowsAffected()
+	switch {
+	case err != nil:
+		return fmt.Errorf("failed to revoke api key: %w", err)
+	case n == 0:
+		return ErrAPIKeyNotFound
+	}
+
+	logging.FromContext(ctx, r.logger).Info("api key revoked",
+		logging.KeyOp, "revoke_api_key",
+		"api_key_id", id,
+		"operator", operator,
+		logging.KeyOutcome, logging.OutcomeSuccess,
+	)
+	return nil
+}
+
+// CheckKey accepts keys that exist and are not revoked and returns their
+// tenant. Lookup is by hash, so the key is never compared or stored in
+// clear.
+func (r *APIKeyRepository) CheckKey(ctx context.Context, key string) (string, error) {
+	hash := sha256.Sum256([]byte(key))
+
+	var tenantID string
+	err := r.db.QueryRowContext(ctx,
+		"SELECT tenant_id FROM api_keys WHERE key_hash = $1 AND revoked_at IS NULL",
+		hash[:],
+	).Scan(&tenantID)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return "", apperrors.ErrForbidden
+	case err != nil:
+		return "", fmt.Errorf("failed to check api key: %w", err)
+	}
+	return tenantID, nil
+}
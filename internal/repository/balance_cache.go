@@ -0,0 +1,135 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"expvar"
+	"fmt"
+	"log/slog"
+
+	"github.com/Andrew44Ashraf/fintech-service/internal/cache"
+	"github.com/Andrew44Ashraf/fintech-service/internal/database"
+	"github.com/Andrew44Ashraf/fintech-service/internal/logging"
+	"github.com/Andrew44Ashraf/fintech-service/internal/tenant"
+	"github.com/lib/pq"
+)
+
+// CacheMetrics counts balance cache lookups, published by expvar as
+// balance_cache: "hit", "miss", "bypass" (reads that must see particular
+// writes) and "error".
+var CacheMetrics = expvar.NewMap("balance_cache")
+
+// WithAccountCache serves GetAccountBalance from balances. The
+// TransactionRepository posting to the same accounts must update it, see
+// WithTransactionCache.
+func WithAccountCache(balances cache.Store) AccountOption {
+	return func(r *AccountRepository) {
+		r.balances = balances
+	}
+}
+
+// WithTransactionCache updates balances with the new balance of every
+// account a posting, review decision, adjustment or batch changed, once it
+// commits. Other writers reach the cache through cache.Notified.
+func WithTransactionCache(balances cache.Store) TransactionOption {
+	return func(r *TransactionRepository) {
+		r.balances = balances
+	}
+}
+
+// cachedBalance looks the balance up in the cache, filling it on a miss.
+// Reads that must see particular writes skip the cache, which may not have
+// heard of writes made by other replicas yet. The cache is filled from the
+// primary: entries do not expire, so a balance read from a lagging replica
+// would be served until the account next changes.
+func (r *AccountRepository) cachedBalance(ctx context.Context, accountID int) (cache.Entry, error) {
+	key := cache.BalanceKey(tenant.FromContext(ctx), accountID)
+	if database.Consistent(ctx) {
+		CacheMetrics.Add("bypass", 1)
+	} else {
+		e, ok, err := r.balances.Get(ctx, key)
+		switch {
+		case err != nil:
+			CacheMetrics.Add("error", 1)
+			logging.FromContext(ctx, r.logger).Warn("balance cache lookup failed", logging.KeyError, err.Error())
+		case ok:
+			CacheMetrics.Add("hit", 1)
+			return e, nil
+		default:
+			CacheMetrics.Add("miss", 1)
+		}
+	}
+
+	balance, version, err := r.balanceVersion(database.WithPrimary(ctx), accountID)
+	if err != nil {
+		return cache.Entry{}, err
+	}
+	e := cache.Entry{Balance: balance, Version: version}
+	// A newer version already in the cache is kept
+	if err := r.balances.Put(ctx, key, e); err != nil {
+		CacheMetrics.Add("error", 1)
+		logging.FromContext(ctx, r.logger).Warn("balance cache update failed", logging.KeyError, err.Error())
+	}
+	return e, nil
+}
+
+// accountBalance is an account's balance as of a posting.
+type accountBalance struct {
+	accountID int
+	entry     cache.Entry
+}
+
+// WithFeeCache updates balances with the new balance of every account a
+// fee or fee reversal changed, once it commits, as WithTransactionCache
+// does for postings.
+func WithFeeCache(balances cache.Store) FeeOption {
+	return func(r *FeeRepository) {
+		r.balances = balances
+	}
+}
+
+// readBalances reads the balances a posting left in accounts inside its
+// transaction, for updating balances once it commits. Without a cache it
+// reads nothing.
+func readBalances(ctx context.Context, tx *sql.Tx, balances cache.Store, accountIDs []int) ([]accountBalance, error) {
+	if balances == nil {
+		return nil, nil
+	}
+
+	rows, err := tx.QueryContext(ctx,
+		"SELECT id, balance, version FROM accounts WHERE id = ANY($1)",
+		pq.Array(accountIDs),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read new balances: %w", err)
+	}
+	defer rows.Close()
+
+	var read []accountBalance
+	for rows.Next() {
+		var b accountBalance
+		if err := rows.Scan(&b.accountID, &b.entry.Balance, &b.entry.Version); err != nil {
+			return nil, fmt.Errorf("failed to scan balance: %w", err)
+		}
+		read = append(read, b)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+	return read, nil
+}
+
+// cacheBalances stores committed balances read by readBalances. A failed
+// update is applied again when the notification of the commit arrives.
+func cacheBalances(ctx context.Context, logger *slog.Logger, store cache.Store, balances []accountBalance) {
+	tenantID := tenant.FromContext(ctx)
+	for _, b := range balances {
+		if err := store.Put(ctx, cache.BalanceKey(tenantID, b.accountID), b.entry); err != nil {
+			CacheMetrics.Add("error", 1)
+			logging.FromContext(ctx, logger).Error("balance cache update failed",
+				logging.KeyAccountID, b.accountID,
+				logging.KeyError, err.Error(),
+			)
+		}
+	}
+}
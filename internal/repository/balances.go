@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	apperrors "github.com/Andrew44Ashraf/fintech-service/internal/errors"
+	"github.com/lib/pq"
+)
+
+// MaxBalanceAccounts bounds the number of accounts in one BalancesAsOf call.
+const MaxBalanceAccounts = 1000
+
+// BalancesAsOf returns the balances of the given accounts at the instant
+// asOf, keyed by account ID. A balance is the final_balance of the last
+// transaction posted at or before asOf; pending transactions do not count
+// until they are posted. Accounts opened with an initial balance may have
+// none, so it is then worked back from the first later posting, or is the
+// current balance if there is none. Accounts that do not exist or were
+// opened after asOf are left out.
+func (r *AccountRepository) BalancesAsOf(ctx context.Context, accountIDs []int, asOf time.Time) (map[int]float64, error) {
+	if len(accountIDs) > MaxBalanceAccounts {
+		return nil, apperrors.Invalid("account_ids", "max", fmt.Sprintf("must not list more than %d accounts", MaxBalanceAccounts))
+	}
+
+	// Both lookups are served by idx_transactions_account_posted_id.
+	rows, err := r.reader(ctx).QueryContext(ctx,
+		`SELECT a.id,
+		        COALESCE(
+		            (SELECT t.final_balance FROM transactions t
+		             WHERE t.account_id = a.id AND t.status = 'posted' AND t.posted_at <= $2
+		             ORDER BY t.posted_at DESC, t.id DESC
+		             LIMIT 1),
+		            (SELECT t.final_balance - CASE WHEN t.type = ANY($3) THEN t.amount ELSE -t.amount END
+		             FROM transactions t
+		             WHERE t.account_id = a.id AND t.status = 'posted' AND t.posted_at > $2
+		             ORDER BY t.posted_at, t.id
+		             LIMIT 1),
+		            a.balance)
+		 FROM accounts a
+		 WHERE a.id = ANY($1) AND a.created_at <= $2`,
+		pq.Array(accountIDs), asOf, creditTypes,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query balances: %w", err)
+	}
+	defer rows.Close()
+
+	balances := make(map[int]float64, len(accountIDs))
+	for rows.Next() {
+		var (
+			id      int
+			balance float64
+		)
+		if err := rows.Scan(&id, &balance); err != nil {
+			return nil, fmt.Errorf("failed to scan balance: %w", err)
+		}
+		balances[id] = balance
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+	return balances, nil
+}
+
+// GetBalanceAsOf returns the balance of an account at the instant asOf.
+func (r *AccountRepository) GetBalanceAsOf(ctx context.Context, accountID int, asOf time.Time) (float64, error) {
+	balances, err := r.BalancesAsOf(ctx, []int{accountID}, asOf)
+	if err != nil {
+		return 0, err
+	}
+	if balance, ok := balances[accountID]; ok {
+		return balance, nil
+	}
+
+	var openedAt time.Time
+	err = r.reader(ctx).QueryRowContext(ctx,
+		"SELECT created_at FROM accounts WHERE id = $1",
+		accountID,
+	).Scan(&openedAt)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return 0, ErrAccountNotFound
+	case err != nil:
+		return 0, fmt.Errorf("failed to get account: %w", err)
+	}
+	return 0, apperrors.Invalid("as_of", "gte",
+		fmt.Sprintf("account was opened at %s", openedAt.UTC().Format(time.RFC3339)))
+}
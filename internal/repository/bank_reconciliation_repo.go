@@ -0,0 +1,408 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+
+	apperrors "github.com/Andrew44Ashraf/fintech-service/internal/errors"
+	"github.com/Andrew44Ashraf/fintech-service/internal/logging"
+	"github.com/Andrew44Ashraf/fintech-service/internal/reconciliation"
+)
+
+var (
+	ErrBankStatementNotFound  = apperrors.ErrBankStatementNotFound
+	ErrBankStatementDuplicate = apperrors.ErrBankStatementDuplicate
+	ErrBankLineNotFound       = apperrors.ErrBankLineNotFound
+	ErrBankMatchNotFound      = apperrors.ErrBankMatchNotFound
+	ErrAlreadyMatched         = apperrors.ErrAlreadyMatched
+)
+
+// autoMatcher is recorded as matched_by for automatic matches.
+const autoMatcher = "system"
+
+// BankStatementRecord is an imported bank file.
+type BankStatementRecord struct {
+	ID          int
+	Format      string
+	FileName    string
+	Checksum    string
+	PeriodStart time.Time
+	PeriodEnd   time.Time
+	LineCount   int
+	ImportedBy  string
+	ImportedAt  time.Time
+}
+
+// BankStatementLine is a stored line of an imported bank file.
+type BankStatementLine struct {
+	ID          int
+	StatementID int
+	LineNo      int
+	Reference   string
+	Amount      float64
+	BookingDate time.Time
+	ValueDate   time.Time
+	Description string
+}
+
+// BankMatch pairs a bank line with one of our transactions.
+type BankMatch struct {
+	ID          int
+	Line        BankStatementLine
+	Transaction reconciliation.Candidate
+	Rule        string
+	MatchedBy   string
+	MatchedAt   time.Time
+}
+
+// BankReconciliation is the state of one imported bank file: the matched
+// pairs, the bank lines without a transaction and our transactions in the
+// file's period without a bank line.
+type BankReconciliation struct {
+	Statement       BankStatementRecord
+	Matched         []BankMatch
+	UnmatchedTheirs []BankStatementLine
+	UnmatchedOurs   []reconciliation.Candidate
+}
+
+type BankReconciliationRepository struct {
+	db     *sql.DB
+	logger *slog.Logger
+}
+
+func NewBankReconciliationRepository(db *sql.DB, logger *slog.Logger) *BankReconciliationRepository {
+	return &BankReconciliationRepository{db: db, logger: logger}
+}
+
+// ImportStatement stores a parsed bank file. A file whose checksum was
+// imported before is rejected with ErrBankStatementDuplicate.
+func (r *BankReconciliationRepository) ImportStatement(ctx context.Context, st *reconciliation.BankStatement, fileName, checksum, operator string) (*BankStatementRecord, error) {
+	rec := &BankStatementRecord{
+		Format:      st.Format,
+		FileName:    fileName,
+		Checksum:    checksum,
+		PeriodStart: st.PeriodStart,
+		PeriodEnd:   st.PeriodEnd,
+		LineCount:   len(st.Lines),
+		ImportedBy:  operator,
+	}
+
+	err := withTx(ctx, r.db, r.logger, "import_bank_statement", nil, func(tx *sql.Tx) error {
+		err := tx.QueryRowContext(ctx,
+			`INSERT INTO bank_statements (format, file_name, checksum, period_start, period_end, line_count, imported_by)
+			 VALUES ($1, $2, $3, $4, $5, $6, $7)
+			 ON CONFLICT (tenant_id, checksum) DO NOTHING
+			 RETURNING id, imported_at`,
+			rec.Format, rec.FileName, rec.Checksum, rec.PeriodStart, rec.PeriodEnd, rec.LineCount, rec.ImportedBy,
+		).Scan(&rec.ID, &rec.ImportedAt)
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return ErrBankStatementDuplicate
+		case err != nil:
+			return fmt.Errorf("failed to create bank statement: %w", err)
+		}
+
+		stmt, err := tx.PrepareContext(ctx,
+			`INSERT INTO bank_statement_lines
+			     (statement_id, line_no, reference, amount, booking_date, value_date, description)
+			 VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to prepare line insert: %w", err)
+		}
+		defer stmt.Close()
+
+		for _, l := range st.Lines {
+			_, err := stmt.ExecContext(ctx, rec.ID, l.LineNo, l.Reference, l.Amount, l.BookingDate, l.ValueDate, l.Description)
+			if err != nil {
+				return fmt.Errorf("failed to store line %d: %w", l.LineNo, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	logging.FromContext(ctx, r.logger).Info("bank statement imported",
+		logging.KeyOp, "import_bank_statement",
+		"statement_id", rec.ID,
+		"lines", rec.LineCount,
+		logging.KeyOutcome, logging.OutcomeSuccess,
+	)
+	return rec, nil
+}
+
+// AutoMatch matches the statement's open lines against our open
+// transactions with the given rules and returns the number of new matches.
+// Only deposits, withdrawals and incoming bank transfers move cash at the
+// bank, so only those are candidates.
+func (r *BankReconciliationRepository) AutoMatch(ctx context.Context, statementID int, rules []reconciliation.MatchRule) (int, error) {
+	matched := 0
+	err := withTx(ctx, r.db, r.logger, "bank_auto_match", nil, func(tx *sql.Tx) error {
+		// Locking the statement serializes matching runs over the same file.
+		rec, err := loadBankStatement(ctx, tx, statementID, true)
+		if err != nil {
+			return err
+		}
+
+		lines, err := unmatchedLines(ctx, tx, statementID)
+		if err != nil {
+			return err
+		}
+		window := reconciliation.MaxWindow(rules)
+		candidates, err := unmatchedCandidates(ctx, tx,
+			rec.PeriodStart.AddDate(0, 0, -window), rec.PeriodEnd.AddDate(0, 0, window+1))
+		if err != nil {
+			return err
+		}
+
+		refs := make([]reconciliation.LineRef, 0, len(lines))
+		for _, l := range lines {
+			refs = append(refs, reconciliation.LineRef{ID: l.ID, Line: reconciliation.BankLine{
+				Reference: l.Reference, Amount: l.Amount, BookingDate: l.BookingDate,
+			}})
+		}
+
+		matched = 0
+		for _, p := range reconciliation.Match(refs, candidates, rules) {
+			// A concurrent import of an overlapping file may have taken the
+			// transaction; the unique indexes keep the first match.
+			res, err := tx.ExecContext(ctx,
+				`INSERT INTO bank_matches (line_id, transaction_id, rule, matched_by)
+				 VALUES ($1, $2, $3, $4)
+				 ON CONFLICT DO NOTHING`,
+				p.LineID, p.TransactionID, p.Rule, autoMatcher,
+			)
+			if err != nil {
+				return fmt.Errorf("failed to record match: %w", err)
+			}
+			if n, _ := res.RowsAffected(); n > 0 {
+				matched++
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return matched, nil
+}
+
+// Reconciliation returns the matched and unmatched sets of a statement.
+// Our side covers the posted deposits, withdrawals and incoming bank
+// transfers booked in the statement's period.
+func (r *BankReconciliationRepository) Reconciliation(ctx context.Context, statementID int) (*BankReconciliation, error) {
+	tx, err := r.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	rec, err := loadBankStatement(ctx, tx, statementID, false)
+	if err != nil {
+		return nil, err
+	}
+	result := &BankReconciliation{Statement: *rec}
+
+	rows, err := tx.QueryContext(ctx,
+		`SELECT m.id, m.rule, m.matched_by, m.matched_at,
+		        l.id, l.statement_id, l.line_no, l.reference, l.amount, l.booking_date, l.value_date, l.description,
+		        t.id, t.public_id, CASE WHEN t.type = 'withdrawal' THEN -t.amount ELSE t.amount END, t.posted_at
+		 FROM bank_matches m
+		 JOIN bank_statement_lines l ON l.id = m.line_id
+		 JOIN transactions t ON t.id = m.transaction_id
+		 WHERE l.statement_id = $1 AND m.unmatched_at IS NULL
+		 ORDER BY l.line_no`,
+		statementID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query matches: %w", err)
+	}
+	for rows.Next() {
+		var m BankMatch
+		err := rows.Scan(&m.ID, &m.Rule, &m.MatchedBy, &m.MatchedAt,
+			&m.Line.ID, &m.Line.StatementID, &m.Line.LineNo, &m.Line.Reference, &m.Line.Amount,
+			&m.Line.BookingDate, &m.Line.ValueDate, &m.Line.Description,
+			&m.Transaction.TransactionID, &m.Transaction.PublicID, &m.Transaction.Amount, &m.Transaction.Date)
+		if err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan match: %w", err)
+		}
+		m.Transaction.Reference = strconv.Itoa(m.Transaction.TransactionID)
+		result.Matched = append(result.Matched, m)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	if result.UnmatchedTheirs, err = unmatchedLines(ctx, tx, statementID); err != nil {
+		return nil, err
+	}
+	if result.UnmatchedOurs, err = unmatchedCandidates(ctx, tx, rec.PeriodStart, rec.PeriodEnd.AddDate(0, 0, 1)); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// Match records an operator's match of a bank line with a transaction.
+// Amounts and dates are not checked: the operator has the final say.
+func (r *BankReconciliationRepository) Match(ctx context.Context, lineID, transactionID int, operator string) (int, error) {
+	var matchID int
+	err := withTx(ctx, r.db, r.logger, "bank_match", nil, func(tx *sql.Tx) error {
+		var exists bool
+		err := tx.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM bank_statement_lines WHERE id = $1)", lineID).Scan(&exists)
+		switch {
+		case err != nil:
+			return fmt.Errorf("failed to check bank line: %w", err)
+		case !exists:
+			return ErrBankLineNotFound
+		}
+
+		var txType, status string
+		err = tx.QueryRowContext(ctx, "SELECT type, status FROM transactions WHERE id = $1", transactionID).Scan(&txType, &status)
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return ErrTransactionNotFound
+		case err != nil:
+			return fmt.Errorf("failed to load transaction: %w", err)
+		case txType != "deposit" && txType != "withdrawal" && txType != "bank_transfer_in":
+			return apperrors.Invalid("transaction_id", "oneof", "only deposits, withdrawals and incoming bank transfers settle at the bank")
+		case status != "posted":
+			return ErrInvalidTransactionState
+		}
+
+		err = tx.QueryRowContext(ctx,
+			`INSERT INTO bank_matches (line_id, transaction_id, rule, matched_by)
+			 VALUES ($1, $2, $3, $4)
+			 ON CONFLICT DO NOTHING
+			 RETURNING id`,
+			lineID, transactionID, reconciliation.ManualRule, operator,
+		).Scan(&matchID)
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return ErrAlreadyMatched
+		case err != nil:
+			return fmt.Errorf("failed to record match: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	logging.FromContext(ctx, r.logger).Info("bank line matched",
+		logging.KeyOp, "bank_match",
+		logging.KeyTxID, transactionID,
+		"line_id", lineID,
+		"operator", operator,
+		logging.KeyOutcome, logging.OutcomeSuccess,
+	)
+	return matchID, nil
+}
+
+// Unmatch undoes a match. The match row is kept with who undid it and why.
+func (r *BankReconciliationRepository) Unmatch(ctx context.Context, matchID int, operator, reason string) error {
+	res, err := r.db.ExecContext(ctx,
+		`UPDATE bank_matches
+		 SET unmatched_by = $1, unmatch_reason = $2, unmatched_at = CURRENT_TIMESTAMP
+		 WHERE id = $3 AND unmatched_at IS NULL`,
+		operator, reason, matchID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to unmatch: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrBankMatchNotFound
+	}
+
+	logging.FromContext(ctx, r.logger).Info("bank match undone",
+		logging.KeyOp, "bank_unmatch",
+		"match_id", matchID,
+		"operator", operator,
+		logging.KeyOutcome, logging.OutcomeSuccess,
+	)
+	return nil
+}
+
+func loadBankStatement(ctx context.Context, tx *sql.Tx, id int, lock bool) (*BankStatementRecord, error) {
+	query := `SELECT id, format, file_name, checksum, period_start, period_end, line_count, imported_by, imported_at
+		 FROM bank_statements WHERE id = $1`
+	if lock {
+		query += " FOR UPDATE"
+	}
+
+	var rec BankStatementRecord
+	err := tx.QueryRowContext(ctx, query, id).Scan(&rec.ID, &rec.Format, &rec.FileName, &rec.Checksum,
+		&rec.PeriodStart, &rec.PeriodEnd, &rec.LineCount, &rec.ImportedBy, &rec.ImportedAt)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return nil, ErrBankStatementNotFound
+	case err != nil:
+		return nil, fmt.Errorf("failed to load bank statement: %w", err)
+	}
+	return &rec, nil
+}
+
+func unmatchedLines(ctx context.Context, tx *sql.Tx, statementID int) ([]BankStatementLine, error) {
+	rows, err := tx.QueryContext(ctx,
+		`SELECT l.id, l.statement_id, l.line_no, l.reference, l.amount, l.booking_date, l.value_date, l.description
+		 FROM bank_statement_lines l
+		 WHERE l.statement_id = $1
+		   AND NOT EXISTS (SELECT 1 FROM bank_matches m WHERE m.line_id = l.id AND m.unmatched_at IS NULL)
+		 ORDER BY l.line_no`,
+		statementID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query bank lines: %w", err)
+	}
+	defer rows.Close()
+
+	var lines []BankStatementLine
+	for rows.Next() {
+		var l BankStatementLine
+		err := rows.Scan(&l.ID, &l.StatementID, &l.LineNo, &l.Reference, &l.Amount, &l.BookingDate, &l.ValueDate, &l.Description)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan bank line: %w", err)
+		}
+		lines = append(lines, l)
+	}
+	return lines, rows.Err()
+}
+
+// unmatchedCandidates returns the posted deposits, withdrawals and incoming
+// bank transfers booked in [from, to) that are not matched to a bank line.
+// The reference of a transaction is its ID, which is what we send as the
+// end-to-end ID.
+func unmatchedCandidates(ctx context.Context, tx *sql.Tx, from, to time.Time) ([]reconciliation.Candidate, error) {
+	rows, err := tx.QueryContext(ctx,
+		`SELECT t.id, t.public_id, CASE WHEN t.type = 'withdrawal' THEN -t.amount ELSE t.amount END, t.posted_at
+		 FROM transactions t
+		 WHERE t.status = 'posted' AND t.type IN ('deposit', 'withdrawal', 'bank_transfer_in')
+		   AND t.posted_at >= $1 AND t.posted_at < $2
+		   AND NOT EXISTS (SELECT 1 FROM bank_matches m WHERE m.transaction_id = t.id AND m.unmatched_at IS NULL)
+		 ORDER BY t.posted_at, t.id`,
+		from, to,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query transactions: %w", err)
+	}
+	defer rows.Close()
+
+	var candidates []reconciliation.Candidate
+	for rows.Next() {
+		var c reconciliation.Candidate
+		if err := rows.Scan(&c.TransactionID, &c.PublicID, &c.Amount, &c.Date); err != nil {
+			return nil, fmt.Errorf("failed to scan transaction: %w", err)
+		}
+		c.Reference = strconv.Itoa(c.TransactionID)
+		candidates = append(candidates, c)
+	}
+	return candidates, rows.Err()
+}
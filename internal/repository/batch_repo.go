@@ -0,0 +1,420 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/Andrew44Ashraf/fintech-service/internal/batches"
+	apperrors "github.com/Andrew44Ashraf/fintech-service/internal/errors"
+	"github.com/Andrew44Ashraf/fintech-service/internal/logging"
+	"github.com/lib/pq"
+)
+
+var ErrBatchNotFound = apperrors.ErrBatchNotFound
+
+// Batch statuses
+const (
+	BatchProcessing         = "processing"
+	BatchCompleted          = "completed"
+	BatchPartiallyCompleted = "partially_completed"
+	BatchFailed             = "failed"
+)
+
+// Batch row statuses. Dry runs report would_post and would_hold instead of
+// posted and held; rows undone because an all-or-nothing batch failed are
+// reported as rolled_back.
+const (
+	RowPosted     = "posted"
+	RowHeld       = "held"
+	RowFailed     = "failed"
+	RowRolledBack = "rolled_back"
+	RowWouldPost  = "would_post"
+	RowWouldHold  = "would_hold"
+)
+
+// Batch is a processed bulk posting file and its per-row report.
+type Batch struct {
+	ID            int
+	Mode          string
+	DryRun        bool
+	Status        string
+	TotalRows     int
+	SucceededRows int
+	FailedRows    int
+	CreatedAt     time.Time
+	CompletedAt   *time.Time
+	Rows          []BatchRow
+}
+
+// BatchRow is the outcome of one instruction.
+type BatchRow struct {
+	batches.Instruction
+	AccountPublicID     string
+	ToAccountPublicID   string
+	Status              string
+	TransactionID       int
+	TransactionPublicID string
+	ErrorCode           string
+	ErrorMessage        string
+}
+
+type BatchRepository struct {
+	db           *sql.DB
+	logger       *slog.Logger
+	transactions *TransactionRepository
+}
+
+// NewBatchRepository returns a repository that posts batch rows through
+// transactions, so rows get the same checks, limits and screening as the
+// single-transaction endpoints.
+func NewBatchRepository(db *sql.DB, logger *slog.Logger, transactions *TransactionRepository) *BatchRepository {
+	return &BatchRepository{db: db, logger: logger, transactions: transactions}
+}
+
+// Process posts a validated batch and stores its report.
+//
+// Every row runs inside one database transaction under its own savepoint,
+// so a failed row never affects the others and every failure in the file
+// is reported. In all_or_nothing mode the transaction is committed only if
+// every row succeeded; in best_effort mode the successful rows are
+// committed. Dry runs are always rolled back. Account locks are held until
+// the batch finishes, so batches should be kept to a few hundred rows.
+func (r *BatchRepository) Process(ctx context.Context, mode string, dryRun bool, instructions []batches.Instruction) (*Batch, error) {
+	b := &Batch{Mode: mode, DryRun: dryRun, Status: BatchProcessing, TotalRows: len(instructions)}
+	err := r.db.QueryRowContext(ctx,
+		"INSERT INTO batches (mode, dry_run, total_rows) VALUES ($1, $2, $3) RETURNING id, created_at",
+		mode, dryRun, len(instructions),
+	).Scan(&b.ID, &b.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create batch: %w", err)
+	}
+
+	// Rows are posted and the report saved in one transaction; when the
+	// rows must not be committed, the report is saved in a second one.
+	var (
+		commit   bool
+		balances []accountBalance
+	)
+	err = withTx(ctx, r.db, r.logger, "batch", nil, func(tx *sql.Tx) error {
+		b.Rows, b.SucceededRows, b.FailedRows, balances = nil, 0, 0, nil
+		for _, in := range instructions {
+			row, err := r.postRow(ctx, tx, in)
+			if err != nil {
+				return err
+			}
+			if row.Status == RowFailed {
+				b.FailedRows++
+			} else {
+				b.SucceededRows++
+			}
+			b.Rows = append(b.Rows, row)
+		}
+		if err := loadBatchPublicIDs(ctx, tx, b.Rows); err != nil {
+			return err
+		}
+
+		commit = !dryRun && (mode == batches.ModeBestEffort || b.FailedRows == 0)
+		if !commit {
+			return errRollback
+		}
+		b.Status = b.outcome()
+		if err := saveBatchReport(ctx, tx, b); err != nil {
+			return err
+		}
+		var err error
+		balances, err = readBalances(ctx, tx, r.transactions.balances, b.postedAccounts())
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	cacheBalances(ctx, r.logger, r.transactions.balances, balances)
+	if !commit {
+		b.undo(mode)
+		b.Status = b.outcome()
+		err := withTx(ctx, r.db, r.logger, "batch_report", nil, func(tx *sql.Tx) error {
+			return saveBatchReport(ctx, tx, b)
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	logging.FromContext(ctx, r.logger).Info("batch processed",
+		logging.KeyOp, "batch",
+		"batch_id", b.ID,
+		"mode", mode,
+		"dry_run", dryRun,
+		"status", b.Status,
+		"succeeded_rows", b.SucceededRows,
+		"failed_rows", b.FailedRows,
+	)
+	return b, nil
+}
+
+// postRow posts one instruction under a savepoint. Business rule failures
+// are recorded on the row; only infrastructure errors are returned.
+func (r *BatchRepository) postRow(ctx context.Context, tx *sql.Tx, in batches.Instruction) (BatchRow, error) {
+	row := BatchRow{Instruction: in}
+	start := time.Now()
+
+	if _, err := tx.ExecContext(ctx, "SAVEPOINT batch_row"); err != nil {
+		return row, fmt.Errorf("failed to create savepoint: %w", err)
+	}
+
+	txID, err := r.post(ctx, tx, in)
+	logOutcome(ctx, r.logger, "batch_"+in.Type, in.AccountID, txID, start, err)
+
+	switch {
+	case err == nil || errors.Is(err, ErrPendingReview):
+		if _, err := tx.ExecContext(ctx, "RELEASE SAVEPOINT batch_row"); err != nil {
+			return row, fmt.Errorf("failed to release savepoint: %w", err)
+		}
+		row.TransactionID = txID
+		row.Status = RowPosted
+		if err != nil {
+			row.Status = RowHeld
+		}
+	default:
+		if _, rerr := tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT batch_row"); rerr != nil {
+			return row, fmt.Errorf("failed to roll back savepoint: %w", rerr)
+		}
+		// A deadlock or serialization failure is the batch's, not the row's:
+		// the whole batch runs again
+		if _, retry := retryableState(err); retry {
+			return row, err
+		}
+		entry := apperrors.Lookup(err)
+		row.Status = RowFailed
+		row.ErrorCode = string(entry.Code)
+		row.ErrorMessage = entry.Title
+		if entry.Client() {
+			row.ErrorMessage = err.Error()
+		}
+	}
+	return row, nil
+}
+
+func (r *BatchRepository) post(ctx context.Context, tx *sql.Tx, in batches.Instruction) (int, error) {
+	switch in.Type {
+	case batches.TypeDeposit:
+		return r.transactions.deposit(ctx, tx, in.AccountID, in.Amount, Details{})
+	case batches.TypeWithdrawal:
+		return r.transactions.withdraw(ctx, tx, in.AccountID, in.Amount, Details{})
+	case batches.TypeTransfer:
+		return r.transactions.transfer(ctx, tx, in.AccountID, in.ToAccountID, in.Amount)
+	default:
+		return 0, fmt.Errorf("%w: unknown instruction type %q", ErrInvalidTransaction, in.Type)
+	}
+}
+
+// undo rewrites the report of a batch whose transaction was rolled back.
+func (b *Batch) undo(mode string) {
+	failed := mode == batches.ModeAllOrNothing && b.FailedRows > 0
+	for i := range b.Rows {
+		row := &b.Rows[i]
+		if row.Status == RowFailed {
+			continue
+		}
+		row.TransactionID, row.TransactionPublicID = 0, ""
+		switch {
+		case failed:
+			row.Status = RowRolledBack
+		case row.Status == RowHeld:
+			row.Status = RowWouldHold
+		default:
+			row.Status = RowWouldPost
+		}
+	}
+	if failed {
+		b.SucceededRows = 0
+	}
+}
+
+// postedAccounts returns the accounts whose balance the posted rows
+// changed.
+func (b *Batch) postedAccounts() []int {
+	var accountIDs []int
+	for _, row := range b.Rows {
+		if row.Status != RowPosted {
+			continue
+		}
+		accountIDs = append(accountIDs, row.AccountID)
+		if row.ToAccountID != 0 {
+			accountIDs = append(accountIDs, row.ToAccountID)
+		}
+	}
+	return accountIDs
+}
+
+func (b *Batch) outcome() string {
+	switch {
+	case b.FailedRows == 0:
+		return BatchCompleted
+	case b.SucceededRows == 0:
+		return BatchFailed
+	default:
+		return BatchPartiallyCompleted
+	}
+}
+
+// loadBatchPublicIDs sets the public IDs of the accounts and transactions
+// of the rows, which the report shows instead of the internal keys.
+func loadBatchPublicIDs(ctx context.Context, tx *sql.Tx, rows []BatchRow) error {
+	var accountIDs, txIDs []int
+	for _, row := range rows {
+		accountIDs = append(accountIDs, row.AccountID)
+		if row.ToAccountID != 0 {
+			accountIDs = append(accountIDs, row.ToAccountID)
+		}
+		if row.TransactionID != 0 {
+			txIDs = append(txIDs, row.TransactionID)
+		}
+	}
+
+	accounts, err := publicIDsOf(ctx, tx, "SELECT id, public_id FROM accounts WHERE id = ANY($1)", accountIDs)
+	if err != nil {
+		return fmt.Errorf("failed to load account IDs: %w", err)
+	}
+	transactions, err := publicIDsOf(ctx, tx, "SELECT id, public_id FROM transactions WHERE id = ANY($1)", txIDs)
+	if err != nil {
+		return fmt.Errorf("failed to load transaction IDs: %w", err)
+	}
+	for i := range rows {
+		row := &rows[i]
+		row.AccountPublicID, row.ToAccountPublicID = accounts[row.AccountID], accounts[row.ToAccountID]
+		row.TransactionPublicID = transactions[row.TransactionID]
+	}
+	return nil
+}
+
+func publicIDsOf(ctx context.Context, tx *sql.Tx, query string, internalIDs []int) (map[int]string, error) {
+	publicIDs := make(map[int]string, len(internalIDs))
+	if len(internalIDs) == 0 {
+		return publicIDs, nil
+	}
+
+	rows, err := tx.QueryContext(ctx, query, pq.Array(internalIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var (
+			id       int
+			publicID string
+		)
+		if err := rows.Scan(&id, &publicID); err != nil {
+			return nil, err
+		}
+		publicIDs[id] = publicID
+	}
+	return publicIDs, rows.Err()
+}
+
+func saveBatchReport(ctx context.Context, tx *sql.Tx, b *Batch) error {
+	stmt, err := tx.PrepareContext(ctx,
+		`INSERT INTO batch_rows
+		 (batch_id, row_number, type, account_id, to_account_id, amount, reference, status, transaction_id, error_code, error_message)
+		 VALUES ($1, $2, $3, $4, NULLIF($5, 0), $6, NULLIF($7, ''), $8, NULLIF($9, 0), NULLIF($10, ''), NULLIF($11, ''))`,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to prepare batch report: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, row := range b.Rows {
+		_, err := stmt.ExecContext(ctx,
+			b.ID, row.Row, row.Type, row.AccountID, row.ToAccountID, row.Amount, row.Reference,
+			row.Status, row.TransactionID, row.ErrorCode, row.ErrorMessage,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to save batch row: %w", err)
+		}
+	}
+
+	var completedAt time.Time
+	err = tx.QueryRowContext(ctx,
+		`UPDATE batches
+		 SET status = $1, succeeded_rows = $2, failed_rows = $3, completed_at = CURRENT_TIMESTAMP
+		 WHERE id = $4
+		 RETURNING completed_at`,
+		b.Status, b.SucceededRows, b.FailedRows, b.ID,
+	).Scan(&completedAt)
+	if err != nil {
+		return fmt.Errorf("failed to update batch: %w", err)
+	}
+	b.CompletedAt = &completedAt
+	return nil
+}
+
+// GetBatch returns a batch with its per-row report.
+func (r *BatchRepository) GetBatch(ctx context.Context, id int) (*Batch, error) {
+	var (
+		b           Batch
+		completedAt sql.NullTime
+	)
+	err := r.db.QueryRowContext(ctx,
+		`SELECT id, mode, dry_run, status, total_rows, succeeded_rows, failed_rows, created_at, completed_at
+		 FROM batches WHERE id = $1`,
+		id,
+	).Scan(&b.ID, &b.Mode, &b.DryRun, &b.Status, &b.TotalRows, &b.SucceededRows, &b.FailedRows, &b.CreatedAt, &completedAt)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return nil, ErrBatchNotFound
+	case err != nil:
+		return nil, fmt.Errorf("failed to load batch: %w", err)
+	}
+	if completedAt.Valid {
+		b.CompletedAt = &completedAt.Time
+	}
+
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT r.row_number, r.type, r.account_id, COALESCE(a.public_id, ''),
+		        COALESCE(r.to_account_id, 0), COALESCE(ta.public_id, ''), r.amount, COALESCE(r.reference, ''),
+		        r.status, COALESCE(r.transaction_id, 0), COALESCE(t.public_id, ''),
+		        COALESCE(r.error_code, ''), COALESCE(r.error_message, '')
+		 FROM batch_rows r
+		 LEFT JOIN accounts a ON a.id = r.account_id
+		 LEFT JOIN accounts ta ON ta.id = r.to_account_id
+		 LEFT JOIN transactions t ON t.id = r.transaction_id
+		 WHERE r.batch_id = $1
+		 ORDER BY r.row_number`,
+		id,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query batch rows: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var row BatchRow
+		if err := rows.Scan(
+			&row.Row,
+			&row.Type,
+			&row.AccountID,
+			&row.AccountPublicID,
+			&row.ToAccountID,
+			&row.ToAccountPublicID,
+			&row.Amount,
+			&row.Reference,
+			&row.Status,
+			&row.TransactionID,
+			&row.TransactionPublicID,
+			&row.ErrorCode,
+			&row.ErrorMessage,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan batch row: %w", err)
+		}
+		b.Rows = append(b.Rows, row)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	return &b, nil
+}
@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"unicode/utf8"
+
+	apperrors "github.com/Andrew44Ashraf/fintech-service/internal/errors"
+	"github.com/lib/pq"
+)
+
+var ErrDuplicateExternalRef = apperrors.ErrDuplicateExternalRef
+
+// Size limits of Details, matching the columns.
+const (
+	MaxExternalRefLength = 64
+	MaxDescriptionLength = 255
+	MaxMetadataSize      = 4096
+)
+
+// Details are an integrator's own reference, label and metadata on an
+// account or a transaction. ExternalRef is unique across accounts and, for
+// transactions, per account, so it doubles as a dedup key.
+type Details struct {
+	ExternalRef string
+	Description string
+	// Metadata is a JSON object; empty means {}.
+	Metadata json.RawMessage
+}
+
+func (d Details) validate() error {
+	switch {
+	case utf8.RuneCountInString(d.ExternalRef) > MaxExternalRefLength:
+		return apperrors.Invalid("external_ref", "max", fmt.Sprintf("must be at most %d characters", MaxExternalRefLength))
+	case utf8.RuneCountInString(d.Description) > MaxDescriptionLength:
+		return apperrors.Invalid("description", "max", fmt.Sprintf("must be at most %d characters", MaxDescriptionLength))
+	case len(d.Metadata) > MaxMetadataSize:
+		return apperrors.Invalid("metadata", "max", fmt.Sprintf("must be at most %d bytes", MaxMetadataSize))
+	case len(d.Metadata) > 0 && (!json.Valid(d.Metadata) || !bytes.HasPrefix(bytes.TrimSpace(d.Metadata), []byte("{"))):
+		return apperrors.Invalid("metadata", "json", "must be a JSON object")
+	}
+	return nil
+}
+
+// metadata returns the metadata as a query argument; nil lets the column
+// default apply.
+func (d Details) metadata() any {
+	if len(d.Metadata) == 0 {
+		return nil
+	}
+	return string(d.Metadata)
+}
+
+// checkExternalRef rejects a transaction external_ref the account has
+// used before. Callers hold the account lock, so the check cannot race
+// with another posting to the account.
+func checkExternalRef(ctx context.Context, tx *sql.Tx, accountID int, externalRef string) error {
+	if externalRef == "" {
+		return nil
+	}
+	var txID int
+	err := tx.QueryRowContext(ctx,
+		"SELECT id FROM transactions WHERE account_id = $1 AND external_ref = $2",
+		accountID, externalRef,
+	).Scan(&txID)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return nil
+	case err != nil:
+		return fmt.Errorf("failed to check external_ref: %w", err)
+	}
+	return fmt.Errorf("%w: transaction %d has external_ref %q", ErrDuplicateExternalRef, txID, externalRef)
+}
+
+// isUniqueViolation reports whether err is a unique_violation of the
+// named index.
+func isUniqueViolation(err error, index string) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505" && pqErr.Constraint == index
+}
+
+// scanMetadata converts a scanned metadata column back to JSON, dropping
+// the empty object.
+func scanMetadata(b []byte) json.RawMessage {
+	if len(b) == 0 || string(b) == "{}" {
+		return nil
+	}
+	return json.RawMessage(b)
+}
@@ -0,0 +1,587 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"time"
+
+	"github.com/Andrew44Ashraf/fintech-service/internal/cache"
+	apperrors "github.com/Andrew44Ashraf/fintech-service/internal/errors"
+	"github.com/Andrew44Ashraf/fintech-service/internal/fees"
+	"github.com/Andrew44Ashraf/fintech-service/internal/logging"
+	"github.com/Andrew44Ashraf/fintech-service/internal/models"
+)
+
+var (
+	ErrFeeWaiverNotFound  = apperrors.ErrFeeWaiverNotFound
+	ErrFeeWaiverRevoked   = apperrors.ErrFeeWaiverRevoked
+	ErrFeeAlreadyReversed = apperrors.ErrFeeAlreadyReversed
+)
+
+// Fee audit log actions
+const (
+	FeeAuditWaiverGranted = "waiver_granted"
+	FeeAuditWaiverRevoked = "waiver_revoked"
+	FeeAuditFeeReversed   = "fee_reversed"
+)
+
+// FeeWaiver exempts an account from one fee type for a period. A nil
+// EndsAt means until revoked.
+type FeeWaiver struct {
+	ID              int
+	AccountID       int
+	AccountPublicID string
+	FeeType         fees.Type
+	StartsAt        time.Time
+	EndsAt          *time.Time
+	Reason          string
+	GrantedBy       string
+	RevokedBy       string
+	RevokedAt       *time.Time
+	CreatedAt       time.Time
+}
+
+// FeeAuditEntry is one operator action on fees.
+type FeeAuditEntry struct {
+	ID                  int
+	AccountID           int
+	Action              string
+	FeeType             string
+	WaiverID            int
+	TransactionID       int
+	TransactionPublicID string
+	Amount              float64
+	Operator            string
+	Reason              string
+	CreatedAt           time.Time
+}
+
+type FeeRepository struct {
+	db       *sql.DB
+	logger   *slog.Logger
+	balances cache.Store
+}
+
+// FeeOption configures optional FeeRepository behaviour.
+type FeeOption func(*FeeRepository)
+
+func NewFeeRepository(db *sql.DB, logger *slog.Logger, opts ...FeeOption) *FeeRepository {
+	r := &FeeRepository{db: db, logger: logger}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// withdrawalFee returns the fee the account's product charges per
+// withdrawal, or 0 when it charges none or a waiver is active.
+func withdrawalFee(ctx context.Context, tx *sql.Tx, accountID int) (float64, error) {
+	var fee float64
+	err := tx.QueryRowContext(ctx,
+		`SELECT f.withdrawal_fee
+		 FROM accounts a
+		 JOIN fee_schedules f ON f.product_code = a.product_code
+		 WHERE a.id = $1 AND f.withdrawal_fee > 0
+		   AND NOT EXISTS (
+		       SELECT 1 FROM fee_waivers w
+		       WHERE w.account_id = a.id AND w.fee_type = 'withdrawal' AND w.revoked_at IS NULL
+		         AND w.starts_at <= CURRENT_TIMESTAMP
+		         AND (w.ends_at IS NULL OR w.ends_at > CURRENT_TIMESTAMP))`,
+		accountID,
+	).Scan(&fee)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return 0, nil
+	case err != nil:
+		return 0, fmt.Errorf("failed to load fee schedule: %w", err)
+	}
+	return fee, nil
+}
+
+// postFee books a fee or a fee reversal on a locked account. relatedTxID
+// is the withdrawal a fee was charged for, or the fee a reversal refunds.
+func postFee(ctx context.Context, tx *sql.Tx, accountID int, txType models.TransactionType, feeType fees.Type, amount float64, relatedTxID int) (int, error) {
+	var finalBalance float64
+	err := tx.QueryRowContext(ctx,
+		"UPDATE accounts SET balance = balance + $1 WHERE id = $2 RETURNING balance",
+		txType.Signed(amount), accountID,
+	).Scan(&finalBalance)
+	if err != nil {
+		return 0, fmt.Errorf("balance update failed: %w", err)
+	}
+
+	var txID int
+	err = tx.QueryRowContext(ctx,
+		`INSERT INTO transactions (account_id, amount, type, final_balance, fee_type, related_transaction_id)
+		 VALUES ($1, $2, $3, $4, NULLIF($5, ''), NULLIF($6, 0))
+		 RETURNING id`,
+		accountID, amount, string(txType), finalBalance, string(feeType), relatedTxID,
+	).Scan(&txID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create transaction: %w", err)
+	}
+	return txID, nil
+}
+
+// AccountsDueMonthlyFees returns the open accounts on products with
+// monthly fees that have no fee run for the month starting at from.
+func (r *FeeRepository) AccountsDueMonthlyFees(ctx context.Context, from, to time.Time) ([]int, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT a.id
+		 FROM accounts a
+		 JOIN fee_schedules f ON f.product_code = a.product_code
+		 WHERE a.status <> 'closed' AND a.created_at < $2
+		   AND (f.maintenance_fee > 0 OR f.low_balance_fee > 0)
+		   AND NOT EXISTS (
+		       SELECT 1 FROM monthly_fee_runs m
+		       WHERE m.account_id = a.id AND m.period_start = $1)
+		 ORDER BY a.id`,
+		from, to,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query accounts: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan account: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// ApplyMonthlyFees charges the maintenance and low-balance fees of the
+// month [from, to). The run is recorded first, so a second call for the
+// same month charges nothing. A fee is waived when a waiver overlaps the
+// month. Fees never push an account into overdraft: a fee larger than the
+// balance is charged only up to the balance. It returns the number of fees charged.
+func (r *FeeRepository) ApplyMonthlyFees(ctx context.Context, accountID int, from, to time.Time) (charged int, err error) {
+	start := time.Now()
+	defer func() {
+		if charged > 0 || err != nil {
+			logOutcome(ctx, r.logger, "monthly_fees", accountID, 0, start, err)
+		}
+	}()
+
+	var balances []accountBalance
+	err = withTx(ctx, r.db, r.logger, "monthly_fees", nil, func(tx *sql.Tx) error {
+		charged, balances = 0, nil
+		var (
+			balance float64
+			status  string
+		)
+		err := tx.QueryRowContext(ctx,
+			"SELECT balance, status FROM accounts WHERE id = $1 FOR UPDATE",
+			accountID,
+		).Scan(&balance, &status)
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return ErrAccountNotFound
+		case err != nil:
+			return fmt.Errorf("account verification failed: %w", err)
+		case status == "closed":
+			return errRollback
+		}
+
+		schedule, err := loadFeeSchedule(ctx, tx, accountID)
+		if err != nil {
+			return err
+		}
+		lowest, err := lowestBalance(ctx, tx, accountID, from, to)
+		if err != nil {
+			return err
+		}
+
+		res, err := tx.ExecContext(ctx,
+			`INSERT INTO monthly_fee_runs (account_id, period_start, lowest_balance)
+			 VALUES ($1, $2, $3)
+			 ON CONFLICT (account_id, period_start) DO NOTHING`,
+			accountID, from, lowest,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to record fee run: %w", err)
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			return errRollback
+		}
+
+		for _, c := range schedule.Monthly(lowest) {
+			waiverID, err := overlappingWaiver(ctx, tx, accountID, c.Type, from, to)
+			if err != nil {
+				return err
+			}
+
+			var txID int
+			amount := c.Amount
+			if waiverID == 0 {
+				amount = math.Max(0, math.Min(amount, balance))
+			}
+			if waiverID == 0 && amount > 0 {
+				txID, err = postFee(ctx, tx, accountID, models.Fee, c.Type, amount, 0)
+				if err != nil {
+					return err
+				}
+				balance -= amount
+				charged++
+			}
+
+			_, err = tx.ExecContext(ctx,
+				`INSERT INTO monthly_fee_charges (account_id, period_start, fee_type, amount, transaction_id, waiver_id)
+				 VALUES ($1, $2, $3, $4, NULLIF($5, 0), NULLIF($6, 0))`,
+				accountID, from, string(c.Type), amount, txID, waiverID,
+			)
+			if err != nil {
+				return fmt.Errorf("failed to record fee charge: %w", err)
+			}
+		}
+		if charged == 0 {
+			return nil
+		}
+		balances, err = readBalances(ctx, tx, r.balances, []int{accountID})
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	cacheBalances(ctx, r.logger, r.balances, balances)
+	return charged, nil
+}
+
+func loadFeeSchedule(ctx context.Context, tx *sql.Tx, accountID int) (fees.Schedule, error) {
+	var s fees.Schedule
+	err := tx.QueryRowContext(ctx,
+		`SELECT f.product_code, f.withdrawal_fee, f.maintenance_fee, f.low_balance_threshold, f.low_balance_fee
+		 FROM accounts a
+		 JOIN fee_schedules f ON f.product_code = a.product_code
+		 WHERE a.id = $1`,
+		accountID,
+	).Scan(&s.ProductCode, &s.WithdrawalFee, &s.MaintenanceFee, &s.LowBalanceThreshold, &s.LowBalanceFee)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return fees.Schedule{}, nil
+	case err != nil:
+		return fees.Schedule{}, fmt.Errorf("failed to load fee schedule: %w", err)
+	}
+	return s, nil
+}
+
+// lowestBalance returns the lowest balance the account had during
+// [from, to): the smaller of the balance at from and every posted balance
+// in between. The balance at from is worked back from the current balance
+// when the account has no earlier transaction.
+func lowestBalance(ctx context.Context, tx *sql.Tx, accountID int, from, to time.Time) (float64, error) {
+	var lowest float64
+	err := tx.QueryRowContext(ctx,
+		`SELECT LEAST(
+		     COALESCE(
+		         (SELECT final_balance FROM transactions
+		          WHERE account_id = $1 AND status = 'posted' AND posted_at < $2
+		          ORDER BY posted_at DESC, id DESC
+		          LIMIT 1),
+		         (SELECT a.balance - COALESCE(SUM(CASE WHEN t.type = ANY($4) THEN t.amount ELSE -t.amount END), 0)
+		          FROM accounts a
+		          LEFT JOIN transactions t ON t.account_id = a.id AND t.status = 'posted'
+		          WHERE a.id = $1
+		          GROUP BY a.balance)),
+		     (SELECT MIN(final_balance) FROM transactions
+		      WHERE account_id = $1 AND status = 'posted' AND posted_at >= $2 AND posted_at < $3))`,
+		accountID, from, to, creditTypes,
+	).Scan(&lowest)
+	if err != nil {
+		return 0, fmt.Errorf("failed to compute lowest balance: %w", err)
+	}
+	return lowest, nil
+}
+
+// overlappingWaiver returns the ID of a waiver for feeType in force at any
+// point of [from, to), or 0.
+func overlappingWaiver(ctx context.Context, tx *sql.Tx, accountID int, feeType fees.Type, from, to time.Time) (int, error) {
+	var id int
+	err := tx.QueryRowContext(ctx,
+		`SELECT id FROM fee_waivers
+		 WHERE account_id = $1 AND fee_type = $2
+		   AND starts_at < $4 AND (ends_at IS NULL OR ends_at > $3)
+		   AND (revoked_at IS NULL OR revoked_at > $3)
+		 ORDER BY id
+		 LIMIT 1`,
+		accountID, string(feeType), from, to,
+	).Scan(&id)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return 0, nil
+	case err != nil:
+		return 0, fmt.Errorf("failed to load fee waivers: %w", err)
+	}
+	return id, nil
+}
+
+// ReverseFee refunds a posted fee with a fee_reversal transaction and
+// records the operator's reason in the fee audit log. A fee can be
+// reversed once.
+func (r *FeeRepository) ReverseFee(ctx context.Context, feeTxID int, operator, reason string) (txID int, err error) {
+	start := time.Now()
+	var accountID int
+	defer func() { logOutcome(ctx, r.logger, "fee_reversal", accountID, txID, start, err) }()
+
+	var balances []accountBalance
+	err = withTx(ctx, r.db, r.logger, "fee_reversal", nil, func(tx *sql.Tx) error {
+		balances = nil
+		var (
+			amount          float64
+			txType, status  string
+			feeType         sql.NullString
+			alreadyReversed bool
+		)
+		err := tx.QueryRowContext(ctx,
+			`SELECT account_id, amount, type, status, fee_type,
+			        EXISTS (SELECT 1 FROM transactions r WHERE r.related_transaction_id = t.id AND r.type = 'fee_reversal')
+			 FROM transactions t
+			 WHERE t.id = $1
+			 FOR UPDATE`,
+			feeTxID,
+		).Scan(&accountID, &amount, &txType, &status, &feeType, &alreadyReversed)
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return ErrTransactionNotFound
+		case err != nil:
+			return fmt.Errorf("failed to load transaction: %w", err)
+		case txType != string(models.Fee):
+			return fmt.Errorf("%w: transaction %d is not a fee", ErrInvalidTransaction, feeTxID)
+		case status != "posted":
+			return ErrInvalidTransactionState
+		case alreadyReversed:
+			return ErrFeeAlreadyReversed
+		}
+
+		var accountStatus string
+		err = tx.QueryRowContext(ctx,
+			"SELECT status FROM accounts WHERE id = $1 FOR UPDATE",
+			accountID,
+		).Scan(&accountStatus)
+		switch {
+		case err != nil:
+			return fmt.Errorf("account verification failed: %w", err)
+		case accountStatus != AccountActive:
+			return inactiveAccountError(accountStatus)
+		}
+
+		txID, err = postFee(ctx, tx, accountID, models.FeeReversal, fees.Type(feeType.String), amount, feeTxID)
+		if err != nil {
+			return err
+		}
+
+		err = recordFeeAudit(ctx, tx, FeeAuditEntry{
+			AccountID:     accountID,
+			Action:        FeeAuditFeeReversed,
+			FeeType:       feeType.String,
+			TransactionID: feeTxID,
+			Amount:        amount,
+			Operator:      operator,
+			Reason:        reason,
+		})
+		if err != nil {
+			return err
+		}
+		balances, err = readBalances(ctx, tx, r.balances, []int{accountID})
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	cacheBalances(ctx, r.logger, r.balances, balances)
+	return txID, nil
+}
+
+// GrantWaiver stores w and records the grant in the fee audit log.
+func (r *FeeRepository) GrantWaiver(ctx context.Context, w *FeeWaiver) error {
+	switch {
+	case !w.FeeType.Valid():
+		return apperrors.Invalid("fee_type", "oneof", "must be one of withdrawal, maintenance or low_balance")
+	case w.EndsAt != nil && !w.EndsAt.After(w.StartsAt):
+		return apperrors.Invalid("ends_at", "gtfield", "must be after starts_at")
+	}
+
+	err := withTx(ctx, r.db, r.logger, "grant_fee_waiver", nil, func(tx *sql.Tx) error {
+		var status string
+		err := tx.QueryRowContext(ctx,
+			"SELECT status, public_id FROM accounts WHERE id = $1",
+			w.AccountID,
+		).Scan(&status, &w.AccountPublicID)
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return ErrAccountNotFound
+		case err != nil:
+			return fmt.Errorf("account verification failed: %w", err)
+		case status == "closed":
+			return ErrAccountClosed
+		}
+
+		err = tx.QueryRowContext(ctx,
+			`INSERT INTO fee_waivers (account_id, fee_type, starts_at, ends_at, reason, granted_by)
+			 VALUES ($1, $2, $3, $4, $5, $6)
+			 RETURNING id, created_at`,
+			w.AccountID, string(w.FeeType), w.StartsAt.UTC(), w.EndsAt, w.Reason, w.GrantedBy,
+		).Scan(&w.ID, &w.CreatedAt)
+		if err != nil {
+			return fmt.Errorf("failed to create fee waiver: %w", err)
+		}
+
+		return recordFeeAudit(ctx, tx, FeeAuditEntry{
+			AccountID: w.AccountID,
+			Action:    FeeAuditWaiverGranted,
+			FeeType:   string(w.FeeType),
+			WaiverID:  w.ID,
+			Operator:  w.GrantedBy,
+			Reason:    w.Reason,
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	logging.FromContext(ctx, r.logger).Info("fee waiver granted",
+		logging.KeyOp, "grant_fee_waiver",
+		logging.KeyAccountID, w.AccountID,
+		"fee_waiver_id", w.ID,
+		"fee_type", w.FeeType,
+		logging.KeyOutcome, logging.OutcomeSuccess,
+	)
+	return nil
+}
+
+// RevokeWaiver ends a waiver now and records the revocation in the fee
+// audit log.
+func (r *FeeRepository) RevokeWaiver(ctx context.Context, waiverID int, operator, reason string) error {
+	var accountID int
+	err := withTx(ctx, r.db, r.logger, "revoke_fee_waiver", nil, func(tx *sql.Tx) error {
+		var feeType string
+		err := tx.QueryRowContext(ctx,
+			`UPDATE fee_waivers SET revoked_by = $2, revoked_at = CURRENT_TIMESTAMP
+			 WHERE id = $1 AND revoked_at IS NULL
+			 RETURNING account_id, fee_type`,
+			waiverID, operator,
+		).Scan(&accountID, &feeType)
+		if errors.Is(err, sql.ErrNoRows) {
+			var exists bool
+			if err := tx.QueryRowContext(ctx,
+				"SELECT EXISTS (SELECT 1 FROM fee_waivers WHERE id = $1)",
+				waiverID,
+			).Scan(&exists); err != nil {
+				return fmt.Errorf("failed to load fee waiver: %w", err)
+			}
+			if exists {
+				return ErrFeeWaiverRevoked
+			}
+			return ErrFeeWaiverNotFound
+		}
+		if err != nil {
+			return fmt.Errorf("failed to revoke fee waiver: %w", err)
+		}
+
+		return recordFeeAudit(ctx, tx, FeeAuditEntry{
+			AccountID: accountID,
+			Action:    FeeAuditWaiverRevoked,
+			FeeType:   feeType,
+			WaiverID:  waiverID,
+			Operator:  operator,
+			Reason:    reason,
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	logging.FromContext(ctx, r.logger).Info("fee waiver revoked",
+		logging.KeyOp, "revoke_fee_waiver",
+		logging.KeyAccountID, accountID,
+		"fee_waiver_id", waiverID,
+		logging.KeyOutcome, logging.OutcomeSuccess,
+	)
+	return nil
+}
+
+func recordFeeAudit(ctx context.Context, tx *sql.Tx, e FeeAuditEntry) error {
+	_, err := tx.ExecContext(ctx,
+		`INSERT INTO fee_audit_log (account_id, action, fee_type, waiver_id, transaction_id, amount, operator, reason)
+		 VALUES ($1, $2, NULLIF($3, ''), NULLIF($4, 0), NULLIF($5, 0), NULLIF($6, 0), $7, $8)`,
+		e.AccountID, e.Action, e.FeeType, e.WaiverID, e.TransactionID, e.Amount, e.Operator, e.Reason,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record fee audit entry: %w", err)
+	}
+	return nil
+}
+
+// ListWaivers returns the account's fee waivers, newest first.
+func (r *FeeRepository) ListWaivers(ctx context.Context, accountID int) ([]FeeWaiver, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT w.id, w.account_id, a.public_id, w.fee_type, w.starts_at, w.ends_at, w.reason, w.granted_by,
+		        COALESCE(w.revoked_by, ''), w.revoked_at, w.created_at
+		 FROM fee_waivers w
+		 JOIN accounts a ON a.id = w.account_id
+		 WHERE w.account_id = $1
+		 ORDER BY w.id DESC`,
+		accountID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query fee waivers: %w", err)
+	}
+	defer rows.Close()
+
+	var waivers []FeeWaiver
+	for rows.Next() {
+		var (
+			w                FeeWaiver
+			feeType          string
+			endsAt, revokeAt sql.NullTime
+		)
+		err := rows.Scan(&w.ID, &w.AccountID, &w.AccountPublicID, &feeType, &w.StartsAt, &endsAt, &w.Reason, &w.GrantedBy,
+			&w.RevokedBy, &revokeAt, &w.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan fee waiver: %w", err)
+		}
+		w.FeeType = fees.Type(feeType)
+		w.EndsAt, w.RevokedAt = nullTime(endsAt), nullTime(revokeAt)
+		waivers = append(waivers, w)
+	}
+	return waivers, rows.Err()
+}
+
+// ListFeeAudit returns the account's fee audit log, newest first.
+func (r *FeeRepository) ListFeeAudit(ctx context.Context, accountID, limit, offset int) ([]FeeAuditEntry, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT l.id, l.account_id, l.action, COALESCE(l.fee_type, ''), COALESCE(l.waiver_id, 0),
+		        COALESCE(l.transaction_id, 0), COALESCE(t.public_id, ''), COALESCE(l.amount, 0),
+		        l.operator, l.reason, l.created_at
+		 FROM fee_audit_log l
+		 LEFT JOIN transactions t ON t.id = l.transaction_id
+		 WHERE l.account_id = $1
+		 ORDER BY l.created_at DESC, l.id DESC
+		 LIMIT $2 OFFSET $3`,
+		accountID, limit, offset,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query fee audit log: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []FeeAuditEntry
+	for rows.Next() {
+		var e FeeAuditEntry
+		err := rows.Scan(&e.ID, &e.AccountID, &e.Action, &e.FeeType, &e.WaiverID,
+			&e.TransactionID, &e.TransactionPublicID, &e.Amount, &e.Operator, &e.Reason, &e.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan fee audit entry: %w", err)
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
@@ -0,0 +1,168 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Andrew44Ashraf/fintech-service/internal/models"
+)
+
+// CreateIncomingTransfer records a transfer announced by another bank as
+// pending. It shows in history but leaves the balance alone until
+// PostPendingTransaction; FailPendingTransaction drops it if it does not
+// settle. A zero valueDate means today. Details are checked as for
+// CreateDeposit.
+func (r *TransactionRepository) CreateIncomingTransfer(ctx context.Context, accountID int, amount float64, valueDate time.Time, details Details) (txID int, err error) {
+	start := time.Now()
+	defer func() { logOutcome(ctx, r.logger, "incoming_transfer", accountID, txID, start, err) }()
+	if err := details.validate(); err != nil {
+		return 0, err
+	}
+	if amount <= 0 {
+		return 0, ErrNegativeAmount
+	}
+
+	var date sql.NullTime
+	if !valueDate.IsZero() {
+		date = sql.NullTime{Time: valueDate, Valid: true}
+	}
+
+	err = withTx(ctx, r.db, r.logger, "incoming_transfer", nil, func(tx *sql.Tx) error {
+		var accountStatus string
+		err := tx.QueryRowContext(ctx,
+			"SELECT status FROM accounts WHERE id = $1 FOR UPDATE",
+			accountID,
+		).Scan(&accountStatus)
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return ErrAccountNotFound
+		case err != nil:
+			return fmt.Errorf("account verification failed: %w", err)
+		case accountStatus != AccountActive:
+			return inactiveAccountError(accountStatus)
+		}
+		if err := checkExternalRef(ctx, tx, accountID, details.ExternalRef); err != nil {
+			return err
+		}
+
+		rules, err := loadAccountRules(ctx, tx, accountID)
+		if err != nil {
+			return err
+		}
+		if !rules[accountID].allows(string(models.BankTransferIn)) {
+			return ErrTransactionNotAllowed
+		}
+
+		// Like a held transaction it carries the current balance until it
+		// is posted
+		err = tx.QueryRowContext(ctx,
+			`INSERT INTO transactions (account_id, amount, type, status, final_balance, value_date, external_ref, description, metadata)
+			 SELECT $1, $2, $3, 'pending', balance, COALESCE($4::date, CURRENT_DATE), NULLIF($5, ''), NULLIF($6, ''), COALESCE($7::jsonb, '{}')
+			 FROM accounts WHERE id = $1
+			 RETURNING id`,
+			accountID, amount, string(models.BankTransferIn), date, details.ExternalRef, details.Description, details.metadata(),
+		).Scan(&txID)
+		if isUniqueViolation(err, "idx_transactions_external_ref") {
+			return fmt.Errorf("%w: external_ref %q", ErrDuplicateExternalRef, details.ExternalRef)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to create transaction: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return txID, nil
+}
+
+// PostPendingTransaction credits a pending transaction to its account once
+// it has settled. Its booking date is the day it is posted; the value date
+// recorded with it is kept.
+func (r *TransactionRepository) PostPendingTransaction(ctx context.Context, txID int) (err error) {
+	start := time.Now()
+	var (
+		accountID int
+		balances  []accountBalance
+	)
+	defer func() { logOutcome(ctx, r.logger, "post_pending", accountID, txID, start, err) }()
+
+	err = withTx(ctx, r.db, r.logger, "post_pending", nil, func(tx *sql.Tx) error {
+		var (
+			amount float64
+			txType string
+			err    error
+		)
+		accountID, amount, txType, err = lockInStatus(ctx, tx, txID, StatusPending)
+		if err != nil {
+			return err
+		}
+
+		var accountStatus string
+		err = tx.QueryRowContext(ctx,
+			"SELECT status FROM accounts WHERE id = $1 FOR UPDATE",
+			accountID,
+		).Scan(&accountStatus)
+		switch {
+		case err != nil:
+			return fmt.Errorf("account verification failed: %w", err)
+		case accountStatus != AccountActive:
+			return inactiveAccountError(accountStatus)
+		}
+
+		var finalBalance float64
+		err = tx.QueryRowContext(ctx,
+			"UPDATE accounts SET balance = balance + $1 WHERE id = $2 RETURNING balance",
+			models.TransactionType(txType).Signed(amount), accountID,
+		).Scan(&finalBalance)
+		if err != nil {
+			return fmt.Errorf("balance update failed: %w", err)
+		}
+
+		// The transactions_posted_at trigger stamps posted_at and with it
+		// the booking date
+		_, err = tx.ExecContext(ctx,
+			"UPDATE transactions SET status = 'posted', final_balance = $1 WHERE id = $2",
+			finalBalance, txID,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to update transaction record: %w", err)
+		}
+
+		balances, err = readBalances(ctx, tx, r.balances, []int{accountID})
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	cacheBalances(ctx, r.logger, r.balances, balances)
+	return nil
+}
+
+// FailPendingTransaction marks a pending transaction that did not settle as
+// failed. The balance is untouched.
+func (r *TransactionRepository) FailPendingTransaction(ctx context.Context, txID int) (err error) {
+	start := time.Now()
+	var accountID int
+	defer func() { logOutcome(ctx, r.logger, "fail_pending", accountID, txID, start, err) }()
+
+	return withTx(ctx, r.db, r.logger, "fail_pending", nil, func(tx *sql.Tx) error {
+		var err error
+		accountID, _, _, err = lockInStatus(ctx, tx, txID, StatusPending)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx,
+			"UPDATE transactions SET status = 'failed' WHERE id = $1",
+			txID,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to update transaction record: %w", err)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,277 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"time"
+
+	"github.com/Andrew44Ashraf/fintech-service/internal/interest"
+	"github.com/Andrew44Ashraf/fintech-service/internal/models"
+	"github.com/lib/pq"
+)
+
+// creditTypes lists the transaction types that add to the balance, for
+// queries that work a past balance back from later transactions.
+var creditTypes = pq.StringArray{
+	string(models.Deposit), string(models.TransferIn), string(models.Interest), string(models.FeeReversal),
+	string(models.AdjustmentCredit), string(models.BankTransferIn),
+}
+
+type InterestRepository struct {
+	db     *sql.DB
+	logger *slog.Logger
+}
+
+func NewInterestRepository(db *sql.DB, logger *slog.Logger) *InterestRepository {
+	return &InterestRepository{db: db, logger: logger}
+}
+
+// Products returns the interest-bearing account products by code.
+func (r *InterestRepository) Products(ctx context.Context) (map[string]interest.Product, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT p.code, p.day_count, t.min_balance, t.apr
+		 FROM account_products p
+		 JOIN interest_rate_tiers t ON t.product_code = p.code
+		 ORDER BY p.code, t.min_balance`,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query products: %w", err)
+	}
+	defer rows.Close()
+
+	products := make(map[string]interest.Product)
+	for rows.Next() {
+		var (
+			code, dayCount string
+			tier           interest.Tier
+		)
+		if err := rows.Scan(&code, &dayCount, &tier.MinBalance, &tier.APR); err != nil {
+			return nil, fmt.Errorf("failed to scan product: %w", err)
+		}
+		p := products[code]
+		p.Code = code
+		p.DayCount = interest.DayCount(dayCount)
+		p.Tiers = append(p.Tiers, tier)
+		products[code] = p
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	for code, p := range products {
+		if err := p.Validate(); err != nil {
+			return nil, err
+		}
+		if !p.Bearing() {
+			delete(products, code)
+		}
+	}
+	return products, nil
+}
+
+// AccrueInterest records one day of interest on the end-of-day (UTC)
+// balance of every open account on an interest-bearing product. Accounts
+// that already have an accrual for date are skipped, so reruns are
+// harmless. It returns the number of accruals written.
+func (r *InterestRepository) AccrueInterest(ctx context.Context, date time.Time, products map[string]interest.Product) (int, error) {
+	if len(products) == 0 {
+		return 0, nil
+	}
+	date = time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+	endOfDay := date.AddDate(0, 0, 1)
+
+	codes := make([]string, 0, len(products))
+	for code := range products {
+		codes = append(codes, code)
+	}
+
+	written := 0
+	err := withTx(ctx, r.db, r.logger, "accrue_interest", &sql.TxOptions{Isolation: sql.LevelRepeatableRead}, func(tx *sql.Tx) error {
+		// The end-of-day balance is the balance after the last posted
+		// transaction of the day or before it. Accounts opened with an initial
+		// balance may have none, so it is worked back from the current balance.
+		rows, err := tx.QueryContext(ctx,
+			`SELECT a.id, a.product_code,
+			        COALESCE(
+			            (SELECT t.final_balance FROM transactions t
+			             WHERE t.account_id = a.id AND t.status = 'posted' AND t.posted_at < $2
+			             ORDER BY t.posted_at DESC, t.id DESC
+			             LIMIT 1),
+			            a.balance - COALESCE(
+			                (SELECT SUM(CASE WHEN t.type = ANY($4) THEN t.amount ELSE -t.amount END)
+			                 FROM transactions t
+			                 WHERE t.account_id = a.id AND t.status = 'posted'), 0)
+			        )
+			 FROM accounts a
+			 WHERE a.product_code = ANY($1) AND a.status <> 'closed' AND a.created_at < $2
+			   AND NOT EXISTS (
+			       SELECT 1 FROM interest_accruals ia
+			       WHERE ia.account_id = a.id AND ia.accrual_date = $3)
+			 ORDER BY a.id`,
+			pq.Array(codes), endOfDay, date, creditTypes,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to query balances: %w", err)
+		}
+
+		type accrual struct {
+			accountID int
+			product   string
+			balance   float64
+		}
+		var accruals []accrual
+		for rows.Next() {
+			var a accrual
+			if err := rows.Scan(&a.accountID, &a.product, &a.balance); err != nil {
+				rows.Close()
+				return fmt.Errorf("failed to scan balance: %w", err)
+			}
+			accruals = append(accruals, a)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("rows iteration error: %w", err)
+		}
+
+		stmt, err := tx.PrepareContext(ctx,
+			`INSERT INTO interest_accruals (account_id, accrual_date, product_code, balance, effective_apr, amount)
+			 VALUES ($1, $2, $3, $4, $5, $6)
+			 ON CONFLICT (account_id, accrual_date) DO NOTHING`,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to prepare accrual insert: %w", err)
+		}
+		defer stmt.Close()
+
+		written = 0
+		for _, a := range accruals {
+			p := products[a.product]
+			res, err := stmt.ExecContext(ctx,
+				a.accountID, date, a.product, a.balance,
+				p.EffectiveAPR(a.balance, date), p.Daily(a.balance, date),
+			)
+			if err != nil {
+				return fmt.Errorf("failed to record accrual for account %d: %w", a.accountID, err)
+			}
+			if n, _ := res.RowsAffected(); n > 0 {
+				written++
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return written, nil
+}
+
+// AccountsWithUnpostedInterest returns the accounts holding accruals dated
+// before the given day that have not been posted yet.
+func (r *InterestRepository) AccountsWithUnpostedInterest(ctx context.Context, before time.Time) ([]int, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT DISTINCT account_id FROM interest_accruals
+		 WHERE posted_transaction_id IS NULL AND accrual_date < $1
+		 ORDER BY account_id`,
+		before,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query accruals: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan account: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// PostInterest credits the unposted accruals dated before the given day as
+// one interest transaction and marks them posted. The sum is rounded to
+// cents; when it rounds to zero nothing is posted and the accruals carry
+// over to the next run. Closed accounts are skipped. It returns the ID of
+// the interest transaction, or 0 if nothing was posted.
+func (r *InterestRepository) PostInterest(ctx context.Context, accountID int, before time.Time) (txID int, err error) {
+	start := time.Now()
+	defer func() {
+		if txID != 0 || err != nil {
+			logOutcome(ctx, r.logger, "interest", accountID, txID, start, err)
+		}
+	}()
+
+	err = withTx(ctx, r.db, r.logger, "interest", nil, func(tx *sql.Tx) error {
+		txID = 0
+
+		// Locking the account first serializes concurrent posting runs: the
+		// second one sees the accruals already marked posted.
+		var status string
+		err := tx.QueryRowContext(ctx,
+			"SELECT status FROM accounts WHERE id = $1 FOR UPDATE",
+			accountID,
+		).Scan(&status)
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return ErrAccountNotFound
+		case err != nil:
+			return fmt.Errorf("account verification failed: %w", err)
+		case status == "closed":
+			return errRollback
+		}
+
+		var accrued float64
+		err = tx.QueryRowContext(ctx,
+			`SELECT COALESCE(SUM(amount), 0) FROM interest_accruals
+			 WHERE account_id = $1 AND posted_transaction_id IS NULL AND accrual_date < $2`,
+			accountID, before,
+		).Scan(&accrued)
+		if err != nil {
+			return fmt.Errorf("failed to sum accruals: %w", err)
+		}
+
+		amount := math.Round(accrued*100) / 100
+		if amount <= 0 {
+			return errRollback
+		}
+
+		var finalBalance float64
+		err = tx.QueryRowContext(ctx,
+			"UPDATE accounts SET balance = balance + $1 WHERE id = $2 RETURNING balance",
+			amount, accountID,
+		).Scan(&finalBalance)
+		if err != nil {
+			return fmt.Errorf("balance update failed: %w", err)
+		}
+
+		err = tx.QueryRowContext(ctx,
+			`INSERT INTO transactions (account_id, amount, type, final_balance)
+			 VALUES ($1, $2, $3, $4)
+			 RETURNING id`,
+			accountID, amount, string(models.Interest), finalBalance,
+		).Scan(&txID)
+		if err != nil {
+			return fmt.Errorf("failed to create transaction: %w", err)
+		}
+
+		_, err = tx.ExecContext(ctx,
+			`UPDATE interest_accruals SET posted_transaction_id = $1
+			 WHERE account_id = $2 AND posted_transaction_id IS NULL AND accrual_date < $3`,
+			txID, accountID, before,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to mark accruals posted: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return txID, nil
+}
@@ -0,0 +1,221 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	apperrors "github.com/Andrew44Ashraf/fintech-service/internal/errors"
+	"github.com/Andrew44Ashraf/fintech-service/internal/logging"
+)
+
+var ErrLimitExceeded = apperrors.ErrLimitExceeded
+
+// WithdrawalLimits bounds how much can leave an account, by withdrawal or
+// by transfer out. Zero means unlimited.
+type WithdrawalLimits struct {
+	MaxSingle     float64
+	MaxDaily      float64
+	MaxMonthly    float64
+	MaxDailyCount int
+}
+
+// AccountLimits are one account's overrides of the withdrawal limits. Nil
+// keeps the product or service default; zero means unlimited.
+type AccountLimits struct {
+	MaxSingle     *float64
+	MaxDaily      *float64
+	MaxMonthly    *float64
+	MaxDailyCount *int
+}
+
+// SetAccountLimits replaces an account's limit overrides and returns the
+// new account version. A non-zero ifVersion must match the account's
+// version.
+func (r *AccountRepository) SetAccountLimits(ctx context.Context, accountID int, ifVersion int64, limits AccountLimits) (int64, error) {
+	for field, v := range map[string]*float64{
+		"max_single_withdrawal":  limits.MaxSingle,
+		"max_daily_withdrawal":   limits.MaxDaily,
+		"max_monthly_withdrawal": limits.MaxMonthly,
+	} {
+		if v != nil && *v < 0 {
+			return 0, apperrors.Invalid(field, "gte", "must be at least 0")
+		}
+	}
+	if limits.MaxDailyCount != nil && *limits.MaxDailyCount < 0 {
+		return 0, apperrors.Invalid("max_daily_withdrawals", "gte", "must be at least 0")
+	}
+
+	var version int64
+	err := withTx(ctx, r.db, r.logger, "set_account_limits", nil, func(tx *sql.Tx) error {
+		var status string
+		err := tx.QueryRowContext(ctx,
+			"SELECT status, version FROM accounts WHERE id = $1 FOR UPDATE",
+			accountID,
+		).Scan(&status, &version)
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return ErrAccountNotFound
+		case err != nil:
+			return fmt.Errorf("account verification failed: %w", err)
+		}
+		if err := checkVersion(version, ifVersion); err != nil {
+			return err
+		}
+		if status == AccountClosed {
+			return ErrAccountClosed
+		}
+
+		_, err = tx.ExecContext(ctx,
+			`INSERT INTO account_limits (account_id, max_single_withdrawal, max_daily_withdrawal, max_monthly_withdrawal, max_daily_withdrawals)
+			 VALUES ($1, $2, $3, $4, $5)
+			 ON CONFLICT (account_id) DO UPDATE SET
+			     max_single_withdrawal = EXCLUDED.max_single_withdrawal,
+			     max_daily_withdrawal = EXCLUDED.max_daily_withdrawal,
+			     max_monthly_withdrawal = EXCLUDED.max_monthly_withdrawal,
+			     max_daily_withdrawals = EXCLUDED.max_daily_withdrawals,
+			     updated_at = CURRENT_TIMESTAMP`,
+			accountID, limits.MaxSingle, limits.MaxDaily, limits.MaxMonthly, limits.MaxDailyCount,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to save account limits: %w", err)
+		}
+
+		// The account_limits trigger has bumped the version
+		if err := tx.QueryRowContext(ctx, "SELECT version FROM accounts WHERE id = $1", accountID).Scan(&version); err != nil {
+			return fmt.Errorf("failed to read account version: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	logging.FromContext(ctx, r.logger).Info("account limits updated",
+		logging.KeyOp, "set_account_limits",
+		logging.KeyAccountID, accountID,
+		logging.KeyOutcome, logging.OutcomeSuccess,
+	)
+	return version, nil
+}
+
+func nullFloat(v sql.NullFloat64) *float64 {
+	if !v.Valid {
+		return nil
+	}
+	return &v.Float64
+}
+
+func nullInt(v sql.NullInt64) *int {
+	if !v.Valid {
+		return nil
+	}
+	n := int(v.Int64)
+	return &n
+}
+
+// loadWithdrawalLimits returns the defaults overridden by the account's row
+// in account_limits, if any.
+func loadWithdrawalLimits(ctx context.Context, tx *sql.Tx, accountID int, defaults WithdrawalLimits) (WithdrawalLimits, error) {
+	var (
+		single, daily, monthly sql.NullFloat64
+		count                  sql.NullInt64
+	)
+	err := tx.QueryRowContext(ctx,
+		`SELECT max_single_withdrawal, max_daily_withdrawal, max_monthly_withdrawal, max_daily_withdrawals
+		 FROM account_limits WHERE account_id = $1`,
+		accountID,
+	).Scan(&single, &daily, &monthly, &count)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return defaults, nil
+	case err != nil:
+		return WithdrawalLimits{}, fmt.Errorf("failed to load account limits: %w", err)
+	}
+
+	limits := defaults
+	if single.Valid {
+		limits.MaxSingle = single.Float64
+	}
+	if daily.Valid {
+		limits.MaxDaily = daily.Float64
+	}
+	if monthly.Valid {
+		limits.MaxMonthly = monthly.Float64
+	}
+	if count.Valid {
+		limits.MaxDailyCount = int(count.Int64)
+	}
+	return limits, nil
+}
+
+// checkLimits enforces the account's withdrawal limits on amount leaving
+// it under rule. It must run inside the posting transaction after the
+// account row has been locked.
+func (r *TransactionRepository) checkLimits(ctx context.Context, tx *sql.Tx, accountID int, rule accountRules, amount float64) error {
+	limits, err := loadWithdrawalLimits(ctx, tx, accountID, rule.withdrawalLimits(r.withdrawalLimits))
+	if err != nil {
+		return err
+	}
+	return checkWithdrawalLimits(ctx, tx, accountID, amount, limits)
+}
+
+// checkWithdrawalLimits must run inside the posting transaction after the
+// account row has been locked, so concurrent debits see each other.
+// Withdrawals and transfers out count alike, so a limit can't be dodged by
+// moving money to another account first. They count from when they are
+// posted, so one held for review counts on the day it is approved.
+func checkWithdrawalLimits(ctx context.Context, tx *sql.Tx, accountID int, amount float64, limits WithdrawalLimits) error {
+	if limits.MaxSingle > 0 && amount > limits.MaxSingle {
+		return &apperrors.LimitExceededError{
+			Limit:     apperrors.LimitSingleWithdrawal,
+			Max:       limits.MaxSingle,
+			Remaining: limits.MaxSingle,
+		}
+	}
+	if limits.MaxDaily == 0 && limits.MaxMonthly == 0 && limits.MaxDailyCount == 0 {
+		return nil
+	}
+
+	var (
+		dailyAmount, monthlyAmount float64
+		dailyCount                 int
+	)
+	err := tx.QueryRowContext(ctx,
+		`SELECT
+			COALESCE(SUM(amount) FILTER (WHERE posted_at >= date_trunc('day', LOCALTIMESTAMP)), 0),
+			COUNT(*) FILTER (WHERE posted_at >= date_trunc('day', LOCALTIMESTAMP)),
+			COALESCE(SUM(amount), 0)
+		 FROM transactions
+		 WHERE account_id = $1 AND type IN ('withdrawal', 'transfer_out') AND status = 'posted'
+		   AND posted_at >= date_trunc('month', LOCALTIMESTAMP)`,
+		accountID,
+	).Scan(&dailyAmount, &dailyCount, &monthlyAmount)
+	if err != nil {
+		return fmt.Errorf("failed to load withdrawal totals: %w", err)
+	}
+
+	if limits.MaxDailyCount > 0 && dailyCount >= limits.MaxDailyCount {
+		return &apperrors.LimitExceededError{
+			Limit:     apperrors.LimitDailyWithdrawals,
+			Max:       float64(limits.MaxDailyCount),
+			Remaining: 0,
+		}
+	}
+	if limits.MaxDaily > 0 && dailyAmount+amount > limits.MaxDaily {
+		return &apperrors.LimitExceededError{
+			Limit:     apperrors.LimitDailyWithdrawal,
+			Max:       limits.MaxDaily,
+			Remaining: max(limits.MaxDaily-dailyAmount, 0),
+		}
+	}
+	if limits.MaxMonthly > 0 && monthlyAmount+amount > limits.MaxMonthly {
+		return &apperrors.LimitExceededError{
+			Limit:     apperrors.LimitMonthlyWithdrawal,
+			Max:       limits.MaxMonthly,
+			Remaining: max(limits.MaxMonthly-monthlyAmount, 0),
+		}
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	apperrors "github.com/Andrew44Ashraf/fintech-service/internal/errors"
+	"github.com/Andrew44Ashraf/fintech-service/internal/logging"
+)
+
+// logOutcome writes the standard log line for a write operation.
+// Business rule rejections are logged at warn, anything else at error.
+func logOutcome(ctx context.Context, logger *slog.Logger, op string, accountID, txID int, start time.Time, err error) {
+	attrs := []any{
+		logging.KeyOp, op,
+		logging.KeyAccountID, accountID,
+		logging.KeyLatency, time.Since(start),
+	}
+	if txID != 0 {
+		attrs = append(attrs, logging.KeyTxID, txID)
+	}
+
+	log := logging.FromContext(ctx, logger)
+	switch {
+	case err == nil:
+		log.Info(op+" posted", append(attrs, logging.KeyOutcome, logging.OutcomeSuccess)...)
+	case errors.Is(err, ErrPendingReview):
+		log.Info(op+" held for review", append(attrs, logging.KeyOutcome, logging.OutcomeHeld)...)
+	case isRejection(err):
+		log.Warn(op+" rejected", append(attrs, logging.KeyOutcome, logging.OutcomeRejected, logging.KeyError, err.Error())...)
+	default:
+		log.Error(op+" failed", append(attrs, logging.KeyOutcome, logging.OutcomeError, logging.KeyError, err.Error())...)
+	}
+}
+
+// isRejection reports whether err is an expected business rule violation
+// rather than an infrastructure failure.
+func isRejection(err error) bool {
+	return apperrors.Lookup(err).Client()
+}
@@ -0,0 +1,156 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+
+	apperrors "github.com/Andrew44Ashraf/fintech-service/internal/errors"
+	"github.com/lib/pq"
+)
+
+var ErrTransactionNotAllowed = apperrors.ErrTransactionNotAllowed
+
+// DefaultProduct is the product accounts are opened with when none is given.
+const DefaultProduct = "checking"
+
+// Product is an entry of the account_products catalog. Limits left at zero
+// fall back to the service defaults.
+type Product struct {
+	Code           string
+	Name           string
+	AllowedTypes   []string
+	MinBalance     float64
+	OverdraftLimit float64
+	Limits         WithdrawalLimits
+	Currency       string
+}
+
+// Allows reports whether accounts of this product may book txType.
+func (p Product) Allows(txType string) bool {
+	return slices.Contains(p.AllowedTypes, txType)
+}
+
+const productColumns = `
+	code, name, allowed_transaction_types, min_balance, overdraft_limit,
+	COALESCE(max_single_withdrawal, 0), COALESCE(max_daily_withdrawal, 0),
+	COALESCE(max_monthly_withdrawal, 0), COALESCE(max_daily_withdrawals, 0), currency`
+
+func scanProduct(row rowScanner) (*Product, error) {
+	var (
+		p       Product
+		allowed pq.StringArray
+	)
+	err := row.Scan(&p.Code, &p.Name, &allowed, &p.MinBalance, &p.OverdraftLimit,
+		&p.Limits.MaxSingle, &p.Limits.MaxDaily, &p.Limits.MaxMonthly, &p.Limits.MaxDailyCount, &p.Currency)
+	if err != nil {
+		return nil, err
+	}
+	p.AllowedTypes = allowed
+	return &p, nil
+}
+
+// ListProducts returns the product catalog.
+func (r *AccountRepository) ListProducts(ctx context.Context) ([]Product, error) {
+	rows, err := r.reader(ctx).QueryContext(ctx, "SELECT"+productColumns+" FROM account_products ORDER BY code")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query products: %w", err)
+	}
+	defer rows.Close()
+
+	var products []Product
+	for rows.Next() {
+		p, err := scanProduct(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan product: %w", err)
+		}
+		products = append(products, *p)
+	}
+	return products, rows.Err()
+}
+
+func getProduct(ctx context.Context, tx *sql.Tx, code string) (*Product, error) {
+	p, err := scanProduct(tx.QueryRowContext(ctx,
+		"SELECT"+productColumns+" FROM account_products WHERE code = $1",
+		code,
+	))
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return nil, apperrors.Invalid("product_code", "oneof", fmt.Sprintf("unknown product %q", code))
+	case err != nil:
+		return nil, fmt.Errorf("failed to load product: %w", err)
+	}
+	return p, nil
+}
+
+// accountRules are the product rules that apply to one account.
+type accountRules struct {
+	allowedTypes []string
+	// floor is the lowest balance a debit may leave: the product minimum
+	// less the account's overdraft.
+	floor  float64
+	limits WithdrawalLimits
+}
+
+func (a accountRules) allows(txType string) bool {
+	return a.allowedTypes == nil || slices.Contains(a.allowedTypes, txType)
+}
+
+// withdrawalLimits layers the product limits over the service defaults.
+func (a accountRules) withdrawalLimits(defaults WithdrawalLimits) WithdrawalLimits {
+	limits := defaults
+	if a.limits.MaxSingle > 0 {
+		limits.MaxSingle = a.limits.MaxSingle
+	}
+	if a.limits.MaxDaily > 0 {
+		limits.MaxDaily = a.limits.MaxDaily
+	}
+	if a.limits.MaxMonthly > 0 {
+		limits.MaxMonthly = a.limits.MaxMonthly
+	}
+	if a.limits.MaxDailyCount > 0 {
+		limits.MaxDailyCount = a.limits.MaxDailyCount
+	}
+	return limits
+}
+
+// loadAccountRules returns the product rules of the given accounts by ID.
+// The caller holds the account locks. Accounts without a product row get
+// no restrictions.
+func loadAccountRules(ctx context.Context, tx *sql.Tx, ids ...int) (map[int]accountRules, error) {
+	rows, err := tx.QueryContext(ctx,
+		`SELECT a.id, p.allowed_transaction_types, p.min_balance - a.overdraft_limit,
+		        COALESCE(p.max_single_withdrawal, 0), COALESCE(p.max_daily_withdrawal, 0),
+		        COALESCE(p.max_monthly_withdrawal, 0), COALESCE(p.max_daily_withdrawals, 0)
+		 FROM accounts a
+		 JOIN account_products p ON p.code = a.product_code
+		 WHERE a.id = ANY($1)`,
+		pq.Array(ids),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load product rules: %w", err)
+	}
+	defer rows.Close()
+
+	rules := make(map[int]accountRules, len(ids))
+	for rows.Next() {
+		var (
+			id      int
+			allowed pq.StringArray
+			a       accountRules
+		)
+		err := rows.Scan(&id, &allowed, &a.floor,
+			&a.limits.MaxSingle, &a.limits.MaxDaily, &a.limits.MaxMonthly, &a.limits.MaxDailyCount)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load product rules: %w", err)
+		}
+		a.allowedTypes = allowed
+		rules[id] = a
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to load product rules: %w", err)
+	}
+	return rules, nil
+}
@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+)
+
+// ReadRouter picks the database a read-only query made with ctx runs on,
+// e.g. a replica within a staleness bound. database.Router implements it.
+type ReadRouter interface {
+	Reader(ctx context.Context) *sql.DB
+}
+
+// AccountOption configures optional AccountRepository behaviour.
+type AccountOption func(*AccountRepository)
+
+// WithAccountReads sends the read-only queries of an AccountRepository
+// through reads. Writes always go to the repository's database.
+func WithAccountReads(reads ReadRouter) AccountOption {
+	return func(r *AccountRepository) {
+		r.reads = reads
+	}
+}
+
+// WithTransactionReads sends the read-only queries of a
+// TransactionRepository through reads. Writes always go to the
+// repository's database.
+func WithTransactionReads(reads ReadRouter) TransactionOption {
+	return func(r *TransactionRepository) {
+		r.reads = reads
+	}
+}
+
+// WithStatementReads builds statements on the database reads picks.
+// Stored statements are read and written on the repository's database.
+func WithStatementReads(reads ReadRouter) StatementOption {
+	return func(r *StatementRepository) {
+		r.reads = reads
+	}
+}
+
+func (r *AccountRepository) reader(ctx context.Context) *sql.DB {
+	if r.reads == nil {
+		return r.db
+	}
+	return r.reads.Reader(ctx)
+}
+
+func (r *TransactionRepository) reader(ctx context.Context) *sql.DB {
+	if r.reads == nil {
+		return r.db
+	}
+	return r.reads.Reader(ctx)
+}
+
+func (r *StatementRepository) reader(ctx context.Context) *sql.DB {
+	if r.reads == nil {
+		return r.db
+	}
+	return r.reads.Reader(ctx)
+}
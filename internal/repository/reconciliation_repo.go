@@ -0,0 +1,291 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	apperrors "github.com/Andrew44Ashraf/fintech-service/internal/errors"
+	"github.com/Andrew44Ashraf/fintech-service/internal/logging"
+	"github.com/Andrew44Ashraf/fintech-service/internal/reconciliation"
+)
+
+var ErrReconciliationRunNotFound = apperrors.ErrReconciliationRunNotFound
+
+// Reconciliation triggers.
+const (
+	TriggerScheduled = "scheduled"
+	TriggerManual    = "manual"
+)
+
+// ReconciliationRun is one comparison of every account's ledger balance
+// with its journal.
+type ReconciliationRun struct {
+	ID              int
+	RunDate         time.Time
+	Trigger         string
+	AccountsChecked int
+	BreakCount      int
+	StartedAt       time.Time
+	FinishedAt      *time.Time
+	Breaks          []ReconciliationBreak
+}
+
+// ReconciliationBreak is a recorded disagreement for one account.
+type ReconciliationBreak struct {
+	ID              int
+	AccountID       int
+	AccountPublicID string
+	Kind            reconciliation.Kind
+	LedgerBalance   float64
+	ExpectedBalance float64
+	Difference      float64
+	CreatedAt       time.Time
+}
+
+type ReconciliationRepository struct {
+	db     *sql.DB
+	logger *slog.Logger
+}
+
+func NewReconciliationRepository(db *sql.DB, logger *slog.Logger) *ReconciliationRepository {
+	return &ReconciliationRepository{db: db, logger: logger}
+}
+
+// Reconcile checks every account from a single consistent snapshot and
+// records the breaks found. Scheduled runs also write the day's balance
+// snapshots and happen once per UTC day: when the day's scheduled run
+// already exists it returns nil.
+func (r *ReconciliationRepository) Reconcile(ctx context.Context, trigger string, now time.Time) (*ReconciliationRun, error) {
+	now = now.UTC()
+	runDate := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+	var run *ReconciliationRun
+	err := withTx(ctx, r.db, r.logger, "reconcile", &sql.TxOptions{Isolation: sql.LevelRepeatableRead}, func(tx *sql.Tx) error {
+		run = &ReconciliationRun{RunDate: runDate, Trigger: trigger}
+		err := tx.QueryRowContext(ctx,
+			`INSERT INTO reconciliation_runs (run_date, trigger)
+			 VALUES ($1, $2)
+			 ON CONFLICT DO NOTHING
+			 RETURNING id, started_at`,
+			runDate, trigger,
+		).Scan(&run.ID, &run.StartedAt)
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			run = nil
+			return errRollback
+		case err != nil:
+			return fmt.Errorf("failed to start reconciliation run: %w", err)
+		}
+
+		accounts, lastTxIDs, err := journalBalances(ctx, tx)
+		if err != nil {
+			return err
+		}
+
+		if trigger == TriggerScheduled {
+			stmt, err := tx.PrepareContext(ctx,
+				`INSERT INTO balance_snapshots
+				     (account_id, snapshot_date, balance, journal_balance, last_transaction_id, run_id)
+				 VALUES ($1, $2, $3, $4, NULLIF($5, 0), $6)
+				 ON CONFLICT (account_id, snapshot_date) DO NOTHING`,
+			)
+			if err != nil {
+				return fmt.Errorf("failed to prepare snapshot insert: %w", err)
+			}
+			defer stmt.Close()
+
+			for _, a := range accounts {
+				if _, err := stmt.ExecContext(ctx, a.ID, runDate, a.Ledger, a.Journal, lastTxIDs[a.ID], run.ID); err != nil {
+					return fmt.Errorf("failed to write snapshot for account %d: %w", a.ID, err)
+				}
+			}
+		}
+
+		for _, a := range accounts {
+			for _, b := range reconciliation.Check(a) {
+				rb := ReconciliationBreak{
+					AccountID:       b.AccountID,
+					Kind:            b.Kind,
+					LedgerBalance:   b.Ledger,
+					ExpectedBalance: b.Expected,
+					Difference:      b.Difference(),
+				}
+				err := tx.QueryRowContext(ctx,
+					`INSERT INTO reconciliation_breaks
+					     (run_id, account_id, kind, ledger_balance, expected_balance, difference)
+					 VALUES ($1, $2, $3, $4, $5, $6)
+					 RETURNING id, created_at, (SELECT public_id FROM accounts WHERE id = account_id)`,
+					run.ID, rb.AccountID, string(rb.Kind), rb.LedgerBalance, rb.ExpectedBalance, rb.Difference,
+				).Scan(&rb.ID, &rb.CreatedAt, &rb.AccountPublicID)
+				if err != nil {
+					return fmt.Errorf("failed to record break for account %d: %w", a.ID, err)
+				}
+				run.Breaks = append(run.Breaks, rb)
+			}
+		}
+
+		run.AccountsChecked, run.BreakCount = len(accounts), len(run.Breaks)
+		var finishedAt time.Time
+		err = tx.QueryRowContext(ctx,
+			`UPDATE reconciliation_runs
+			 SET accounts_checked = $1, break_count = $2, finished_at = CURRENT_TIMESTAMP
+			 WHERE id = $3
+			 RETURNING finished_at`,
+			run.AccountsChecked, run.BreakCount, run.ID,
+		).Scan(&finishedAt)
+		if err != nil {
+			return fmt.Errorf("failed to finish reconciliation run: %w", err)
+		}
+		run.FinishedAt = &finishedAt
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if run == nil {
+		return nil, nil
+	}
+
+	log := logging.FromContext(ctx, r.logger)
+	if run.BreakCount > 0 {
+		log.Warn("reconciliation found breaks",
+			logging.KeyOp, "reconcile",
+			"run_id", run.ID,
+			"accounts_checked", run.AccountsChecked,
+			"breaks", run.BreakCount,
+		)
+	} else {
+		log.Info("reconciliation clean",
+			logging.KeyOp, "reconcile",
+			"run_id", run.ID,
+			"accounts_checked", run.AccountsChecked,
+			logging.KeyOutcome, logging.OutcomeSuccess,
+		)
+	}
+	return run, nil
+}
+
+// journalBalances returns every account's ledger and journal balance and
+// the final_balance of its last posted transaction, plus the ID of that
+// transaction by account.
+func journalBalances(ctx context.Context, tx *sql.Tx) ([]reconciliation.Account, map[int]int, error) {
+	rows, err := tx.QueryContext(ctx,
+		`SELECT a.id, a.balance,
+		        a.opening_balance + COALESCE(
+		            (SELECT SUM(CASE WHEN t.type = ANY($1) THEN t.amount ELSE -t.amount END)
+		             FROM transactions t
+		             WHERE t.account_id = a.id AND t.status = 'posted'), 0),
+		        last.id, last.final_balance
+		 FROM accounts a
+		 LEFT JOIN LATERAL (
+		     SELECT t.id, t.final_balance FROM transactions t
+		     WHERE t.account_id = a.id AND t.status = 'posted'
+		     ORDER BY t.posted_at DESC, t.id DESC
+		     LIMIT 1
+		 ) last ON true
+		 ORDER BY a.id`,
+		creditTypes,
+	)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to query balances: %w", err)
+	}
+	defer rows.Close()
+
+	var (
+		accounts  []reconciliation.Account
+		lastTxIDs = make(map[int]int)
+	)
+	for rows.Next() {
+		var (
+			a         reconciliation.Account
+			lastTxID  sql.NullInt64
+			lastFinal sql.NullFloat64
+		)
+		if err := rows.Scan(&a.ID, &a.Ledger, &a.Journal, &lastTxID, &lastFinal); err != nil {
+			return nil, nil, fmt.Errorf("failed to scan balance: %w", err)
+		}
+		if lastTxID.Valid {
+			lastTxIDs[a.ID] = int(lastTxID.Int64)
+		}
+		if lastFinal.Valid {
+			a.LastFinalBalance = &lastFinal.Float64
+		}
+		accounts = append(accounts, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+	return accounts, lastTxIDs, nil
+}
+
+// LatestRun returns the most recent finished reconciliation run with its
+// breaks.
+func (r *ReconciliationRepository) LatestRun(ctx context.Context) (*ReconciliationRun, error) {
+	return r.loadRun(ctx,
+		`SELECT id, run_date, trigger, accounts_checked, break_count, started_at, finished_at
+		 FROM reconciliation_runs
+		 WHERE finished_at IS NOT NULL
+		 ORDER BY started_at DESC, id DESC
+		 LIMIT 1`,
+	)
+}
+
+// GetRun returns a reconciliation run with its breaks.
+func (r *ReconciliationRepository) GetRun(ctx context.Context, id int) (*ReconciliationRun, error) {
+	return r.loadRun(ctx,
+		`SELECT id, run_date, trigger, accounts_checked, break_count, started_at, finished_at
+		 FROM reconciliation_runs
+		 WHERE id = $1`,
+		id,
+	)
+}
+
+func (r *ReconciliationRepository) loadRun(ctx context.Context, query string, args ...any) (*ReconciliationRun, error) {
+	var (
+		run        ReconciliationRun
+		finishedAt sql.NullTime
+	)
+	err := r.db.QueryRowContext(ctx, query, args...).Scan(
+		&run.ID, &run.RunDate, &run.Trigger, &run.AccountsChecked, &run.BreakCount, &run.StartedAt, &finishedAt)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return nil, ErrReconciliationRunNotFound
+	case err != nil:
+		return nil, fmt.Errorf("failed to get reconciliation run: %w", err)
+	}
+	run.FinishedAt = nullTime(finishedAt)
+
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT b.id, b.account_id, a.public_id, b.kind, b.ledger_balance, b.expected_balance, b.difference, b.created_at
+		 FROM reconciliation_breaks b
+		 JOIN accounts a ON a.id = b.account_id
+		 WHERE b.run_id = $1
+		 ORDER BY b.account_id, b.id`,
+		run.ID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query breaks: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			b    ReconciliationBreak
+			kind string
+		)
+		err := rows.Scan(&b.ID, &b.AccountID, &b.AccountPublicID, &kind, &b.LedgerBalance, &b.ExpectedBalance, &b.Difference, &b.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan break: %w", err)
+		}
+		b.Kind = reconciliation.Kind(kind)
+		run.Breaks = append(run.Breaks, b)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+	return &run, nil
+}
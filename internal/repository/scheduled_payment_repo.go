@@ -0,0 +1,593 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	apperrors "github.com/Andrew44Ashraf/fintech-service/internal/errors"
+	"github.com/Andrew44Ashraf/fintech-service/internal/ids"
+	"github.com/Andrew44Ashraf/fintech-service/internal/logging"
+	"github.com/Andrew44Ashraf/fintech-service/internal/recurrence"
+	"github.com/lib/pq"
+)
+
+var (
+	ErrScheduledPaymentNotFound = apperrors.ErrScheduledPaymentNotFound
+	ErrScheduledPaymentEnded    = apperrors.ErrScheduledPaymentEnded
+)
+
+// Scheduled payment statuses
+const (
+	ScheduleActive    = "active"
+	SchedulePaused    = "paused"
+	ScheduleCompleted = "completed"
+	ScheduleCancelled = "cancelled"
+)
+
+// What to do when a scheduled payment finds insufficient funds
+const (
+	OnInsufficientFundsSkip  = "skip"
+	OnInsufficientFundsRetry = "retry"
+)
+
+// Scheduled payment run outcomes
+const (
+	RunPosted   = "posted"
+	RunHeld     = "held"
+	RunSkipped  = "skipped"
+	RunRetrying = "retrying"
+	RunFailed   = "failed"
+)
+
+// ScheduleRetryDelay is how long the worker waits before retrying a
+// payment that failed for insufficient funds under the retry policy.
+const ScheduleRetryDelay = time.Hour
+
+// ScheduledPayment is a standing order: a transfer when ToAccountID is set,
+// otherwise a withdrawal. The IDs are internal keys; clients know the
+// payment and its accounts by the public IDs.
+type ScheduledPayment struct {
+	ID                  int
+	PublicID            string
+	AccountID           int
+	AccountPublicID     string
+	ToAccountID         int
+	ToAccountPublicID   string
+	Amount              float64
+	Description         string
+	Rule                recurrence.Rule
+	StartAt             time.Time
+	EndAt               *time.Time
+	DueAt               *time.Time
+	NextRunAt           *time.Time
+	OnInsufficientFunds string
+	MaxRetries          int
+	RetryCount          int
+	Status              string
+	CreatedAt           time.Time
+	UpdatedAt           time.Time
+}
+
+// ScheduledPaymentUpdate lists the fields that can change after creation.
+// Nil fields are left alone. Status may only be set to active or paused.
+type ScheduledPaymentUpdate struct {
+	Amount              *float64
+	Description         *string
+	EndAt               *time.Time
+	Status              *string
+	OnInsufficientFunds *string
+	MaxRetries          *int
+}
+
+// ScheduledPaymentRun records one execution attempt.
+type ScheduledPaymentRun struct {
+	ID                  int
+	DueAt               time.Time
+	Attempt             int
+	Outcome             string
+	TransactionID       int
+	TransactionPublicID string
+	ErrorCode           string
+	RunAt               time.Time
+}
+
+type ScheduledPaymentRepository struct {
+	db           *sql.DB
+	logger       *slog.Logger
+	transactions *TransactionRepository
+}
+
+// NewScheduledPaymentRepository returns a repository that executes due
+// payments through transactions, with the usual limits and screening.
+func NewScheduledPaymentRepository(db *sql.DB, logger *slog.Logger, transactions *TransactionRepository) *ScheduledPaymentRepository {
+	return &ScheduledPaymentRepository{db: db, logger: logger, transactions: transactions}
+}
+
+const scheduledPaymentColumns = `
+	id, public_id,
+	account_id, (SELECT a.public_id FROM accounts a WHERE a.id = scheduled_payments.account_id),
+	COALESCE(to_account_id, 0), COALESCE((SELECT a.public_id FROM accounts a WHERE a.id = scheduled_payments.to_account_id), ''),
+	amount, COALESCE(description, ''),
+	COALESCE(cron_expr, ''), COALESCE(interval_count, 0), COALESCE(interval_unit, ''),
+	start_at, end_at, due_at, next_run_at, on_insufficient_funds, max_retries, retry_count,
+	status, created_at, updated_at`
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanScheduledPayment(row rowScanner) (*ScheduledPayment, error) {
+	var (
+		p                       ScheduledPayment
+		endAt, dueAt, nextRunAt sql.NullTime
+	)
+	err := row.Scan(
+		&p.ID, &p.PublicID, &p.AccountID, &p.AccountPublicID, &p.ToAccountID, &p.ToAccountPublicID, &p.Amount, &p.Description,
+		&p.Rule.Cron, &p.Rule.Every, &p.Rule.Unit,
+		&p.StartAt, &endAt, &dueAt, &nextRunAt, &p.OnInsufficientFunds, &p.MaxRetries, &p.RetryCount,
+		&p.Status, &p.CreatedAt, &p.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	p.EndAt, p.DueAt, p.NextRunAt = nullTime(endAt), nullTime(dueAt), nullTime(nextRunAt)
+	return &p, nil
+}
+
+func nullTime(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	v := t.Time.UTC()
+	return &v
+}
+
+// CreateScheduledPayment validates and stores p. Its first occurrence must
+// be after now.
+func (r *ScheduledPaymentRepository) CreateScheduledPayment(ctx context.Context, p *ScheduledPayment, now time.Time) error {
+	switch {
+	case p.Amount <= 0:
+		return ErrNegativeAmount
+	case p.ToAccountID == p.AccountID:
+		return apperrors.Invalid("to_account_id", "nefield", "must differ from the paying account")
+	case p.EndAt != nil && !p.EndAt.After(p.StartAt):
+		return apperrors.Invalid("end_at", "gtfield", "must be after start_at")
+	}
+	if err := p.Rule.Validate(); err != nil {
+		return err
+	}
+	if p.OnInsufficientFunds == "" {
+		p.OnInsufficientFunds = OnInsufficientFundsSkip
+	}
+
+	first, err := p.Rule.Next(p.StartAt, now)
+	if err != nil {
+		return err
+	}
+	if first.IsZero() || (p.EndAt != nil && first.After(*p.EndAt)) {
+		return apperrors.Invalid("start_at", "future", "schedule has no occurrence in the future")
+	}
+
+	accountIDs := []int{p.AccountID}
+	if p.ToAccountID != 0 {
+		accountIDs = append(accountIDs, p.ToAccountID)
+	}
+	publicIDs, err := r.checkAccounts(ctx, accountIDs)
+	if err != nil {
+		return err
+	}
+	p.AccountPublicID, p.ToAccountPublicID = publicIDs[p.AccountID], publicIDs[p.ToAccountID]
+
+	p.DueAt, p.NextRunAt = &first, &first
+	p.Status = ScheduleActive
+	err = r.db.QueryRowContext(ctx,
+		`INSERT INTO scheduled_payments
+		 (account_id, to_account_id, amount, description, cron_expr, interval_count, interval_unit,
+		  start_at, end_at, due_at, next_run_at, on_insufficient_funds, max_retries)
+		 VALUES ($1, NULLIF($2, 0), $3, NULLIF($4, ''), NULLIF($5, ''), NULLIF($6, 0), NULLIF($7, ''),
+		         $8, $9, $10, $10, $11, $12)
+		 RETURNING id, public_id, created_at, updated_at`,
+		p.AccountID, p.ToAccountID, p.Amount, p.Description, p.Rule.Cron, p.Rule.Every, p.Rule.Unit,
+		p.StartAt.UTC(), p.EndAt, first, p.OnInsufficientFunds, p.MaxRetries,
+	).Scan(&p.ID, &p.PublicID, &p.CreatedAt, &p.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create scheduled payment: %w", err)
+	}
+
+	logging.FromContext(ctx, r.logger).Info("scheduled payment created",
+		logging.KeyOp, "create_scheduled_payment",
+		logging.KeyAccountID, p.AccountID,
+		"scheduled_payment_id", p.ID,
+		logging.KeyOutcome, logging.OutcomeSuccess,
+	)
+	return nil
+}
+
+// checkAccounts verifies that every account exists and is active, and
+// returns their public IDs.
+func (r *ScheduledPaymentRepository) checkAccounts(ctx context.Context, ids []int) (map[int]string, error) {
+	rows, err := r.db.QueryContext(ctx,
+		"SELECT id, status, public_id FROM accounts WHERE id = ANY($1)",
+		pq.Array(ids),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("account verification failed: %w", err)
+	}
+	defer rows.Close()
+
+	statuses := map[int]string{}
+	publicIDs := map[int]string{}
+	for rows.Next() {
+		var (
+			id               int
+			status, publicID string
+		)
+		if err := rows.Scan(&id, &status, &publicID); err != nil {
+			return nil, fmt.Errorf("account verification failed: %w", err)
+		}
+		statuses[id], publicIDs[id] = status, publicID
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("account verification failed: %w", err)
+	}
+
+	for _, id := range ids {
+		switch status, ok := statuses[id]; {
+		case !ok:
+			return nil, ErrAccountNotFound
+		case status != AccountActive:
+			return nil, inactiveAccountError(status)
+		}
+	}
+	return publicIDs, nil
+}
+
+// GetScheduledPayment returns a scheduled payment by ID.
+func (r *ScheduledPaymentRepository) GetScheduledPayment(ctx context.Context, id int) (*ScheduledPayment, error) {
+	p, err := scanScheduledPayment(r.db.QueryRowContext(ctx,
+		"SELECT"+scheduledPaymentColumns+" FROM scheduled_payments WHERE id = $1",
+		id,
+	))
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return nil, ErrScheduledPaymentNotFound
+	case err != nil:
+		return nil, fmt.Errorf("failed to load scheduled payment: %w", err)
+	}
+	return p, nil
+}
+
+// ResolveScheduledPayment returns the internal ID of the scheduled payment
+// with the given public ID.
+func (r *ScheduledPaymentRepository) ResolveScheduledPayment(ctx context.Context, publicID string) (int, error) {
+	if !ids.IsScheduledPaymentID(publicID) {
+		return 0, apperrors.Invalid("id", "scheduled_payment", "must be a scheduled payment ID")
+	}
+
+	var id int
+	err := r.db.QueryRowContext(ctx,
+		"SELECT id FROM scheduled_payments WHERE public_id = $1",
+		publicID,
+	).Scan(&id)
+
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return 0, ErrScheduledPaymentNotFound
+	case err != nil:
+		return 0, fmt.Errorf("failed to resolve scheduled payment: %w", err)
+	}
+	return id, nil
+}
+
+// ListScheduledPayments returns the account's scheduled payments, newest first.
+func (r *ScheduledPaymentRepository) ListScheduledPayments(ctx context.Context, accountID int) ([]ScheduledPayment, error) {
+	rows, err := r.db.QueryContext(ctx,
+		"SELECT"+scheduledPaymentColumns+" FROM scheduled_payments WHERE account_id = $1 ORDER BY id DESC",
+		accountID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query scheduled payments: %w", err)
+	}
+	defer rows.Close()
+
+	var payments []ScheduledPayment
+	for rows.Next() {
+		p, err := scanScheduledPayment(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan scheduled payment: %w", err)
+		}
+		payments = append(payments, *p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	return payments, nil
+}
+
+// UpdateScheduledPayment applies upd. Resuming a paused payment skips the
+// occurrences missed while it was paused.
+func (r *ScheduledPaymentRepository) UpdateScheduledPayment(ctx context.Context, id int, upd ScheduledPaymentUpdate, now time.Time) (*ScheduledPayment, error) {
+	var p *ScheduledPayment
+	err := withTx(ctx, r.db, r.logger, "update_scheduled_payment", nil, func(tx *sql.Tx) error {
+		var err error
+		p, err = scanScheduledPayment(tx.QueryRowContext(ctx,
+			"SELECT"+scheduledPaymentColumns+" FROM scheduled_payments WHERE id = $1 FOR UPDATE",
+			id,
+		))
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return ErrScheduledPaymentNotFound
+		case err != nil:
+			return fmt.Errorf("failed to load scheduled payment: %w", err)
+		case p.Status == ScheduleCompleted || p.Status == ScheduleCancelled:
+			return ErrScheduledPaymentEnded
+		}
+
+		if upd.Amount != nil {
+			if *upd.Amount <= 0 {
+				return ErrNegativeAmount
+			}
+			p.Amount = *upd.Amount
+		}
+		if upd.Description != nil {
+			p.Description = *upd.Description
+		}
+		if upd.OnInsufficientFunds != nil {
+			p.OnInsufficientFunds = *upd.OnInsufficientFunds
+		}
+		if upd.MaxRetries != nil {
+			p.MaxRetries = *upd.MaxRetries
+		}
+		if upd.Status != nil {
+			resumed := *upd.Status == ScheduleActive && p.Status == SchedulePaused
+			p.Status = *upd.Status
+			if resumed && p.DueAt != nil && !p.DueAt.After(now) {
+				next, err := p.Rule.Next(p.StartAt, now)
+				if err != nil {
+					return err
+				}
+				p.RetryCount = 0
+				p.setNext(next)
+			}
+		}
+		if upd.EndAt != nil {
+			if !upd.EndAt.After(p.StartAt) {
+				return apperrors.Invalid("end_at", "gtfield", "must be after start_at")
+			}
+			p.EndAt = upd.EndAt
+		}
+		if p.DueAt == nil || (p.EndAt != nil && p.DueAt.After(*p.EndAt)) {
+			p.Status, p.NextRunAt = ScheduleCompleted, nil
+		}
+
+		err = tx.QueryRowContext(ctx,
+			`UPDATE scheduled_payments
+			 SET amount = $1, description = NULLIF($2, ''), end_at = $3, due_at = $4, next_run_at = $5,
+			     on_insufficient_funds = $6, max_retries = $7, retry_count = $8, status = $9,
+			     updated_at = CURRENT_TIMESTAMP
+			 WHERE id = $10
+			 RETURNING updated_at`,
+			p.Amount, p.Description, p.EndAt, p.DueAt, p.NextRunAt,
+			p.OnInsufficientFunds, p.MaxRetries, p.RetryCount, p.Status, p.ID,
+		).Scan(&p.UpdatedAt)
+		if err != nil {
+			return fmt.Errorf("failed to update scheduled payment: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// CancelScheduledPayment stops a payment for good. Its run history is kept.
+func (r *ScheduledPaymentRepository) CancelScheduledPayment(ctx context.Context, id int) error {
+	res, err := r.db.ExecContext(ctx,
+		`UPDATE scheduled_payments
+		 SET status = 'cancelled', next_run_at = NULL, updated_at = CURRENT_TIMESTAMP
+		 WHERE id = $1 AND status IN ('active', 'paused')`,
+		id,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to cancel scheduled payment: %w", err)
+	}
+	if n, err := res.RowsAffected(); err != nil || n > 0 {
+		return err
+	}
+
+	// Nothing updated: tell a missing payment from one that already ended
+	if _, err := r.GetScheduledPayment(ctx, id); err != nil {
+		return err
+	}
+	return ErrScheduledPaymentEnded
+}
+
+// ListRuns returns the execution history of a scheduled payment, newest first.
+func (r *ScheduledPaymentRepository) ListRuns(ctx context.Context, id, limit, offset int) ([]ScheduledPaymentRun, error) {
+	if _, err := r.GetScheduledPayment(ctx, id); err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT r.id, r.due_at, r.attempt, r.outcome, COALESCE(r.transaction_id, 0), COALESCE(t.public_id, ''),
+		        COALESCE(r.error_code, ''), r.run_at
+		 FROM scheduled_payment_runs r
+		 LEFT JOIN transactions t ON t.id = r.transaction_id
+		 WHERE r.scheduled_payment_id = $1
+		 ORDER BY r.id DESC
+		 LIMIT $2 OFFSET $3`,
+		id, limit, offset,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query scheduled payment runs: %w", err)
+	}
+	defer rows.Close()
+
+	var runs []ScheduledPaymentRun
+	for rows.Next() {
+		var run ScheduledPaymentRun
+		if err := rows.Scan(&run.ID, &run.DueAt, &run.Attempt, &run.Outcome, &run.TransactionID, &run.TransactionPublicID, &run.ErrorCode, &run.RunAt); err != nil {
+			return nil, fmt.Errorf("failed to scan scheduled payment run: %w", err)
+		}
+		runs = append(runs, run)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	return runs, nil
+}
+
+// RunNext claims one due payment and executes it. Claiming uses
+// FOR UPDATE SKIP LOCKED, so any number of replicas can run the worker
+// without executing a payment twice: the posting, the run record and the
+// move to the next occurrence commit together. It reports false when
+// nothing is due.
+func (r *ScheduledPaymentRepository) RunNext(ctx context.Context, now time.Time) (bool, error) {
+	var (
+		p   *ScheduledPayment
+		run ScheduledPaymentRun
+	)
+	err := withTx(ctx, r.db, r.logger, "scheduled_payment", nil, func(tx *sql.Tx) error {
+		var err error
+		p, err = scanScheduledPayment(tx.QueryRowContext(ctx,
+			"SELECT"+scheduledPaymentColumns+`
+			 FROM scheduled_payments
+			 WHERE status = 'active' AND next_run_at <= $1
+			 ORDER BY next_run_at
+			 LIMIT 1
+			 FOR UPDATE SKIP LOCKED`,
+			now.UTC(),
+		))
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			p = nil
+			return errRollback
+		case err != nil:
+			return fmt.Errorf("failed to claim scheduled payment: %w", err)
+		}
+
+		txID, postErr := r.post(ctx, tx, p)
+		run = ScheduledPaymentRun{DueAt: *p.DueAt, Attempt: p.RetryCount + 1, TransactionID: txID}
+
+		switch {
+		case postErr == nil:
+			run.Outcome = RunPosted
+			err = p.advance()
+		case errors.Is(postErr, ErrPendingReview):
+			run.Outcome = RunHeld
+			err = p.advance()
+		case errors.Is(postErr, ErrInsufficientFunds) && p.OnInsufficientFunds == OnInsufficientFundsRetry && p.RetryCount < p.MaxRetries:
+			run.Outcome, run.ErrorCode = RunRetrying, string(apperrors.CodeInsufficientFunds)
+			p.RetryCount++
+			retryAt := now.UTC().Add(ScheduleRetryDelay)
+			p.NextRunAt = &retryAt
+		case errors.Is(postErr, ErrInsufficientFunds) && p.OnInsufficientFunds == OnInsufficientFundsSkip:
+			run.Outcome, run.ErrorCode = RunSkipped, string(apperrors.CodeInsufficientFunds)
+			err = p.advance()
+		case errors.Is(postErr, ErrAccountClosed) || errors.Is(postErr, ErrAccountNotFound):
+			// The payment can never succeed again
+			run.Outcome, run.ErrorCode = RunFailed, string(apperrors.Lookup(postErr).Code)
+			p.Status, p.NextRunAt = ScheduleCancelled, nil
+		case apperrors.Lookup(postErr).Client():
+			run.Outcome, run.ErrorCode = RunFailed, string(apperrors.Lookup(postErr).Code)
+			err = p.advance()
+		default:
+			return postErr
+		}
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx,
+			`INSERT INTO scheduled_payment_runs (scheduled_payment_id, due_at, attempt, outcome, transaction_id, error_code)
+			 VALUES ($1, $2, $3, $4, NULLIF($5, 0), NULLIF($6, ''))`,
+			p.ID, run.DueAt, run.Attempt, run.Outcome, run.TransactionID, run.ErrorCode,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to record scheduled payment run: %w", err)
+		}
+
+		_, err = tx.ExecContext(ctx,
+			`UPDATE scheduled_payments
+			 SET due_at = $1, next_run_at = $2, retry_count = $3, status = $4, updated_at = CURRENT_TIMESTAMP
+			 WHERE id = $5`,
+			p.DueAt, p.NextRunAt, p.RetryCount, p.Status, p.ID,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to update scheduled payment: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+	if p == nil {
+		return false, nil
+	}
+
+	logging.FromContext(ctx, r.logger).Info("scheduled payment run",
+		logging.KeyOp, "scheduled_payment",
+		logging.KeyAccountID, p.AccountID,
+		"scheduled_payment_id", p.ID,
+		"due_at", run.DueAt,
+		"attempt", run.Attempt,
+		"run_outcome", run.Outcome,
+	)
+	return true, nil
+}
+
+// post executes the payment under a savepoint so that a rejected posting
+// leaves the claim transaction usable for recording the outcome.
+func (r *ScheduledPaymentRepository) post(ctx context.Context, tx *sql.Tx, p *ScheduledPayment) (txID int, err error) {
+	start := time.Now()
+	op := "scheduled_withdrawal"
+	if p.ToAccountID != 0 {
+		op = "scheduled_transfer"
+	}
+	defer func() { logOutcome(ctx, r.logger, op, p.AccountID, txID, start, err) }()
+
+	if _, err := tx.ExecContext(ctx, "SAVEPOINT scheduled_payment"); err != nil {
+		return 0, fmt.Errorf("failed to create savepoint: %w", err)
+	}
+
+	if p.ToAccountID != 0 {
+		txID, err = r.transactions.transfer(ctx, tx, p.AccountID, p.ToAccountID, p.Amount)
+	} else {
+		txID, err = r.transactions.withdraw(ctx, tx, p.AccountID, p.Amount, Details{})
+	}
+
+	if err != nil && !errors.Is(err, ErrPendingReview) {
+		if _, rerr := tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT scheduled_payment"); rerr != nil {
+			return 0, fmt.Errorf("failed to roll back savepoint: %w", rerr)
+		}
+		return 0, err
+	}
+	return txID, err
+}
+
+// advance moves the payment to its next occurrence, completing it when
+// there is none left.
+func (p *ScheduledPayment) advance() error {
+	next, err := p.Rule.Next(p.StartAt, *p.DueAt)
+	if err != nil {
+		return err
+	}
+	p.RetryCount = 0
+	p.setNext(next)
+	return nil
+}
+
+func (p *ScheduledPayment) setNext(next time.Time) {
+	if next.IsZero() || (p.EndAt != nil && next.After(*p.EndAt)) {
+		p.Status, p.NextRunAt = ScheduleCompleted, nil
+		return
+	}
+	p.DueAt, p.NextRunAt = &next, &next
+}
@@ -0,0 +1,445 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	apperrors "github.com/Andrew44Ashraf/fintech-service/internal/errors"
+	"github.com/Andrew44Ashraf/fintech-service/internal/fees"
+	"github.com/Andrew44Ashraf/fintech-service/internal/logging"
+	"github.com/Andrew44Ashraf/fintech-service/internal/models"
+	"github.com/Andrew44Ashraf/fintech-service/internal/screening"
+	"github.com/lib/pq"
+)
+
+var (
+	ErrTransactionDenied       = apperrors.ErrTransactionDenied
+	ErrTransactionNotFound     = apperrors.ErrTransactionNotFound
+	ErrInvalidTransactionState = apperrors.ErrInvalidTransactionState
+
+	// ErrPendingReview is returned together with the transaction ID when a
+	// transaction was recorded but held for manual review. It is not a failure.
+	ErrPendingReview = errors.New("transaction held for review")
+)
+
+// Transaction statuses. Held transactions go from pending_review to posted
+// or rejected, incoming bank transfers from pending to posted or failed.
+const (
+	StatusPosted        = "posted"
+	StatusPendingReview = "pending_review"
+	StatusRejected      = "rejected"
+	StatusPending       = "pending"
+	StatusFailed        = "failed"
+)
+
+// WithScreener sets the screener consulted before every posting.
+func WithScreener(s screening.Screener) TransactionOption {
+	return func(r *TransactionRepository) {
+		r.screener = s
+	}
+}
+
+// Review is a transaction waiting for an operator decision.
+type Review struct {
+	TransactionID int
+	AccountID     int
+	Amount        float64
+	Type          string
+	Reasons       []string
+	CreatedAt     time.Time
+	// Public IDs of the transaction and its account
+	TransactionPublicID string
+	AccountPublicID     string
+}
+
+// txFacts answers screening questions from inside the posting transaction.
+type txFacts struct {
+	tx        *sql.Tx
+	accountID int
+}
+
+func (f txFacts) AccountOpenedAt(ctx context.Context) (time.Time, error) {
+	var openedAt time.Time
+	err := f.tx.QueryRowContext(ctx,
+		"SELECT created_at FROM accounts WHERE id = $1",
+		f.accountID,
+	).Scan(&openedAt)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to load account age: %w", err)
+	}
+	return openedAt, nil
+}
+
+func (f txFacts) RecentActivity(ctx context.Context, window time.Duration, types []string) (screening.Activity, error) {
+	var a screening.Activity
+	err := f.tx.QueryRowContext(ctx,
+		`SELECT COUNT(*), COALESCE(SUM(amount), 0)
+		 FROM transactions
+		 WHERE account_id = $1
+		   AND status IN ('posted', 'pending_review')
+		   AND created_at >= LOCALTIMESTAMP - make_interval(secs => $2)
+		   AND (cardinality($3::text[]) = 0 OR type = ANY($3))`,
+		f.accountID, window.Seconds(), pq.Array(types),
+	).Scan(&a.Count, &a.Total)
+	if err != nil {
+		return a, fmt.Errorf("failed to load recent activity: %w", err)
+	}
+	return a, nil
+}
+
+// screen runs the configured screener. Denials are returned as
+// ErrTransactionDenied; the reasons are logged but never shown to clients.
+func (r *TransactionRepository) screen(ctx context.Context, tx *sql.Tx, accountID int, txType string, amount float64) (screening.Result, error) {
+	if r.screener == nil {
+		return screening.Result{Decision: screening.Allow}, nil
+	}
+
+	result, err := r.screener.Screen(ctx, screening.Request{
+		AccountID: accountID,
+		Type:      txType,
+		Amount:    amount,
+		Facts:     txFacts{tx: tx, accountID: accountID},
+	})
+	if err != nil {
+		return result, fmt.Errorf("screening failed: %w", err)
+	}
+
+	if result.Decision != screening.Allow {
+		logging.FromContext(ctx, r.logger).Warn("transaction flagged by screening",
+			logging.KeyOp, txType,
+			logging.KeyAccountID, accountID,
+			"decision", string(result.Decision),
+			"reasons", strings.Join(result.Reasons, "; "),
+		)
+	}
+	if result.Decision == screening.Deny {
+		return result, ErrTransactionDenied
+	}
+	return result, nil
+}
+
+// holdForReview records the transaction as pending_review without touching
+// the balance. It returns the new ID with ErrPendingReview; the caller
+// still commits.
+func (r *TransactionRepository) holdForReview(ctx context.Context, tx *sql.Tx, accountID int, txType string, amount float64, details Details, reasons []string) (int, error) {
+	var txID int
+	err := tx.QueryRowContext(ctx,
+		`INSERT INTO transactions (account_id, amount, type, status, final_balance, external_ref, description, metadata)
+		 SELECT $1, $2, $3, 'pending_review', balance, NULLIF($4, ''), NULLIF($5, ''), COALESCE($6::jsonb, '{}')
+		 FROM accounts WHERE id = $1
+		 RETURNING id`,
+		accountID, amount, txType, details.ExternalRef, details.Description, details.metadata(),
+	).Scan(&txID)
+	if isUniqueViolation(err, "idx_transactions_external_ref") {
+		return 0, fmt.Errorf("%w: external_ref %q", ErrDuplicateExternalRef, details.ExternalRef)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to create transaction: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx,
+		"INSERT INTO transaction_reviews (transaction_id, reasons) VALUES ($1, $2)",
+		txID, pq.Array(reasons),
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create review: %w", err)
+	}
+
+	return txID, ErrPendingReview
+}
+
+// ListPendingReviews returns held transactions, oldest first
+func (r *TransactionRepository) ListPendingReviews(ctx context.Context, limit, offset int) ([]Review, error) {
+	const query = `
+		SELECT t.id, t.account_id, t.public_id, a.public_id, t.amount, t.type, rv.reasons, t.created_at
+		FROM transaction_reviews rv
+		JOIN transactions t ON t.id = rv.transaction_id
+		JOIN accounts a ON a.id = t.account_id
+		WHERE rv.decision IS NULL
+		ORDER BY t.created_at, t.id
+		LIMIT $1 OFFSET $2
+	`
+
+	rows, err := r.reader(ctx).QueryContext(ctx, query, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query reviews: %w", err)
+	}
+	defer rows.Close()
+
+	var reviews []Review
+	for rows.Next() {
+		var rv Review
+		if err := rows.Scan(
+			&rv.TransactionID,
+			&rv.AccountID,
+			&rv.TransactionPublicID,
+			&rv.AccountPublicID,
+			&rv.Amount,
+			&rv.Type,
+			pq.Array(&rv.Reasons),
+			&rv.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan review: %w", err)
+		}
+		reviews = append(reviews, rv)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	return reviews, nil
+}
+
+// ApproveTransaction posts a held transaction. Balance, account status,
+// product rules and withdrawal limits are checked again because they may
+// have changed since it was held.
+func (r *TransactionRepository) ApproveTransaction(ctx context.Context, txID int, operator, note string) (err error) {
+	start := time.Now()
+	var accountID int
+	defer func() { logOutcome(ctx, r.logger, "approve_review", accountID, txID, start, err) }()
+
+	var balances []accountBalance
+	err = withTx(ctx, r.db, r.logger, "approve_review", nil, func(tx *sql.Tx) error {
+		var (
+			amount float64
+			txType string
+			err    error
+		)
+		balances = nil
+		accountID, amount, txType, err = lockPendingReview(ctx, tx, txID)
+		if err != nil {
+			return err
+		}
+
+		accountIDs := []int{accountID}
+		if txType == "transfer_out" {
+			var counterpartyID int
+			counterpartyID, err = r.approveTransfer(ctx, tx, txID, accountID, amount)
+			accountIDs = append(accountIDs, counterpartyID)
+		} else {
+			err = r.approvePosting(ctx, tx, txID, accountID, amount, txType)
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := decideReview(ctx, tx, txID, "approved", operator, note); err != nil {
+			return err
+		}
+		balances, err = readBalances(ctx, tx, r.balances, accountIDs)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	cacheBalances(ctx, r.logger, r.balances, balances)
+	return nil
+}
+
+// RejectTransaction discards a held transaction. The balance is untouched
+// but is refreshed in the cache all the same, like after an approval.
+func (r *TransactionRepository) RejectTransaction(ctx context.Context, txID int, operator, note string) (err error) {
+	start := time.Now()
+	var accountID int
+	defer func() { logOutcome(ctx, r.logger, "reject_review", accountID, txID, start, err) }()
+
+	var balances []accountBalance
+	err = withTx(ctx, r.db, r.logger, "reject_review", nil, func(tx *sql.Tx) error {
+		var err error
+		balances = nil
+		accountID, _, _, err = lockPendingReview(ctx, tx, txID)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx,
+			"UPDATE transactions SET status = 'rejected' WHERE id = $1",
+			txID,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to update transaction record: %w", err)
+		}
+
+		if err := decideReview(ctx, tx, txID, "rejected", operator, note); err != nil {
+			return err
+		}
+		balances, err = readBalances(ctx, tx, r.balances, []int{accountID})
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	cacheBalances(ctx, r.logger, r.balances, balances)
+	return nil
+}
+
+// approvePosting applies a held deposit or withdrawal to the balance. A
+// withdrawal is held before it counts towards the limits, so they are
+// enforced here against what has been posted since.
+func (r *TransactionRepository) approvePosting(ctx context.Context, tx *sql.Tx, txID, accountID int, amount float64, txType string) error {
+	var (
+		currentBalance float64
+		accountStatus  string
+	)
+	err := tx.QueryRowContext(ctx,
+		"SELECT balance, status FROM accounts WHERE id = $1 FOR UPDATE",
+		accountID,
+	).Scan(&currentBalance, &accountStatus)
+	switch {
+	case err != nil:
+		return fmt.Errorf("account verification failed: %w", err)
+	case accountStatus != AccountActive:
+		return inactiveAccountError(accountStatus)
+	}
+
+	rules, err := loadAccountRules(ctx, tx, accountID)
+	if err != nil {
+		return err
+	}
+	rule := rules[accountID]
+	if !rule.allows(txType) {
+		return ErrTransactionNotAllowed
+	}
+
+	delta, fee := amount, 0.0
+	if txType == "withdrawal" {
+		if err := r.checkLimits(ctx, tx, accountID, rule, amount); err != nil {
+			return err
+		}
+		if fee, err = withdrawalFee(ctx, tx, accountID); err != nil {
+			return err
+		}
+		if currentBalance < amount+fee+rule.floor {
+			return ErrInsufficientFunds
+		}
+		delta = -amount
+	}
+
+	var finalBalance float64
+	err = tx.QueryRowContext(ctx,
+		"UPDATE accounts SET balance = balance + $1 WHERE id = $2 RETURNING balance",
+		delta, accountID,
+	).Scan(&finalBalance)
+	if err != nil {
+		return fmt.Errorf("balance update failed: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx,
+		"UPDATE transactions SET status = 'posted', final_balance = $1 WHERE id = $2",
+		finalBalance, txID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update transaction record: %w", err)
+	}
+
+	if fee > 0 {
+		if _, err := postFee(ctx, tx, accountID, models.Fee, fees.Withdrawal, fee, txID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// approveTransfer completes a held transfer: the held transaction becomes
+// the transfer_out leg and the matching transfer_in leg is booked. Like a
+// held withdrawal, it counts towards the limits only now. It returns the
+// account credited.
+func (r *TransactionRepository) approveTransfer(ctx context.Context, tx *sql.Tx, txID, accountID int, amount float64) (counterpartyID int, err error) {
+	err = tx.QueryRowContext(ctx,
+		"SELECT counterparty_account_id FROM transactions WHERE id = $1",
+		txID,
+	).Scan(&counterpartyID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to load transaction: %w", err)
+	}
+
+	accounts, err := lockAccounts(ctx, tx, accountID, counterpartyID)
+	if err != nil {
+		return 0, err
+	}
+	from, to := accounts[accountID], accounts[counterpartyID]
+	if err := inactiveAccountError(from.status, to.status); err != nil {
+		return 0, err
+	}
+	if err := checkSameCurrency(from, to); err != nil {
+		return 0, err
+	}
+
+	rules, err := loadAccountRules(ctx, tx, accountID, counterpartyID)
+	if err != nil {
+		return 0, err
+	}
+	switch {
+	case !rules[accountID].allows("transfer_out") || !rules[counterpartyID].allows("transfer_in"):
+		return 0, ErrTransactionNotAllowed
+	case from.balance < amount+rules[accountID].floor:
+		return 0, ErrInsufficientFunds
+	}
+	if err := r.checkLimits(ctx, tx, accountID, rules[accountID], amount); err != nil {
+		return 0, err
+	}
+
+	var finalBalance float64
+	err = tx.QueryRowContext(ctx,
+		"UPDATE accounts SET balance = balance - $1 WHERE id = $2 RETURNING balance",
+		amount, accountID,
+	).Scan(&finalBalance)
+	if err != nil {
+		return 0, fmt.Errorf("balance update failed: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx,
+		"UPDATE transactions SET status = 'posted', final_balance = $1 WHERE id = $2",
+		finalBalance, txID,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to update transaction record: %w", err)
+	}
+
+	_, err = postLeg(ctx, tx, counterpartyID, accountID, "transfer_in", amount)
+	return counterpartyID, err
+}
+
+func lockPendingReview(ctx context.Context, tx *sql.Tx, txID int) (accountID int, amount float64, txType string, err error) {
+	return lockInStatus(ctx, tx, txID, StatusPendingReview)
+}
+
+// lockInStatus locks a transaction that must be in status want, e.g. before
+// moving it out of it. Other statuses are reported as
+// ErrInvalidTransactionState.
+func lockInStatus(ctx context.Context, tx *sql.Tx, txID int, want string) (accountID int, amount float64, txType string, err error) {
+	var status string
+	err = tx.QueryRowContext(ctx,
+		"SELECT account_id, amount, type, status FROM transactions WHERE id = $1 FOR UPDATE",
+		txID,
+	).Scan(&accountID, &amount, &txType, &status)
+
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return 0, 0, "", ErrTransactionNotFound
+	case err != nil:
+		return 0, 0, "", fmt.Errorf("failed to load transaction: %w", err)
+	case status != want:
+		return 0, 0, "", ErrInvalidTransactionState
+	}
+	return accountID, amount, txType, nil
+}
+
+func decideReview(ctx context.Context, tx *sql.Tx, txID int, decision, operator, note string) error {
+	_, err := tx.ExecContext(ctx,
+		`UPDATE transaction_reviews
+		 SET decision = $1, decided_by = $2, note = $3, decided_at = CURRENT_TIMESTAMP
+		 WHERE transaction_id = $4`,
+		decision, operator, note, txID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record review decision: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,267 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/Andrew44Ashraf/fintech-service/internal/models"
+	"github.com/Andrew44Ashraf/fintech-service/internal/statements"
+)
+
+type StatementRepository struct {
+	db     *sql.DB
+	logger *slog.Logger
+	reads  ReadRouter
+}
+
+// StatementOption configures optional StatementRepository behaviour.
+type StatementOption func(*StatementRepository)
+
+func NewStatementRepository(db *sql.DB, logger *slog.Logger, opts ...StatementOption) *StatementRepository {
+	r := &StatementRepository{db: db, logger: logger}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// BuildStatement computes a statement for transactions posted in [from, to)
+// from a single consistent snapshot, listing those still pending at to
+// apart. The snapshot is taken on the read router's database.
+func (r *StatementRepository) BuildStatement(ctx context.Context, accountID int, from, to time.Time) (*statements.Statement, error) {
+	tx, err := r.reader(ctx).BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// 1. Verify account exists
+	var (
+		currentBalance float64
+		openedAt       time.Time
+		currency       string
+	)
+	err = tx.QueryRowContext(ctx,
+		"SELECT balance, created_at, currency FROM accounts WHERE id = $1",
+		accountID,
+	).Scan(&currentBalance, &openedAt, &currency)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return nil, ErrAccountNotFound
+	case err != nil:
+		return nil, fmt.Errorf("account verification failed: %w", err)
+	}
+
+	stmt := &statements.Statement{
+		AccountID:   accountID,
+		Currency:    currency,
+		From:        from,
+		To:          to,
+		GeneratedAt: time.Now().UTC(),
+	}
+
+	// 2. Lines with their running balance
+	rows, err := tx.QueryContext(ctx,
+		`SELECT id, type, amount, posted_at, value_date, final_balance
+		 FROM transactions
+		 WHERE account_id = $1 AND status = 'posted'
+		   AND posted_at >= $2 AND posted_at < $3
+		 ORDER BY posted_at, id`,
+		accountID, from, to,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query transactions: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var l statements.Line
+		if err := rows.Scan(&l.TransactionID, &l.Type, &l.Amount, &l.Date, &l.ValueDate, &l.Balance); err != nil {
+			return nil, fmt.Errorf("failed to scan transaction: %w", err)
+		}
+		stmt.Lines = append(stmt.Lines, l)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	// 3. Transactions not posted by the end of the period
+	stmt.Pending, err = pendingLines(ctx, tx, accountID, to)
+	if err != nil {
+		return nil, err
+	}
+
+	// 4. Opening balance
+	if !openedAt.Before(to) {
+		stmt.Summarize()
+		return stmt, nil
+	}
+	stmt.OpeningBalance, err = openingBalance(ctx, tx, accountID, from, to, stmt.Lines, currentBalance)
+	if err != nil {
+		return nil, err
+	}
+
+	stmt.Summarize()
+	return stmt, nil
+}
+
+// pendingLines returns the transactions recorded before to that were not
+// posted by then: those still pending or held for review, and those posted
+// since. Transactions since failed or rejected are left out.
+func pendingLines(ctx context.Context, tx *sql.Tx, accountID int, to time.Time) ([]statements.Line, error) {
+	rows, err := tx.QueryContext(ctx,
+		`SELECT id, type, amount, created_at, value_date
+		 FROM transactions
+		 WHERE account_id = $1 AND created_at < $2
+		   AND (status IN ('pending', 'pending_review') OR (status = 'posted' AND posted_at >= $2))
+		 ORDER BY created_at, id`,
+		accountID, to,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query pending transactions: %w", err)
+	}
+	defer rows.Close()
+
+	var lines []statements.Line
+	for rows.Next() {
+		var l statements.Line
+		if err := rows.Scan(&l.TransactionID, &l.Type, &l.Amount, &l.Date, &l.ValueDate); err != nil {
+			return nil, fmt.Errorf("failed to scan transaction: %w", err)
+		}
+		lines = append(lines, l)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+	return lines, nil
+}
+
+// openingBalance is the balance after the last posted transaction before
+// from. Accounts may be opened with an initial balance that has no
+// transaction, so without an earlier transaction the balance is worked back
+// from the first later one, or is the current balance if there is none.
+func openingBalance(ctx context.Context, tx *sql.Tx, accountID int, from, to time.Time, lines []statements.Line, currentBalance float64) (float64, error) {
+	var balance float64
+	err := tx.QueryRowContext(ctx,
+		`SELECT final_balance FROM transactions
+		 WHERE account_id = $1 AND status = 'posted' AND posted_at < $2
+		 ORDER BY posted_at DESC, id DESC
+		 LIMIT 1`,
+		accountID, from,
+	).Scan(&balance)
+	switch {
+	case err == nil:
+		return balance, nil
+	case !errors.Is(err, sql.ErrNoRows):
+		return 0, fmt.Errorf("failed to get opening balance: %w", err)
+	}
+
+	if len(lines) > 0 {
+		return lines[0].Balance - lines[0].Signed(), nil
+	}
+
+	var (
+		txType string
+		amount float64
+	)
+	err = tx.QueryRowContext(ctx,
+		`SELECT type, amount, final_balance FROM transactions
+		 WHERE account_id = $1 AND status = 'posted' AND posted_at >= $2
+		 ORDER BY posted_at, id
+		 LIMIT 1`,
+		accountID, to,
+	).Scan(&txType, &amount, &balance)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return currentBalance, nil
+	case err != nil:
+		return 0, fmt.Errorf("failed to get opening balance: %w", err)
+	}
+	return balance - models.TransactionType(txType).Signed(amount), nil
+}
+
+// GetStoredStatement returns the pre-generated statement for exactly
+// [from, to), or nil if none was stored.
+func (r *StatementRepository) GetStoredStatement(ctx context.Context, accountID int, from, to time.Time) (*statements.Statement, error) {
+	var payload []byte
+	err := r.db.QueryRowContext(ctx,
+		`SELECT payload FROM statements
+		 WHERE account_id = $1 AND period_start = $2 AND period_end = $3`,
+		accountID, from, to,
+	).Scan(&payload)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return nil, nil
+	case err != nil:
+		return nil, fmt.Errorf("failed to load statement: %w", err)
+	}
+
+	var stmt statements.Statement
+	if err := json.Unmarshal(payload, &stmt); err != nil {
+		return nil, fmt.Errorf("failed to decode statement: %w", err)
+	}
+	return &stmt, nil
+}
+
+// SaveStatement stores a statement. Stored statements are immutable, so an
+// existing one for the same period is kept and false is returned.
+func (r *StatementRepository) SaveStatement(ctx context.Context, stmt *statements.Statement) (bool, error) {
+	payload, err := json.Marshal(stmt)
+	if err != nil {
+		return false, fmt.Errorf("failed to encode statement: %w", err)
+	}
+
+	res, err := r.db.ExecContext(ctx,
+		`INSERT INTO statements
+		 (account_id, period_start, period_end, opening_balance, closing_balance, payload)
+		 VALUES ($1, $2, $3, $4, $5, $6)
+		 ON CONFLICT (account_id, period_start, period_end) DO NOTHING`,
+		stmt.AccountID, stmt.From, stmt.To, stmt.OpeningBalance, stmt.ClosingBalance, payload,
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to save statement: %w", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to save statement: %w", err)
+	}
+	return n == 1, nil
+}
+
+// AccountsMissingStatement lists accounts opened before to that have no
+// stored statement for [from, to).
+func (r *StatementRepository) AccountsMissingStatement(ctx context.Context, from, to time.Time) ([]int, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT a.id FROM accounts a
+		 WHERE a.created_at < $2
+		   AND NOT EXISTS (
+			SELECT 1 FROM statements s
+			WHERE s.account_id = a.id AND s.period_start = $1 AND s.period_end = $2
+		   )
+		 ORDER BY a.id`,
+		from, to,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query accounts: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan account: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+	return ids, nil
+}
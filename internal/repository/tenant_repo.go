@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	apperrors "github.com/Andrew44Ashraf/fintech-service/internal/errors"
+	"github.com/Andrew44Ashraf/fintech-service/internal/logging"
+	"github.com/Andrew44Ashraf/fintech-service/internal/tenant"
+)
+
+var ErrTenantExists = apperrors.ErrTenantExists
+
+// Tenant is an isolated customer of the service. Accounts and all data
+// about them belong to one tenant and are invisible to the others.
+type Tenant struct {
+	ID        string
+	Name      string
+	CreatedAt time.Time
+}
+
+type TenantRepository struct {
+	db     *sql.DB
+	logger *slog.Logger
+}
+
+func NewTenantRepository(db *sql.DB, logger *slog.Logger) *TenantRepository {
+	return &TenantRepository{db: db, logger: logger}
+}
+
+// CreateTenant registers a tenant. The ID is what API keys and the
+// X-Tenant-ID header refer to, so it cannot change.
+func (r *TenantRepository) CreateTenant(ctx context.Context, id, name string) (*Tenant, error) {
+	switch {
+	case !tenant.Valid(id):
+		return nil, apperrors.Invalid("id", "tenant",
+			fmt.Sprintf("must be 1 to %d lowercase letters, digits or hyphens", tenant.MaxIDLength))
+	case name == "":
+		return nil, apperrors.Invalid("name", "required", "is required")
+	}
+
+	t := &Tenant{ID: id, Name: name}
+	err := r.db.QueryRowContext(ctx,
+		`INSERT INTO tenants (id, name) VALUES ($1, $2)
+		 ON CONFLICT (id) DO NOTHING
+		 RETURNING created_at`,
+		id, name,
+	).Scan(&t.CreatedAt)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return nil, ErrTenantExists
+	case err != nil:
+		return nil, fmt.Errorf("failed to create tenant: %w", err)
+	}
+
+	logging.FromContext(ctx, r.logger).Info("tenant created",
+		logging.KeyOp, "create_tenant",
+		logging.KeyTenantID, id,
+		logging.KeyOutcome, logging.OutcomeSuccess,
+	)
+	return t, nil
+}
+
+// ListTenants returns all tenants in ID order.
+func (r *TenantRepository) ListTenants(ctx context.Context) ([]Tenant, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT id, name, created_at FROM tenants ORDER BY id")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query tenants: %w", err)
+	}
+	defer rows.Close()
+
+	var tenants []Tenant
+	for rows.Next() {
+		var t Tenant
+		if err := rows.Scan(&t.ID, &t.Name, &t.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan tenant: %w", err)
+		}
+		tenants = append(tenants, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+	return tenants, nil
+}
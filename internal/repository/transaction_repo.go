@@ -5,48 +5,151 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"log/slog"
+	"math"
 	"time"
+
+	"github.com/Andrew44Ashraf/fintech-service/internal/cache"
+	apperrors "github.com/Andrew44Ashraf/fintech-service/internal/errors"
+	"github.com/Andrew44Ashraf/fintech-service/internal/fees"
+	"github.com/Andrew44Ashraf/fintech-service/internal/ids"
+	"github.com/Andrew44Ashraf/fintech-service/internal/models"
+	"github.com/Andrew44Ashraf/fintech-service/internal/screening"
+	"github.com/lib/pq"
 )
 
 var (
-	ErrTransactionFailed   = errors.New("transaction processing failed")
-	ErrNegativeAmount      = errors.New("amount must be positive")
-	ErrInvalidTransaction = errors.New("invalid transaction type")
+	ErrTransactionFailed  = errors.New("transaction processing failed")
+	ErrNegativeAmount     = apperrors.ErrNegativeAmount
+	ErrInvalidTransaction = apperrors.ErrInvalidTransaction
+	ErrCurrencyMismatch   = apperrors.ErrCurrencyMismatch
 )
 
 type TransactionRepository struct {
-	db *sql.DB
+	db               *sql.DB
+	logger           *slog.Logger
+	withdrawalLimits WithdrawalLimits
+	screener         screening.Screener
+	reads            ReadRouter
+	balances         cache.Store
+}
+
+// TransactionOption configures optional TransactionRepository behaviour.
+type TransactionOption func(*TransactionRepository)
+
+// WithWithdrawalLimits sets the default withdrawal limits. Accounts can
+// override them through the account_limits table.
+func WithWithdrawalLimits(limits WithdrawalLimits) TransactionOption {
+	return func(r *TransactionRepository) {
+		r.withdrawalLimits = limits
+	}
 }
 
-func NewTransactionRepository(db *sql.DB) *TransactionRepository {
-	return &TransactionRepository{db: db}
+func NewTransactionRepository(db *sql.DB, logger *slog.Logger, opts ...TransactionOption) *TransactionRepository {
+	r := &TransactionRepository{db: db, logger: logger}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
 }
 
 // Transaction represents a financial transaction
 type Transaction struct {
-	ID          int
-	AccountID   int
-	Amount      float64
-	Type        string // "deposit" or "withdrawal"
-	CreatedAt   time.Time
+	ID           int
+	AccountID    int
+	Amount       float64
+	Type         string // see models.TransactionType
+	Status       string // "posted", "pending_review", "rejected", "pending" or "failed"
+	CreatedAt    time.Time
 	FinalBalance float64
+	Details      Details
+	// PublicID is the ID clients know the transaction by.
+	PublicID string
+	// ValueDate is the date from which the amount counts. BookingDate is
+	// the date it was posted, nil until then.
+	ValueDate   time.Time
+	BookingDate *time.Time
 }
 
-// CreateDeposit handles deposit transactions atomically
-func (r *TransactionRepository) CreateDeposit(ctx context.Context, accountID int, amount float64) (int, error) {
-	if amount <= 0 {
-		return 0, ErrNegativeAmount
+// CreateDeposit handles deposit transactions atomically. A non-empty
+// details.ExternalRef the account has used before fails with
+// ErrDuplicateExternalRef.
+func (r *TransactionRepository) CreateDeposit(ctx context.Context, accountID int, amount float64, details Details) (txID int, err error) {
+	start := time.Now()
+	defer func() { logOutcome(ctx, r.logger, "deposit", accountID, txID, start, err) }()
+	if err := details.validate(); err != nil {
+		return 0, err
 	}
 
-	tx, err := r.db.BeginTx(ctx, nil)
+	return r.inTx(ctx, "deposit", []int{accountID}, func(tx *sql.Tx) (int, error) {
+		return r.deposit(ctx, tx, accountID, amount, details)
+	})
+}
+
+// CreateWithdrawal handles withdrawal transactions atomically. Details are
+// checked as for CreateDeposit.
+func (r *TransactionRepository) CreateWithdrawal(ctx context.Context, accountID int, amount float64, details Details) (txID int, err error) {
+	start := time.Now()
+	defer func() { logOutcome(ctx, r.logger, "withdrawal", accountID, txID, start, err) }()
+	if err := details.validate(); err != nil {
+		return 0, err
+	}
+
+	return r.inTx(ctx, "withdrawal", []int{accountID}, func(tx *sql.Tx) (int, error) {
+		return r.withdraw(ctx, tx, accountID, amount, details)
+	})
+}
+
+// CreateTransfer moves amount between two accounts atomically. It returns
+// the ID of the transfer_out leg booked on the source account.
+func (r *TransactionRepository) CreateTransfer(ctx context.Context, fromAccountID, toAccountID int, amount float64) (txID int, err error) {
+	start := time.Now()
+	defer func() { logOutcome(ctx, r.logger, "transfer", fromAccountID, txID, start, err) }()
+
+	return r.inTx(ctx, "transfer", []int{fromAccountID, toAccountID}, func(tx *sql.Tx) (int, error) {
+		return r.transfer(ctx, tx, fromAccountID, toAccountID, amount)
+	})
+}
+
+// inTx runs post in a database transaction through withTx. Held
+// transactions are committed too: ErrPendingReview is not a failure. The
+// balance cache gets the new balances of accountIDs as soon as the
+// transaction commits, before the caller can read them.
+func (r *TransactionRepository) inTx(ctx context.Context, op string, accountIDs []int, post func(*sql.Tx) (int, error)) (txID int, err error) {
+	var (
+		held     error
+		balances []accountBalance
+	)
+	err = withTx(ctx, r.db, r.logger, op, nil, func(tx *sql.Tx) error {
+		txID, held, balances = 0, nil, nil
+		id, err := post(tx)
+		switch {
+		case errors.Is(err, ErrPendingReview):
+			txID, held = id, err
+			return nil
+		case err != nil:
+			return err
+		}
+		txID = id
+		balances, err = readBalances(ctx, tx, r.balances, accountIDs)
+		return err
+	})
 	if err != nil {
-		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+		return 0, err
+	}
+	cacheBalances(ctx, r.logger, r.balances, balances)
+	return txID, held
+}
+
+// deposit posts a deposit inside tx. The caller commits.
+func (r *TransactionRepository) deposit(ctx context.Context, tx *sql.Tx, accountID int, amount float64, details Details) (int, error) {
+	if amount <= 0 {
+		return 0, ErrNegativeAmount
 	}
-	defer tx.Rollback()
 
 	// 1. Verify account exists and is active
 	var accountStatus string
-	err = tx.QueryRowContext(ctx,
+	err := tx.QueryRowContext(ctx,
 		"SELECT status FROM accounts WHERE id = $1 FOR UPDATE",
 		accountID,
 	).Scan(&accountStatus)
@@ -56,19 +159,43 @@ func (r *TransactionRepository) CreateDeposit(ctx context.Context, accountID int
 		return 0, ErrAccountNotFound
 	case err != nil:
 		return 0, fmt.Errorf("account verification failed: %w", err)
-	case accountStatus != "active":
-		return 0, ErrAccountClosed
+	case accountStatus != AccountActive:
+		return 0, inactiveAccountError(accountStatus)
+	}
+	if err := checkExternalRef(ctx, tx, accountID, details.ExternalRef); err != nil {
+		return 0, err
+	}
+
+	// The account's product decides which transaction types it accepts
+	rules, err := loadAccountRules(ctx, tx, accountID)
+	if err != nil {
+		return 0, err
+	}
+	if !rules[accountID].allows("deposit") {
+		return 0, ErrTransactionNotAllowed
+	}
+
+	// Screen before anything is written; held transactions don't move money
+	result, err := r.screen(ctx, tx, accountID, "deposit", amount)
+	if err != nil {
+		return 0, err
+	}
+	if result.Decision == screening.Review {
+		return r.holdForReview(ctx, tx, accountID, "deposit", amount, details, result.Reasons)
 	}
 
 	// 2. Create transaction record
 	var txID int
 	err = tx.QueryRowContext(ctx,
 		`INSERT INTO transactions 
-		 (account_id, amount, type) 
-		 VALUES ($1, $2, 'deposit')
+		 (account_id, amount, type, external_ref, description, metadata) 
+		 VALUES ($1, $2, 'deposit', NULLIF($3, ''), NULLIF($4, ''), COALESCE($5::jsonb, '{}'))
 		 RETURNING id`,
-		accountID, amount,
+		accountID, amount, details.ExternalRef, details.Description, details.metadata(),
 	).Scan(&txID)
+	if isUniqueViolation(err, "idx_transactions_external_ref") {
+		return 0, fmt.Errorf("%w: external_ref %q", ErrDuplicateExternalRef, details.ExternalRef)
+	}
 	if err != nil {
 		return 0, fmt.Errorf("failed to create transaction: %w", err)
 	}
@@ -101,31 +228,21 @@ func (r *TransactionRepository) CreateDeposit(ctx context.Context, accountID int
 		return 0, fmt.Errorf("failed to update transaction record: %w", err)
 	}
 
-	if err := tx.Commit(); err != nil {
-		return 0, fmt.Errorf("transaction commit failed: %w", err)
-	}
-
 	return txID, nil
 }
 
-// CreateWithdrawal handles withdrawal transactions atomically
-func (r *TransactionRepository) CreateWithdrawal(ctx context.Context, accountID int, amount float64) (int, error) {
+// withdraw posts a withdrawal inside tx. The caller commits.
+func (r *TransactionRepository) withdraw(ctx context.Context, tx *sql.Tx, accountID int, amount float64, details Details) (int, error) {
 	if amount <= 0 {
 		return 0, ErrNegativeAmount
 	}
 
-	tx, err := r.db.BeginTx(ctx, nil)
-	if err != nil {
-		return 0, fmt.Errorf("failed to begin transaction: %w", err)
-	}
-	defer tx.Rollback()
-
 	// 1. Verify account status and get current balance
 	var (
 		currentBalance float64
 		accountStatus  string
 	)
-	err = tx.QueryRowContext(ctx,
+	err := tx.QueryRowContext(ctx,
 		"SELECT balance, status FROM accounts WHERE id = $1 FOR UPDATE",
 		accountID,
 	).Scan(&currentBalance, &accountStatus)
@@ -135,9 +252,46 @@ func (r *TransactionRepository) CreateWithdrawal(ctx context.Context, accountID
 		return 0, ErrAccountNotFound
 	case err != nil:
 		return 0, fmt.Errorf("account verification failed: %w", err)
-	case accountStatus != "active":
-		return 0, ErrAccountClosed
-	case currentBalance < amount:
+	case accountStatus != AccountActive:
+		return 0, inactiveAccountError(accountStatus)
+	}
+	if err := checkExternalRef(ctx, tx, accountID, details.ExternalRef); err != nil {
+		return 0, err
+	}
+
+	// Product rules: allowed types, minimum balance or overdraft, and limits
+	rules, err := loadAccountRules(ctx, tx, accountID)
+	if err != nil {
+		return 0, err
+	}
+	rule := rules[accountID]
+	switch {
+	case !rule.allows("withdrawal"):
+		return 0, ErrTransactionNotAllowed
+	case currentBalance < amount+rule.floor:
+		return 0, ErrInsufficientFunds
+	}
+
+	// Enforce withdrawal limits while holding the account lock
+	if err := r.checkLimits(ctx, tx, accountID, rule, amount); err != nil {
+		return 0, err
+	}
+
+	// Screen before anything is written; held transactions don't move money
+	result, err := r.screen(ctx, tx, accountID, "withdrawal", amount)
+	if err != nil {
+		return 0, err
+	}
+	if result.Decision == screening.Review {
+		return r.holdForReview(ctx, tx, accountID, "withdrawal", amount, details, result.Reasons)
+	}
+
+	// The fee is posted with the withdrawal, so the balance must cover both
+	fee, err := withdrawalFee(ctx, tx, accountID)
+	if err != nil {
+		return 0, err
+	}
+	if currentBalance < amount+fee+rule.floor {
 		return 0, ErrInsufficientFunds
 	}
 
@@ -145,11 +299,14 @@ func (r *TransactionRepository) CreateWithdrawal(ctx context.Context, accountID
 	var txID int
 	err = tx.QueryRowContext(ctx,
 		`INSERT INTO transactions 
-		 (account_id, amount, type) 
-		 VALUES ($1, $2, 'withdrawal')
+		 (account_id, amount, type, external_ref, description, metadata) 
+		 VALUES ($1, $2, 'withdrawal', NULLIF($3, ''), NULLIF($4, ''), COALESCE($5::jsonb, '{}'))
 		 RETURNING id`,
-		accountID, amount,
+		accountID, amount, details.ExternalRef, details.Description, details.metadata(),
 	).Scan(&txID)
+	if isUniqueViolation(err, "idx_transactions_external_ref") {
+		return 0, fmt.Errorf("%w: external_ref %q", ErrDuplicateExternalRef, details.ExternalRef)
+	}
 	if err != nil {
 		return 0, fmt.Errorf("failed to create transaction: %w", err)
 	}
@@ -182,24 +339,173 @@ func (r *TransactionRepository) CreateWithdrawal(ctx context.Context, accountID
 		return 0, fmt.Errorf("failed to update transaction record: %w", err)
 	}
 
-	if err := tx.Commit(); err != nil {
-		return 0, fmt.Errorf("transaction commit failed: %w", err)
+	// 6. Charge the withdrawal fee as its own transaction
+	if fee > 0 {
+		if _, err := postFee(ctx, tx, accountID, models.Fee, fees.Withdrawal, fee, txID); err != nil {
+			return 0, err
+		}
+	}
+
+	return txID, nil
+}
+
+// transfer posts both legs of a transfer inside tx. The caller commits.
+func (r *TransactionRepository) transfer(ctx context.Context, tx *sql.Tx, fromAccountID, toAccountID int, amount float64) (int, error) {
+	switch {
+	case amount <= 0:
+		return 0, ErrNegativeAmount
+	case fromAccountID == toAccountID:
+		return 0, fmt.Errorf("%w: cannot transfer to the same account", ErrInvalidTransaction)
+	}
+
+	// 1. Lock both accounts in ID order so concurrent transfers can't deadlock
+	accounts, err := lockAccounts(ctx, tx, fromAccountID, toAccountID)
+	if err != nil {
+		return 0, err
+	}
+	from, to := accounts[fromAccountID], accounts[toAccountID]
+	if err := inactiveAccountError(from.status, to.status); err != nil {
+		return 0, err
+	}
+	if err := checkSameCurrency(from, to); err != nil {
+		return 0, err
+	}
+
+	rules, err := loadAccountRules(ctx, tx, fromAccountID, toAccountID)
+	if err != nil {
+		return 0, err
+	}
+	switch {
+	case !rules[fromAccountID].allows("transfer_out") || !rules[toAccountID].allows("transfer_in"):
+		return 0, ErrTransactionNotAllowed
+	case from.balance < amount+rules[fromAccountID].floor:
+		return 0, ErrInsufficientFunds
+	}
+
+	// Money leaving by transfer counts towards the withdrawal limits
+	if err := r.checkLimits(ctx, tx, fromAccountID, rules[fromAccountID], amount); err != nil {
+		return 0, err
+	}
+
+	// Screen the debit side; a held transfer is completed on approval
+	result, err := r.screen(ctx, tx, fromAccountID, "transfer", amount)
+	if err != nil {
+		return 0, err
+	}
+	if result.Decision == screening.Review {
+		txID, err := r.holdForReview(ctx, tx, fromAccountID, "transfer_out", amount, Details{}, result.Reasons)
+		if txID != 0 {
+			if _, uerr := tx.ExecContext(ctx,
+				"UPDATE transactions SET counterparty_account_id = $1 WHERE id = $2",
+				toAccountID, txID,
+			); uerr != nil {
+				return 0, fmt.Errorf("failed to update transaction record: %w", uerr)
+			}
+		}
+		return txID, err
+	}
+
+	// 2. Book both legs
+	txID, err := postLeg(ctx, tx, fromAccountID, toAccountID, "transfer_out", -amount)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := postLeg(ctx, tx, toAccountID, fromAccountID, "transfer_in", amount); err != nil {
+		return 0, err
 	}
 
 	return txID, nil
 }
 
-// GetTransactions retrieves transaction history for an account
-func (r *TransactionRepository) GetTransactions(ctx context.Context, accountID int, limit, offset int) ([]Transaction, error) {
+type lockedAccount struct {
+	balance  float64
+	status   string
+	currency string
+}
+
+// checkSameCurrency refuses transfers between accounts in different
+// currencies; there is no conversion.
+func checkSameCurrency(from, to lockedAccount) error {
+	if from.currency != to.currency {
+		return fmt.Errorf("%w: %s to %s", ErrCurrencyMismatch, from.currency, to.currency)
+	}
+	return nil
+}
+
+// lockAccounts locks the given accounts FOR UPDATE in ID order and returns
+// them by ID. A missing account is reported as ErrAccountNotFound.
+func lockAccounts(ctx context.Context, tx *sql.Tx, ids ...int) (map[int]lockedAccount, error) {
+	rows, err := tx.QueryContext(ctx,
+		"SELECT id, balance, status, currency FROM accounts WHERE id = ANY($1) ORDER BY id FOR UPDATE",
+		pq.Array(ids),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("account verification failed: %w", err)
+	}
+	defer rows.Close()
+
+	accounts := make(map[int]lockedAccount, len(ids))
+	for rows.Next() {
+		var (
+			id int
+			a  lockedAccount
+		)
+		if err := rows.Scan(&id, &a.balance, &a.status, &a.currency); err != nil {
+			return nil, fmt.Errorf("account verification failed: %w", err)
+		}
+		accounts[id] = a
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("account verification failed: %w", err)
+	}
+
+	for _, id := range ids {
+		if _, ok := accounts[id]; !ok {
+			return nil, ErrAccountNotFound
+		}
+	}
+	return accounts, nil
+}
+
+// postLeg books one transfer leg: it applies delta to the account balance
+// and records the transaction with the resulting balance.
+func postLeg(ctx context.Context, tx *sql.Tx, accountID, counterpartyID int, txType string, delta float64) (int, error) {
+	var finalBalance float64
+	err := tx.QueryRowContext(ctx,
+		"UPDATE accounts SET balance = balance + $1 WHERE id = $2 RETURNING balance",
+		delta, accountID,
+	).Scan(&finalBalance)
+	if err != nil {
+		return 0, fmt.Errorf("balance update failed: %w", err)
+	}
+
+	var txID int
+	err = tx.QueryRowContext(ctx,
+		`INSERT INTO transactions (account_id, amount, type, final_balance, counterparty_account_id)
+		 VALUES ($1, $2, $3, $4, $5)
+		 RETURNING id`,
+		accountID, math.Abs(delta), txType, finalBalance, counterpartyID,
+	).Scan(&txID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create transaction: %w", err)
+	}
+	return txID, nil
+}
+
+// GetTransactions retrieves transaction history for an account. A
+// non-empty externalRef returns only the transaction with that reference.
+func (r *TransactionRepository) GetTransactions(ctx context.Context, accountID int, limit, offset int, externalRef string) ([]Transaction, error) {
 	const query = `
-		SELECT id, account_id, amount, type, created_at, final_balance
+		SELECT id, account_id, amount, type, status, created_at, final_balance,
+		       COALESCE(external_ref, ''), COALESCE(description, ''), metadata, public_id,
+		       value_date, booking_date
 		FROM transactions
-		WHERE account_id = $1
+		WHERE account_id = $1 AND ($4 = '' OR external_ref = $4)
 		ORDER BY created_at DESC
 		LIMIT $2 OFFSET $3
 	`
 
-	rows, err := r.db.QueryContext(ctx, query, accountID, limit, offset)
+	rows, err := r.reader(ctx).QueryContext(ctx, query, accountID, limit, offset, externalRef)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query transactions: %w", err)
 	}
@@ -207,17 +513,28 @@ func (r *TransactionRepository) GetTransactions(ctx context.Context, accountID i
 
 	var transactions []Transaction
 	for rows.Next() {
-		var t Transaction
+		var (
+			t        Transaction
+			metadata []byte
+		)
 		if err := rows.Scan(
 			&t.ID,
 			&t.AccountID,
 			&t.Amount,
 			&t.Type,
+			&t.Status,
 			&t.CreatedAt,
 			&t.FinalBalance,
+			&t.Details.ExternalRef,
+			&t.Details.Description,
+			&metadata,
+			&t.PublicID,
+			&t.ValueDate,
+			&t.BookingDate,
 		); err != nil {
 			return nil, fmt.Errorf("failed to scan transaction: %w", err)
 		}
+		t.Details.Metadata = scanMetadata(metadata)
 		transactions = append(transactions, t)
 	}
 
@@ -226,4 +543,43 @@ func (r *TransactionRepository) GetTransactions(ctx context.Context, accountID i
 	}
 
 	return transactions, nil
-}
\ No newline at end of file
+}
+
+// PublicID returns the public ID of a transaction.
+func (r *TransactionRepository) PublicID(ctx context.Context, txID int) (string, error) {
+	var publicID string
+	err := r.reader(ctx).QueryRowContext(ctx,
+		"SELECT public_id FROM transactions WHERE id = $1",
+		txID,
+	).Scan(&publicID)
+
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return "", ErrTransactionNotFound
+	case err != nil:
+		return "", fmt.Errorf("failed to get transaction: %w", err)
+	}
+	return publicID, nil
+}
+
+// ResolveTransaction returns the internal ID of the transaction with the
+// given public ID.
+func (r *TransactionRepository) ResolveTransaction(ctx context.Context, publicID string) (int, error) {
+	if !ids.IsTransactionID(publicID) {
+		return 0, apperrors.Invalid("id", "transaction", "must be a transaction ID")
+	}
+
+	var txID int
+	err := r.reader(ctx).QueryRowContext(ctx,
+		"SELECT id FROM transactions WHERE public_id = $1",
+		publicID,
+	).Scan(&txID)
+
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return 0, ErrTransactionNotFound
+	case err != nil:
+		return 0, fmt.Errorf("failed to resolve transaction: %w", err)
+	}
+	return txID, nil
+}
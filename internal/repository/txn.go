@@ -0,0 +1,150 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"expvar"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"time"
+
+	apperrors "github.com/Andrew44Ashraf/fintech-service/internal/errors"
+	"github.com/Andrew44Ashraf/fintech-service/internal/logging"
+	"github.com/lib/pq"
+)
+
+var ErrContention = apperrors.ErrContention
+
+// retryableStates are the SQLSTATEs after which a transaction can simply
+// run again: serialization_failure and deadlock_detected.
+var retryableStates = map[pq.ErrorCode]bool{
+	"40001": true,
+	"40P01": true,
+}
+
+// RetryPolicy bounds how often a transaction aborted with a retryable
+// SQLSTATE runs again.
+type RetryPolicy struct {
+	// MaxAttempts counts every run of the transaction, the first included.
+	MaxAttempts int
+	// BaseDelay is the backoff before the first retry. It doubles with each
+	// retry up to MaxDelay; the actual wait is a random duration up to it.
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+}
+
+// DefaultRetryPolicy is used unless SetRetryPolicy is called.
+var DefaultRetryPolicy = RetryPolicy{MaxAttempts: 5, BaseDelay: 10 * time.Millisecond, MaxDelay: 250 * time.Millisecond}
+
+var retryPolicy = DefaultRetryPolicy
+
+// SetRetryPolicy replaces the retry policy of all repositories. Call it
+// before serving requests.
+func SetRetryPolicy(p RetryPolicy) {
+	if p.MaxAttempts < 1 {
+		p.MaxAttempts = 1
+	}
+	retryPolicy = p
+}
+
+// backoff returns the wait before the given retry, counting from 1.
+func (p RetryPolicy) backoff(retry int) time.Duration {
+	d := p.BaseDelay << (retry - 1)
+	if d > p.MaxDelay || d <= 0 {
+		d = p.MaxDelay
+	}
+	if d <= 0 {
+		return 0
+	}
+	return rand.N(d + 1)
+}
+
+// TxMetrics counts transaction retries, published by expvar as
+// repository_tx. "retries" and "exhausted" are keyed by operation,
+// "sqlstates" by the SQLSTATE that caused the retry.
+var TxMetrics = expvar.NewMap("repository_tx")
+
+var (
+	txRetries   = new(expvar.Map)
+	txExhausted = new(expvar.Map)
+	txSQLStates = new(expvar.Map)
+)
+
+func init() {
+	TxMetrics.Set("retries", txRetries)
+	TxMetrics.Set("exhausted", txExhausted)
+	TxMetrics.Set("sqlstates", txSQLStates)
+}
+
+// errRollback ends a transaction without committing it. withTx returns nil
+// for it; dry runs use it.
+var errRollback = errors.New("transaction rolled back")
+
+// withTx runs fn in a database transaction with the given options (nil
+// for the defaults) and commits it when fn returns nil. When fn or the
+// commit fails with a retryable SQLSTATE, the transaction is rolled back
+// and fn runs again in a new one after a jittered backoff, until the retry
+// policy's attempts are spent and ErrContention is returned. fn must not
+// have effects outside tx that can't be repeated.
+func withTx(ctx context.Context, db *sql.DB, logger *slog.Logger, op string, opts *sql.TxOptions, fn func(tx *sql.Tx) error) error {
+	policy := retryPolicy
+	for attempt := 1; ; attempt++ {
+		err := runTx(ctx, db, opts, fn)
+		state, retry := retryableState(err)
+		if !retry {
+			return err
+		}
+		if attempt >= policy.MaxAttempts {
+			txExhausted.Add(op, 1)
+			return fmt.Errorf("%w: %s aborted %d times: %v", ErrContention, op, attempt, err)
+		}
+
+		txRetries.Add(op, 1)
+		txSQLStates.Add(state, 1)
+		logging.FromContext(ctx, logger).Warn("transaction aborted, retrying",
+			logging.KeyOp, op,
+			"attempt", attempt,
+			"sqlstate", state,
+		)
+
+		timer := time.NewTimer(policy.backoff(attempt))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("%s: %w", op, ctx.Err())
+		case <-timer.C:
+		}
+	}
+}
+
+// runTx is one attempt of withTx.
+func runTx(ctx context.Context, db *sql.DB, opts *sql.TxOptions, fn func(tx *sql.Tx) error) error {
+	tx, err := db.BeginTx(ctx, opts)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		if errors.Is(err, errRollback) {
+			return nil
+		}
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("transaction commit failed: %w", err)
+	}
+	return nil
+}
+
+// retryableState returns the SQLSTATE of err when the transaction that
+// failed with it can be retried.
+func retryableState(err error) (string, bool) {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && retryableStates[pqErr.Code] {
+		return string(pqErr.Code), true
+	}
+	return "", false
+}
@@ -1,32 +1,181 @@
 package routes
 
 import (
+	"database/sql"
+	"expvar"
+	"log/slog"
+
+	"github.com/Andrew44Ashraf/fintech-service/internal/auth"
+	"github.com/Andrew44Ashraf/fintech-service/internal/cache"
+	"github.com/Andrew44Ashraf/fintech-service/internal/database"
 	"github.com/Andrew44Ashraf/fintech-service/internal/handlers"
+	"github.com/Andrew44Ashraf/fintech-service/internal/middleware"
+	"github.com/Andrew44Ashraf/fintech-service/internal/ratelimit"
+	"github.com/Andrew44Ashraf/fintech-service/internal/reconciliation"
 	"github.com/Andrew44Ashraf/fintech-service/internal/repository"
+	"github.com/Andrew44Ashraf/fintech-service/internal/screening"
 	"github.com/gin-gonic/gin"
-	"database/sql"
 )
 
+// Rate limit budgets per route group. Reads are keyed on the caller and the
+// target account to slow down enumeration of account IDs; writes get tighter
+// budgets, withdrawals the tightest.
+var (
+	openAccountLimits = []middleware.RateLimitRule{
+		{Name: "ip", Key: middleware.ByClientIP, Limit: ratelimit.PerMinute(10, 5)},
+	}
+	readLimits = []middleware.RateLimitRule{
+		{Name: "api_key", Key: middleware.ByAPIKey, Limit: ratelimit.PerMinute(600, 100)},
+		{Name: "ip", Key: middleware.ByClientIP, Limit: ratelimit.PerMinute(120, 30)},
+		{Name: "account", Key: middleware.ByAccount, Limit: ratelimit.PerMinute(60, 20)},
+	}
+	depositLimits = []middleware.RateLimitRule{
+		{Name: "api_key", Key: middleware.ByAPIKey, Limit: ratelimit.PerMinute(300, 50)},
+		{Name: "ip", Key: middleware.ByClientIP, Limit: ratelimit.PerMinute(60, 20)},
+		{Name: "account", Key: middleware.ByAccount, Limit: ratelimit.PerMinute(30, 10)},
+	}
+	scheduleLimits = []middleware.RateLimitRule{
+		{Name: "api_key", Key: middleware.ByAPIKey, Limit: ratelimit.PerMinute(120, 20)},
+		{Name: "ip", Key: middleware.ByClientIP, Limit: ratelimit.PerMinute(30, 10)},
+	}
+	withdrawLimits = []middleware.RateLimitRule{
+		{Name: "api_key", Key: middleware.ByAPIKey, Limit: ratelimit.PerMinute(120, 20)},
+		{Name: "ip", Key: middleware.ByClientIP, Limit: ratelimit.PerMinute(30, 10)},
+		{Name: "account", Key: middleware.ByAccount, Limit: ratelimit.PerMinute(10, 3)},
+	}
+)
+
+// Config carries the dependencies and settings routes are built with.
+type Config struct {
+	Limiter          ratelimit.Store
+	WithdrawalLimits repository.WithdrawalLimits
+	Screener         screening.Screener
+	// Keys authenticate clients by X-API-Key and give their tenant. Nil
+	// turns authentication off and every client acts for the default
+	// tenant.
+	Keys       auth.KeyChecker
+	AdminToken string
+	// BankMatchRules drive automatic matching of bank files. Empty means
+	// reconciliation.DefaultRules.
+	BankMatchRules []reconciliation.MatchRule
+	// Reads routes balance and history reads to a replica. Nil reads
+	// from db.
+	Reads *database.Router
+	// BalanceCache serves balance reads. Nil turns caching off.
+	BalanceCache cache.Store
+}
+
 // SetupRoutes initializes all API routes with dependency injection
-func SetupRoutes(router *gin.Engine, db *sql.DB) {
+func SetupRoutes(router *gin.Engine, db *sql.DB, logger *slog.Logger, cfg Config) {
+	limiter := cfg.Limiter
+
 	// Initialize repositories
-	accountRepo := repository.NewAccountRepository(db)
-	transactionRepo := repository.NewTransactionRepository(db)
+	var (
+		accountOpts     []repository.AccountOption
+		feeOpts         []repository.FeeOption
+		statementOpts   []repository.StatementOption
+		transactionOpts = []repository.TransactionOption{
+			repository.WithWithdrawalLimits(cfg.WithdrawalLimits),
+			repository.WithScreener(cfg.Screener),
+		}
+	)
+	if cfg.Reads != nil {
+		accountOpts = append(accountOpts, repository.WithAccountReads(cfg.Reads))
+		transactionOpts = append(transactionOpts, repository.WithTransactionReads(cfg.Reads))
+		statementOpts = append(statementOpts, repository.WithStatementReads(cfg.Reads))
+	}
+	if cfg.BalanceCache != nil {
+		accountOpts = append(accountOpts, repository.WithAccountCache(cfg.BalanceCache))
+		transactionOpts = append(transactionOpts, repository.WithTransactionCache(cfg.BalanceCache))
+		feeOpts = append(feeOpts, repository.WithFeeCache(cfg.BalanceCache))
+	}
+	accountRepo := repository.NewAccountRepository(db, logger, accountOpts...)
+	statementRepo := repository.NewStatementRepository(db, logger, statementOpts...)
+	transactionRepo := repository.NewTransactionRepository(db, logger, transactionOpts...)
+	batchRepo := repository.NewBatchRepository(db, logger, transactionRepo)
+	scheduleRepo := repository.NewScheduledPaymentRepository(db, logger, transactionRepo)
+	feeRepo := repository.NewFeeRepository(db, logger, feeOpts...)
+	reconciliationRepo := repository.NewReconciliationRepository(db, logger)
+	bankRepo := repository.NewBankReconciliationRepository(db, logger)
 
 	// Initialize handlers
-	accountHandler := handlers.NewAccountHandler(accountRepo)
-	transactionHandler := handlers.NewTransactionHandler(transactionRepo, accountRepo)
+	accountHandler := handlers.NewAccountHandler(accountRepo, logger)
+	transactionHandler := handlers.NewTransactionHandler(transactionRepo, accountRepo, logger)
+	reviewHandler := handlers.NewReviewHandler(transactionRepo, logger)
+	incomingHandler := handlers.NewIncomingTransferHandler(transactionRepo, logger)
+	statementHandler := handlers.NewStatementHandler(statementRepo, logger)
+	batchHandler := handlers.NewBatchHandler(batchRepo, accountRepo, logger)
+	scheduleHandler := handlers.NewScheduledPaymentHandler(scheduleRepo, accountRepo, logger)
+	feeHandler := handlers.NewFeeHandler(feeRepo, transactionRepo, logger)
+	reconciliationHandler := handlers.NewReconciliationHandler(reconciliationRepo, logger)
+	bankHandler := handlers.NewBankReconciliationHandler(bankRepo, transactionRepo, cfg.BankMatchRules, logger)
+
+	// Routes with an account in the path take its public ID or number
+	account := handlers.ResolveAccount(accountRepo, logger)
+
+	// Client routes act for the tenant of the caller's API key. Keys are
+	// checked after rate limiting so guessing them is throttled.
+	authn := middleware.APIKeyAuth(cfg.Keys, logger)
 
 	// API routes
-	api := router.Group("/api")
+	// Writes return a token that later reads send to see them on the
+	// replica
+	api := router.Group("/api", middleware.Consistency(cfg.Reads, logger))
 	{
 		// Account routes
-		api.POST("/accounts", accountHandler.OpenAccount)
-		api.GET("/accounts/:id/balance", accountHandler.GetBalance)
+		api.POST("/accounts", middleware.RateLimit(limiter, logger, "open_account", openAccountLimits...), authn, accountHandler.OpenAccount)
+
+		reads := api.Group("", middleware.RateLimit(limiter, logger, "read", readLimits...), authn)
+		reads.GET("/products", accountHandler.ListProducts)
+		reads.GET("/accounts/:id", account, accountHandler.GetAccount)
+		reads.GET("/accounts/:id/balance", account, accountHandler.GetBalance)
+		reads.GET("/accounts/:id/transactions", account, transactionHandler.GetTransactions) // ?limit=10&offset=0
+		reads.GET("/accounts/:id/statements", account, statementHandler.GetStatement)        // ?from=2026-01-01&to=2026-01-31&format=csv
+		reads.GET("/accounts/:id/scheduled-payments", account, scheduleHandler.List)
+		reads.GET("/scheduled-payments/:id", scheduleHandler.Get)
+		reads.GET("/scheduled-payments/:id/runs", scheduleHandler.ListRuns)
 
 		// Transaction routes
-		api.POST("/accounts/:id/deposit", transactionHandler.Deposit)
-		api.POST("/accounts/:id/withdraw", transactionHandler.Withdraw)
-		api.GET("/accounts/:id/transactions", transactionHandler.GetTransactions) // ?limit=10&offset=0
+		api.POST("/accounts/:id/deposit", middleware.RateLimit(limiter, logger, "deposit", depositLimits...), authn, account, transactionHandler.Deposit)
+		api.POST("/accounts/:id/withdraw", middleware.RateLimit(limiter, logger, "withdraw", withdrawLimits...), authn, account, transactionHandler.Withdraw)
+
+		// Standing orders
+		schedules := api.Group("", middleware.RateLimit(limiter, logger, "schedule", scheduleLimits...), authn)
+		schedules.POST("/accounts/:id/scheduled-payments", account, scheduleHandler.Create)
+		schedules.PATCH("/scheduled-payments/:id", scheduleHandler.Update)
+		schedules.DELETE("/scheduled-payments/:id", scheduleHandler.Cancel)
+
+		// Operator routes act for the tenant in X-Tenant-ID
+		operator := api.Group("/admin", middleware.AdminAuth(cfg.AdminToken))
+		operator.GET("/metrics", gin.WrapH(expvar.Handler())) // expvar JSON, including repository_tx retries
+
+		admin := operator.Group("", middleware.AdminTenant())
+		admin.GET("/reviews", reviewHandler.ListPending)
+		admin.POST("/reviews/:id/approve", reviewHandler.Approve)
+		admin.POST("/reviews/:id/reject", reviewHandler.Reject)
+		admin.POST("/accounts/:id/incoming-transfers", account, incomingHandler.Record) // pending until posted
+		admin.POST("/incoming-transfers/:id/post", incomingHandler.Post)
+		admin.POST("/incoming-transfers/:id/fail", incomingHandler.Fail)
+		admin.PATCH("/accounts/:id", account, accountHandler.ChangeStatus) // If-Match required
+		admin.PUT("/accounts/:id/limits", account, accountHandler.SetLimits)
+		admin.GET("/accounts/:id/fee-waivers", account, feeHandler.ListWaivers)
+		admin.POST("/accounts/:id/fee-waivers", account, feeHandler.GrantWaiver)
+		admin.POST("/fee-waivers/:id/revoke", feeHandler.RevokeWaiver)
+		admin.POST("/fees/:id/reverse", feeHandler.ReverseFee)
+		admin.GET("/accounts/:id/fee-audit", account, feeHandler.ListAudit)
+		admin.POST("/balances", accountHandler.BalancesAsOf) // point-in-time balances for reporting
+		admin.GET("/reconciliation", reconciliationHandler.Latest)
+		admin.POST("/reconciliation", reconciliationHandler.Run)
+		admin.GET("/reconciliation/:id", reconciliationHandler.Get)
+		admin.POST("/bank-statements", bankHandler.Import) // ?operator=ops&format=camt053
+		admin.GET("/bank-statements/:id", bankHandler.Get)
+		admin.POST("/bank-statements/:id/auto-match", bankHandler.AutoMatch)
+		admin.POST("/bank-matches", bankHandler.Match)
+		admin.POST("/bank-matches/:id/unmatch", bankHandler.Unmatch)
+
+		// Bulk postings are an operator tool as well
+		batches := api.Group("/batches", middleware.AdminAuth(cfg.AdminToken), middleware.AdminTenant())
+		batches.POST("", batchHandler.CreateBatch) // ?mode=best_effort&dry_run=true
+		batches.GET("/:id", batchHandler.GetBatch)
 	}
-}
\ No newline at end of file
+}
@@ -0,0 +1,165 @@
+package screening
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"slices"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Rule kinds supported by the rules engine.
+const (
+	KindAmountThreshold = "amount_threshold"
+	KindVelocity        = "velocity"
+	KindNewAccount      = "new_account"
+	KindRoundAmount     = "round_amount"
+)
+
+// Rule is one screening rule as written in the rules file.
+type Rule struct {
+	Name             string        `yaml:"name"`
+	Kind             string        `yaml:"kind"`
+	Action           Decision      `yaml:"action"`
+	TransactionTypes []string      `yaml:"transaction_types"` // empty matches all types
+	MinAmount        float64       `yaml:"min_amount"`
+	Window           time.Duration `yaml:"window"`
+	MaxCount         int           `yaml:"max_count"`
+	MaxTotal         float64       `yaml:"max_total"`
+	MaxAccountAge    time.Duration `yaml:"max_account_age"`
+	MultipleOf       float64       `yaml:"multiple_of"`
+}
+
+type rulesFile struct {
+	Rules []Rule `yaml:"rules"`
+}
+
+// RulesEngine evaluates every rule and returns the strictest decision.
+type RulesEngine struct {
+	rules []Rule
+	now   func() time.Time
+}
+
+// NewRulesEngine validates rules and builds an engine from them.
+func NewRulesEngine(rules []Rule) (*RulesEngine, error) {
+	for i, r := range rules {
+		if err := r.validate(); err != nil {
+			return nil, fmt.Errorf("rule %d (%s): %w", i, r.Name, err)
+		}
+	}
+	return &RulesEngine{rules: rules, now: time.Now}, nil
+}
+
+// LoadRules reads a YAML rules file.
+func LoadRules(path string) (*RulesEngine, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read rules file: %w", err)
+	}
+
+	var f rulesFile
+	if err := yaml.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("failed to parse rules file: %w", err)
+	}
+	return NewRulesEngine(f.Rules)
+}
+
+func (e *RulesEngine) Screen(ctx context.Context, req Request) (Result, error) {
+	result := Result{Decision: Allow}
+	for _, r := range e.rules {
+		if len(r.TransactionTypes) > 0 && !slices.Contains(r.TransactionTypes, req.Type) {
+			continue
+		}
+
+		reason, hit, err := e.evaluate(ctx, r, req)
+		if err != nil {
+			return Result{}, fmt.Errorf("rule %s: %w", r.Name, err)
+		}
+		if !hit {
+			continue
+		}
+
+		result.Reasons = append(result.Reasons, r.Name+": "+reason)
+		if r.Action.severity() > result.Decision.severity() {
+			result.Decision = r.Action
+		}
+	}
+	return result, nil
+}
+
+func (e *RulesEngine) evaluate(ctx context.Context, r Rule, req Request) (string, bool, error) {
+	switch r.Kind {
+	case KindAmountThreshold:
+		return fmt.Sprintf("amount %.2f is at least %.2f", req.Amount, r.MinAmount), req.Amount >= r.MinAmount, nil
+
+	case KindVelocity:
+		activity, err := req.Facts.RecentActivity(ctx, r.Window, r.TransactionTypes)
+		if err != nil {
+			return "", false, err
+		}
+		count := activity.Count + 1
+		total := activity.Total + req.Amount
+		switch {
+		case r.MaxCount > 0 && count > r.MaxCount:
+			return fmt.Sprintf("%d transactions within %s", count, r.Window), true, nil
+		case r.MaxTotal > 0 && total > r.MaxTotal:
+			return fmt.Sprintf("%.2f moved within %s", total, r.Window), true, nil
+		}
+		return "", false, nil
+
+	case KindNewAccount:
+		if req.Amount < r.MinAmount {
+			return "", false, nil
+		}
+		opened, err := req.Facts.AccountOpenedAt(ctx)
+		if err != nil {
+			return "", false, err
+		}
+		age := e.now().Sub(opened)
+		return fmt.Sprintf("account opened %s ago", age.Round(time.Minute)), age < r.MaxAccountAge, nil
+
+	case KindRoundAmount:
+		if req.Amount < r.MinAmount {
+			return "", false, nil
+		}
+		remainder := math.Mod(req.Amount, r.MultipleOf)
+		return fmt.Sprintf("amount is a multiple of %.2f", r.MultipleOf), remainder == 0, nil
+	}
+	return "", false, nil
+}
+
+func (r Rule) validate() error {
+	switch r.Action {
+	case Allow, Review, Deny:
+	default:
+		return fmt.Errorf("unknown action %q", r.Action)
+	}
+
+	switch r.Kind {
+	case KindAmountThreshold:
+		if r.MinAmount <= 0 {
+			return fmt.Errorf("min_amount must be positive")
+		}
+	case KindVelocity:
+		if r.Window <= 0 {
+			return fmt.Errorf("window must be positive")
+		}
+		if r.MaxCount <= 0 && r.MaxTotal <= 0 {
+			return fmt.Errorf("max_count or max_total is required")
+		}
+	case KindNewAccount:
+		if r.MaxAccountAge <= 0 {
+			return fmt.Errorf("max_account_age must be positive")
+		}
+	case KindRoundAmount:
+		if r.MultipleOf <= 0 {
+			return fmt.Errorf("multiple_of must be positive")
+		}
+	default:
+		return fmt.Errorf("unknown kind %q", r.Kind)
+	}
+	return nil
+}
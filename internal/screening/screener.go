@@ -0,0 +1,69 @@
+// Package screening decides whether a transaction may be posted, must be
+// held for manual review, or is denied outright.
+package screening
+
+import (
+	"context"
+	"time"
+)
+
+// Decision is the outcome of screening a transaction.
+type Decision string
+
+const (
+	Allow  Decision = "allow"
+	Review Decision = "review"
+	Deny   Decision = "deny"
+)
+
+// severity orders decisions so the strictest one wins.
+func (d Decision) severity() int {
+	switch d {
+	case Deny:
+		return 2
+	case Review:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// Activity summarises an account's recent transactions.
+type Activity struct {
+	Count int
+	Total float64
+}
+
+// Facts gives screeners access to account data inside the posting
+// transaction. Implementations query lazily, so screeners only pay for
+// what they use.
+type Facts interface {
+	AccountOpenedAt(ctx context.Context) (time.Time, error)
+	RecentActivity(ctx context.Context, window time.Duration, types []string) (Activity, error)
+}
+
+// Request describes the transaction being screened.
+type Request struct {
+	AccountID int
+	Type      string // "deposit", "withdrawal" or "transfer"
+	Amount    float64
+	Facts     Facts
+}
+
+// Result is a decision with the reasons that led to it.
+type Result struct {
+	Decision Decision
+	Reasons  []string
+}
+
+// Screener is invoked by every posting path before commit.
+type Screener interface {
+	Screen(ctx context.Context, req Request) (Result, error)
+}
+
+// AllowAll approves every transaction.
+type AllowAll struct{}
+
+func (AllowAll) Screen(context.Context, Request) (Result, error) {
+	return Result{Decision: Allow}, nil
+}
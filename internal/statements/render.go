@@ -0,0 +1,97 @@
+package statements
+
+import (
+	"embed"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"strconv"
+	"time"
+)
+
+// Supported output formats
+const (
+	FormatJSON = "json"
+	FormatCSV  = "csv"
+	FormatHTML = "html"
+)
+
+//go:embed templates/statement.html
+var templateFS embed.FS
+
+var htmlTemplate = template.Must(template.New("statement.html").Funcs(template.FuncMap{
+	"money": func(v float64) string { return strconv.FormatFloat(v, 'f', 2, 64) },
+	"date":  func(t time.Time) string { return t.Format("2006-01-02") },
+	"lastDay": func(t time.Time) string {
+		return t.AddDate(0, 0, -1).Format("2006-01-02")
+	},
+}).ParseFS(templateFS, "templates/statement.html"))
+
+// Supports reports whether format is one of the statement formats above.
+func Supports(format string) bool {
+	return format == FormatJSON || format == FormatCSV || format == FormatHTML
+}
+
+// ContentType returns the media type for format.
+func ContentType(format string) string {
+	switch format {
+	case FormatCSV:
+		return "text/csv; charset=utf-8"
+	case FormatHTML:
+		return "text/html; charset=utf-8"
+	default:
+		return "application/json; charset=utf-8"
+	}
+}
+
+// Render writes s to w in the given format.
+func Render(w io.Writer, s *Statement, format string) error {
+	switch format {
+	case FormatJSON:
+		return json.NewEncoder(w).Encode(s)
+	case FormatCSV:
+		return renderCSV(w, s)
+	case FormatHTML:
+		return htmlTemplate.Execute(w, s)
+	default:
+		return fmt.Errorf("unsupported statement format %q", format)
+	}
+}
+
+func renderCSV(w io.Writer, s *Statement) error {
+	cw := csv.NewWriter(w)
+	money := func(v float64) string { return strconv.FormatFloat(v, 'f', 2, 64) }
+
+	line := func(l Line, balance, status string) []string {
+		debit, credit := money(l.Amount), ""
+		if l.Credit() {
+			debit, credit = "", money(l.Amount)
+		}
+		return []string{
+			l.Date.Format(time.RFC3339),
+			l.ValueDay().Format("2006-01-02"),
+			strconv.Itoa(l.TransactionID),
+			l.Type,
+			debit,
+			credit,
+			balance,
+			status,
+		}
+	}
+
+	cw.Write([]string{"date", "value_date", "transaction_id", "type", "debit", "credit", "balance", "status"})
+	cw.Write([]string{s.From.Format("2006-01-02"), "", "", "opening_balance", "", "", money(s.OpeningBalance), ""})
+	for _, l := range s.Lines {
+		cw.Write(line(l, money(l.Balance), "posted"))
+	}
+	cw.Write([]string{s.To.AddDate(0, 0, -1).Format("2006-01-02"), "", "", "closing_balance", "", "", money(s.ClosingBalance), ""})
+	// Pending transactions follow the closing balance, without a balance
+	for _, l := range s.Pending {
+		cw.Write(line(l, "", "pending"))
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,83 @@
+// Package statements holds the account statement model and renders it as
+// JSON, CSV or printable HTML.
+package statements
+
+import (
+	"time"
+
+	"github.com/Andrew44Ashraf/fintech-service/internal/models"
+)
+
+// Statement covers transactions posted in [From, To). Pending lists the
+// transactions recorded before To but not posted by then, such as incoming
+// bank transfers awaiting settlement; they take no part in the balances.
+type Statement struct {
+	AccountID      int         `json:"account_id"`
+	Currency       string      `json:"currency,omitempty"`
+	From           time.Time   `json:"from"`
+	To             time.Time   `json:"to"`
+	OpeningBalance float64     `json:"opening_balance"`
+	ClosingBalance float64     `json:"closing_balance"`
+	Totals         []TypeTotal `json:"totals"`
+	Lines          []Line      `json:"lines"`
+	Pending        []Line      `json:"pending,omitempty"`
+	GeneratedAt    time.Time   `json:"generated_at"`
+}
+
+// Line is one posted transaction with the balance after it. Date is when
+// it was posted, its booking date; pending lines have no balance and are
+// dated when they were recorded.
+type Line struct {
+	TransactionID int       `json:"transaction_id"`
+	Date          time.Time `json:"date"`
+	ValueDate     time.Time `json:"value_date"`
+	Type          string    `json:"type"`
+	Amount        float64   `json:"amount"`
+	Balance       float64   `json:"balance"`
+}
+
+// TypeTotal sums the lines of one transaction type.
+type TypeTotal struct {
+	Type   string  `json:"type"`
+	Count  int     `json:"count"`
+	Amount float64 `json:"amount"`
+}
+
+// Credit reports whether the line added to the balance.
+func (l Line) Credit() bool {
+	return models.TransactionType(l.Type).IsCredit()
+}
+
+// Signed returns the line amount signed by its effect on the balance.
+func (l Line) Signed() float64 {
+	return models.TransactionType(l.Type).Signed(l.Amount)
+}
+
+// ValueDay returns the date from which the amount counts. Statements stored
+// before value dates were recorded have none; their lines are valued on the
+// day they were booked.
+func (l Line) ValueDay() time.Time {
+	if l.ValueDate.IsZero() {
+		return l.Date.UTC()
+	}
+	return l.ValueDate
+}
+
+// Summarize fills Totals and ClosingBalance from Lines and OpeningBalance.
+func (s *Statement) Summarize() {
+	s.ClosingBalance = s.OpeningBalance
+	s.Totals = nil
+
+	index := map[string]int{}
+	for _, l := range s.Lines {
+		i, ok := index[l.Type]
+		if !ok {
+			i = len(s.Totals)
+			index[l.Type] = i
+			s.Totals = append(s.Totals, TypeTotal{Type: l.Type})
+		}
+		s.Totals[i].Count++
+		s.Totals[i].Amount += l.Amount
+		s.ClosingBalance = l.Balance
+	}
+}
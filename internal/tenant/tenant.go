@@ -0,0 +1,43 @@
+// Package tenant carries the tenant a request acts for through
+// context.Context. The tenant comes from the authenticated principal: the
+// API key of REST and gRPC clients, or the X-Tenant-ID header of operator
+// requests. The database layer reads it back to scope every statement, see
+// database.WithTenants.
+package tenant
+
+import "context"
+
+// Default owns the data from before multi-tenancy. Bare API keys and
+// single-tenant deployments act for it.
+const Default = "default"
+
+// MaxIDLength matches tenants.id.
+const MaxIDLength = 40
+
+type ctxKey struct{}
+
+// WithID stores the tenant ID in ctx.
+func WithID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, ctxKey{}, id)
+}
+
+// FromContext returns the tenant ID stored in ctx, or "" if there is none.
+// Statements run without a tenant see no tenant data.
+func FromContext(ctx context.Context) string {
+	id, _ := ctx.Value(ctxKey{}).(string)
+	return id
+}
+
+// Valid reports whether id is a well-formed tenant ID: 1 to MaxIDLength
+// lowercase letters, digits and hyphens, starting with a letter or digit.
+func Valid(id string) bool {
+	if id == "" || len(id) > MaxIDLength || id[0] == '-' {
+		return false
+	}
+	for _, r := range id {
+		if !('a' <= r && r <= 'z' || '0' <= r && r <= '9' || r == '-') {
+			return false
+		}
+	}
+	return true
+}
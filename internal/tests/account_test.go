@@ -1,28 +1,49 @@
 package tests
 
 import (
+	"context"
 	"database/sql"
+	"os"
+	"testing"
+
 	"github.com/Andrew44Ashraf/fintech-service/internal/database"
+	"github.com/Andrew44Ashraf/fintech-service/internal/ids"
+	"github.com/Andrew44Ashraf/fintech-service/internal/logging"
 	"github.com/Andrew44Ashraf/fintech-service/internal/repository"
+	"github.com/Andrew44Ashraf/fintech-service/internal/tenant"
 	"github.com/stretchr/testify/assert"
-	"log"
-	"testing"
 )
 
-func setupTestDB() *sql.DB {
-	// Use an in-memory database for testing
-	db, err := sql.Open("postgres", "host=localhost user=postgres password=password dbname=fintech_db sslmode=disable")
+// setupTestDB connects to the integration database (TEST_DATABASE_URL, or the
+// local docker-compose instance) and skips the test when it is unreachable.
+func setupTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		dsn = "host=localhost user=postgres password=password dbname=fintech_db sslmode=disable"
+	}
+	db, err := database.Open(dsn)
 	if err != nil {
-		log.Fatal("Failed to connect to test database:", err)
+		t.Fatalf("Failed to open test database: %v", err)
+	}
+	if err := db.Ping(); err != nil {
+		db.Close()
+		t.Skipf("test database unavailable: %v", err)
 	}
 	return db
 }
 
 func TestCreateAccount(t *testing.T) {
-	db := setupTestDB()
+	db := setupTestDB(t)
 	defer db.Close()
 
-	accountID, err := repository.CreateAccount(db)
+	repo := repository.NewAccountRepository(db, logging.Discard())
+	account, err := repo.CreateAccount(tenant.WithID(context.Background(), tenant.Default), "", 0, repository.Details{})
 	assert.NoError(t, err, "Expected no error when creating account")
-	assert.NotZero(t, accountID, "Account ID should be greater than 0")
+	assert.NotZero(t, account.ID, "Account ID should be greater than 0")
+	assert.True(t, ids.IsAccountID(account.PublicID), "Public ID should be acc_ and a UUID")
+
+	_, ok := ids.ParseAccountNumber(account.Number)
+	assert.True(t, ok, "Account number check digits should be valid")
 }
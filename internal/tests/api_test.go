@@ -2,24 +2,33 @@ package tests
 
 import (
 	"bytes"
-	"github.com/Andrew44Ashraf/fintech-service/internal/database"
-	"github.com/Andrew44Ashraf/fintech-service/internal/routes"
-	"github.com/gin-gonic/gin"
-	"github.com/stretchr/testify/assert"
+	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+
+	"github.com/Andrew44Ashraf/fintech-service/internal/logging"
+	"github.com/Andrew44Ashraf/fintech-service/internal/middleware"
+	"github.com/Andrew44Ashraf/fintech-service/internal/ratelimit"
+	"github.com/Andrew44Ashraf/fintech-service/internal/routes"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
 )
 
-func setupRouter() *gin.Engine {
-	r := gin.Default()
-	routes.SetupRoutes(r)
+func setupRouter(t *testing.T) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	db := setupTestDB(t)
+	t.Cleanup(func() { db.Close() })
+
+	r := gin.New()
+	r.Use(middleware.RequestID())
+	routes.SetupRoutes(r, db, logging.Discard(), routes.Config{Limiter: ratelimit.NewMemoryStore()})
 	return r
 }
 
 func TestOpenAccountAPI(t *testing.T) {
-	database.InitDB()
-	router := setupRouter()
+	router := setupRouter(t)
 
 	req, _ := http.NewRequest("POST", "/api/accounts", nil)
 	w := httptest.NewRecorder()
@@ -29,17 +38,22 @@ func TestOpenAccountAPI(t *testing.T) {
 }
 
 func TestDepositAPI(t *testing.T) {
-	database.InitDB()
-	router := setupRouter()
+	router := setupRouter(t)
 
 	// Create account first
-	accountID := 1
+	req, _ := http.NewRequest("POST", "/api/accounts", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	var account struct {
+		AccountID int `json:"account_id"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &account)
 
 	// Perform a deposit
 	body := []byte(`{"amount": 100}`)
-	req, _ := http.NewRequest("POST", "/api/accounts/1/deposit", bytes.NewBuffer(body))
+	req, _ = http.NewRequest("POST", fmt.Sprintf("/api/accounts/%d/deposit", account.AccountID), bytes.NewBuffer(body))
 	req.Header.Set("Content-Type", "application/json")
-	w := httptest.NewRecorder()
+	w = httptest.NewRecorder()
 	router.ServeHTTP(w, req)
 
 	assert.Equal(t, http.StatusOK, w.Code)
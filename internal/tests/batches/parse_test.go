@@ -0,0 +1,140 @@
+package batches_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Andrew44Ashraf/fintech-service/internal/batches"
+	apperrors "github.com/Andrew44Ashraf/fintech-service/internal/errors"
+	"github.com/Andrew44Ashraf/fintech-service/internal/ids"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	accountA = "acc_0123456789abcdef0123456789abcdef"
+	accountB = "acc_fedcba9876543210fedcba9876543210"
+)
+
+func TestParseCSV(t *testing.T) {
+	number := ids.AccountNumber("0000000000000002")
+	file := "type,account_id,to_account_id,amount,reference\n" +
+		"deposit," + accountA + ",,100.50,payroll-march\n" +
+		"Withdrawal, " + number + ",,20,\n" +
+		"transfer," + accountA + "," + number + ",5,\n"
+
+	instructions, err := batches.Parse(strings.NewReader(file), batches.FormatCSV)
+
+	require.NoError(t, err)
+	assert.Equal(t, []batches.Instruction{
+		{Row: 1, Type: "deposit", Account: accountA, Amount: 100.5, Reference: "payroll-march"},
+		{Row: 2, Type: "withdrawal", Account: number, Amount: 20},
+		{Row: 3, Type: "transfer", Account: accountA, ToAccount: number, Amount: 5},
+	}, instructions)
+}
+
+func TestParseCSVColumnOrderIsFree(t *testing.T) {
+	instructions, err := batches.Parse(strings.NewReader("amount,account_id,type\n7,"+accountB+",deposit\n"), batches.FormatCSV)
+
+	require.NoError(t, err)
+	assert.Equal(t, []batches.Instruction{{Row: 1, Type: "deposit", Account: accountB, Amount: 7}}, instructions)
+}
+
+func TestParseJSONL(t *testing.T) {
+	file := `{"type":"deposit","account_id":"` + accountA + `","amount":10}` + "\n\n" +
+		`{"type":"transfer","account_id":"` + accountA + `","to_account_id":"` + accountB + `","amount":2.5,"reference":"fix"}` + "\n"
+
+	instructions, err := batches.Parse(strings.NewReader(file), batches.FormatJSONL)
+
+	require.NoError(t, err)
+	assert.Equal(t, []batches.Instruction{
+		{Row: 1, Type: "deposit", Account: accountA, Amount: 10},
+		{Row: 2, Type: "transfer", Account: accountA, ToAccount: accountB, Amount: 2.5, Reference: "fix"},
+	}, instructions)
+}
+
+func TestParseReportsEveryInvalidRow(t *testing.T) {
+	file := "type,account_id,to_account_id,amount\n" +
+		"deposit," + accountA + ",,100\n" +
+		"refund," + accountA + ",,10\n" +
+		"withdrawal,x,,-5\n" +
+		"transfer," + accountB + "," + accountB + ",1.234\n"
+
+	_, err := batches.Parse(strings.NewReader(file), batches.FormatCSV)
+
+	var verr *apperrors.ValidationError
+	require.ErrorAs(t, err, &verr)
+	var fields []string
+	for _, f := range verr.Fields {
+		fields = append(fields, f.Field)
+	}
+	assert.Equal(t, []string{
+		"rows[2].type",
+		"rows[3].account_id",
+		"rows[3].amount",
+		"rows[4].to_account_id",
+		"rows[4].amount",
+	}, fields)
+	assert.Equal(t, "must be an account ID or account number", verr.Fields[1].Message)
+}
+
+func TestResolve(t *testing.T) {
+	number := ids.AccountNumber("0000000000000002")
+	instructions := []batches.Instruction{
+		{Row: 1, Type: "deposit", Account: accountA, Amount: 10},
+		{Row: 2, Type: "transfer", Account: accountA, ToAccount: number, Amount: 5},
+	}
+	assert.Equal(t, []string{accountA, number}, batches.AccountRefs(instructions))
+
+	t.Run("sets internal IDs", func(t *testing.T) {
+		rows := append([]batches.Instruction(nil), instructions...)
+
+		require.NoError(t, batches.Resolve(rows, map[string]int{accountA: 1, number: 2}))
+		assert.Equal(t, 1, rows[0].AccountID)
+		assert.Equal(t, 2, rows[1].ToAccountID)
+	})
+
+	t.Run("reports unknown accounts", func(t *testing.T) {
+		rows := append([]batches.Instruction(nil), instructions...)
+
+		err := batches.Resolve(rows, map[string]int{accountA: 1})
+
+		var verr *apperrors.ValidationError
+		require.ErrorAs(t, err, &verr)
+		require.Len(t, verr.Fields, 1)
+		assert.Equal(t, "rows[2].to_account_id", verr.Fields[0].Field)
+	})
+
+	t.Run("an account named two ways is still the same account", func(t *testing.T) {
+		rows := append([]batches.Instruction(nil), instructions...)
+
+		err := batches.Resolve(rows, map[string]int{accountA: 1, number: 1})
+
+		var verr *apperrors.ValidationError
+		require.ErrorAs(t, err, &verr)
+		assert.Equal(t, "nefield", verr.Fields[0].Rule)
+	})
+}
+
+func TestParseRejectsBadFiles(t *testing.T) {
+	tests := map[string]struct {
+		file, format, field string
+	}{
+		"empty":          {"", batches.FormatCSV, "file"},
+		"header only":    {"type,account_id,amount\n", batches.FormatCSV, "file"},
+		"missing column": {"type,amount\ndeposit,1\n", batches.FormatCSV, "file"},
+		"bad json":       {`{"type":"deposit"` + "\n", batches.FormatJSONL, "rows[1]"},
+		"unknown field":  {`{"type":"deposit","account_id":"` + accountA + `","amount":1,"currency":"EUR"}`, batches.FormatJSONL, "rows[1]"},
+		"unknown format": {"", "xlsx", "format"},
+		"too many rows":  {"type,account_id,amount\n" + strings.Repeat("deposit,"+accountA+",1\n", batches.MaxRows+1), batches.FormatCSV, "file"},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := batches.Parse(strings.NewReader(tt.file), tt.format)
+
+			var verr *apperrors.ValidationError
+			require.ErrorAs(t, err, &verr)
+			assert.Equal(t, tt.field, verr.Fields[0].Field)
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package cache_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Andrew44Ashraf/fintech-service/internal/cache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLRU(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("newer versions replace older ones, not the other way round", func(t *testing.T) {
+		c := cache.NewLRU(10)
+		require.NoError(t, c.Put(ctx, "a", cache.Entry{Balance: 100, Version: 1}))
+		require.NoError(t, c.Put(ctx, "a", cache.Entry{Balance: 150, Version: 2}))
+		require.NoError(t, c.Put(ctx, "a", cache.Entry{Balance: 100, Version: 1}))
+
+		e, ok, err := c.Get(ctx, "a")
+		require.NoError(t, err)
+		assert.True(t, ok)
+		assert.Equal(t, cache.Entry{Balance: 150, Version: 2}, e)
+	})
+
+	t.Run("the least recently used entry is evicted", func(t *testing.T) {
+		c := cache.NewLRU(2)
+		c.Put(ctx, "a", cache.Entry{Version: 1})
+		c.Put(ctx, "b", cache.Entry{Version: 1})
+		c.Get(ctx, "a")
+		c.Put(ctx, "c", cache.Entry{Version: 1})
+
+		_, ok, _ := c.Get(ctx, "b")
+		assert.False(t, ok)
+		_, ok, _ = c.Get(ctx, "a")
+		assert.True(t, ok)
+		assert.Equal(t, 2, c.Len())
+	})
+
+	t.Run("clear drops everything", func(t *testing.T) {
+		c := cache.NewLRU(2)
+		c.Put(ctx, "a", cache.Entry{Version: 1})
+		require.NoError(t, c.Clear(ctx))
+
+		_, ok, _ := c.Get(ctx, "a")
+		assert.False(t, ok)
+		assert.Zero(t, c.Len())
+	})
+}
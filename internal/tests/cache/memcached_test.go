@@ -0,0 +1,182 @@
+package cache_test
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Andrew44Ashraf/fintech-service/internal/cache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeMemcached speaks the part of the memcached text protocol the store
+// uses: gets, add, cas and flush_all.
+type fakeMemcached struct {
+	mu     sync.Mutex
+	items  map[string]fakeItem
+	nextID uint64
+	// beforeCas runs before each cas is applied, to simulate other writers.
+	beforeCas func(m *fakeMemcached)
+}
+
+type fakeItem struct {
+	data string
+	cas  uint64
+}
+
+func startMemcached(t *testing.T) (*fakeMemcached, string) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { l.Close() })
+
+	m := &fakeMemcached{items: make(map[string]fakeItem)}
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go m.serve(conn)
+		}
+	}()
+	return m, l.Addr().String()
+}
+
+// set stores data as another client would.
+func (m *fakeMemcached) set(key, data string) {
+	m.nextID++
+	m.items[key] = fakeItem{data: data, cas: m.nextID}
+}
+
+func (m *fakeMemcached) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		f := strings.Fields(line)
+		if len(f) == 0 {
+			return
+		}
+
+		var reply string
+		switch f[0] {
+		case "gets":
+			m.mu.Lock()
+			item, ok := m.items[f[1]]
+			m.mu.Unlock()
+			if ok {
+				reply = fmt.Sprintf("VALUE %s 0 %d %d\r\n%s\r\n", f[1], len(item.data), item.cas, item.data)
+			}
+			reply += "END\r\n"
+		case "add", "cas":
+			data, _ := r.ReadString('\n')
+			data = strings.TrimRight(data, "\r\n")
+			m.mu.Lock()
+			if f[0] == "cas" && m.beforeCas != nil {
+				m.beforeCas(m)
+			}
+			item, ok := m.items[f[1]]
+			switch {
+			case f[0] == "add" && ok:
+				reply = "NOT_STORED\r\n"
+			case f[0] == "cas" && !ok:
+				reply = "NOT_FOUND\r\n"
+			case f[0] == "cas" && strconv.FormatUint(item.cas, 10) != f[5]:
+				reply = "EXISTS\r\n"
+			default:
+				m.set(f[1], data)
+				reply = "STORED\r\n"
+			}
+			m.mu.Unlock()
+		case "flush_all":
+			m.mu.Lock()
+			m.items = make(map[string]fakeItem)
+			m.mu.Unlock()
+			reply = "OK\r\n"
+		default:
+			reply = "ERROR\r\n"
+		}
+		if _, err := conn.Write([]byte(reply)); err != nil {
+			return
+		}
+	}
+}
+
+func TestMemcached(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("entries round-trip", func(t *testing.T) {
+		_, addr := startMemcached(t)
+		c := cache.NewMemcached(addr, time.Minute)
+
+		_, ok, err := c.Get(ctx, "a")
+		require.NoError(t, err)
+		assert.False(t, ok)
+
+		require.NoError(t, c.Put(ctx, "a", cache.Entry{Balance: 100.25, Version: 1}))
+		e, ok, err := c.Get(ctx, "a")
+		require.NoError(t, err)
+		assert.True(t, ok)
+		assert.Equal(t, cache.Entry{Balance: 100.25, Version: 1}, e)
+	})
+
+	t.Run("older versions do not replace newer ones", func(t *testing.T) {
+		_, addr := startMemcached(t)
+		c := cache.NewMemcached(addr, time.Minute)
+
+		require.NoError(t, c.Put(ctx, "a", cache.Entry{Balance: 150, Version: 2}))
+		require.NoError(t, c.Put(ctx, "a", cache.Entry{Balance: 100, Version: 1}))
+		require.NoError(t, c.Put(ctx, "a", cache.Entry{Balance: 175, Version: 3}))
+
+		e, _, err := c.Get(ctx, "a")
+		require.NoError(t, err)
+		assert.Equal(t, cache.Entry{Balance: 175, Version: 3}, e)
+	})
+
+	t.Run("a newer version written concurrently wins", func(t *testing.T) {
+		m, addr := startMemcached(t)
+		c := cache.NewMemcached(addr, time.Minute)
+		require.NoError(t, c.Put(ctx, "a", cache.Entry{Balance: 100, Version: 1}))
+
+		// Another replica stores version 3 between our gets and cas
+		m.mu.Lock()
+		m.beforeCas = func(m *fakeMemcached) {
+			m.set("a", "3 175")
+			m.beforeCas = nil
+		}
+		m.mu.Unlock()
+		require.NoError(t, c.Put(ctx, "a", cache.Entry{Balance: 150, Version: 2}))
+
+		e, _, err := c.Get(ctx, "a")
+		require.NoError(t, err)
+		assert.Equal(t, cache.Entry{Balance: 175, Version: 3}, e)
+	})
+
+	t.Run("clear flushes the server", func(t *testing.T) {
+		_, addr := startMemcached(t)
+		c := cache.NewMemcached(addr, time.Minute)
+		require.NoError(t, c.Put(ctx, "a", cache.Entry{Balance: 100, Version: 1}))
+
+		require.NoError(t, c.Clear(ctx))
+		_, ok, err := c.Get(ctx, "a")
+		require.NoError(t, err)
+		assert.False(t, ok)
+	})
+
+	t.Run("an unreachable server is an error", func(t *testing.T) {
+		c := cache.NewMemcached("127.0.0.1:1", time.Minute)
+
+		_, _, err := c.Get(ctx, "a")
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,86 @@
+package cache_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Andrew44Ashraf/fintech-service/internal/cache"
+	"github.com/Andrew44Ashraf/fintech-service/internal/logging"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func balanceChange(payload string) *pq.Notification {
+	return &pq.Notification{Channel: cache.Channel, Extra: payload}
+}
+
+func TestNotified(t *testing.T) {
+	ctx := context.Background()
+	key := cache.BalanceKey("acme", 7)
+
+	// listening returns a store that has (re)connected to its channel.
+	listening := func() *cache.Notified {
+		n := cache.NewNotified(cache.NewLRU(10), logging.Discard())
+		n.HandleNotification(ctx, nil)
+		return n
+	}
+
+	t.Run("nothing is served before listening", func(t *testing.T) {
+		n := cache.NewNotified(cache.NewLRU(10), logging.Discard())
+		require.NoError(t, n.Put(ctx, key, cache.Entry{Balance: 100, Version: 1}))
+
+		_, ok, err := n.Get(ctx, key)
+		require.NoError(t, err)
+		assert.False(t, ok)
+	})
+
+	t.Run("another replica's write replaces the cached balance", func(t *testing.T) {
+		n := listening()
+		require.NoError(t, n.Put(ctx, key, cache.Entry{Balance: 100, Version: 1}))
+
+		n.HandleNotification(ctx, balanceChange(`{"tenant_id":"acme","account_id":7,"balance":150.5,"version":2}`))
+
+		e, ok, err := n.Get(ctx, key)
+		require.NoError(t, err)
+		assert.True(t, ok)
+		assert.Equal(t, cache.Entry{Balance: 150.5, Version: 2}, e)
+	})
+
+	t.Run("notifications arriving late do not undo newer balances", func(t *testing.T) {
+		n := listening()
+		require.NoError(t, n.Put(ctx, key, cache.Entry{Balance: 150, Version: 3}))
+
+		n.HandleNotification(ctx, balanceChange(`{"tenant_id":"acme","account_id":7,"balance":100,"version":2}`))
+
+		e, _, _ := n.Get(ctx, key)
+		assert.Equal(t, 150.0, e.Balance)
+	})
+
+	t.Run("malformed notifications are ignored", func(t *testing.T) {
+		n := listening()
+		require.NoError(t, n.Put(ctx, key, cache.Entry{Balance: 100, Version: 1}))
+
+		n.HandleNotification(ctx, balanceChange(`not json`))
+
+		e, _, _ := n.Get(ctx, key)
+		assert.Equal(t, 100.0, e.Balance)
+	})
+
+	t.Run("losing the connection stops serving and drops entries", func(t *testing.T) {
+		n := listening()
+		require.NoError(t, n.Put(ctx, key, cache.Entry{Balance: 100, Version: 1}))
+
+		n.HandleEvent(pq.ListenerEventDisconnected, errors.New("connection reset"))
+		_, ok, _ := n.Get(ctx, key)
+		assert.False(t, ok)
+
+		// Entries stored while disconnected may have missed changes
+		require.NoError(t, n.Put(ctx, key, cache.Entry{Balance: 100, Version: 1}))
+		n.HandleEvent(pq.ListenerEventReconnected, nil)
+		n.HandleNotification(ctx, nil)
+		_, ok, _ = n.Get(ctx, key)
+		assert.False(t, ok)
+	})
+}
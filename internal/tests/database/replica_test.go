@@ -0,0 +1,133 @@
+package database_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Andrew44Ashraf/fintech-service/internal/database"
+	"github.com/Andrew44Ashraf/fintech-service/internal/logging"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	walQuery    = `SELECT pg_current_wal_lsn\(\)::text`
+	replayQuery = `SELECT COALESCE\(pg_last_wal_replay_lsn\(\)`
+)
+
+func newMockDB(t *testing.T) (*sql.DB, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	return db, mock
+}
+
+// checkedRouter returns a router whose replica has replayed up to replayed
+// while the primary is at current, lagAge seconds behind if it has not
+// caught up.
+func checkedRouter(t *testing.T, maxLag time.Duration, current, replayed string, lagAge float64) (r *database.Router, primary, replica *sql.DB) {
+	primary, pmock := newMockDB(t)
+	replica, rmock := newMockDB(t)
+	pmock.ExpectQuery(walQuery).WillReturnRows(sqlmock.NewRows([]string{"lsn"}).AddRow(current))
+	rmock.ExpectQuery(replayQuery).WillReturnRows(sqlmock.NewRows([]string{"lsn", "age"}).AddRow(replayed, lagAge))
+
+	r = database.NewRouter(primary, replica, maxLag, logging.Discard())
+	require.NoError(t, r.Check(context.Background()))
+	return r, primary, replica
+}
+
+func TestParseLSN(t *testing.T) {
+	lsn, err := database.ParseLSN("16/B374D848")
+	require.NoError(t, err)
+	assert.Equal(t, database.LSN(0x16_B374D848), lsn)
+	assert.Equal(t, "16/B374D848", lsn.String())
+
+	for _, s := range []string{"", "16", "16/", "/1", "G/1", "1/100000000", "1/2/3"} {
+		_, err := database.ParseLSN(s)
+		assert.Error(t, err, s)
+	}
+}
+
+func TestRouter(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("without a replica reads go to the primary", func(t *testing.T) {
+		primary, _ := newMockDB(t)
+		r := database.NewRouter(primary, nil, time.Second, logging.Discard())
+
+		assert.False(t, r.HasReplica())
+		assert.NoError(t, r.Check(ctx))
+		assert.Same(t, primary, r.Reader(ctx))
+	})
+
+	t.Run("reads go to the primary until the replica is checked", func(t *testing.T) {
+		primary, _ := newMockDB(t)
+		replica, _ := newMockDB(t)
+		r := database.NewRouter(primary, replica, time.Second, logging.Discard())
+
+		assert.Same(t, primary, r.Reader(ctx))
+	})
+
+	t.Run("a caught up replica serves reads", func(t *testing.T) {
+		r, _, replica := checkedRouter(t, time.Second, "0/3000", "0/3000", 3600)
+
+		assert.Same(t, replica, r.Reader(ctx))
+	})
+
+	t.Run("a replica lagging past the bound does not", func(t *testing.T) {
+		r, primary, _ := checkedRouter(t, time.Second, "0/3000", "0/2000", 2)
+
+		assert.Same(t, primary, r.Reader(ctx))
+	})
+
+	t.Run("a replica lagging within the bound does", func(t *testing.T) {
+		r, _, replica := checkedRouter(t, 5*time.Second, "0/3000", "0/2000", 2)
+
+		assert.Same(t, replica, r.Reader(ctx))
+	})
+
+	t.Run("an old check cannot vouch for the replica", func(t *testing.T) {
+		r, primary, _ := checkedRouter(t, 50*time.Millisecond, "0/3000", "0/3000", 0)
+
+		time.Sleep(60 * time.Millisecond)
+		assert.Same(t, primary, r.Reader(ctx))
+	})
+
+	t.Run("a replica that is down does not serve reads", func(t *testing.T) {
+		primary, pmock := newMockDB(t)
+		replica, rmock := newMockDB(t)
+		pmock.ExpectQuery(walQuery).WillReturnRows(sqlmock.NewRows([]string{"lsn"}).AddRow("0/3000"))
+		rmock.ExpectQuery(replayQuery).WillReturnError(errors.New("connection refused"))
+
+		r := database.NewRouter(primary, replica, time.Second, logging.Discard())
+		assert.Error(t, r.Check(ctx))
+		assert.Same(t, primary, r.Reader(ctx))
+	})
+
+	t.Run("a token the replica has not replayed goes to the primary", func(t *testing.T) {
+		r, primary, replica := checkedRouter(t, 5*time.Second, "0/3000", "0/2000", 1)
+
+		assert.Same(t, primary, r.Reader(database.WithToken(ctx, 0x2001)))
+		assert.Same(t, replica, r.Reader(database.WithToken(ctx, 0x2000)))
+	})
+
+	t.Run("WithPrimary skips the replica", func(t *testing.T) {
+		r, primary, _ := checkedRouter(t, time.Second, "0/3000", "0/3000", 0)
+
+		assert.Same(t, primary, r.Reader(database.WithPrimary(ctx)))
+	})
+
+	t.Run("Token is the primary's WAL position", func(t *testing.T) {
+		primary, pmock := newMockDB(t)
+		pmock.ExpectQuery(walQuery).WillReturnRows(sqlmock.NewRows([]string{"lsn"}).AddRow("1/A0"))
+		r := database.NewRouter(primary, nil, time.Second, logging.Discard())
+
+		lsn, err := r.Token(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, "1/A0", lsn.String())
+	})
+}
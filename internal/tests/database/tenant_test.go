@@ -0,0 +1,64 @@
+package database_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Andrew44Ashraf/fintech-service/internal/tenant"
+	"github.com/Andrew44Ashraf/fintech-service/internal/tests/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithTenants(t *testing.T) {
+	alpha := tenant.WithID(context.Background(), "alpha")
+	bravo := tenant.WithID(context.Background(), "bravo")
+
+	t.Run("statements run for the tenant of their context", func(t *testing.T) {
+		db, log := testutils.NewTenantDB()
+		db.SetMaxOpenConns(1)
+
+		db.ExecContext(alpha, "UPDATE accounts SET balance = 0")
+		db.QueryRowContext(bravo, "SELECT balance FROM accounts").Scan(new(float64))
+		db.ExecContext(context.Background(), "DELETE FROM accounts")
+
+		assert.Equal(t, []testutils.TenantStatement{
+			{Tenant: "alpha", Query: "UPDATE accounts SET balance = 0"},
+			{Tenant: "bravo", Query: "SELECT balance FROM accounts"},
+			{Tenant: "", Query: "DELETE FROM accounts"},
+		}, log.Statements())
+	})
+
+	t.Run("the tenant is only sent when it changes", func(t *testing.T) {
+		db, log := testutils.NewTenantDB()
+		db.SetMaxOpenConns(1)
+
+		for range 3 {
+			db.ExecContext(alpha, "SELECT 1")
+		}
+		tx, err := db.BeginTx(alpha, nil)
+		require.NoError(t, err)
+		tx.ExecContext(alpha, "SELECT 2")
+		require.NoError(t, tx.Commit())
+		db.ExecContext(alpha, "SELECT 3")
+
+		assert.Equal(t, 1, log.Sets())
+		assert.Len(t, log.Statements(), 5)
+	})
+
+	t.Run("a rollback that undoes the setting is noticed", func(t *testing.T) {
+		db, log := testutils.NewTenantDB()
+		db.SetMaxOpenConns(1)
+
+		tx, err := db.BeginTx(alpha, nil)
+		require.NoError(t, err)
+		tx.ExecContext(bravo, "SELECT 1")
+		require.NoError(t, tx.Rollback())
+		db.ExecContext(bravo, "SELECT 2")
+
+		assert.Equal(t, []testutils.TenantStatement{
+			{Tenant: "bravo", Query: "SELECT 1"},
+			{Tenant: "bravo", Query: "SELECT 2"},
+		}, log.Statements())
+	})
+}
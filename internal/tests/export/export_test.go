@@ -0,0 +1,154 @@
+package export_test
+
+import (
+	"bytes"
+	"encoding/xml"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Andrew44Ashraf/fintech-service/internal/export"
+	"github.com/Andrew44Ashraf/fintech-service/internal/statements"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var generatedAt = time.Date(2026, 4, 2, 3, 0, 0, 0, time.UTC)
+
+func sampleStatement() *statements.Statement {
+	from := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	s := &statements.Statement{
+		AccountID:      42,
+		Currency:       "EUR",
+		From:           from,
+		To:             from.AddDate(0, 1, 0),
+		OpeningBalance: 100,
+		Lines: []statements.Line{
+			{TransactionID: 1, Date: from.Add(24 * time.Hour), Type: "deposit", Amount: 50, Balance: 150},
+			{TransactionID: 2, Date: from.Add(48 * time.Hour), Type: "withdrawal", Amount: 30, Balance: 120},
+			{TransactionID: 3, Date: from.Add(72 * time.Hour), Type: "deposit", Amount: 5.5, Balance: 125.5},
+		},
+		GeneratedAt: generatedAt,
+	}
+	s.Summarize()
+	return s
+}
+
+func TestCamt053ValidatesAgainstSchema(t *testing.T) {
+	xmllint, err := exec.LookPath("xmllint")
+	if err != nil {
+		t.Skip("xmllint not installed")
+	}
+
+	for name, s := range map[string]*statements.Statement{
+		"with entries": sampleStatement(),
+		"empty period": {AccountID: 7, From: time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), To: time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)},
+		"overdrawn":    {AccountID: 8, From: time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), To: time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC), OpeningBalance: -10, ClosingBalance: -10},
+	} {
+		t.Run(name, func(t *testing.T) {
+			var buf bytes.Buffer
+			require.NoError(t, export.Camt053(&buf, s, generatedAt))
+
+			file := filepath.Join(t.TempDir(), "statement.xml")
+			require.NoError(t, os.WriteFile(file, buf.Bytes(), 0o600))
+
+			out, err := exec.Command(xmllint, "--noout", "--schema", "testdata/camt.053.001.08.xsd", file).CombinedOutput()
+			assert.NoError(t, err, "%s\n%s", out, buf.String())
+		})
+	}
+}
+
+func TestCamt053Content(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, export.Camt053(&buf, sampleStatement(), generatedAt))
+
+	var doc struct {
+		Stmt struct {
+			Acct struct {
+				ID  string `xml:"Id>Othr>Id"`
+				Ccy string `xml:"Ccy"`
+			} `xml:"Acct"`
+			Bal []struct {
+				Code string `xml:"Tp>CdOrPrtry>Cd"`
+				Amt  string `xml:"Amt"`
+				Ind  string `xml:"CdtDbtInd"`
+				Date string `xml:"Dt>Dt"`
+			} `xml:"Bal"`
+			Ntry []struct {
+				Ref    string `xml:"NtryRef"`
+				Amt    string `xml:"Amt"`
+				Ind    string `xml:"CdtDbtInd"`
+				SubFml string `xml:"BkTxCd>Domn>Fmly>SubFmlyCd"`
+			} `xml:"Ntry"`
+			Summary struct {
+				Credits string `xml:"TtlCdtNtries>Sum"`
+				Debits  string `xml:"TtlDbtNtries>Sum"`
+			} `xml:"TxsSummry"`
+		} `xml:"BkToCstmrStmt>Stmt"`
+	}
+	require.NoError(t, xml.Unmarshal(buf.Bytes(), &doc))
+
+	assert.Equal(t, "42", doc.Stmt.Acct.ID)
+	assert.Equal(t, "EUR", doc.Stmt.Acct.Ccy)
+	require.Len(t, doc.Stmt.Bal, 2)
+	assert.Equal(t, "OPBD", doc.Stmt.Bal[0].Code)
+	assert.Equal(t, "100.00", doc.Stmt.Bal[0].Amt)
+	assert.Equal(t, "2026-03-01", doc.Stmt.Bal[0].Date)
+	assert.Equal(t, "CLBD", doc.Stmt.Bal[1].Code)
+	assert.Equal(t, "125.50", doc.Stmt.Bal[1].Amt)
+	assert.Equal(t, "2026-03-31", doc.Stmt.Bal[1].Date)
+
+	require.Len(t, doc.Stmt.Ntry, 3)
+	assert.Equal(t, "CRDT", doc.Stmt.Ntry[0].Ind)
+	assert.Equal(t, "CDPT", doc.Stmt.Ntry[0].SubFml)
+	assert.Equal(t, "2", doc.Stmt.Ntry[1].Ref)
+	assert.Equal(t, "DBIT", doc.Stmt.Ntry[1].Ind)
+	assert.Equal(t, "30.00", doc.Stmt.Ntry[1].Amt)
+	assert.Equal(t, "CWDL", doc.Stmt.Ntry[1].SubFml)
+	assert.Equal(t, "55.50", doc.Stmt.Summary.Credits)
+	assert.Equal(t, "30.00", doc.Stmt.Summary.Debits)
+}
+
+func TestMT940(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, export.MT940(&buf, sampleStatement()))
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\r\n"), "\r\n")
+	assert.Equal(t, []string{
+		":20:STMT42-20260301",
+		":25:42",
+		":28C:2603/1",
+		":60F:C260301EUR100,00",
+		":61:2603020302C50,00NMSCNONREF//1",
+		":86:deposit",
+		":61:2603030303D30,00NMSCNONREF//2",
+		":86:withdrawal",
+		":61:2603040304C5,50NMSCNONREF//3",
+		":86:deposit",
+		":62F:C260331EUR125,50",
+		"-",
+	}, lines)
+}
+
+func TestMT940DebitBalanceAndDefaultCurrency(t *testing.T) {
+	s := &statements.Statement{
+		AccountID:      7,
+		From:           time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC),
+		To:             time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC),
+		OpeningBalance: -12.5,
+		ClosingBalance: -12.5,
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, export.MT940(&buf, s))
+	assert.Contains(t, buf.String(), ":60F:D260301USD12,50\r\n")
+	assert.Contains(t, buf.String(), ":62F:D260331USD12,50\r\n")
+}
+
+func TestRenderRejectsUnknownFormat(t *testing.T) {
+	assert.False(t, export.Supports("pdf"))
+	assert.Error(t, export.Render(&bytes.Buffer{}, sampleStatement(), "pdf", generatedAt))
+}
@@ -0,0 +1,38 @@
+package fees_test
+
+import (
+	"testing"
+
+	"github.com/Andrew44Ashraf/fintech-service/internal/fees"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMonthly(t *testing.T) {
+	schedule := fees.Schedule{
+		ProductCode:         "savings",
+		MaintenanceFee:      5,
+		LowBalanceThreshold: 100,
+		LowBalanceFee:       2,
+	}
+
+	t.Run("maintenance only above threshold", func(t *testing.T) {
+		assert.Equal(t, []fees.Charge{{Type: fees.Maintenance, Amount: 5}}, schedule.Monthly(100))
+	})
+
+	t.Run("low balance fee when the balance dipped", func(t *testing.T) {
+		assert.Equal(t, []fees.Charge{
+			{Type: fees.Maintenance, Amount: 5},
+			{Type: fees.LowBalance, Amount: 2},
+		}, schedule.Monthly(99.99))
+	})
+
+	t.Run("zero amounts disable fees", func(t *testing.T) {
+		assert.Empty(t, fees.Schedule{LowBalanceThreshold: 100}.Monthly(0))
+	})
+}
+
+func TestTypeValid(t *testing.T) {
+	assert.True(t, fees.Withdrawal.Valid())
+	assert.True(t, fees.LowBalance.Valid())
+	assert.False(t, fees.Type("overdraft").Valid())
+}
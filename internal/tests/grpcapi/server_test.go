@@ -0,0 +1,279 @@
+package grpcapi_test
+
+import (
+	"context"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/Andrew44Ashraf/fintech-service/internal/auth"
+	"github.com/Andrew44Ashraf/fintech-service/internal/grpcapi"
+	"github.com/Andrew44Ashraf/fintech-service/internal/grpcapi/fintechv1"
+	"github.com/Andrew44Ashraf/fintech-service/internal/logging"
+	"github.com/Andrew44Ashraf/fintech-service/internal/repository"
+	"github.com/Andrew44Ashraf/fintech-service/internal/tests/testutils"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+const (
+	testKey     = "test-key"
+	testAccount = "acc_00000000000000000000000000000001"
+)
+
+// expectResolve expects testAccount to be resolved to internal ID 1.
+func expectResolve(mock sqlmock.Sqlmock) {
+	mock.ExpectQuery(`SELECT id FROM accounts WHERE public_id = \$1`).WithArgs(testAccount).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+}
+
+// newClient serves the API over an in-memory listener backed by a mock DB.
+func newClient(t *testing.T) (fintechv1.FintechServiceClient, sqlmock.Sqlmock) {
+	t.Helper()
+	db, mock := testutils.NewMockDB()
+	logger := logging.Discard()
+
+	server := grpcapi.NewServer(
+		repository.NewAccountRepository(db, logger),
+		repository.NewTransactionRepository(db, logger),
+		logger,
+		grpcapi.Config{Keys: auth.NewStaticKeys(testKey), WatchInterval: 10 * time.Millisecond},
+	)
+	lis := bufconn.Listen(1 << 20)
+	go server.Serve(lis)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	return fintechv1.NewFintechServiceClient(conn), mock
+}
+
+func authed(key string) context.Context {
+	return metadata.AppendToOutgoingContext(context.Background(), grpcapi.APIKeyMetadata, key)
+}
+
+// reason returns the catalog code carried by a status error.
+func reason(t *testing.T, err error) string {
+	t.Helper()
+	for _, d := range status.Convert(err).Details() {
+		if info, ok := d.(*errdetails.ErrorInfo); ok {
+			return info.Reason
+		}
+	}
+	return ""
+}
+
+func TestAuth(t *testing.T) {
+	client, mock := newClient(t)
+
+	t.Run("missing key", func(t *testing.T) {
+		_, err := client.GetBalance(context.Background(), &fintechv1.GetBalanceRequest{AccountId: testAccount})
+
+		assert.Equal(t, codes.Unauthenticated, status.Code(err))
+		assert.Equal(t, "unauthorized", reason(t, err))
+	})
+
+	t.Run("wrong key", func(t *testing.T) {
+		_, err := client.GetBalance(authed("nope"), &fintechv1.GetBalanceRequest{AccountId: testAccount})
+
+		assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	})
+
+	t.Run("streams are guarded too", func(t *testing.T) {
+		stream, err := client.WatchAccount(context.Background(), &fintechv1.WatchAccountRequest{AccountId: testAccount})
+		require.NoError(t, err)
+		_, err = stream.Recv()
+
+		assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	})
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetBalance(t *testing.T) {
+	client, mock := newClient(t)
+
+	t.Run("current balance", func(t *testing.T) {
+		expectResolve(mock)
+		mock.ExpectQuery(`SELECT balance FROM accounts`).WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"balance"}).AddRow(250.0))
+
+		resp, err := client.GetBalance(authed(testKey), &fintechv1.GetBalanceRequest{AccountId: testAccount})
+
+		require.NoError(t, err)
+		assert.Equal(t, 250.0, resp.Balance)
+		assert.Equal(t, testAccount, resp.AccountId)
+	})
+
+	t.Run("by account number", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT id FROM accounts WHERE account_number = \$1`).WithArgs("FT430000000000000001").
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+		mock.ExpectQuery(`SELECT balance FROM accounts`).WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"balance"}).AddRow(250.0))
+
+		resp, err := client.GetBalance(authed(testKey), &fintechv1.GetBalanceRequest{AccountId: "FT43 0000 0000 0000 0001"})
+
+		require.NoError(t, err)
+		assert.Equal(t, 250.0, resp.Balance)
+	})
+
+	t.Run("unknown account", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT id FROM accounts WHERE public_id = \$1`).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+		_, err := client.GetBalance(authed(testKey), &fintechv1.GetBalanceRequest{AccountId: "acc_00000000000000000000000000000002"})
+
+		assert.Equal(t, codes.NotFound, status.Code(err))
+		assert.Equal(t, "account_not_found", reason(t, err))
+	})
+
+	t.Run("internal IDs are refused", func(t *testing.T) {
+		_, err := client.GetBalance(authed(testKey), &fintechv1.GetBalanceRequest{AccountId: "1"})
+
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		assert.Equal(t, "validation_failed", reason(t, err))
+	})
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDeposit(t *testing.T) {
+	client, mock := newClient(t)
+
+	t.Run("posts and returns the new balance", func(t *testing.T) {
+		expectResolve(mock)
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT status FROM accounts`).
+			WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow("active"))
+		testutils.ExpectAccountRules(mock)
+		mock.ExpectQuery(`INSERT INTO transactions`).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+		mock.ExpectExec(`UPDATE accounts`).WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectQuery(`SELECT balance FROM accounts`).
+			WillReturnRows(sqlmock.NewRows([]string{"balance"}).AddRow(100.0))
+		mock.ExpectExec(`UPDATE transactions`).WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+		mock.ExpectQuery(`SELECT public_id FROM transactions`).WithArgs(7).
+			WillReturnRows(sqlmock.NewRows([]string{"public_id"}).AddRow("tx_00000000000000000000000000000007"))
+		mock.ExpectQuery(`SELECT balance FROM accounts`).
+			WillReturnRows(sqlmock.NewRows([]string{"balance"}).AddRow(100.0))
+
+		resp, err := client.Deposit(authed(testKey), &fintechv1.DepositRequest{AccountId: testAccount, Amount: 100})
+
+		require.NoError(t, err)
+		assert.Equal(t, "tx_00000000000000000000000000000007", resp.TransactionId)
+		assert.Equal(t, repository.StatusPosted, resp.Status)
+		assert.Equal(t, 100.0, resp.NewBalance)
+	})
+
+	t.Run("validation errors name the field", func(t *testing.T) {
+		_, err := client.Deposit(authed(testKey), &fintechv1.DepositRequest{AccountId: testAccount, Amount: -5})
+
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		assert.Equal(t, "validation_failed", reason(t, err))
+		var fields []string
+		for _, d := range status.Convert(err).Details() {
+			if br, ok := d.(*errdetails.BadRequest); ok {
+				for _, v := range br.FieldViolations {
+					fields = append(fields, v.Field)
+				}
+			}
+		}
+		assert.Equal(t, []string{"amount"}, fields)
+	})
+
+	t.Run("closed account", func(t *testing.T) {
+		expectResolve(mock)
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT status FROM accounts`).
+			WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow("closed"))
+		mock.ExpectRollback()
+
+		_, err := client.Deposit(authed(testKey), &fintechv1.DepositRequest{AccountId: testAccount, Amount: 10})
+
+		assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+		assert.Equal(t, "account_closed", reason(t, err))
+	})
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestListTransactions(t *testing.T) {
+	client, mock := newClient(t)
+	created := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	expectResolve(mock)
+	mock.ExpectQuery(`SELECT a.public_id, a.account_number, a.product_code`).WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{
+			"public_id", "account_number", "product_code", "status", "balance", "currency", "overdraft_limit", "version", "created_at", "updated_at",
+			"external_ref", "description", "metadata",
+			"max_single_withdrawal", "max_daily_withdrawal", "max_monthly_withdrawal", "max_daily_withdrawals",
+		}).AddRow(testAccount, "FT430000000000000001", "checking", "active", 30.0, "USD", 0.0, 3, created, created, "", "", []byte(`{}`), nil, nil, nil, nil))
+	mock.ExpectQuery(`FROM transactions`).WithArgs(1, 2, 0, "").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "account_id", "amount", "type", "status", "created_at", "final_balance",
+			"external_ref", "description", "metadata", "public_id", "value_date", "booking_date"}).
+			AddRow(3, 1, 10.0, "deposit", "posted", created, 30.0, "inv-3", "", []byte(`{}`), "tx_00000000000000000000000000000003", created, created).
+			AddRow(2, 1, 10.0, "deposit", "posted", created, 20.0, "", "", []byte(`{}`), "tx_00000000000000000000000000000002", created, created))
+
+	stream, err := client.ListTransactions(authed(testKey), &fintechv1.ListTransactionsRequest{AccountId: testAccount, Limit: 2})
+	require.NoError(t, err)
+
+	var (
+		ids      []string
+		accounts []string
+		refs     []string
+	)
+	for {
+		tx, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		ids = append(ids, tx.PublicId)
+		accounts = append(accounts, tx.AccountId)
+		refs = append(refs, tx.GetDetails().GetExternalRef())
+	}
+
+	assert.Equal(t, []string{"tx_00000000000000000000000000000003", "tx_00000000000000000000000000000002"}, ids)
+	assert.Equal(t, []string{testAccount, testAccount}, accounts)
+	assert.Equal(t, []string{"inv-3", ""}, refs)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestWatchAccount(t *testing.T) {
+	client, mock := newClient(t)
+	balanceRows := func(b float64) *sqlmock.Rows { return sqlmock.NewRows([]string{"balance"}).AddRow(b) }
+
+	expectResolve(mock)
+	mock.ExpectQuery(`SELECT balance FROM accounts`).WillReturnRows(balanceRows(10))
+	mock.ExpectQuery(`SELECT balance FROM accounts`).WillReturnRows(balanceRows(10))
+	mock.ExpectQuery(`SELECT balance FROM accounts`).WillReturnRows(balanceRows(25))
+
+	ctx, cancel := context.WithCancel(authed(testKey))
+	defer cancel()
+	stream, err := client.WatchAccount(ctx, &fintechv1.WatchAccountRequest{AccountId: testAccount})
+	require.NoError(t, err)
+
+	first, err := stream.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, 10.0, first.Balance)
+
+	// The unchanged poll is not sent.
+	next, err := stream.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, 25.0, next.Balance)
+}
@@ -0,0 +1,107 @@
+package grpcapi_test
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/Andrew44Ashraf/fintech-service/internal/auth"
+	"github.com/Andrew44Ashraf/fintech-service/internal/grpcapi"
+	"github.com/Andrew44Ashraf/fintech-service/internal/grpcapi/fintechv1"
+	"github.com/Andrew44Ashraf/fintech-service/internal/logging"
+	"github.com/Andrew44Ashraf/fintech-service/internal/repository"
+	"github.com/Andrew44Ashraf/fintech-service/internal/tests/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// TestCrossTenantAccess calls every method as tenant bravo with the IDs of
+// tenant alpha's accounts and checks that every statement was scoped to
+// bravo. Row-level security hides alpha's rows; the fake database returns
+// none.
+func TestCrossTenantAccess(t *testing.T) {
+	db, log := testutils.NewTenantDB()
+	t.Cleanup(func() { db.Close() })
+	logger := logging.Discard()
+
+	server := grpcapi.NewServer(
+		repository.NewAccountRepository(db, logger),
+		repository.NewTransactionRepository(db, logger),
+		logger,
+		grpcapi.Config{Keys: auth.NewStaticKeys("alpha:key-alpha", "bravo:key-bravo")},
+	)
+	lis := bufconn.Listen(1 << 20)
+	go server.Serve(lis)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	client := fintechv1.NewFintechServiceClient(conn)
+	ctx := authed("key-bravo")
+	const (
+		alphaAccount = "acc_0123456789abcdef0123456789abcdef"
+		alphaOther   = "acc_fedcba9876543210fedcba9876543210"
+	)
+
+	calls := map[string]func() error{
+		"OpenAccount": func() error {
+			_, err := client.OpenAccount(ctx, &fintechv1.OpenAccountRequest{})
+			return err
+		},
+		"GetBalance": func() error {
+			_, err := client.GetBalance(ctx, &fintechv1.GetBalanceRequest{AccountId: alphaAccount})
+			return err
+		},
+		"Deposit": func() error {
+			_, err := client.Deposit(ctx, &fintechv1.DepositRequest{AccountId: alphaAccount, Amount: 10})
+			return err
+		},
+		"Withdraw": func() error {
+			_, err := client.Withdraw(ctx, &fintechv1.WithdrawRequest{AccountId: alphaAccount, Amount: 10})
+			return err
+		},
+		"Transfer": func() error {
+			_, err := client.Transfer(ctx, &fintechv1.TransferRequest{FromAccountId: alphaAccount, ToAccountId: alphaOther, Amount: 10})
+			return err
+		},
+		"ListTransactions": func() error {
+			stream, err := client.ListTransactions(ctx, &fintechv1.ListTransactionsRequest{AccountId: alphaAccount})
+			if err != nil {
+				return err
+			}
+			_, err = stream.Recv()
+			return err
+		},
+		"WatchAccount": func() error {
+			stream, err := client.WatchAccount(ctx, &fintechv1.WatchAccountRequest{AccountId: alphaAccount})
+			if err != nil {
+				return err
+			}
+			_, err = stream.Recv()
+			return err
+		},
+	}
+	desc := fintechv1.FintechService_ServiceDesc
+	require.Len(t, calls, len(desc.Methods)+len(desc.Streams), "every method needs a call")
+
+	for name, call := range calls {
+		t.Run(name, func(t *testing.T) {
+			log.Reset()
+
+			assert.Error(t, call(), "alpha's account was reached")
+
+			statements := log.Statements()
+			require.NotEmpty(t, statements)
+			for _, s := range statements {
+				assert.Equal(t, "bravo", s.Tenant, s.Query)
+			}
+		})
+	}
+}
@@ -0,0 +1,258 @@
+package handlers_test
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"expvar"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Andrew44Ashraf/fintech-service/internal/cache"
+	apperrors "github.com/Andrew44Ashraf/fintech-service/internal/errors"
+	"github.com/Andrew44Ashraf/fintech-service/internal/handlers"
+	"github.com/Andrew44Ashraf/fintech-service/internal/logging"
+	"github.com/Andrew44Ashraf/fintech-service/internal/repository"
+	"github.com/Andrew44Ashraf/fintech-service/internal/tenant"
+	"github.com/Andrew44Ashraf/fintech-service/internal/tests/testutils"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConditionalGet(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	accountRepo, mock := testutils.NewMockRepository()
+	handler := handlers.NewAccountHandler(accountRepo, logging.Discard())
+
+	balance := func(ifNoneMatch string) *httptest.ResponseRecorder {
+		mock.ExpectQuery(`SELECT balance, version FROM accounts`).
+			WillReturnRows(sqlmock.NewRows([]string{"balance", "version"}).AddRow(120.0, 5))
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest("GET", "/accounts/1/balance", nil)
+		if ifNoneMatch != "" {
+			c.Request.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		c.Params = []gin.Param{{Key: "id", Value: "1"}}
+		handler.GetBalance(c)
+		return w
+	}
+
+	t.Run("balance carries the version", func(t *testing.T) {
+		w := balance("")
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, `"5"`, w.Header().Get("ETag"))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("unchanged balance is not sent again", func(t *testing.T) {
+		w := balance(`"5"`)
+
+		assert.Equal(t, http.StatusNotModified, w.Code)
+		assert.Empty(t, w.Body.String())
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("changed balance is sent", func(t *testing.T) {
+		w := balance(`"4"`)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"balance":120`)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("account carries the version", func(t *testing.T) {
+		now := time.Now()
+		mock.ExpectQuery(`SELECT a.public_id, a.account_number, a.product_code`).
+			WillReturnRows(sqlmock.NewRows([]string{
+				"public_id", "account_number", "product_code", "status", "balance", "currency", "overdraft_limit", "version", "created_at", "updated_at",
+				"external_ref", "description", "metadata",
+				"max_single_withdrawal", "max_daily_withdrawal", "max_monthly_withdrawal", "max_daily_withdrawals",
+			}).AddRow("acc_00000000000000000000000000000001", "FT430000000000000001", "checking", "active", 120.0, "USD", 0.0, 5, now, now, "crm-42", "", []byte(`{"tier":"gold"}`), 250.0, nil, nil, nil))
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest("GET", "/accounts/1", nil)
+		c.Params = []gin.Param{{Key: "id", Value: "1"}}
+
+		handler.GetAccount(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, `"5"`, w.Header().Get("ETag"))
+		assert.Contains(t, w.Body.String(), `"limits":{"max_single_withdrawal":250}`)
+		assert.Contains(t, w.Body.String(), `"account_id":"acc_00000000000000000000000000000001"`)
+		assert.Contains(t, w.Body.String(), `"external_ref":"crm-42"`)
+		assert.Contains(t, w.Body.String(), `"metadata":{"tier":"gold"}`)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestCachedBalancePolls(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ctx := tenant.WithID(context.Background(), tenant.Default)
+	db, mock := testutils.NewMockDB()
+	balances := cache.NewLRU(100)
+	accountRepo := repository.NewAccountRepository(db, logging.Discard(), repository.WithAccountCache(balances))
+	transactionRepo := repository.NewTransactionRepository(db, logging.Discard(), repository.WithTransactionCache(balances))
+	handler := handlers.NewAccountHandler(accountRepo, logging.Discard())
+
+	poll := func(ifNoneMatch string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequestWithContext(ctx, "GET", "/accounts/1/balance", nil)
+		if ifNoneMatch != "" {
+			c.Request.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		c.Params = []gin.Param{{Key: "id", Value: "1"}}
+		handler.GetBalance(c)
+		return w
+	}
+	hits := func() int64 {
+		if n, ok := repository.CacheMetrics.Get("hit").(*expvar.Int); ok {
+			return n.Value()
+		}
+		return 0
+	}
+
+	// The first poll fills the cache
+	mock.ExpectQuery(`SELECT balance, version FROM accounts`).
+		WillReturnRows(sqlmock.NewRows([]string{"balance", "version"}).AddRow(100.0, 1))
+	w := poll("")
+	assert.Equal(t, `"1"`, w.Header().Get("ETag"))
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT status FROM accounts`).WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow("active"))
+	testutils.ExpectAccountRules(mock)
+	mock.ExpectQuery(`INSERT INTO transactions`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(10))
+	mock.ExpectExec(`UPDATE accounts SET balance`).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`SELECT balance FROM accounts`).
+		WillReturnRows(sqlmock.NewRows([]string{"balance"}).AddRow(150.0))
+	mock.ExpectExec(`UPDATE transactions SET final_balance`).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`SELECT id, balance, version FROM accounts`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "balance", "version"}).AddRow(1, 150.0, 2))
+	mock.ExpectCommit()
+	_, err := transactionRepo.CreateDeposit(ctx, 1, 50, repository.Details{})
+	require.NoError(t, err)
+
+	before := hits()
+	w = poll(`"1"`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `"2"`, w.Header().Get("ETag"))
+	assert.Contains(t, w.Body.String(), `"balance":150`)
+
+	w = poll(`"2"`)
+	assert.Equal(t, http.StatusNotModified, w.Code)
+	assert.Equal(t, before+2, hits())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAccountPreconditions(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	accountRepo, mock := testutils.NewMockRepository()
+	handler := handlers.NewAccountHandler(accountRepo, logging.Discard())
+
+	changeStatus := func(ifMatch string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest("PATCH", "/admin/accounts/1",
+			bytes.NewBufferString(`{"status": "frozen", "operator": "ops", "reason": "card stolen"}`))
+		if ifMatch != "" {
+			c.Request.Header.Set("If-Match", ifMatch)
+		}
+		c.Params = []gin.Param{{Key: "id", Value: "1"}}
+		handler.ChangeStatus(c)
+		return w
+	}
+
+	t.Run("If-Match is required", func(t *testing.T) {
+		w := changeStatus("")
+
+		assert.Equal(t, http.StatusPreconditionRequired, w.Code)
+		assert.Equal(t, apperrors.CodePreconditionRequired, decodeProblem(t, w).Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("stale ETag", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT status, balance, version FROM accounts`).
+			WillReturnRows(sqlmock.NewRows([]string{"status", "balance", "version"}).AddRow("active", 10.0, 6))
+		mock.ExpectRollback()
+
+		w := changeStatus(`"5"`)
+
+		assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+		assert.Equal(t, apperrors.CodeVersionMismatch, decodeProblem(t, w).Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("malformed ETag", func(t *testing.T) {
+		w := changeStatus(`"abc"`)
+
+		assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestResolveAccountRoute(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	accountRepo, mock := testutils.NewMockRepository()
+	handler := handlers.NewAccountHandler(accountRepo, logging.Discard())
+
+	router := gin.New()
+	router.GET("/accounts/:id/balance", handlers.ResolveAccount(accountRepo, logging.Discard()), handler.GetBalance)
+	get := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", path, nil)
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("public ID is resolved to the account", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT id FROM accounts WHERE public_id`).
+			WithArgs("acc_0f8fad5bd9cb469fa16570867728950e").
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+		mock.ExpectQuery(`SELECT balance, version FROM accounts`).WithArgs(7).
+			WillReturnRows(sqlmock.NewRows([]string{"balance", "version"}).AddRow(120.0, 5))
+
+		w := get("/accounts/acc_0f8fad5bd9cb469fa16570867728950e/balance")
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("account number is resolved to the account", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT id FROM accounts WHERE account_number`).
+			WithArgs("FT430000000000000001").
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+		mock.ExpectQuery(`SELECT balance, version FROM accounts`).WithArgs(7).
+			WillReturnRows(sqlmock.NewRows([]string{"balance", "version"}).AddRow(120.0, 5))
+
+		w := get("/accounts/FT430000000000000001/balance")
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("internal ID is not accepted", func(t *testing.T) {
+		w := get("/accounts/7/balance")
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, "id", decodeProblem(t, w).Errors[0].Field)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("unknown account", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT id FROM accounts WHERE public_id`).WillReturnError(sql.ErrNoRows)
+
+		w := get("/accounts/acc_0f8fad5bd9cb469fa16570867728950e/balance")
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
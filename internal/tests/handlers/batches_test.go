@@ -0,0 +1,110 @@
+package handlers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Andrew44Ashraf/fintech-service/internal/dto/responses"
+	apperrors "github.com/Andrew44Ashraf/fintech-service/internal/errors"
+	"github.com/Andrew44Ashraf/fintech-service/internal/handlers"
+	"github.com/Andrew44Ashraf/fintech-service/internal/logging"
+	"github.com/Andrew44Ashraf/fintech-service/internal/repository"
+	"github.com/Andrew44Ashraf/fintech-service/internal/tests/testutils"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateBatch(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db, mock := testutils.NewMockDB()
+	txRepo := repository.NewTransactionRepository(db, logging.Discard())
+	accountRepo := repository.NewAccountRepository(db, logging.Discard())
+	handler := handlers.NewBatchHandler(repository.NewBatchRepository(db, logging.Discard(), txRepo), accountRepo, logging.Discard())
+	const account = "acc_0123456789abcdef0123456789abcdef"
+
+	post := func(query, contentType, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest("POST", "/api/batches"+query, bytes.NewBufferString(body))
+		c.Request.Header.Set("Content-Type", contentType)
+		handler.CreateBatch(c)
+		return w
+	}
+
+	t.Run("invalid rows are rejected before anything is posted", func(t *testing.T) {
+		w := post("", "text/csv", "type,account_id,amount\ndeposit,"+account+",10\nwithdrawal,"+account+",0\n")
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		p := decodeProblem(t, w)
+		assert.Equal(t, apperrors.CodeValidationFailed, p.Code)
+		require.Len(t, p.Errors, 1)
+		assert.Equal(t, "rows[2].amount", p.Errors[0].Field)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("unknown content type", func(t *testing.T) {
+		w := post("", "application/pdf", "%PDF")
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, "format", decodeProblem(t, w).Errors[0].Field)
+	})
+
+	t.Run("invalid mode", func(t *testing.T) {
+		w := post("?mode=sometimes", "text/csv", "type,account_id,amount\ndeposit,"+account+",10\n")
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, "mode", decodeProblem(t, w).Errors[0].Field)
+	})
+
+	t.Run("accounts that are not found are rejected before anything is posted", func(t *testing.T) {
+		mock.ExpectQuery(`FROM accounts WHERE public_id = ANY`).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "public_id", "account_number"}))
+
+		w := post("", "text/csv", "type,account_id,amount\ndeposit,"+account+",10\n")
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		p := decodeProblem(t, w)
+		require.Len(t, p.Errors, 1)
+		assert.Equal(t, "rows[1].account_id", p.Errors[0].Field)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("json lines dry run", func(t *testing.T) {
+		mock.ExpectQuery(`FROM accounts WHERE public_id = ANY`).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "public_id", "account_number"}).AddRow(1, account, "FT00"))
+		mock.ExpectQuery(`INSERT INTO batches`).
+			WithArgs("all_or_nothing", true, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(3, time.Now()))
+		mock.ExpectBegin()
+		mock.ExpectExec(`^SAVEPOINT`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(`SELECT status FROM accounts`).
+			WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow("closed"))
+		mock.ExpectExec(`^ROLLBACK TO SAVEPOINT`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(`SELECT id, public_id FROM accounts WHERE id = ANY`).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "public_id"}).AddRow(1, account))
+		mock.ExpectRollback()
+		mock.ExpectBegin()
+		mock.ExpectPrepare(`INSERT INTO batch_rows`).ExpectExec().WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(`UPDATE batches`).
+			WillReturnRows(sqlmock.NewRows([]string{"completed_at"}).AddRow(time.Now()))
+		mock.ExpectCommit()
+
+		w := post("?dry_run=true", "application/x-ndjson", `{"type":"deposit","account_id":"`+account+`","amount":10}`)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.Equal(t, "/api/batches/3", w.Header().Get("Location"))
+		var resp responses.BatchResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.True(t, resp.DryRun)
+		assert.Equal(t, repository.BatchFailed, resp.Status)
+		assert.Equal(t, "account_closed", resp.Rows[0].ErrorCode)
+		assert.Equal(t, account, resp.Rows[0].AccountID)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
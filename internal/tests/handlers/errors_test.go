@@ -0,0 +1,102 @@
+package handlers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Andrew44Ashraf/fintech-service/internal/dto/responses"
+	apperrors "github.com/Andrew44Ashraf/fintech-service/internal/errors"
+	"github.com/Andrew44Ashraf/fintech-service/internal/handlers"
+	"github.com/Andrew44Ashraf/fintech-service/internal/logging"
+	"github.com/Andrew44Ashraf/fintech-service/internal/tests/testutils"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func decodeProblem(t *testing.T, w *httptest.ResponseRecorder) responses.Problem {
+	t.Helper()
+	assert.Equal(t, responses.ProblemContentType, w.Header().Get("Content-Type"))
+	var p responses.Problem
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &p))
+	return p
+}
+
+func TestProblemResponses(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	accountRepo, _ := testutils.NewMockRepository()
+	transactionRepo, mock := testutils.NewMockTransactionRepository()
+	handler := handlers.NewTransactionHandler(transactionRepo, accountRepo, logging.Discard())
+
+	withdraw := func(id, payload string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest("POST", "/accounts/"+id+"/withdraw", bytes.NewBufferString(payload))
+		c.Params = []gin.Param{{Key: "id", Value: id}}
+		handler.Withdraw(c)
+		return w
+	}
+
+	t.Run("insufficient funds", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT balance, status FROM accounts`).
+			WillReturnRows(sqlmock.NewRows([]string{"balance", "status"}).AddRow(10.0, "active"))
+		testutils.ExpectAccountRules(mock)
+		mock.ExpectRollback()
+
+		w := withdraw("1", `{"amount": 50}`)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		p := decodeProblem(t, w)
+		assert.Equal(t, apperrors.CodeInsufficientFunds, p.Code)
+		assert.Equal(t, http.StatusUnprocessableEntity, p.Status)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("closed account", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT balance, status FROM accounts`).
+			WillReturnRows(sqlmock.NewRows([]string{"balance", "status"}).AddRow(10.0, "closed"))
+		mock.ExpectRollback()
+
+		w := withdraw("1", `{"amount": 5}`)
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.Equal(t, apperrors.CodeAccountClosed, decodeProblem(t, w).Code)
+	})
+
+	t.Run("field level validation details", func(t *testing.T) {
+		w := withdraw("1", `{"amount": -5}`)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		p := decodeProblem(t, w)
+		assert.Equal(t, apperrors.CodeValidationFailed, p.Code)
+		if assert.Len(t, p.Errors, 1) {
+			assert.Equal(t, "amount", p.Errors[0].Field)
+			assert.Equal(t, "gt", p.Errors[0].Rule)
+		}
+		assert.NotContains(t, w.Body.String(), "Key: ")
+	})
+
+	t.Run("invalid path id", func(t *testing.T) {
+		w := withdraw("abc", `{"amount": 5}`)
+
+		p := decodeProblem(t, w)
+		assert.Equal(t, apperrors.CodeValidationFailed, p.Code)
+		assert.Equal(t, "id", p.Errors[0].Field)
+	})
+
+	t.Run("server errors hide details", func(t *testing.T) {
+		mock.ExpectBegin().WillReturnError(assert.AnError)
+
+		w := withdraw("1", `{"amount": 5}`)
+
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		p := decodeProblem(t, w)
+		assert.Equal(t, apperrors.CodeInternal, p.Code)
+		assert.Empty(t, p.Detail)
+	})
+}
@@ -6,9 +6,12 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
+	"github.com/Andrew44Ashraf/fintech-service/internal/dto/responses"
+	apperrors "github.com/Andrew44Ashraf/fintech-service/internal/errors"
 	"github.com/Andrew44Ashraf/fintech-service/internal/handlers"
-	"github.com/Andrew44Ashraf/fintech-service/internal/repository"
-	"github.com/Andrew44Ashraf/fintech-service/internal/testutils"
+	"github.com/Andrew44Ashraf/fintech-service/internal/logging"
+	"github.com/Andrew44Ashraf/fintech-service/internal/tests/testutils"
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
 	"github.com/DATA-DOG/go-sqlmock"
@@ -19,15 +22,23 @@ func TestDepositHandler(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	accountRepo, _ := testutils.NewMockRepository()
 	transactionRepo, mock := testutils.NewMockTransactionRepository()
-	handler := handlers.NewTransactionHandler(transactionRepo, accountRepo)
+	handler := handlers.NewTransactionHandler(transactionRepo, accountRepo, logging.Discard())
 
 	t.Run("successful deposit", func(t *testing.T) {
 		// Mock expectations
 		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT status FROM accounts`).
+			WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow("active"))
+		testutils.ExpectAccountRules(mock)
 		mock.ExpectQuery(`INSERT INTO transactions`).
 			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
 		mock.ExpectExec(`UPDATE accounts`).WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectQuery(`SELECT balance FROM accounts`).
+			WillReturnRows(sqlmock.NewRows([]string{"balance"}).AddRow(100.0))
+		mock.ExpectExec(`UPDATE transactions`).WillReturnResult(sqlmock.NewResult(1, 1))
 		mock.ExpectCommit()
+		mock.ExpectQuery(`SELECT public_id FROM transactions`).WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"public_id"}).AddRow("tx_00000000000000000000000000000001"))
 
 		// Create test request
 		payload := `{"amount": 100.0}`
@@ -43,9 +54,9 @@ func TestDepositHandler(t *testing.T) {
 		assert.Equal(t, http.StatusOK, w.Code)
 		assert.NoError(t, mock.ExpectationsWereMet())
 		
-		var response handlers.TransactionResponse
+		var response responses.TransactionResponse
 		json.Unmarshal(w.Body.Bytes(), &response)
-		assert.Equal(t, 1, response.TransactionID)
+		assert.Equal(t, "tx_00000000000000000000000000000001", response.TransactionID)
 	})
 
 	t.Run("invalid amount", func(t *testing.T) {
@@ -59,4 +70,63 @@ func TestDepositHandler(t *testing.T) {
 
 		assert.Equal(t, http.StatusBadRequest, w.Code)
 	})
-}
\ No newline at end of file
+}
+
+func TestExternalRefHandlers(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	accountRepo, _ := testutils.NewMockRepository()
+	transactionRepo, mock := testutils.NewMockTransactionRepository()
+	handler := handlers.NewTransactionHandler(transactionRepo, accountRepo, logging.Discard())
+
+	t.Run("reused external_ref is a conflict", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT status FROM accounts`).
+			WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow("active"))
+		mock.ExpectQuery(`SELECT id FROM transactions WHERE account_id`).WithArgs(1, "inv-7").
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3))
+		mock.ExpectRollback()
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest("POST", "/accounts/1/deposit",
+			bytes.NewBufferString(`{"amount": 10, "external_ref": "inv-7", "metadata": {"order": 7}}`))
+		c.Params = []gin.Param{{Key: "id", Value: "1"}}
+
+		handler.Deposit(c)
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.Equal(t, apperrors.CodeDuplicateExternalRef, decodeProblem(t, w).Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("metadata must be an object", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest("POST", "/accounts/1/withdraw",
+			bytes.NewBufferString(`{"amount": 10, "metadata": ["a"]}`))
+		c.Params = []gin.Param{{Key: "id", Value: "1"}}
+
+		handler.Withdraw(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, "metadata", decodeProblem(t, w).Errors[0].Field)
+	})
+
+	t.Run("history filters by external_ref", func(t *testing.T) {
+		mock.ExpectQuery(`FROM transactions`).WithArgs(1, 10, 0, "inv-7").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "account_id", "amount", "type", "status", "created_at", "final_balance",
+				"external_ref", "description", "metadata", "public_id", "value_date", "booking_date"}).
+				AddRow(3, 1, 10.0, "deposit", "posted", time.Now(), 10.0, "inv-7", "", []byte(`{"order":7}`), "tx_00000000000000000000000000000003", time.Now(), time.Now()))
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest("GET", "/accounts/1/transactions?external_ref=inv-7", nil)
+		c.Params = []gin.Param{{Key: "id", Value: "1"}}
+
+		handler.GetTransactions(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"external_ref":"inv-7","metadata":{"order":7}`)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,60 @@
+package ids_test
+
+import (
+	"testing"
+
+	"github.com/Andrew44Ashraf/fintech-service/internal/ids"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPublicIDs(t *testing.T) {
+	assert.True(t, ids.IsAccountID("acc_0f8fad5bd9cb469fa16570867728950e"))
+	assert.True(t, ids.IsTransactionID("tx_0f8fad5bd9cb469fa16570867728950e"))
+	assert.True(t, ids.IsScheduledPaymentID("sp_0f8fad5bd9cb469fa16570867728950e"))
+
+	for _, s := range []string{
+		"1",
+		"tx_0f8fad5bd9cb469fa16570867728950e",
+		"acc_0f8fad5b-d9cb-469f-a165-70867728950e",
+		"acc_0F8FAD5BD9CB469FA16570867728950E",
+		"acc_0f8fad5bd9cb469fa16570867728950",
+	} {
+		assert.False(t, ids.IsAccountID(s), s)
+	}
+}
+
+func TestAccountNumbers(t *testing.T) {
+	t.Run("check digits follow ISO 13616", func(t *testing.T) {
+		// 0000000000000001 FT00 -> 1152900 mod 97 = 55, check = 98 - 55
+		assert.Equal(t, "FT430000000000000001", ids.AccountNumber("0000000000000001"))
+	})
+
+	t.Run("grouped and lower case numbers are accepted", func(t *testing.T) {
+		n := ids.AccountNumber("1234567890123456")
+
+		got, ok := ids.ParseAccountNumber("ft" + ids.FormatAccountNumber(n)[2:])
+
+		assert.True(t, ok)
+		assert.Equal(t, n, got)
+	})
+
+	t.Run("a mistyped digit is caught", func(t *testing.T) {
+		n := []byte(ids.AccountNumber("1234567890123456"))
+		n[10] = '0' + (n[10]-'0'+1)%10
+
+		_, ok := ids.ParseAccountNumber(string(n))
+
+		assert.False(t, ok)
+	})
+
+	t.Run("malformed numbers are rejected", func(t *testing.T) {
+		for _, s := range []string{"", "1", "GB430000000000000001", "FT43000000000000001", "FT4300000000000000A1"} {
+			_, ok := ids.ParseAccountNumber(s)
+			assert.False(t, ok, s)
+		}
+	})
+
+	t.Run("display groups in fours", func(t *testing.T) {
+		assert.Equal(t, "FT43 0000 0000 0000 0001", ids.FormatAccountNumber("FT430000000000000001"))
+	})
+}
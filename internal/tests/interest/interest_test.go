@@ -0,0 +1,86 @@
+package interest_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Andrew44Ashraf/fintech-service/internal/interest"
+	"github.com/stretchr/testify/assert"
+)
+
+func day(y int, m time.Month, d int) time.Time {
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+func TestDayFraction(t *testing.T) {
+	tests := []struct {
+		name     string
+		dayCount interest.DayCount
+		date     time.Time
+		want     float64
+	}{
+		{"act/365", interest.Actual365, day(2024, 3, 1), 1.0 / 365},
+		{"act/360", interest.Actual360, day(2026, 3, 1), 1.0 / 360},
+		{"act/act leap year", interest.ActualActual, day(2024, 3, 1), 1.0 / 366},
+		{"act/act common year", interest.ActualActual, day(2026, 3, 1), 1.0 / 365},
+		{"30/360 ordinary day", interest.Thirty360, day(2026, 1, 15), 1.0 / 360},
+		{"30/360 31st accrues nothing", interest.Thirty360, day(2026, 1, 31), 0},
+		{"30/360 end of february", interest.Thirty360, day(2026, 2, 28), 3.0 / 360},
+		{"30/360 end of leap february", interest.Thirty360, day(2024, 2, 29), 2.0 / 360},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.InDelta(t, tt.want, tt.dayCount.DayFraction(tt.date), 1e-12)
+		})
+	}
+}
+
+func TestThirty360MonthSumsToThirtyDays(t *testing.T) {
+	for _, month := range []time.Month{time.January, time.February, time.April} {
+		var total float64
+		for d := day(2026, month, 1); d.Month() == month; d = d.AddDate(0, 0, 1) {
+			total += interest.Thirty360.DayFraction(d)
+		}
+		assert.InDelta(t, 30.0/360, total, 1e-12, month.String())
+	}
+}
+
+func TestDailyTiers(t *testing.T) {
+	savings := interest.Product{
+		Code:     "savings",
+		DayCount: interest.Actual360,
+		Tiers: []interest.Tier{
+			{MinBalance: 10000, APR: 2},
+			{MinBalance: 0, APR: 1},
+		},
+	}
+	date := day(2026, 3, 1)
+
+	t.Run("single tier", func(t *testing.T) {
+		assert.InDelta(t, 3600*0.01/360, savings.Daily(3600, date), 1e-12)
+	})
+
+	t.Run("balance is banded across tiers", func(t *testing.T) {
+		want := (10000*0.01 + 5000*0.02) / 360
+		assert.InDelta(t, want, savings.Daily(15000, date), 1e-12)
+		assert.InDelta(t, 200.0/150, savings.EffectiveAPR(15000, date), 1e-9)
+	})
+
+	t.Run("no interest on zero or negative balances", func(t *testing.T) {
+		assert.Zero(t, savings.Daily(0, date))
+		assert.Zero(t, savings.Daily(-50, date))
+	})
+
+	t.Run("below the first tier earns nothing", func(t *testing.T) {
+		p := interest.Product{DayCount: interest.Actual365, Tiers: []interest.Tier{{MinBalance: 1000, APR: 3}}}
+		assert.Zero(t, p.Daily(999, date))
+		assert.InDelta(t, 1000*0.03/365, p.Daily(2000, date), 1e-12)
+	})
+}
+
+func TestProductValidate(t *testing.T) {
+	assert.NoError(t, interest.Product{Code: "savings", DayCount: interest.Actual365}.Validate())
+	assert.Error(t, interest.Product{Code: "savings", DayCount: "ACT/364"}.Validate())
+	assert.Error(t, interest.Product{Code: "savings", DayCount: interest.Actual365, Tiers: []interest.Tier{{APR: -1}}}.Validate())
+	assert.False(t, interest.Product{Tiers: []interest.Tier{{APR: 0}}}.Bearing())
+}
@@ -0,0 +1,138 @@
+package middleware_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Andrew44Ashraf/fintech-service/internal/database"
+	"github.com/Andrew44Ashraf/fintech-service/internal/logging"
+	"github.com/Andrew44Ashraf/fintech-service/internal/middleware"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const walQuery = `SELECT pg_current_wal_lsn\(\)::text`
+
+// consistencyRouter serves reads that report whether the replica or the
+// primary would answer them, over a replica that has replayed up to 0/2000.
+func consistencyRouter(t *testing.T) (*gin.Engine, sqlmock.Sqlmock) {
+	primary, pmock, err := sqlmock.New()
+	require.NoError(t, err)
+	replica, rmock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { primary.Close(); replica.Close() })
+
+	pmock.ExpectQuery(walQuery).WillReturnRows(sqlmock.NewRows([]string{"lsn"}).AddRow("0/3000"))
+	rmock.ExpectQuery(`SELECT COALESCE`).WillReturnRows(sqlmock.NewRows([]string{"lsn", "age"}).AddRow("0/2000", 0.1))
+	reads := database.NewRouter(primary, replica, time.Minute, logging.Discard())
+	require.NoError(t, reads.Check(context.Background()))
+
+	source := func(c *gin.Context) string {
+		if reads.Reader(c.Request.Context()) == replica {
+			return "replica"
+		}
+		return "primary"
+	}
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(middleware.Consistency(reads, logging.Discard()))
+	r.GET("/read", func(c *gin.Context) { c.String(http.StatusOK, source(c)) })
+	r.POST("/write", func(c *gin.Context) { c.String(http.StatusCreated, source(c)) })
+	r.POST("/invalid", func(c *gin.Context) { c.Status(http.StatusBadRequest) })
+	r.DELETE("/write", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+	return r, pmock
+}
+
+func request(r *gin.Engine, method, token string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	path := "/read"
+	switch method {
+	case http.MethodPost, http.MethodDelete:
+		path = "/write"
+	}
+	req, _ := http.NewRequest(method, path, nil)
+	if token != "" {
+		req.Header.Set(middleware.ConsistencyHeader, token)
+	}
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestConsistency(t *testing.T) {
+	t.Run("writes read from the primary and return a token", func(t *testing.T) {
+		r, pmock := consistencyRouter(t)
+		pmock.ExpectQuery(walQuery).WillReturnRows(sqlmock.NewRows([]string{"lsn"}).AddRow("0/2500"))
+
+		w := request(r, http.MethodPost, "")
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.Equal(t, "primary", w.Body.String())
+		assert.Equal(t, "0/2500", w.Header().Get(middleware.ConsistencyHeader))
+		assert.NoError(t, pmock.ExpectationsWereMet())
+	})
+
+	t.Run("writes without a body return a token", func(t *testing.T) {
+		r, pmock := consistencyRouter(t)
+		pmock.ExpectQuery(walQuery).WillReturnRows(sqlmock.NewRows([]string{"lsn"}).AddRow("0/2500"))
+
+		w := request(r, http.MethodDelete, "")
+
+		assert.Equal(t, http.StatusNoContent, w.Code)
+		assert.Equal(t, "0/2500", w.Header().Get(middleware.ConsistencyHeader))
+	})
+
+	t.Run("failed writes return no token", func(t *testing.T) {
+		r, pmock := consistencyRouter(t)
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, "/invalid", nil)
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Empty(t, w.Header().Get(middleware.ConsistencyHeader))
+		assert.NoError(t, pmock.ExpectationsWereMet())
+	})
+
+	t.Run("reads without a token use the replica", func(t *testing.T) {
+		r, _ := consistencyRouter(t)
+
+		assert.Equal(t, "replica", request(r, http.MethodGet, "").Body.String())
+	})
+
+	t.Run("reads with a replayed token use the replica", func(t *testing.T) {
+		r, _ := consistencyRouter(t)
+
+		assert.Equal(t, "replica", request(r, http.MethodGet, "0/2000").Body.String())
+	})
+
+	t.Run("reads with a newer token use the primary", func(t *testing.T) {
+		r, _ := consistencyRouter(t)
+
+		assert.Equal(t, "primary", request(r, http.MethodGet, "0/2500").Body.String())
+	})
+
+	t.Run("malformed tokens are rejected", func(t *testing.T) {
+		r, _ := consistencyRouter(t)
+
+		assert.Equal(t, http.StatusBadRequest, request(r, http.MethodGet, "latest").Code)
+	})
+
+	t.Run("without a replica it does nothing", func(t *testing.T) {
+		primary, _, err := sqlmock.New()
+		require.NoError(t, err)
+		defer primary.Close()
+
+		r := gin.New()
+		r.Use(middleware.Consistency(database.NewRouter(primary, nil, time.Minute, logging.Discard()), logging.Discard()))
+		r.POST("/write", func(c *gin.Context) { c.Status(http.StatusCreated) })
+
+		w := request(r, http.MethodPost, "")
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.Empty(t, w.Header().Get(middleware.ConsistencyHeader))
+	})
+}
@@ -0,0 +1,176 @@
+package middleware_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/Andrew44Ashraf/fintech-service/internal/logging"
+	"github.com/Andrew44Ashraf/fintech-service/internal/middleware"
+	"github.com/Andrew44Ashraf/fintech-service/internal/ratelimit"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	store := ratelimit.NewMemoryStoreWithClock(func() time.Time { return now })
+
+	r := gin.New()
+	r.POST("/accounts/:id/withdraw",
+		middleware.RateLimit(store, logging.Discard(), "withdraw",
+			middleware.RateLimitRule{Name: "ip", Key: middleware.ByClientIP, Limit: ratelimit.PerMinute(60, 10)},
+			middleware.RateLimitRule{Name: "account", Key: middleware.ByAccount, Limit: ratelimit.PerMinute(60, 2)},
+		),
+		func(c *gin.Context) { c.Status(http.StatusOK) },
+	)
+
+	do := func(account string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/accounts/"+account+"/withdraw", nil)
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := do("1")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "2", w.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, "1", w.Header().Get("RateLimit-Remaining"))
+
+	assert.Equal(t, http.StatusOK, do("1").Code)
+
+	w = do("1")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "1", w.Header().Get("Retry-After"))
+	assert.Equal(t, "0", w.Header().Get("RateLimit-Remaining"))
+	assert.Contains(t, w.Body.String(), `"code":"rate_limited"`)
+
+	// Other accounts have their own budget
+	assert.Equal(t, http.StatusOK, do("2").Code)
+
+	// Tokens refill over time
+	now = now.Add(time.Second)
+	assert.Equal(t, http.StatusOK, do("1").Code)
+}
+
+func TestRateLimitSkipsEmptyKeys(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	store := ratelimit.NewMemoryStore()
+
+	r := gin.New()
+	r.GET("/ping",
+		middleware.RateLimit(store, logging.Discard(), "read",
+			middleware.RateLimitRule{Name: "api_key", Key: middleware.ByAPIKey, Limit: ratelimit.PerMinute(1, 1)},
+		),
+		func(c *gin.Context) { c.Status(http.StatusOK) },
+	)
+
+	for i := 0; i < 3; i++ {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/ping", nil)
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+	}
+}
+
+func TestRateLimitRefundsOnRejection(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	store := ratelimit.NewMemoryStoreWithClock(func() time.Time { return now })
+
+	r := gin.New()
+	r.POST("/accounts/:id/withdraw",
+		middleware.RateLimit(store, logging.Discard(), "withdraw",
+			middleware.RateLimitRule{Name: "api_key", Key: middleware.ByAPIKey, Limit: ratelimit.PerMinute(1, 3)},
+			middleware.RateLimitRule{Name: "account", Key: middleware.ByAccount, Limit: ratelimit.PerMinute(1, 1)},
+		),
+		func(c *gin.Context) { c.Status(http.StatusOK) },
+	)
+
+	do := func(account string) int {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/accounts/"+account+"/withdraw", nil)
+		req.Header.Set(middleware.APIKeyHeader, "key")
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusOK, do("1"))
+	assert.Equal(t, http.StatusTooManyRequests, do("1"))
+
+	// The rejected request gave its API key token back
+	assert.Equal(t, http.StatusOK, do("2"))
+	assert.Equal(t, http.StatusOK, do("3"))
+	assert.Equal(t, http.StatusTooManyRequests, do("4"))
+}
+
+func TestRateLimitAccountNumberSpellingsShareABudget(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	store := ratelimit.NewMemoryStore()
+
+	r := gin.New()
+	r.GET("/accounts/:id/balance",
+		middleware.RateLimit(store, logging.Discard(), "read",
+			middleware.RateLimitRule{Name: "account", Key: middleware.ByAccount, Limit: ratelimit.PerMinute(1, 2)},
+		),
+		func(c *gin.Context) { c.Status(http.StatusOK) },
+	)
+
+	do := func(account string) int {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/accounts/"+url.PathEscape(account)+"/balance", nil)
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusOK, do("FT430000000000000001"))
+	assert.Equal(t, http.StatusOK, do("ft43 0000 0000 0000 0001"))
+	assert.Equal(t, http.StatusTooManyRequests, do("FT43 0000 0000 0000 0001"))
+}
+
+// brokenStore fails every call, as a store whose database is down.
+type brokenStore struct{}
+
+func (brokenStore) Take(context.Context, string, ratelimit.Limit) (ratelimit.Result, error) {
+	return ratelimit.Result{}, errors.New("connection refused")
+}
+
+func (brokenStore) Refund(context.Context, string, ratelimit.Limit) error {
+	return errors.New("connection refused")
+}
+
+func TestRateLimitStoreFailure(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	do := func(store ratelimit.Store) *httptest.ResponseRecorder {
+		r := gin.New()
+		r.GET("/accounts/:id/balance",
+			middleware.RateLimit(store, logging.Discard(), "read",
+				middleware.RateLimitRule{Name: "account", Key: middleware.ByAccount, Limit: ratelimit.PerMinute(60, 10)},
+			),
+			func(c *gin.Context) { c.Status(http.StatusOK) },
+		)
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/accounts/1/balance", nil)
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("fails closed", func(t *testing.T) {
+		w := do(brokenStore{})
+
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+		assert.Contains(t, w.Body.String(), `"code":"rate_limit_unavailable"`)
+	})
+
+	t.Run("fails open when asked to", func(t *testing.T) {
+		w := do(ratelimit.FailOpen(brokenStore{}, logging.Discard()))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+}
@@ -0,0 +1,67 @@
+package middleware_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Andrew44Ashraf/fintech-service/internal/logging"
+	"github.com/Andrew44Ashraf/fintech-service/internal/middleware"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func newRouter(logger *bytes.Buffer) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(middleware.RequestID(), middleware.AccessLog(logging.New(logger, "info")))
+	r.GET("/accounts/:id/balance", func(c *gin.Context) {
+		c.String(http.StatusOK, logging.RequestID(c.Request.Context()))
+	})
+	return r
+}
+
+func TestRequestID(t *testing.T) {
+	t.Run("echoes incoming id", func(t *testing.T) {
+		var logs bytes.Buffer
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/accounts/1/balance", nil)
+		req.Header.Set(middleware.RequestIDHeader, "abc-123")
+
+		newRouter(&logs).ServeHTTP(w, req)
+
+		assert.Equal(t, "abc-123", w.Header().Get(middleware.RequestIDHeader))
+		assert.Equal(t, "abc-123", w.Body.String())
+	})
+
+	t.Run("generates id when missing", func(t *testing.T) {
+		var logs bytes.Buffer
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/accounts/1/balance", nil)
+
+		newRouter(&logs).ServeHTTP(w, req)
+
+		id := w.Header().Get(middleware.RequestIDHeader)
+		assert.Len(t, id, 32)
+		assert.Equal(t, id, w.Body.String())
+	})
+}
+
+func TestAccessLog(t *testing.T) {
+	var logs bytes.Buffer
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/accounts/42/balance?token=secret", nil)
+	req.Header.Set(middleware.RequestIDHeader, "req-1")
+
+	newRouter(&logs).ServeHTTP(w, req)
+
+	var entry map[string]any
+	assert.NoError(t, json.Unmarshal(logs.Bytes(), &entry))
+	assert.Equal(t, "req-1", entry[logging.KeyRequestID])
+	assert.Equal(t, "/accounts/:id/balance", entry["route"])
+	assert.Equal(t, float64(http.StatusOK), entry["status"])
+	assert.Contains(t, entry, logging.KeyLatency)
+	assert.NotContains(t, logs.String(), "secret")
+}
@@ -0,0 +1,89 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Andrew44Ashraf/fintech-service/internal/auth"
+	"github.com/Andrew44Ashraf/fintech-service/internal/logging"
+	"github.com/Andrew44Ashraf/fintech-service/internal/middleware"
+	"github.com/Andrew44Ashraf/fintech-service/internal/tenant"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// tenantRouter echoes the tenant a request acts for.
+func tenantRouter(mw gin.HandlerFunc) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/whoami", mw, func(c *gin.Context) {
+		c.String(http.StatusOK, tenant.FromContext(c.Request.Context()))
+	})
+	return r
+}
+
+func serve(r *gin.Engine, headers map[string]string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/whoami", nil)
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestAPIKeyAuth(t *testing.T) {
+	r := tenantRouter(middleware.APIKeyAuth(auth.NewStaticKeys("acme:key-a", "key-default"), logging.Discard()))
+
+	t.Run("the key gives the tenant", func(t *testing.T) {
+		w := serve(r, map[string]string{middleware.APIKeyHeader: "key-a"})
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "acme", w.Body.String())
+	})
+
+	t.Run("bare keys act for the default tenant", func(t *testing.T) {
+		w := serve(r, map[string]string{middleware.APIKeyHeader: "key-default"})
+
+		assert.Equal(t, tenant.Default, w.Body.String())
+	})
+
+	t.Run("the tenant header cannot override the key", func(t *testing.T) {
+		w := serve(r, map[string]string{middleware.APIKeyHeader: "key-a", middleware.TenantHeader: "other"})
+
+		assert.Equal(t, "acme", w.Body.String())
+	})
+
+	t.Run("missing key", func(t *testing.T) {
+		w := serve(r, nil)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("unknown key", func(t *testing.T) {
+		w := serve(r, map[string]string{middleware.APIKeyHeader: "acme:key-a"})
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("no keys means the default tenant", func(t *testing.T) {
+		w := serve(tenantRouter(middleware.APIKeyAuth(nil, logging.Discard())), nil)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, tenant.Default, w.Body.String())
+	})
+}
+
+func TestAdminTenant(t *testing.T) {
+	r := tenantRouter(middleware.AdminTenant())
+
+	w := serve(r, map[string]string{middleware.TenantHeader: "acme"})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "acme", w.Body.String())
+
+	for _, id := range []string{"", "Acme", "-acme", "acme;drop"} {
+		w := serve(r, map[string]string{middleware.TenantHeader: id})
+		assert.Equal(t, http.StatusBadRequest, w.Code, id)
+	}
+}
@@ -0,0 +1,114 @@
+package reconciliation_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	apperrors "github.com/Andrew44Ashraf/fintech-service/internal/errors"
+	"github.com/Andrew44Ashraf/fintech-service/internal/export"
+	"github.com/Andrew44Ashraf/fintech-service/internal/reconciliation"
+	"github.com/Andrew44Ashraf/fintech-service/internal/statements"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func date(s string) time.Time {
+	d, _ := time.Parse("2006-01-02", s)
+	return d
+}
+
+func TestParseCSV(t *testing.T) {
+	t.Run("signed amounts", func(t *testing.T) {
+		file := "booking_date,value_date,amount,reference,description\n" +
+			"2026-03-02,2026-03-03,150.00,101,salary\n" +
+			"2026-03-04,,-20.5,102,atm\n"
+
+		st, err := reconciliation.Parse(strings.NewReader(file), reconciliation.FormatCSV)
+
+		require.NoError(t, err)
+		assert.Equal(t, []reconciliation.BankLine{
+			{LineNo: 1, Reference: "101", Amount: 150, BookingDate: date("2026-03-02"), ValueDate: date("2026-03-03"), Description: "salary"},
+			{LineNo: 2, Reference: "102", Amount: -20.5, BookingDate: date("2026-03-04"), ValueDate: date("2026-03-04"), Description: "atm"},
+		}, st.Lines)
+		assert.Equal(t, date("2026-03-02"), st.PeriodStart)
+		assert.Equal(t, date("2026-03-04"), st.PeriodEnd)
+	})
+
+	t.Run("credit/debit indicator", func(t *testing.T) {
+		file := "Reference,Booking_Date,Amount,Credit_Debit\nA,2026-03-02,10,D\nB,2026-03-02,5,CRDT\n"
+
+		st, err := reconciliation.Parse(strings.NewReader(file), reconciliation.FormatCSV)
+
+		require.NoError(t, err)
+		assert.Equal(t, -10.0, st.Lines[0].Amount)
+		assert.Equal(t, 5.0, st.Lines[1].Amount)
+	})
+
+	t.Run("errors are validation errors", func(t *testing.T) {
+		for name, file := range map[string]string{
+			"missing column": "booking_date,amount\n2026-03-02,1\n",
+			"bad date":       "booking_date,amount,reference\n02/03/2026,1,x\n",
+			"bad amount":     "booking_date,amount,reference\n2026-03-02,ten,x\n",
+			"no lines":       "booking_date,amount,reference\n",
+		} {
+			_, err := reconciliation.Parse(strings.NewReader(file), reconciliation.FormatCSV)
+
+			var verr *apperrors.ValidationError
+			assert.ErrorAs(t, err, &verr, name)
+		}
+	})
+}
+
+func TestParseCamt053(t *testing.T) {
+	t.Run("reads our own export", func(t *testing.T) {
+		from := date("2026-03-01")
+		s := &statements.Statement{
+			AccountID: 1, Currency: "EUR", From: from, To: from.AddDate(0, 1, 0),
+			Lines: []statements.Line{
+				{TransactionID: 7, Date: from.Add(30 * time.Hour), Type: "deposit", Amount: 50, Balance: 50},
+				{TransactionID: 8, Date: from.Add(54 * time.Hour), Type: "withdrawal", Amount: 20, Balance: 30},
+			},
+		}
+		s.Summarize()
+		var buf bytes.Buffer
+		require.NoError(t, export.Camt053(&buf, s, from.AddDate(0, 1, 1)))
+
+		st, err := reconciliation.Parse(&buf, reconciliation.FormatCamt053)
+
+		require.NoError(t, err)
+		require.Len(t, st.Lines, 2)
+		assert.Equal(t, "7", st.Lines[0].Reference)
+		assert.Equal(t, 50.0, st.Lines[0].Amount)
+		assert.Equal(t, date("2026-03-02"), st.Lines[0].BookingDate)
+		assert.Equal(t, -20.0, st.Lines[1].Amount)
+		assert.Equal(t, date("2026-03-01"), st.PeriodStart)
+		assert.Equal(t, date("2026-03-31"), st.PeriodEnd)
+	})
+
+	t.Run("prefers the end-to-end ID and skips pending entries", func(t *testing.T) {
+		file := `<?xml version="1.0"?>
+<Document xmlns="urn:iso:std:iso:20022:tech:xsd:camt.053.001.02">
+  <BkToCstmrStmt><Stmt>
+    <Ntry>
+      <Amt Ccy="EUR">12.00</Amt><CdtDbtInd>CRDT</CdtDbtInd><Sts>BOOK</Sts>
+      <BookgDt><DtTm>2026-03-05T10:00:00</DtTm></BookgDt>
+      <AcctSvcrRef>BANK-1</AcctSvcrRef>
+      <NtryDtls><TxDtls><Refs><EndToEndId>42</EndToEndId></Refs></TxDtls></NtryDtls>
+    </Ntry>
+    <Ntry>
+      <Amt Ccy="EUR">3.00</Amt><CdtDbtInd>DBIT</CdtDbtInd><Sts>PDNG</Sts>
+      <BookgDt><Dt>2026-03-05</Dt></BookgDt>
+    </Ntry>
+  </Stmt></BkToCstmrStmt>
+</Document>`
+
+		st, err := reconciliation.Parse(strings.NewReader(file), reconciliation.FormatCamt053)
+
+		require.NoError(t, err)
+		require.Len(t, st.Lines, 1)
+		assert.Equal(t, "42", st.Lines[0].Reference)
+		assert.Equal(t, date("2026-03-05"), st.Lines[0].ValueDate)
+	})
+}
@@ -0,0 +1,108 @@
+package reconciliation_test
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/Andrew44Ashraf/fintech-service/internal/reconciliation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func line(id int, ref string, amount float64, booked string) reconciliation.LineRef {
+	return reconciliation.LineRef{ID: id, Line: reconciliation.BankLine{Reference: ref, Amount: amount, BookingDate: date(booked)}}
+}
+
+func candidate(id int, amount float64, booked string) reconciliation.Candidate {
+	return reconciliation.Candidate{TransactionID: id, Amount: amount, Date: date(booked).Add(15 * time.Hour), Reference: strconv.Itoa(id)}
+}
+
+func TestMatch(t *testing.T) {
+	t.Run("reference first, then amount and date", func(t *testing.T) {
+		lines := []reconciliation.LineRef{
+			line(1, "101", 50, "2026-03-04"), // reference, booked three days late
+			line(2, "", -20, "2026-03-02"),   // no reference
+			line(3, "", 75, "2026-03-02"),    // nothing of ours
+		}
+		candidates := []reconciliation.Candidate{
+			candidate(101, 50, "2026-03-01"),
+			candidate(102, -20, "2026-03-01"),
+			candidate(103, 50, "2026-03-04"), // same amount as line 1 but a different reference
+		}
+
+		pairs := reconciliation.Match(lines, candidates, reconciliation.DefaultRules)
+
+		assert.Equal(t, []reconciliation.Pair{
+			{LineID: 1, TransactionID: 101, Rule: "reference"},
+			{LineID: 2, TransactionID: 102, Rule: "amount_and_date"},
+		}, pairs)
+	})
+
+	t.Run("each transaction matches once, closest date first", func(t *testing.T) {
+		lines := []reconciliation.LineRef{line(1, "", 10, "2026-03-02"), line(2, "", 10, "2026-03-02")}
+		candidates := []reconciliation.Candidate{
+			candidate(201, 10, "2026-03-01"),
+			candidate(202, 10, "2026-03-02"),
+			candidate(203, 10, "2026-03-03"),
+		}
+
+		pairs := reconciliation.Match(lines, candidates, reconciliation.DefaultRules)
+
+		assert.Equal(t, []reconciliation.Pair{
+			{LineID: 1, TransactionID: 202, Rule: "amount_and_date"},
+			{LineID: 2, TransactionID: 201, Rule: "amount_and_date"},
+		}, pairs)
+	})
+
+	t.Run("tolerance and window", func(t *testing.T) {
+		rules := []reconciliation.MatchRule{{Name: "loose", DateWindowDays: 2, AmountTolerance: 0.05}}
+
+		assert.Len(t, reconciliation.Match(
+			[]reconciliation.LineRef{line(1, "", 10.04, "2026-03-03")},
+			[]reconciliation.Candidate{candidate(1, 10, "2026-03-01")}, rules), 1)
+		assert.Empty(t, reconciliation.Match(
+			[]reconciliation.LineRef{line(1, "", 10.06, "2026-03-03")},
+			[]reconciliation.Candidate{candidate(1, 10, "2026-03-01")}, rules))
+		assert.Empty(t, reconciliation.Match(
+			[]reconciliation.LineRef{line(1, "", 10, "2026-03-04")},
+			[]reconciliation.Candidate{candidate(1, 10, "2026-03-01")}, rules))
+	})
+}
+
+func TestLoadRules(t *testing.T) {
+	dir := t.TempDir()
+
+	t.Run("valid file", func(t *testing.T) {
+		path := filepath.Join(dir, "rules.yaml")
+		require.NoError(t, os.WriteFile(path, []byte(`
+rules:
+  - name: reference
+    match_reference: true
+    date_window_days: 3
+  - name: amount
+    date_window_days: 0
+    amount_tolerance: 0.01
+`), 0o600))
+
+		rules, err := reconciliation.LoadRules(path)
+
+		require.NoError(t, err)
+		assert.Equal(t, []reconciliation.MatchRule{
+			{Name: "reference", MatchReference: true, DateWindowDays: 3},
+			{Name: "amount", AmountTolerance: 0.01},
+		}, rules)
+		assert.Equal(t, 3, reconciliation.MaxWindow(rules))
+	})
+
+	t.Run("reserved name", func(t *testing.T) {
+		path := filepath.Join(dir, "bad.yaml")
+		require.NoError(t, os.WriteFile(path, []byte("rules:\n  - name: manual\n"), 0o600))
+
+		_, err := reconciliation.LoadRules(path)
+
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,43 @@
+package reconciliation_test
+
+import (
+	"testing"
+
+	"github.com/Andrew44Ashraf/fintech-service/internal/reconciliation"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheck(t *testing.T) {
+	final := func(v float64) *float64 { return &v }
+
+	t.Run("clean account", func(t *testing.T) {
+		assert.Empty(t, reconciliation.Check(reconciliation.Account{ID: 1, Ledger: 100, Journal: 100, LastFinalBalance: final(100)}))
+	})
+
+	t.Run("account without transactions is only checked against its journal", func(t *testing.T) {
+		assert.Empty(t, reconciliation.Check(reconciliation.Account{ID: 1, Ledger: 50, Journal: 50}))
+	})
+
+	t.Run("sub-cent noise is not a break", func(t *testing.T) {
+		assert.Empty(t, reconciliation.Check(reconciliation.Account{ID: 1, Ledger: 0.1 + 0.2, Journal: 0.3, LastFinalBalance: final(0.3)}))
+	})
+
+	t.Run("balance edited outside the repository", func(t *testing.T) {
+		breaks := reconciliation.Check(reconciliation.Account{ID: 7, Ledger: 150, Journal: 100, LastFinalBalance: final(100)})
+
+		assert.Equal(t, []reconciliation.Break{
+			{AccountID: 7, Kind: reconciliation.JournalMismatch, Ledger: 150, Expected: 100},
+			{AccountID: 7, Kind: reconciliation.FinalBalanceMismatch, Ledger: 150, Expected: 100},
+		}, breaks)
+		assert.Equal(t, 50.0, breaks[0].Difference())
+	})
+
+	t.Run("transaction deleted", func(t *testing.T) {
+		breaks := reconciliation.Check(reconciliation.Account{ID: 7, Ledger: 80, Journal: 100, LastFinalBalance: final(80)})
+
+		assert.Equal(t, []reconciliation.Break{
+			{AccountID: 7, Kind: reconciliation.JournalMismatch, Ledger: 80, Expected: 100},
+		}, breaks)
+		assert.Equal(t, -20.0, breaks[0].Difference())
+	})
+}
@@ -0,0 +1,103 @@
+package recurrence_test
+
+import (
+	"testing"
+	"time"
+
+	apperrors "github.com/Andrew44Ashraf/fintech-service/internal/errors"
+	"github.com/Andrew44Ashraf/fintech-service/internal/recurrence"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func date(y int, m time.Month, d, h, min int) time.Time {
+	return time.Date(y, m, d, h, min, 0, 0, time.UTC)
+}
+
+func TestCronNext(t *testing.T) {
+	tests := []struct {
+		expr  string
+		after time.Time
+		want  time.Time
+	}{
+		{"0 9 1 * *", date(2026, 3, 15, 12, 0), date(2026, 4, 1, 9, 0)},
+		{"0 9 1 * *", date(2026, 4, 1, 9, 0), date(2026, 5, 1, 9, 0)},
+		{"*/15 * * * *", date(2026, 3, 15, 12, 7), date(2026, 3, 15, 12, 15)},
+		{"30 8 * * 1-5", date(2026, 3, 13, 9, 0), date(2026, 3, 16, 8, 30)}, // Friday -> Monday
+		{"0 0 * * 7", date(2026, 3, 16, 0, 0), date(2026, 3, 22, 0, 0)},     // 7 is Sunday
+		{"0 0 13 * 5", date(2026, 3, 1, 0, 0), date(2026, 3, 6, 0, 0)},      // day of month OR Friday
+		{"0 12 29 2 *", date(2026, 3, 1, 0, 0), date(2028, 2, 29, 12, 0)},
+		{"@monthly", date(2026, 12, 31, 23, 59), date(2027, 1, 1, 0, 0)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			c, err := recurrence.ParseCron(tt.expr)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, c.Next(tt.after))
+		})
+	}
+}
+
+func TestCronNextImpossible(t *testing.T) {
+	c, err := recurrence.ParseCron("0 0 30 2 *")
+	require.NoError(t, err)
+	assert.True(t, c.Next(date(2026, 1, 1, 0, 0)).IsZero())
+}
+
+func TestParseCronErrors(t *testing.T) {
+	for _, expr := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "* * * 13 *", "*/0 * * * *", "5-1 * * * *", "a * * * *"} {
+		_, err := recurrence.ParseCron(expr)
+		assert.Error(t, err, expr)
+	}
+}
+
+func TestIntervalNext(t *testing.T) {
+	tests := []struct {
+		name  string
+		rule  recurrence.Rule
+		start time.Time
+		after time.Time
+		want  time.Time
+	}{
+		{"before start", recurrence.Rule{Every: 1, Unit: recurrence.UnitMonth}, date(2026, 4, 1, 6, 0), date(2026, 3, 1, 0, 0), date(2026, 4, 1, 6, 0)},
+		{"monthly on the 1st", recurrence.Rule{Every: 1, Unit: recurrence.UnitMonth}, date(2026, 1, 1, 6, 0), date(2026, 3, 1, 6, 0), date(2026, 4, 1, 6, 0)},
+		{"month end clamps", recurrence.Rule{Every: 1, Unit: recurrence.UnitMonth}, date(2026, 1, 31, 6, 0), date(2026, 1, 31, 6, 0), date(2026, 2, 28, 6, 0)},
+		{"clamping doesn't drift", recurrence.Rule{Every: 1, Unit: recurrence.UnitMonth}, date(2026, 1, 31, 6, 0), date(2026, 2, 28, 6, 0), date(2026, 3, 31, 6, 0)},
+		{"quarterly", recurrence.Rule{Every: 3, Unit: recurrence.UnitMonth}, date(2026, 1, 15, 0, 0), date(2026, 2, 1, 0, 0), date(2026, 4, 15, 0, 0)},
+		{"fortnightly", recurrence.Rule{Every: 2, Unit: recurrence.UnitWeek}, date(2026, 3, 2, 0, 0), date(2026, 3, 20, 0, 0), date(2026, 3, 30, 0, 0)},
+		{"daily", recurrence.Rule{Every: 1, Unit: recurrence.UnitDay}, date(2026, 3, 1, 8, 0), date(2026, 3, 10, 8, 0), date(2026, 3, 11, 8, 0)},
+		{"one-off pending", recurrence.Rule{}, date(2026, 3, 1, 8, 0), date(2026, 2, 1, 0, 0), date(2026, 3, 1, 8, 0)},
+		{"one-off done", recurrence.Rule{}, date(2026, 3, 1, 8, 0), date(2026, 3, 1, 8, 0), time.Time{}},
+		{"cron from start", recurrence.Rule{Cron: "0 9 1 * *"}, date(2026, 5, 1, 9, 0), date(2026, 3, 1, 0, 0), date(2026, 5, 1, 9, 0)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.rule.Next(tt.start, tt.after)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestRuleValidate(t *testing.T) {
+	valid := []recurrence.Rule{
+		{},
+		{Cron: "0 9 1 * *"},
+		{Every: 2, Unit: recurrence.UnitWeek},
+	}
+	for _, r := range valid {
+		assert.NoError(t, r.Validate(), "%+v", r)
+	}
+
+	invalid := map[string]recurrence.Rule{
+		"cron":  {Cron: "0 9 1 *"},
+		"every": {Unit: recurrence.UnitDay},
+		"unit":  {Every: 1, Unit: "year"},
+	}
+	for field, r := range invalid {
+		var verr *apperrors.ValidationError
+		require.ErrorAs(t, r.Validate(), &verr, "%+v", r)
+		assert.Equal(t, field, verr.Fields[0].Field)
+	}
+	assert.Error(t, recurrence.Rule{Cron: "@daily", Every: 1, Unit: recurrence.UnitDay}.Validate())
+}
@@ -0,0 +1,174 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Andrew44Ashraf/fintech-service/internal/repository"
+	"github.com/Andrew44Ashraf/fintech-service/internal/tests/testutils"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChangeAccountStatus(t *testing.T) {
+	ctx := context.Background()
+	expectAccount := func(mock sqlmock.Sqlmock, status string, balance float64) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT status, balance, version FROM accounts WHERE id = \$1 FOR UPDATE`).WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"status", "balance", "version"}).AddRow(status, balance, 7))
+	}
+
+	t.Run("freeze records the change", func(t *testing.T) {
+		repo, mock := testutils.NewMockRepository()
+		expectAccount(mock, "active", 50)
+		mock.ExpectQuery(`UPDATE accounts SET status = \$1.*RETURNING version`).WithArgs("frozen", 1).
+			WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(8))
+		mock.ExpectQuery(`INSERT INTO account_status_changes`).
+			WithArgs(1, "active", "frozen", "ops", "card stolen").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "changed_at"}).AddRow(4, time.Now()))
+		mock.ExpectCommit()
+
+		change, err := repo.ChangeAccountStatus(ctx, 1, 0, repository.AccountFrozen, "ops", "card stolen", false)
+
+		require.NoError(t, err)
+		assert.Equal(t, "active", change.From)
+		assert.Equal(t, "frozen", change.To)
+		assert.Equal(t, int64(8), change.Version)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("dry run rolls back", func(t *testing.T) {
+		repo, mock := testutils.NewMockRepository()
+		expectAccount(mock, "frozen", 0)
+		mock.ExpectQuery(`UPDATE accounts SET status = \$1`).
+			WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(8))
+		mock.ExpectQuery(`INSERT INTO account_status_changes`).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "changed_at"}).AddRow(5, time.Now()))
+		mock.ExpectRollback()
+
+		_, err := repo.ChangeAccountStatus(ctx, 1, 0, repository.AccountClosed, "ops", "customer request", true)
+
+		require.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("matching version", func(t *testing.T) {
+		repo, mock := testutils.NewMockRepository()
+		expectAccount(mock, "active", 0)
+		mock.ExpectQuery(`UPDATE accounts SET status = \$1`).
+			WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(8))
+		mock.ExpectQuery(`INSERT INTO account_status_changes`).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "changed_at"}).AddRow(6, time.Now()))
+		mock.ExpectCommit()
+
+		_, err := repo.ChangeAccountStatus(ctx, 1, 7, repository.AccountFrozen, "ops", "review", false)
+
+		require.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("stale version", func(t *testing.T) {
+		repo, mock := testutils.NewMockRepository()
+		expectAccount(mock, "active", 0)
+		mock.ExpectRollback()
+
+		_, err := repo.ChangeAccountStatus(ctx, 1, 6, repository.AccountFrozen, "ops", "review", false)
+
+		assert.ErrorIs(t, err, repository.ErrVersionMismatch)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("only empty accounts close", func(t *testing.T) {
+		repo, mock := testutils.NewMockRepository()
+		expectAccount(mock, "active", 0.01)
+		mock.ExpectRollback()
+
+		_, err := repo.ChangeAccountStatus(ctx, 1, 0, repository.AccountClosed, "ops", "customer request", false)
+
+		assert.ErrorIs(t, err, repository.ErrAccountHasBalance)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("closed is final", func(t *testing.T) {
+		repo, mock := testutils.NewMockRepository()
+		expectAccount(mock, "closed", 0)
+		mock.ExpectRollback()
+
+		_, err := repo.ChangeAccountStatus(ctx, 1, 0, repository.AccountActive, "ops", "reopen", false)
+
+		assert.ErrorIs(t, err, repository.ErrAccountClosed)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("unfreezing an active account", func(t *testing.T) {
+		repo, mock := testutils.NewMockRepository()
+		expectAccount(mock, "active", 0)
+		mock.ExpectRollback()
+
+		_, err := repo.ChangeAccountStatus(ctx, 1, 0, repository.AccountActive, "ops", "cleared", false)
+
+		assert.ErrorIs(t, err, repository.ErrInvalidAccountState)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("reason is mandatory", func(t *testing.T) {
+		repo, mock := testutils.NewMockRepository()
+
+		_, err := repo.ChangeAccountStatus(ctx, 1, 0, repository.AccountFrozen, "ops", "", false)
+
+		assert.Error(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestSetAccountLimits(t *testing.T) {
+	ctx := context.Background()
+	single := 250.0
+
+	t.Run("saves the limits and returns the new version", func(t *testing.T) {
+		repo, mock := testutils.NewMockRepository()
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT status, version FROM accounts WHERE id = \$1 FOR UPDATE`).WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"status", "version"}).AddRow("active", 3))
+		mock.ExpectExec(`INSERT INTO account_limits .* ON CONFLICT \(account_id\) DO UPDATE`).
+			WithArgs(1, &single, nil, nil, nil).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(`SELECT version FROM accounts`).
+			WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(4))
+		mock.ExpectCommit()
+
+		version, err := repo.SetAccountLimits(ctx, 1, 3, repository.AccountLimits{MaxSingle: &single})
+
+		require.NoError(t, err)
+		assert.Equal(t, int64(4), version)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("stale version", func(t *testing.T) {
+		repo, mock := testutils.NewMockRepository()
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT status, version FROM accounts`).
+			WillReturnRows(sqlmock.NewRows([]string{"status", "version"}).AddRow("active", 4))
+		mock.ExpectRollback()
+
+		_, err := repo.SetAccountLimits(ctx, 1, 3, repository.AccountLimits{MaxSingle: &single})
+
+		assert.ErrorIs(t, err, repository.ErrVersionMismatch)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestPostingToFrozenAccount(t *testing.T) {
+	repo, mock := testutils.NewMockTransactionRepository()
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT status FROM accounts`).
+		WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow("frozen"))
+	mock.ExpectRollback()
+
+	_, err := repo.CreateDeposit(context.Background(), 1, 10, repository.Details{})
+
+	assert.ErrorIs(t, err, repository.ErrAccountFrozen)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -1,11 +1,16 @@
 package repository_test
 
 import (
+	"context"
+	"database/sql"
 	"testing"
 	"time"
+
+	apperrors "github.com/Andrew44Ashraf/fintech-service/internal/errors"
 	"github.com/Andrew44Ashraf/fintech-service/internal/repository"
-	"github.com/Andrew44Ashraf/fintech-service/internal/testutils"
+	"github.com/Andrew44Ashraf/fintech-service/internal/tests/testutils"
 	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -14,21 +19,100 @@ func TestCreateAccount(t *testing.T) {
 
 	t.Run("successful account creation", func(t *testing.T) {
 		mock.ExpectBegin()
-		mock.ExpectQuery(`INSERT INTO accounts`).
-			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+		mock.ExpectQuery(`FROM account_products WHERE code`).
+			WithArgs("checking").
+			WillReturnRows(productRows().AddRow("checking", "Checking", pq.StringArray{"deposit", "withdrawal", "transfer_in", "transfer_out"}, 0.0, 250.0, 0.0, 0.0, 0.0, 0, "USD"))
+		now := time.Now()
+		mock.ExpectQuery(`INSERT INTO accounts .* RETURNING id, public_id, account_number`).
+			WithArgs(100.0, "checking", "USD", 250.0, "", "", nil).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "public_id", "account_number", "version", "created_at", "updated_at"}).
+				AddRow(1, "acc_00000000000000000000000000000001", "FT430000000000000001", 1, now, now))
 		mock.ExpectCommit()
 
-		id, err := repo.CreateAccount(context.Background(), 100.0)
-		
+		account, err := repo.CreateAccount(context.Background(), "", 100.0, repository.Details{})
+
 		assert.NoError(t, err)
-		assert.Equal(t, 1, id)
+		if assert.NotNil(t, account) {
+			assert.Equal(t, 1, account.ID)
+			assert.Equal(t, "acc_00000000000000000000000000000001", account.PublicID)
+			assert.Equal(t, "FT430000000000000001", account.Number)
+			assert.Equal(t, 100.0, account.Balance)
+		}
 		assert.NoError(t, mock.ExpectationsWereMet())
 	})
 
 	t.Run("negative balance should fail", func(t *testing.T) {
-		_, err := repo.CreateAccount(context.Background(), -50.0)
+		_, err := repo.CreateAccount(context.Background(), "checking", -50.0, repository.Details{})
 		assert.ErrorIs(t, err, repository.ErrNegativeBalance)
 	})
+
+	t.Run("unknown product is rejected", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(`FROM account_products WHERE code`).
+			WithArgs("gold").
+			WillReturnError(sql.ErrNoRows)
+		mock.ExpectRollback()
+
+		_, err := repo.CreateAccount(context.Background(), "gold", 0, repository.Details{})
+
+		var verr *apperrors.ValidationError
+		assert.ErrorAs(t, err, &verr)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("initial balance below product minimum is rejected", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(`FROM account_products WHERE code`).
+			WithArgs("savings").
+			WillReturnRows(productRows().AddRow("savings", "Savings", pq.StringArray{"deposit", "withdrawal"}, 25.0, 0.0, 0.0, 0.0, 0.0, 6, "USD"))
+		mock.ExpectRollback()
+
+		_, err := repo.CreateAccount(context.Background(), "savings", 10, repository.Details{})
+
+		var verr *apperrors.ValidationError
+		assert.ErrorAs(t, err, &verr)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("check opens the account and rolls it back", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(`FROM account_products WHERE code`).
+			WillReturnRows(productRows().AddRow("checking", "Checking", pq.StringArray{"deposit"}, 0.0, 0.0, 0.0, 0.0, 0.0, 0, "USD"))
+		now := time.Now()
+		mock.ExpectQuery(`INSERT INTO accounts`).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "public_id", "account_number", "version", "created_at", "updated_at"}).
+				AddRow(1, "acc_00000000000000000000000000000001", "FT430000000000000001", 1, now, now))
+		mock.ExpectRollback()
+
+		account, err := repo.CheckCreateAccount(context.Background(), "checking", 0, repository.Details{})
+
+		assert.NoError(t, err)
+		if assert.NotNil(t, account) {
+			assert.Zero(t, account.ID)
+			assert.Empty(t, account.Number)
+			assert.Equal(t, "checking", account.ProductCode)
+		}
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("check fails on a used external_ref", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(`FROM account_products WHERE code`).
+			WillReturnRows(productRows().AddRow("checking", "Checking", pq.StringArray{"deposit"}, 0.0, 0.0, 0.0, 0.0, 0.0, 0, "USD"))
+		mock.ExpectQuery(`INSERT INTO accounts`).
+			WillReturnError(&pq.Error{Code: "23505", Constraint: "idx_accounts_external_ref"})
+		mock.ExpectRollback()
+
+		_, err := repo.CheckCreateAccount(context.Background(), "checking", 0, repository.Details{ExternalRef: "crm-42"})
+
+		assert.ErrorIs(t, err, repository.ErrDuplicateExternalRef)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func productRows() *sqlmock.Rows {
+	return sqlmock.NewRows([]string{"code", "name", "allowed", "min_balance", "overdraft_limit",
+		"max_single", "max_daily", "max_monthly", "max_count", "currency"})
 }
 
 func TestGetAccountBalance(t *testing.T) {
@@ -0,0 +1,180 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Andrew44Ashraf/fintech-service/internal/models"
+	"github.com/Andrew44Ashraf/fintech-service/internal/repository"
+	"github.com/Andrew44Ashraf/fintech-service/internal/tests/testutils"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func expectAdjustable(mock sqlmock.Sqlmock, balance float64, status string, overdraft float64) {
+	mock.ExpectQuery(`SELECT balance, status, overdraft_limit FROM accounts WHERE id = \$1 FOR UPDATE`).WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"balance", "status", "overdraft_limit"}).AddRow(balance, status, overdraft))
+}
+
+func expectAdjustmentPosted(mock sqlmock.Sqlmock, signed, finalBalance float64, txType string, reversed int) {
+	mock.ExpectQuery(`UPDATE accounts SET balance = balance \+ \$1`).WithArgs(signed, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"balance"}).AddRow(finalBalance))
+	mock.ExpectQuery(`INSERT INTO transactions`).WithArgs(1, abs(signed), txType, finalBalance, reversed).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(90, time.Now()))
+	mock.ExpectExec(`INSERT INTO adjustments`).WithArgs(90, 1, reversed, "ops", "fix").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+}
+
+func abs(v float64) float64 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+func TestPostAdjustment(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("debit", func(t *testing.T) {
+		repo, mock := testutils.NewMockTransactionRepository()
+		mock.ExpectBegin()
+		expectAdjustable(mock, 100, "frozen", 0)
+		expectAdjustmentPosted(mock, -12.5, 87.5, "adjustment_debit", 0)
+		mock.ExpectCommit()
+
+		adj, err := repo.PostAdjustment(ctx, 1, -12.5, "ops", "fix", false)
+
+		require.NoError(t, err)
+		assert.Equal(t, models.AdjustmentDebit, adj.Type)
+		assert.Equal(t, 12.5, adj.Amount)
+		assert.Equal(t, -12.5, adj.Signed())
+		assert.Equal(t, 87.5, adj.FinalBalance)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("dry run rolls back", func(t *testing.T) {
+		repo, mock := testutils.NewMockTransactionRepository()
+		mock.ExpectBegin()
+		expectAdjustable(mock, 0, "active", 0)
+		expectAdjustmentPosted(mock, 5, 5, "adjustment_credit", 0)
+		mock.ExpectRollback()
+
+		adj, err := repo.PostAdjustment(ctx, 1, 5, "ops", "fix", true)
+
+		require.NoError(t, err)
+		assert.True(t, adj.DryRun)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("debit beyond the overdraft", func(t *testing.T) {
+		repo, mock := testutils.NewMockTransactionRepository()
+		mock.ExpectBegin()
+		expectAdjustable(mock, 10, "active", 50)
+		mock.ExpectRollback()
+
+		_, err := repo.PostAdjustment(ctx, 1, -60.01, "ops", "fix", false)
+
+		assert.ErrorIs(t, err, repository.ErrInsufficientFunds)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("closed account", func(t *testing.T) {
+		repo, mock := testutils.NewMockTransactionRepository()
+		mock.ExpectBegin()
+		expectAdjustable(mock, 0, "closed", 0)
+		mock.ExpectRollback()
+
+		_, err := repo.PostAdjustment(ctx, 1, 5, "ops", "fix", false)
+
+		assert.ErrorIs(t, err, repository.ErrAccountClosed)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("reason is mandatory", func(t *testing.T) {
+		repo, mock := testutils.NewMockTransactionRepository()
+
+		_, err := repo.PostAdjustment(ctx, 1, 5, "ops", "", false)
+
+		assert.Error(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestReverseTransaction(t *testing.T) {
+	ctx := context.Background()
+	expectOriginal := func(mock sqlmock.Sqlmock, txType, status string) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT account_id, amount, type, status FROM transactions WHERE id = \$1 FOR UPDATE`).WithArgs(40).
+			WillReturnRows(sqlmock.NewRows([]string{"account_id", "amount", "type", "status"}).AddRow(1, 30.0, txType, status))
+	}
+	expectReversed := func(mock sqlmock.Sqlmock, reversed bool) {
+		mock.ExpectQuery(`SELECT EXISTS`).WithArgs(40).
+			WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(reversed))
+	}
+
+	t.Run("a deposit is debited back", func(t *testing.T) {
+		repo, mock := testutils.NewMockTransactionRepository()
+		expectOriginal(mock, "deposit", "posted")
+		expectReversed(mock, false)
+		expectAdjustable(mock, 100, "active", 0)
+		expectAdjustmentPosted(mock, -30, 70, "adjustment_debit", 40)
+		mock.ExpectCommit()
+
+		adj, err := repo.ReverseTransaction(ctx, 40, "ops", "fix", false)
+
+		require.NoError(t, err)
+		assert.Equal(t, 40, adj.ReversedTransactionID)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("a withdrawal is credited back", func(t *testing.T) {
+		repo, mock := testutils.NewMockTransactionRepository()
+		expectOriginal(mock, "withdrawal", "posted")
+		expectReversed(mock, false)
+		expectAdjustable(mock, 100, "active", 0)
+		expectAdjustmentPosted(mock, 30, 130, "adjustment_credit", 40)
+		mock.ExpectCommit()
+
+		adj, err := repo.ReverseTransaction(ctx, 40, "ops", "fix", false)
+
+		require.NoError(t, err)
+		assert.Equal(t, models.AdjustmentCredit, adj.Type)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("once only", func(t *testing.T) {
+		repo, mock := testutils.NewMockTransactionRepository()
+		expectOriginal(mock, "deposit", "posted")
+		expectReversed(mock, true)
+		mock.ExpectRollback()
+
+		_, err := repo.ReverseTransaction(ctx, 40, "ops", "fix", false)
+
+		assert.ErrorIs(t, err, repository.ErrAlreadyReversed)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("fees have their own reversal", func(t *testing.T) {
+		repo, mock := testutils.NewMockTransactionRepository()
+		expectOriginal(mock, "fee", "posted")
+		mock.ExpectRollback()
+
+		_, err := repo.ReverseTransaction(ctx, 40, "ops", "fix", false)
+
+		assert.ErrorIs(t, err, repository.ErrInvalidTransaction)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("held transactions", func(t *testing.T) {
+		repo, mock := testutils.NewMockTransactionRepository()
+		expectOriginal(mock, "deposit", "pending_review")
+		mock.ExpectRollback()
+
+		_, err := repo.ReverseTransaction(ctx, 40, "ops", "fix", false)
+
+		assert.ErrorIs(t, err, repository.ErrInvalidTransactionState)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
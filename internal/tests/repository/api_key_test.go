@@ -0,0 +1,109 @@
+package repository_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"database/sql/driver"
+	"strings"
+	"testing"
+	"time"
+
+	apperrors "github.com/Andrew44Ashraf/fintech-service/internal/errors"
+	"github.com/Andrew44Ashraf/fintech-service/internal/logging"
+	"github.com/Andrew44Ashraf/fintech-service/internal/repository"
+	"github.com/Andrew44Ashraf/fintech-service/internal/tenant"
+	"github.com/Andrew44Ashraf/fintech-service/internal/tests/testutils"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPIKeys(t *testing.T) {
+	ctx := tenant.WithID(context.Background(), "acme")
+	newRepo := func() (*repository.APIKeyRepository, sqlmock.Sqlmock) {
+		db, mock := testutils.NewMockDB()
+		return repository.NewAPIKeyRepository(db, logging.Discard()), mock
+	}
+
+	t.Run("only the hash is stored", func(t *testing.T) {
+		repo, mock := newRepo()
+		var stored []byte
+		mock.ExpectQuery(`INSERT INTO api_keys`).
+			WithArgs("payouts", sqlmock.AnyArg(), hashArg{&stored}, "ops", "acme").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(3, time.Now()))
+
+		key, secret, err := repo.CreateKey(ctx, "payouts", "ops")
+
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(secret, key.Prefix))
+		assert.Equal(t, "acme", key.TenantID)
+		sum := sha256.Sum256([]byte(secret))
+		assert.Equal(t, sum[:], stored)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("keys need a tenant", func(t *testing.T) {
+		repo, mock := newRepo()
+
+		_, _, err := repo.CreateKey(context.Background(), "payouts", "ops")
+
+		var verr *apperrors.ValidationError
+		assert.ErrorAs(t, err, &verr)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("a key gives its tenant", func(t *testing.T) {
+		repo, mock := newRepo()
+		mock.ExpectQuery(`SELECT tenant_id FROM api_keys WHERE key_hash = \$1`).
+			WillReturnRows(sqlmock.NewRows([]string{"tenant_id"}).AddRow("acme"))
+
+		id, err := repo.CheckKey(context.Background(), "fk_good")
+
+		assert.NoError(t, err)
+		assert.Equal(t, "acme", id)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("unknown or revoked keys are refused", func(t *testing.T) {
+		repo, mock := newRepo()
+		mock.ExpectQuery(`FROM api_keys WHERE key_hash = \$1 AND revoked_at IS NULL`).WillReturnError(sql.ErrNoRows)
+
+		_, err := repo.CheckKey(ctx, "fk_nope")
+
+		assert.ErrorIs(t, err, apperrors.ErrForbidden)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("listing is scoped to the tenant", func(t *testing.T) {
+		repo, mock := newRepo()
+		mock.ExpectQuery(`FROM api_keys\s+WHERE tenant_id = \$1`).WithArgs("acme").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "tenant_id", "name", "prefix", "created_by", "created_at", "revoked_by", "revoked_at"}))
+
+		keys, err := repo.ListKeys(ctx)
+
+		assert.NoError(t, err)
+		assert.Empty(t, keys)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("revoke twice", func(t *testing.T) {
+		repo, mock := newRepo()
+		mock.ExpectExec(`UPDATE api_keys SET revoked_by`).WithArgs("ops", 3, "acme").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		err := repo.RevokeKey(ctx, 3, "ops")
+
+		assert.ErrorIs(t, err, repository.ErrAPIKeyNotFound)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+// hashArg captures the stored key hash.
+type hashArg struct{ got *[]byte }
+
+func (a hashArg) Match(v driver.Value) bool {
+	b, ok := v.([]byte)
+	*a.got = b
+	return ok && len(b) == sha256.Size
+}
@@ -0,0 +1,229 @@
+package repository_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/Andrew44Ashraf/fintech-service/internal/cache"
+	"github.com/Andrew44Ashraf/fintech-service/internal/database"
+	"github.com/Andrew44Ashraf/fintech-service/internal/logging"
+	"github.com/Andrew44Ashraf/fintech-service/internal/repository"
+	"github.com/Andrew44Ashraf/fintech-service/internal/tenant"
+	"github.com/Andrew44Ashraf/fintech-service/internal/tests/testutils"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newCachedRepos returns repositories sharing one database and balance
+// cache.
+func newCachedRepos() (*repository.AccountRepository, *repository.TransactionRepository, *cache.LRU, sqlmock.Sqlmock) {
+	db, mock := testutils.NewMockDB()
+	balances := cache.NewLRU(100)
+	accounts := repository.NewAccountRepository(db, logging.Discard(), repository.WithAccountCache(balances))
+	transactions := repository.NewTransactionRepository(db, logging.Discard(), repository.WithTransactionCache(balances))
+	return accounts, transactions, balances, mock
+}
+
+// primaryReads sends reads made WithPrimary to primary and the rest to
+// replica, as database.Router does while the replica is within its bound.
+type primaryReads struct{ primary, replica *sql.DB }
+
+func (r primaryReads) Reader(ctx context.Context) *sql.DB {
+	if database.Consistent(ctx) {
+		return r.primary
+	}
+	return r.replica
+}
+
+func expectBalanceVersion(mock sqlmock.Sqlmock, balance float64, version int64) {
+	mock.ExpectQuery(`SELECT balance, version FROM accounts WHERE id = \$1`).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"balance", "version"}).AddRow(balance, version))
+}
+
+func expectNewBalances(mock sqlmock.Sqlmock, balance float64, version int64) {
+	mock.ExpectQuery(`SELECT id, balance, version FROM accounts WHERE id = ANY\(\$1\)`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "balance", "version"}).AddRow(1, balance, version))
+}
+
+func TestBalanceCache(t *testing.T) {
+	ctx := tenant.WithID(context.Background(), tenant.Default)
+
+	t.Run("balances are read once", func(t *testing.T) {
+		accounts, _, _, mock := newCachedRepos()
+		expectBalanceVersion(mock, 100, 1)
+
+		for range 3 {
+			balance, err := accounts.GetAccountBalance(ctx, 1)
+			require.NoError(t, err)
+			assert.Equal(t, 100.0, balance)
+		}
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("no stale balance after a deposit", func(t *testing.T) {
+		accounts, transactions, _, mock := newCachedRepos()
+		expectBalanceVersion(mock, 100, 1)
+		_, err := accounts.GetAccountBalance(ctx, 1)
+		require.NoError(t, err)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT status FROM accounts`).WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow("active"))
+		testutils.ExpectAccountRules(mock)
+		mock.ExpectQuery(`INSERT INTO transactions`).WithArgs(1, 50.0, "", "", nil).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(10))
+		mock.ExpectExec(`UPDATE accounts SET balance = balance \+ \$1`).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(`SELECT balance FROM accounts`).
+			WillReturnRows(sqlmock.NewRows([]string{"balance"}).AddRow(150.0))
+		mock.ExpectExec(`UPDATE transactions SET final_balance`).WillReturnResult(sqlmock.NewResult(0, 1))
+		expectNewBalances(mock, 150, 2)
+		mock.ExpectCommit()
+
+		_, err = transactions.CreateDeposit(ctx, 1, 50, repository.Details{})
+		require.NoError(t, err)
+
+		balance, err := accounts.GetAccountBalance(ctx, 1)
+		require.NoError(t, err)
+		assert.Equal(t, 150.0, balance)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("no stale balance after a withdrawal", func(t *testing.T) {
+		accounts, transactions, _, mock := newCachedRepos()
+		expectBalanceVersion(mock, 100, 1)
+		_, err := accounts.GetAccountBalance(ctx, 1)
+		require.NoError(t, err)
+
+		expectLockedAccount(mock, 100)
+		mock.ExpectQuery(`FROM account_limits`).WillReturnError(sql.ErrNoRows)
+		mock.ExpectQuery(`JOIN fee_schedules`).WithArgs(1).WillReturnError(sql.ErrNoRows)
+		mock.ExpectQuery(`INSERT INTO transactions`).WithArgs(1, 30.0, "", "", nil).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(10))
+		mock.ExpectExec(`UPDATE accounts SET balance = balance - \$1`).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(`SELECT balance FROM accounts`).
+			WillReturnRows(sqlmock.NewRows([]string{"balance"}).AddRow(70.0))
+		mock.ExpectExec(`UPDATE transactions SET final_balance`).WillReturnResult(sqlmock.NewResult(0, 1))
+		expectNewBalances(mock, 70, 2)
+		mock.ExpectCommit()
+
+		_, err = transactions.CreateWithdrawal(ctx, 1, 30, repository.Details{})
+		require.NoError(t, err)
+
+		balance, err := accounts.GetAccountBalance(ctx, 1)
+		require.NoError(t, err)
+		assert.Equal(t, 70.0, balance)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("no stale balance after approving a held withdrawal", func(t *testing.T) {
+		accounts, transactions, _, mock := newCachedRepos()
+		expectBalanceVersion(mock, 100, 1)
+		_, err := accounts.GetAccountBalance(ctx, 1)
+		require.NoError(t, err)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT account_id, amount, type, status FROM transactions WHERE id = \$1 FOR UPDATE`).
+			WithArgs(7).
+			WillReturnRows(sqlmock.NewRows([]string{"account_id", "amount", "type", "status"}).AddRow(1, 30.0, "withdrawal", "pending_review"))
+		mock.ExpectQuery(`SELECT balance, status FROM accounts WHERE id = \$1 FOR UPDATE`).
+			WillReturnRows(sqlmock.NewRows([]string{"balance", "status"}).AddRow(100.0, "active"))
+		testutils.ExpectAccountRules(mock)
+		mock.ExpectQuery(`FROM account_limits`).WillReturnError(sql.ErrNoRows)
+		mock.ExpectQuery(`JOIN fee_schedules`).WithArgs(1).WillReturnError(sql.ErrNoRows)
+		mock.ExpectQuery(`UPDATE accounts SET balance = balance \+ \$1`).
+			WithArgs(-30.0, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"balance"}).AddRow(70.0))
+		mock.ExpectExec(`UPDATE transactions SET status = 'posted'`).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`UPDATE transaction_reviews`).WillReturnResult(sqlmock.NewResult(0, 1))
+		expectNewBalances(mock, 70, 2)
+		mock.ExpectCommit()
+
+		require.NoError(t, transactions.ApproveTransaction(ctx, 7, "ops@example.com", ""))
+
+		balance, err := accounts.GetAccountBalance(ctx, 1)
+		require.NoError(t, err)
+		assert.Equal(t, 70.0, balance)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("a failed posting leaves the cache alone", func(t *testing.T) {
+		accounts, transactions, _, mock := newCachedRepos()
+		expectBalanceVersion(mock, 100, 1)
+		_, err := accounts.GetAccountBalance(ctx, 1)
+		require.NoError(t, err)
+
+		expectLockedAccount(mock, 100)
+		mock.ExpectRollback()
+
+		_, err = transactions.CreateWithdrawal(ctx, 1, 500, repository.Details{})
+		require.ErrorIs(t, err, repository.ErrInsufficientFunds)
+
+		balance, err := accounts.GetAccountBalance(ctx, 1)
+		require.NoError(t, err)
+		assert.Equal(t, 100.0, balance)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("a read racing a posting cannot put back the old balance", func(t *testing.T) {
+		accounts, _, balances, mock := newCachedRepos()
+		key := cache.BalanceKey(tenant.Default, 1)
+		// The posting committed version 2 after the read saw version 1
+		require.NoError(t, balances.Put(ctx, key, cache.Entry{Balance: 150, Version: 2}))
+		require.NoError(t, balances.Put(ctx, key, cache.Entry{Balance: 100, Version: 1}))
+
+		balance, err := accounts.GetAccountBalance(ctx, 1)
+		require.NoError(t, err)
+		assert.Equal(t, 150.0, balance)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("reads that must see particular writes skip the cache", func(t *testing.T) {
+		accounts, _, balances, mock := newCachedRepos()
+		require.NoError(t, balances.Put(ctx, cache.BalanceKey(tenant.Default, 1), cache.Entry{Balance: 100, Version: 1}))
+		expectBalanceVersion(mock, 150, 2)
+
+		balance, err := accounts.GetAccountBalance(database.WithPrimary(ctx), 1)
+		require.NoError(t, err)
+		assert.Equal(t, 150.0, balance)
+
+		balance, err = accounts.GetAccountBalance(ctx, 1)
+		require.NoError(t, err)
+		assert.Equal(t, 150.0, balance)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("misses are filled from the primary, not a lagging replica", func(t *testing.T) {
+		primary, pmock := testutils.NewMockDB()
+		replica, rmock := testutils.NewMockDB()
+		defer primary.Close()
+		defer replica.Close()
+		accounts := repository.NewAccountRepository(primary, logging.Discard(),
+			repository.WithAccountReads(primaryReads{primary: primary, replica: replica}),
+			repository.WithAccountCache(cache.NewLRU(100)))
+		// The replica has not replayed the deposit that made version 2
+		rmock.ExpectQuery(`SELECT balance, version FROM accounts`).WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"balance", "version"}).AddRow(100.0, 1))
+		expectBalanceVersion(pmock, 150, 2)
+
+		for range 2 {
+			balance, err := accounts.GetAccountBalance(ctx, 1)
+			require.NoError(t, err)
+			assert.Equal(t, 150.0, balance)
+		}
+		assert.NoError(t, pmock.ExpectationsWereMet())
+	})
+
+	t.Run("tenants do not share entries", func(t *testing.T) {
+		accounts, _, balances, mock := newCachedRepos()
+		require.NoError(t, balances.Put(ctx, cache.BalanceKey("acme", 1), cache.Entry{Balance: 999, Version: 5}))
+		expectBalanceVersion(mock, 100, 1)
+
+		balance, err := accounts.GetAccountBalance(ctx, 1)
+		require.NoError(t, err)
+		assert.Equal(t, 100.0, balance)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
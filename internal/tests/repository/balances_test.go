@@ -0,0 +1,84 @@
+package repository_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	apperrors "github.com/Andrew44Ashraf/fintech-service/internal/errors"
+	"github.com/Andrew44Ashraf/fintech-service/internal/repository"
+	"github.com/Andrew44Ashraf/fintech-service/internal/tests/testutils"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBalancesAsOf(t *testing.T) {
+	ctx := context.Background()
+	asOf := time.Date(2026, 3, 31, 23, 59, 0, 0, time.UTC)
+
+	t.Run("returns the balance of every account open at the instant", func(t *testing.T) {
+		repo, mock := testutils.NewMockRepository()
+		mock.ExpectQuery(`SELECT a.id,.*final_balance.*created_at <= \$2`).
+			WithArgs(sqlmock.AnyArg(), asOf, sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "balance"}).AddRow(1, 120.5).AddRow(2, 0.0))
+
+		balances, err := repo.BalancesAsOf(ctx, []int{1, 2, 3}, asOf)
+
+		require.NoError(t, err)
+		assert.Equal(t, map[int]float64{1: 120.5, 2: 0}, balances)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("too many accounts", func(t *testing.T) {
+		repo, _ := testutils.NewMockRepository()
+
+		_, err := repo.BalancesAsOf(ctx, make([]int, repository.MaxBalanceAccounts+1), asOf)
+
+		var verr *apperrors.ValidationError
+		assert.ErrorAs(t, err, &verr)
+	})
+}
+
+func TestGetBalanceAsOf(t *testing.T) {
+	ctx := context.Background()
+	asOf := time.Date(2026, 3, 31, 23, 59, 0, 0, time.UTC)
+	noRows := func() *sqlmock.Rows { return sqlmock.NewRows([]string{"id", "balance"}) }
+
+	t.Run("balance at the instant", func(t *testing.T) {
+		repo, mock := testutils.NewMockRepository()
+		mock.ExpectQuery(`FROM accounts a`).
+			WillReturnRows(noRows().AddRow(1, 75.0))
+
+		balance, err := repo.GetBalanceAsOf(ctx, 1, asOf)
+
+		require.NoError(t, err)
+		assert.Equal(t, 75.0, balance)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("account opened later", func(t *testing.T) {
+		repo, mock := testutils.NewMockRepository()
+		mock.ExpectQuery(`FROM accounts a`).WillReturnRows(noRows())
+		mock.ExpectQuery(`SELECT created_at FROM accounts`).WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"created_at"}).AddRow(asOf.AddDate(0, 1, 0)))
+
+		_, err := repo.GetBalanceAsOf(ctx, 1, asOf)
+
+		var verr *apperrors.ValidationError
+		assert.ErrorAs(t, err, &verr)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("unknown account", func(t *testing.T) {
+		repo, mock := testutils.NewMockRepository()
+		mock.ExpectQuery(`FROM accounts a`).WillReturnRows(noRows())
+		mock.ExpectQuery(`SELECT created_at FROM accounts`).WithArgs(99).WillReturnError(sql.ErrNoRows)
+
+		_, err := repo.GetBalanceAsOf(ctx, 99, asOf)
+
+		assert.ErrorIs(t, err, repository.ErrAccountNotFound)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
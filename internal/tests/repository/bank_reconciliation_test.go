@@ -0,0 +1,155 @@
+package repository_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/Andrew44Ashraf/fintech-service/internal/logging"
+	"github.com/Andrew44Ashraf/fintech-service/internal/reconciliation"
+	"github.com/Andrew44Ashraf/fintech-service/internal/repository"
+	"github.com/Andrew44Ashraf/fintech-service/internal/tests/testutils"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newBankRepo() (*repository.BankReconciliationRepository, sqlmock.Sqlmock) {
+	db, mock := testutils.NewMockDB()
+	return repository.NewBankReconciliationRepository(db, logging.Discard()), mock
+}
+
+var (
+	bankDay      = time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)
+	bankStmtCols = []string{"id", "format", "file_name", "checksum", "period_start", "period_end", "line_count", "imported_by", "imported_at"}
+	bankLineCols = []string{"id", "statement_id", "line_no", "reference", "amount", "booking_date", "value_date", "description"}
+)
+
+func TestImportBankStatement(t *testing.T) {
+	ctx := context.Background()
+	st := &reconciliation.BankStatement{
+		Format: reconciliation.FormatCSV, PeriodStart: bankDay, PeriodEnd: bankDay,
+		Lines: []reconciliation.BankLine{{LineNo: 1, Reference: "7", Amount: 50, BookingDate: bankDay, ValueDate: bankDay}},
+	}
+
+	t.Run("stores the lines", func(t *testing.T) {
+		repo, mock := newBankRepo()
+		mock.ExpectBegin()
+		mock.ExpectQuery(`INSERT INTO bank_statements`).
+			WithArgs("csv", "march.csv", "abc", bankDay, bankDay, 1, "ops").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "imported_at"}).AddRow(3, bankDay))
+		mock.ExpectPrepare(`INSERT INTO bank_statement_lines`).ExpectExec().
+			WithArgs(3, 1, "7", 50.0, bankDay, bankDay, "").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		rec, err := repo.ImportStatement(ctx, st, "march.csv", "abc", "ops")
+
+		require.NoError(t, err)
+		assert.Equal(t, 3, rec.ID)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("the same file twice", func(t *testing.T) {
+		repo, mock := newBankRepo()
+		mock.ExpectBegin()
+		mock.ExpectQuery(`INSERT INTO bank_statements`).WillReturnError(sql.ErrNoRows)
+		mock.ExpectRollback()
+
+		_, err := repo.ImportStatement(ctx, st, "march.csv", "abc", "ops")
+
+		assert.ErrorIs(t, err, repository.ErrBankStatementDuplicate)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestAutoMatch(t *testing.T) {
+	repo, mock := newBankRepo()
+	mock.ExpectBegin()
+	mock.ExpectQuery(`FROM bank_statements WHERE id = \$1 FOR UPDATE`).WithArgs(3).
+		WillReturnRows(sqlmock.NewRows(bankStmtCols).AddRow(3, "csv", "", "abc", bankDay, bankDay, 2, "ops", bankDay))
+	mock.ExpectQuery(`FROM bank_statement_lines l`).WithArgs(3).
+		WillReturnRows(sqlmock.NewRows(bankLineCols).
+			AddRow(30, 3, 1, "7", 50.0, bankDay, bankDay, "").
+			AddRow(31, 3, 2, "", -20.0, bankDay, bankDay, ""))
+	// The window is widened by the widest rule: five days either side.
+	mock.ExpectQuery(`FROM transactions t`).
+		WithArgs(bankDay.AddDate(0, 0, -5), bankDay.AddDate(0, 0, 6)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "public_id", "amount", "posted_at"}).
+			AddRow(7, "tx_7", 50.0, bankDay.AddDate(0, 0, -1)).
+			AddRow(8, "tx_8", 99.0, bankDay))
+	mock.ExpectExec(`INSERT INTO bank_matches`).
+		WithArgs(30, 7, "reference", "system").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	matched, err := repo.AutoMatch(context.Background(), 3, reconciliation.DefaultRules)
+
+	require.NoError(t, err)
+	assert.Equal(t, 1, matched)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestManualBankMatch(t *testing.T) {
+	ctx := context.Background()
+	expectLine := func(mock sqlmock.Sqlmock) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(`FROM bank_statement_lines WHERE id`).WithArgs(30).
+			WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	}
+
+	t.Run("matches", func(t *testing.T) {
+		repo, mock := newBankRepo()
+		expectLine(mock)
+		mock.ExpectQuery(`SELECT type, status FROM transactions`).WithArgs(7).
+			WillReturnRows(sqlmock.NewRows([]string{"type", "status"}).AddRow("deposit", "posted"))
+		mock.ExpectQuery(`INSERT INTO bank_matches`).WithArgs(30, 7, "manual", "ops").
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(9))
+		mock.ExpectCommit()
+
+		id, err := repo.Match(ctx, 30, 7, "ops")
+
+		require.NoError(t, err)
+		assert.Equal(t, 9, id)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("already matched", func(t *testing.T) {
+		repo, mock := newBankRepo()
+		expectLine(mock)
+		mock.ExpectQuery(`SELECT type, status FROM transactions`).
+			WillReturnRows(sqlmock.NewRows([]string{"type", "status"}).AddRow("withdrawal", "posted"))
+		mock.ExpectQuery(`INSERT INTO bank_matches`).WillReturnError(sql.ErrNoRows)
+		mock.ExpectRollback()
+
+		_, err := repo.Match(ctx, 30, 7, "ops")
+
+		assert.ErrorIs(t, err, repository.ErrAlreadyMatched)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("transfers do not settle at the bank", func(t *testing.T) {
+		repo, mock := newBankRepo()
+		expectLine(mock)
+		mock.ExpectQuery(`SELECT type, status FROM transactions`).
+			WillReturnRows(sqlmock.NewRows([]string{"type", "status"}).AddRow("transfer_in", "posted"))
+		mock.ExpectRollback()
+
+		_, err := repo.Match(ctx, 30, 7, "ops")
+
+		assert.Error(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("unmatch twice", func(t *testing.T) {
+		repo, mock := newBankRepo()
+		mock.ExpectExec(`UPDATE bank_matches`).WithArgs("ops", "wrong line", 9).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		err := repo.Unmatch(ctx, 9, "ops", "wrong line")
+
+		assert.ErrorIs(t, err, repository.ErrBankMatchNotFound)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
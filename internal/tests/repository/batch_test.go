@@ -0,0 +1,211 @@
+package repository_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/Andrew44Ashraf/fintech-service/internal/batches"
+	"github.com/Andrew44Ashraf/fintech-service/internal/logging"
+	"github.com/Andrew44Ashraf/fintech-service/internal/repository"
+	"github.com/Andrew44Ashraf/fintech-service/internal/tests/testutils"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// twoDeposits is a batch whose second row targets a missing account.
+var twoDeposits = []batches.Instruction{
+	{Row: 1, Type: batches.TypeDeposit, AccountID: 1, Amount: 100},
+	{Row: 2, Type: batches.TypeDeposit, AccountID: 99, Amount: 5},
+}
+
+func newBatchRepo() (*repository.BatchRepository, sqlmock.Sqlmock) {
+	db, mock := testutils.NewMockDB()
+	txRepo := repository.NewTransactionRepository(db, logging.Discard())
+	return repository.NewBatchRepository(db, logging.Discard(), txRepo), mock
+}
+
+func expectBatchRows(mock sqlmock.Sqlmock) {
+	mock.ExpectQuery(`INSERT INTO batches`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(9, time.Now()))
+	mock.ExpectBegin()
+
+	// Row 1 posts
+	mock.ExpectExec(`^SAVEPOINT batch_row`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`SELECT status FROM accounts`).WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow("active"))
+	testutils.ExpectAccountRules(mock)
+	mock.ExpectQuery(`INSERT INTO transactions`).WithArgs(1, 100.0, "", "", nil).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(11))
+	mock.ExpectExec(`UPDATE accounts SET balance`).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`SELECT balance FROM accounts`).
+		WillReturnRows(sqlmock.NewRows([]string{"balance"}).AddRow(150.0))
+	mock.ExpectExec(`UPDATE transactions SET final_balance`).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`^RELEASE SAVEPOINT batch_row`).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	// Row 2 fails and is undone on its own
+	mock.ExpectExec(`^SAVEPOINT batch_row`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`SELECT status FROM accounts`).WithArgs(99).WillReturnError(sql.ErrNoRows)
+	mock.ExpectExec(`^ROLLBACK TO SAVEPOINT batch_row`).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	// The report shows public IDs
+	mock.ExpectQuery(`SELECT id, public_id FROM accounts WHERE id = ANY`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "public_id"}).AddRow(1, "acc_1"))
+	mock.ExpectQuery(`SELECT id, public_id FROM transactions WHERE id = ANY`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "public_id"}).AddRow(11, "tx_11"))
+}
+
+func expectBatchReport(mock sqlmock.Sqlmock, firstRowStatus string, firstRowTxID any, status string) {
+	prep := mock.ExpectPrepare(`INSERT INTO batch_rows`)
+	prep.ExpectExec().
+		WithArgs(9, 1, "deposit", 1, 0, 100.0, "", firstRowStatus, firstRowTxID, "", "").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	prep.ExpectExec().
+		WithArgs(9, 2, "deposit", 99, 0, 5.0, "", repository.RowFailed, 0, "account_not_found", "account not found").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`UPDATE batches`).
+		WithArgs(status, sqlmock.AnyArg(), 1, 9).
+		WillReturnRows(sqlmock.NewRows([]string{"completed_at"}).AddRow(time.Now()))
+	mock.ExpectCommit()
+}
+
+func TestProcessBatch(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("best effort commits the rows that succeeded", func(t *testing.T) {
+		repo, mock := newBatchRepo()
+		expectBatchRows(mock)
+		expectBatchReport(mock, repository.RowPosted, 11, repository.BatchPartiallyCompleted)
+
+		b, err := repo.Process(ctx, batches.ModeBestEffort, false, twoDeposits)
+
+		require.NoError(t, err)
+		assert.Equal(t, repository.BatchPartiallyCompleted, b.Status)
+		assert.Equal(t, 1, b.SucceededRows)
+		assert.Equal(t, 1, b.FailedRows)
+		assert.Equal(t, 11, b.Rows[0].TransactionID)
+		assert.Equal(t, "acc_1", b.Rows[0].AccountPublicID)
+		assert.Equal(t, "tx_11", b.Rows[0].TransactionPublicID)
+		assert.Equal(t, "account_not_found", b.Rows[1].ErrorCode)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("all or nothing rolls back everything on a failed row", func(t *testing.T) {
+		repo, mock := newBatchRepo()
+		expectBatchRows(mock)
+		mock.ExpectRollback()
+		mock.ExpectBegin()
+		expectBatchReport(mock, repository.RowRolledBack, 0, repository.BatchFailed)
+
+		b, err := repo.Process(ctx, batches.ModeAllOrNothing, false, twoDeposits)
+
+		require.NoError(t, err)
+		assert.Equal(t, repository.BatchFailed, b.Status)
+		assert.Equal(t, 0, b.SucceededRows)
+		assert.Equal(t, repository.RowRolledBack, b.Rows[0].Status)
+		assert.Zero(t, b.Rows[0].TransactionID)
+		assert.Empty(t, b.Rows[0].TransactionPublicID)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("dry run never commits postings", func(t *testing.T) {
+		repo, mock := newBatchRepo()
+		expectBatchRows(mock)
+		mock.ExpectRollback()
+		mock.ExpectBegin()
+		expectBatchReport(mock, repository.RowWouldPost, 0, repository.BatchPartiallyCompleted)
+
+		b, err := repo.Process(ctx, batches.ModeBestEffort, true, twoDeposits)
+
+		require.NoError(t, err)
+		assert.True(t, b.DryRun)
+		assert.Equal(t, repository.RowWouldPost, b.Rows[0].Status)
+		assert.Equal(t, repository.RowFailed, b.Rows[1].Status)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestGetBatchNotFound(t *testing.T) {
+	repo, mock := newBatchRepo()
+	mock.ExpectQuery(`SELECT .* FROM batches`).WithArgs(5).WillReturnError(sql.ErrNoRows)
+
+	_, err := repo.GetBatch(context.Background(), 5)
+
+	assert.ErrorIs(t, err, repository.ErrBatchNotFound)
+}
+
+func TestCreateTransfer(t *testing.T) {
+	ctx := context.Background()
+	lockRows := func(fromBalance float64) *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"id", "balance", "status", "currency"}).
+			AddRow(1, fromBalance, "active", "USD").
+			AddRow(2, 10.0, "active", "USD")
+	}
+
+	t.Run("books both legs", func(t *testing.T) {
+		repo, mock := testutils.NewMockTransactionRepository()
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT id, balance, status, currency FROM accounts .* ORDER BY id FOR UPDATE`).
+			WillReturnRows(lockRows(50))
+		testutils.ExpectAccountRules(mock)
+		mock.ExpectQuery(`FROM account_limits`).WillReturnError(sql.ErrNoRows)
+		mock.ExpectQuery(`UPDATE accounts SET balance`).WithArgs(-20.0, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"balance"}).AddRow(30.0))
+		mock.ExpectQuery(`INSERT INTO transactions`).WithArgs(1, 20.0, "transfer_out", 30.0, 2).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(21))
+		mock.ExpectQuery(`UPDATE accounts SET balance`).WithArgs(20.0, 2).
+			WillReturnRows(sqlmock.NewRows([]string{"balance"}).AddRow(30.0))
+		mock.ExpectQuery(`INSERT INTO transactions`).WithArgs(2, 20.0, "transfer_in", 30.0, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(22))
+		mock.ExpectCommit()
+
+		txID, err := repo.CreateTransfer(ctx, 1, 2, 20)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 21, txID)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("insufficient funds", func(t *testing.T) {
+		repo, mock := testutils.NewMockTransactionRepository()
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT id, balance, status, currency FROM accounts`).WillReturnRows(lockRows(5))
+		testutils.ExpectAccountRules(mock)
+		mock.ExpectRollback()
+
+		_, err := repo.CreateTransfer(ctx, 1, 2, 20)
+
+		assert.ErrorIs(t, err, repository.ErrInsufficientFunds)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("accounts in different currencies", func(t *testing.T) {
+		repo, mock := testutils.NewMockTransactionRepository()
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT id, balance, status, currency FROM accounts`).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "balance", "status", "currency"}).
+				AddRow(1, 50.0, "active", "USD").
+				AddRow(2, 10.0, "active", "EUR"))
+		mock.ExpectRollback()
+
+		_, err := repo.CreateTransfer(ctx, 1, 2, 20)
+
+		assert.ErrorIs(t, err, repository.ErrCurrencyMismatch)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("missing destination", func(t *testing.T) {
+		repo, mock := testutils.NewMockTransactionRepository()
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT id, balance, status, currency FROM accounts`).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "balance", "status", "currency"}).AddRow(1, 50.0, "active", "USD"))
+		mock.ExpectRollback()
+
+		_, err := repo.CreateTransfer(ctx, 1, 2, 20)
+
+		assert.ErrorIs(t, err, repository.ErrAccountNotFound)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,135 @@
+package repository_test
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	apperrors "github.com/Andrew44Ashraf/fintech-service/internal/errors"
+	"github.com/Andrew44Ashraf/fintech-service/internal/repository"
+	"github.com/Andrew44Ashraf/fintech-service/internal/tests/testutils"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTransactionDetails(t *testing.T) {
+	ctx := context.Background()
+	details := repository.Details{
+		ExternalRef: "inv-1001",
+		Description: "March invoice",
+		Metadata:    json.RawMessage(`{"invoice":1001}`),
+	}
+
+	expectLocked := func(mock sqlmock.Sqlmock) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT status FROM accounts`).WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow("active"))
+	}
+
+	t.Run("details are stored with the deposit", func(t *testing.T) {
+		repo, mock := testutils.NewMockTransactionRepository()
+		expectLocked(mock)
+		mock.ExpectQuery(`SELECT id FROM transactions WHERE account_id = \$1 AND external_ref = \$2`).
+			WithArgs(1, "inv-1001").
+			WillReturnError(sql.ErrNoRows)
+		testutils.ExpectAccountRules(mock)
+		mock.ExpectQuery(`INSERT INTO transactions`).
+			WithArgs(1, 100.0, "inv-1001", "March invoice", `{"invoice":1001}`).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(5))
+		mock.ExpectExec(`UPDATE accounts`).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(`SELECT balance FROM accounts`).
+			WillReturnRows(sqlmock.NewRows([]string{"balance"}).AddRow(100.0))
+		mock.ExpectExec(`UPDATE transactions`).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		txID, err := repo.CreateDeposit(ctx, 1, 100, details)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 5, txID)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("reused external_ref is rejected", func(t *testing.T) {
+		repo, mock := testutils.NewMockTransactionRepository()
+		expectLocked(mock)
+		mock.ExpectQuery(`SELECT id FROM transactions WHERE account_id`).
+			WithArgs(1, "inv-1001").
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(5))
+		mock.ExpectRollback()
+
+		_, err := repo.CreateDeposit(ctx, 1, 100, details)
+
+		assert.ErrorIs(t, err, repository.ErrDuplicateExternalRef)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("unique index violation is a duplicate", func(t *testing.T) {
+		repo, mock := testutils.NewMockTransactionRepository()
+		expectLocked(mock)
+		mock.ExpectQuery(`SELECT id FROM transactions WHERE account_id`).WillReturnError(sql.ErrNoRows)
+		testutils.ExpectAccountRules(mock)
+		mock.ExpectQuery(`INSERT INTO transactions`).
+			WillReturnError(&pq.Error{Code: "23505", Constraint: "idx_transactions_external_ref"})
+		mock.ExpectRollback()
+
+		_, err := repo.CreateDeposit(ctx, 1, 100, details)
+
+		assert.ErrorIs(t, err, repository.ErrDuplicateExternalRef)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("invalid details are rejected before the database", func(t *testing.T) {
+		repo, mock := testutils.NewMockTransactionRepository()
+		for name, d := range map[string]repository.Details{
+			"external_ref": {ExternalRef: strings.Repeat("x", repository.MaxExternalRefLength+1)},
+			"description":  {Description: strings.Repeat("x", repository.MaxDescriptionLength+1)},
+			"array":        {Metadata: json.RawMessage(`[1,2]`)},
+			"malformed":    {Metadata: json.RawMessage(`{"a":`)},
+			"too large":    {Metadata: json.RawMessage(`{"a":"` + strings.Repeat("x", repository.MaxMetadataSize) + `"}`)},
+		} {
+			_, err := repo.CreateWithdrawal(ctx, 1, 10, d)
+
+			var verr *apperrors.ValidationError
+			assert.ErrorAs(t, err, &verr, name)
+		}
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("history is filtered by external_ref", func(t *testing.T) {
+		repo, mock := testutils.NewMockTransactionRepository()
+		mock.ExpectQuery(`external_ref = \$4`).
+			WithArgs(1, 10, 0, "inv-1001").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "account_id", "amount", "type", "status", "created_at", "final_balance",
+				"external_ref", "description", "metadata", "public_id", "value_date", "booking_date"}).
+				AddRow(5, 1, 100.0, "deposit", "posted", time.Now(), 100.0, "inv-1001", "March invoice", []byte(`{"invoice":1001}`), "tx_00000000000000000000000000000005", time.Now(), time.Now()))
+
+		txs, err := repo.GetTransactions(ctx, 1, 10, 0, "inv-1001")
+
+		assert.NoError(t, err)
+		if assert.Len(t, txs, 1) {
+			assert.Equal(t, details, txs[0].Details)
+		}
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestAccountExternalRef(t *testing.T) {
+	repo, mock := testutils.NewMockRepository()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`FROM account_products WHERE code`).
+		WillReturnRows(productRows().AddRow("checking", "Checking", pq.StringArray{"deposit"}, 0.0, 0.0, 0.0, 0.0, 0.0, 0, "USD"))
+	mock.ExpectQuery(`INSERT INTO accounts`).
+		WithArgs(0.0, "checking", "USD", 0.0, "crm-42", "", nil).
+		WillReturnError(&pq.Error{Code: "23505", Constraint: "idx_accounts_external_ref"})
+	mock.ExpectRollback()
+
+	_, err := repo.CreateAccount(context.Background(), "checking", 0, repository.Details{ExternalRef: "crm-42"})
+
+	assert.ErrorIs(t, err, repository.ErrDuplicateExternalRef)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
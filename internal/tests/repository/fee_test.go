@@ -0,0 +1,233 @@
+package repository_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/Andrew44Ashraf/fintech-service/internal/logging"
+	"github.com/Andrew44Ashraf/fintech-service/internal/repository"
+	"github.com/Andrew44Ashraf/fintech-service/internal/tests/testutils"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newFeeRepo() (*repository.FeeRepository, sqlmock.Sqlmock) {
+	db, mock := testutils.NewMockDB()
+	return repository.NewFeeRepository(db, logging.Discard()), mock
+}
+
+func TestWithdrawalFee(t *testing.T) {
+	ctx := context.Background()
+
+	expectWithdrawal := func(mock sqlmock.Sqlmock, balance, fee float64) {
+		expectLockedAccount(mock, balance)
+		mock.ExpectQuery(`FROM account_limits`).WillReturnError(sql.ErrNoRows)
+		mock.ExpectQuery(`JOIN fee_schedules`).WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"withdrawal_fee"}).AddRow(fee))
+	}
+
+	t.Run("fee is posted as its own transaction", func(t *testing.T) {
+		repo, mock := testutils.NewMockTransactionRepository()
+		expectWithdrawal(mock, 100, 1.5)
+		mock.ExpectQuery(`INSERT INTO transactions`).WithArgs(1, 50.0, "", "", nil).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(10))
+		mock.ExpectExec(`UPDATE accounts SET balance = balance - \$1`).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(`SELECT balance FROM accounts`).
+			WillReturnRows(sqlmock.NewRows([]string{"balance"}).AddRow(50.0))
+		mock.ExpectExec(`UPDATE transactions SET final_balance`).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(`UPDATE accounts SET balance = balance \+ \$1`).WithArgs(-1.5, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"balance"}).AddRow(48.5))
+		mock.ExpectQuery(`INSERT INTO transactions .*fee_type, related_transaction_id`).
+			WithArgs(1, 1.5, "fee", 48.5, "withdrawal", 10).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(11))
+		mock.ExpectCommit()
+
+		txID, err := repo.CreateWithdrawal(ctx, 1, 50, repository.Details{})
+
+		require.NoError(t, err)
+		assert.Equal(t, 10, txID)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("fee counts towards insufficient funds", func(t *testing.T) {
+		repo, mock := testutils.NewMockTransactionRepository()
+		expectWithdrawal(mock, 50, 1)
+		mock.ExpectRollback()
+
+		_, err := repo.CreateWithdrawal(ctx, 1, 50, repository.Details{})
+
+		assert.ErrorIs(t, err, repository.ErrInsufficientFunds)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestApplyMonthlyFees(t *testing.T) {
+	ctx := context.Background()
+	from := time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 1, 0)
+
+	expectRun := func(mock sqlmock.Sqlmock, balance, lowest float64, inserted int64) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT balance, status FROM accounts WHERE id = \$1 FOR UPDATE`).WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"balance", "status"}).AddRow(balance, "active"))
+		mock.ExpectQuery(`JOIN fee_schedules`).WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"product_code", "withdrawal_fee", "maintenance_fee", "low_balance_threshold", "low_balance_fee"}).
+				AddRow("savings", 1.0, 5.0, 100.0, 2.0))
+		mock.ExpectQuery(`SELECT LEAST`).WithArgs(1, from, to, sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows([]string{"lowest"}).AddRow(lowest))
+		mock.ExpectExec(`INSERT INTO monthly_fee_runs`).WithArgs(1, from, lowest).
+			WillReturnResult(sqlmock.NewResult(0, inserted))
+	}
+
+	t.Run("charges maintenance and low balance fees", func(t *testing.T) {
+		repo, mock := newFeeRepo()
+		expectRun(mock, 300, 40, 1)
+
+		mock.ExpectQuery(`FROM fee_waivers`).WithArgs(1, "maintenance", from, to).WillReturnError(sql.ErrNoRows)
+		mock.ExpectQuery(`UPDATE accounts SET balance`).WithArgs(-5.0, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"balance"}).AddRow(295.0))
+		mock.ExpectQuery(`INSERT INTO transactions`).WithArgs(1, 5.0, "fee", 295.0, "maintenance", 0).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(20))
+		mock.ExpectExec(`INSERT INTO monthly_fee_charges`).WithArgs(1, from, "maintenance", 5.0, 20, 0).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		mock.ExpectQuery(`FROM fee_waivers`).WithArgs(1, "low_balance", from, to).WillReturnError(sql.ErrNoRows)
+		mock.ExpectQuery(`UPDATE accounts SET balance`).WithArgs(-2.0, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"balance"}).AddRow(293.0))
+		mock.ExpectQuery(`INSERT INTO transactions`).WithArgs(1, 2.0, "fee", 293.0, "low_balance", 0).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(21))
+		mock.ExpectExec(`INSERT INTO monthly_fee_charges`).WithArgs(1, from, "low_balance", 2.0, 21, 0).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		charged, err := repo.ApplyMonthlyFees(ctx, 1, from, to)
+
+		require.NoError(t, err)
+		assert.Equal(t, 2, charged)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("waived fee is recorded without a transaction", func(t *testing.T) {
+		repo, mock := newFeeRepo()
+		expectRun(mock, 300, 300, 1)
+
+		mock.ExpectQuery(`FROM fee_waivers`).WithArgs(1, "maintenance", from, to).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3))
+		mock.ExpectExec(`INSERT INTO monthly_fee_charges`).WithArgs(1, from, "maintenance", 5.0, 0, 3).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		charged, err := repo.ApplyMonthlyFees(ctx, 1, from, to)
+
+		require.NoError(t, err)
+		assert.Zero(t, charged)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("rerun for the same month charges nothing", func(t *testing.T) {
+		repo, mock := newFeeRepo()
+		expectRun(mock, 300, 40, 0)
+		mock.ExpectRollback()
+
+		charged, err := repo.ApplyMonthlyFees(ctx, 1, from, to)
+
+		require.NoError(t, err)
+		assert.Zero(t, charged)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestReverseFee(t *testing.T) {
+	ctx := context.Background()
+	feeRow := func(txType string, reversed bool) *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"account_id", "amount", "type", "status", "fee_type", "reversed"}).
+			AddRow(1, 5.0, txType, "posted", "maintenance", reversed)
+	}
+
+	t.Run("refunds and audits", func(t *testing.T) {
+		repo, mock := newFeeRepo()
+		mock.ExpectBegin()
+		mock.ExpectQuery(`FROM transactions t`).WithArgs(20).WillReturnRows(feeRow("fee", false))
+		mock.ExpectQuery(`SELECT status FROM accounts`).WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow("active"))
+		mock.ExpectQuery(`UPDATE accounts SET balance`).WithArgs(5.0, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"balance"}).AddRow(300.0))
+		mock.ExpectQuery(`INSERT INTO transactions`).WithArgs(1, 5.0, "fee_reversal", 300.0, "maintenance", 20).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(22))
+		mock.ExpectExec(`INSERT INTO fee_audit_log`).
+			WithArgs(1, repository.FeeAuditFeeReversed, "maintenance", 0, 20, 5.0, "ops@example.com", "goodwill").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		txID, err := repo.ReverseFee(ctx, 20, "ops@example.com", "goodwill")
+
+		require.NoError(t, err)
+		assert.Equal(t, 22, txID)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("only once", func(t *testing.T) {
+		repo, mock := newFeeRepo()
+		mock.ExpectBegin()
+		mock.ExpectQuery(`FROM transactions t`).WillReturnRows(feeRow("fee", true))
+		mock.ExpectRollback()
+
+		_, err := repo.ReverseFee(ctx, 20, "ops", "again")
+
+		assert.ErrorIs(t, err, repository.ErrFeeAlreadyReversed)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("not a fee", func(t *testing.T) {
+		repo, mock := newFeeRepo()
+		mock.ExpectBegin()
+		mock.ExpectQuery(`FROM transactions t`).WillReturnRows(feeRow("withdrawal", false))
+		mock.ExpectRollback()
+
+		_, err := repo.ReverseFee(ctx, 20, "ops", "oops")
+
+		assert.ErrorIs(t, err, repository.ErrInvalidTransaction)
+	})
+}
+
+func TestRevokeWaiver(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("audits the revocation", func(t *testing.T) {
+		repo, mock := newFeeRepo()
+		mock.ExpectBegin()
+		mock.ExpectQuery(`UPDATE fee_waivers SET revoked_by`).WithArgs(3, "ops").
+			WillReturnRows(sqlmock.NewRows([]string{"account_id", "fee_type"}).AddRow(1, "maintenance"))
+		mock.ExpectExec(`INSERT INTO fee_audit_log`).
+			WithArgs(1, repository.FeeAuditWaiverRevoked, "maintenance", 3, 0, 0.0, "ops", "promotion ended").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		assert.NoError(t, repo.RevokeWaiver(ctx, 3, "ops", "promotion ended"))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("already revoked", func(t *testing.T) {
+		repo, mock := newFeeRepo()
+		mock.ExpectBegin()
+		mock.ExpectQuery(`UPDATE fee_waivers SET revoked_by`).WillReturnError(sql.ErrNoRows)
+		mock.ExpectQuery(`SELECT EXISTS`).WithArgs(3).
+			WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+		mock.ExpectRollback()
+
+		assert.ErrorIs(t, repo.RevokeWaiver(ctx, 3, "ops", "again"), repository.ErrFeeWaiverRevoked)
+	})
+
+	t.Run("unknown waiver", func(t *testing.T) {
+		repo, mock := newFeeRepo()
+		mock.ExpectBegin()
+		mock.ExpectQuery(`UPDATE fee_waivers SET revoked_by`).WillReturnError(sql.ErrNoRows)
+		mock.ExpectQuery(`SELECT EXISTS`).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+		mock.ExpectRollback()
+
+		assert.ErrorIs(t, repo.RevokeWaiver(ctx, 3, "ops", "x"), repository.ErrFeeWaiverNotFound)
+	})
+}
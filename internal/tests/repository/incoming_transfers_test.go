@@ -0,0 +1,170 @@
+package repository_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/Andrew44Ashraf/fintech-service/internal/repository"
+	"github.com/Andrew44Ashraf/fintech-service/internal/tenant"
+	"github.com/Andrew44Ashraf/fintech-service/internal/tests/testutils"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func expectPendingTransaction(mock sqlmock.Sqlmock, status string) {
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT account_id, amount, type, status FROM transactions WHERE id = \$1 FOR UPDATE`).
+		WithArgs(7).
+		WillReturnRows(sqlmock.NewRows([]string{"account_id", "amount", "type", "status"}).AddRow(1, 250.0, "bank_transfer_in", status))
+}
+
+func TestCreateIncomingTransfer(t *testing.T) {
+	ctx := context.Background()
+	valueDate := time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)
+
+	expectActiveAccount := func(mock sqlmock.Sqlmock, status string) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT status FROM accounts WHERE id = \$1 FOR UPDATE`).
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow(status))
+	}
+
+	t.Run("records the transfer as pending without moving money", func(t *testing.T) {
+		repo, mock := testutils.NewMockTransactionRepository()
+		expectActiveAccount(mock, "active")
+		mock.ExpectQuery(`SELECT id FROM transactions WHERE account_id`).WillReturnError(sql.ErrNoRows)
+		testutils.ExpectAccountRules(mock)
+		mock.ExpectQuery(`INSERT INTO transactions .* 'pending', balance, COALESCE\(\$4::date, CURRENT_DATE\)`).
+			WithArgs(1, 250.0, "bank_transfer_in", valueDate, "sepa-42", "", nil).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+		mock.ExpectCommit()
+
+		txID, err := repo.CreateIncomingTransfer(ctx, 1, 250, valueDate, repository.Details{ExternalRef: "sepa-42"})
+
+		require.NoError(t, err)
+		assert.Equal(t, 7, txID)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("the value date defaults to today", func(t *testing.T) {
+		repo, mock := testutils.NewMockTransactionRepository()
+		expectActiveAccount(mock, "active")
+		testutils.ExpectAccountRules(mock)
+		mock.ExpectQuery(`INSERT INTO transactions`).
+			WithArgs(1, 250.0, "bank_transfer_in", nil, "", "", nil).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+		mock.ExpectCommit()
+
+		_, err := repo.CreateIncomingTransfer(ctx, 1, 250, time.Time{}, repository.Details{})
+
+		require.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("closed accounts take no transfers", func(t *testing.T) {
+		repo, mock := testutils.NewMockTransactionRepository()
+		expectActiveAccount(mock, "closed")
+		mock.ExpectRollback()
+
+		_, err := repo.CreateIncomingTransfer(ctx, 1, 250, valueDate, repository.Details{})
+
+		assert.ErrorIs(t, err, repository.ErrAccountClosed)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("amount must be positive", func(t *testing.T) {
+		repo, mock := testutils.NewMockTransactionRepository()
+
+		_, err := repo.CreateIncomingTransfer(ctx, 1, -5, valueDate, repository.Details{})
+
+		assert.ErrorIs(t, err, repository.ErrNegativeAmount)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestPostPendingTransaction(t *testing.T) {
+	ctx := tenant.WithID(context.Background(), tenant.Default)
+
+	t.Run("credits the account and updates the cached balance", func(t *testing.T) {
+		accounts, transactions, _, mock := newCachedRepos()
+		expectBalanceVersion(mock, 100, 1)
+		_, err := accounts.GetAccountBalance(ctx, 1)
+		require.NoError(t, err)
+
+		expectPendingTransaction(mock, "pending")
+		mock.ExpectQuery(`SELECT status FROM accounts WHERE id = \$1 FOR UPDATE`).
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow("active"))
+		mock.ExpectQuery(`UPDATE accounts SET balance = balance \+ \$1`).
+			WithArgs(250.0, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"balance"}).AddRow(350.0))
+		mock.ExpectExec(`UPDATE transactions SET status = 'posted', final_balance = \$1`).
+			WithArgs(350.0, 7).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		expectNewBalances(mock, 350, 2)
+		mock.ExpectCommit()
+
+		require.NoError(t, transactions.PostPendingTransaction(ctx, 7))
+
+		balance, err := accounts.GetAccountBalance(ctx, 1)
+		require.NoError(t, err)
+		assert.Equal(t, 350.0, balance)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("frozen accounts are not credited", func(t *testing.T) {
+		repo, mock := testutils.NewMockTransactionRepository()
+		expectPendingTransaction(mock, "pending")
+		mock.ExpectQuery(`SELECT status FROM accounts`).
+			WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow("frozen"))
+		mock.ExpectRollback()
+
+		err := repo.PostPendingTransaction(ctx, 7)
+
+		assert.ErrorIs(t, err, repository.ErrAccountFrozen)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	for _, status := range []string{"posted", "failed", "pending_review"} {
+		t.Run("only pending transactions can be posted: "+status, func(t *testing.T) {
+			repo, mock := testutils.NewMockTransactionRepository()
+			expectPendingTransaction(mock, status)
+			mock.ExpectRollback()
+
+			err := repo.PostPendingTransaction(ctx, 7)
+
+			assert.ErrorIs(t, err, repository.ErrInvalidTransactionState)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestFailPendingTransaction(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("leaves the balance alone", func(t *testing.T) {
+		repo, mock := testutils.NewMockTransactionRepository()
+		expectPendingTransaction(mock, "pending")
+		mock.ExpectExec(`UPDATE transactions SET status = 'failed' WHERE id = \$1`).
+			WithArgs(7).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		require.NoError(t, repo.FailPendingTransaction(ctx, 7))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("posted transactions cannot fail", func(t *testing.T) {
+		repo, mock := testutils.NewMockTransactionRepository()
+		expectPendingTransaction(mock, "posted")
+		mock.ExpectRollback()
+
+		err := repo.FailPendingTransaction(ctx, 7)
+
+		assert.ErrorIs(t, err, repository.ErrInvalidTransactionState)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
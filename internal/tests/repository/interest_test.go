@@ -0,0 +1,155 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Andrew44Ashraf/fintech-service/internal/interest"
+	"github.com/Andrew44Ashraf/fintech-service/internal/logging"
+	"github.com/Andrew44Ashraf/fintech-service/internal/repository"
+	"github.com/Andrew44Ashraf/fintech-service/internal/tests/testutils"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var savingsProduct = map[string]interest.Product{
+	"savings": {Code: "savings", DayCount: interest.Actual365, Tiers: []interest.Tier{{MinBalance: 0, APR: 1}}},
+}
+
+func TestAccrueInterest(t *testing.T) {
+	ctx := context.Background()
+	date := time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC)
+
+	t.Run("accrues on end of day balances", func(t *testing.T) {
+		db, mock := testutils.NewMockDB()
+		repo := repository.NewInterestRepository(db, logging.Discard())
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`FROM accounts a .* NOT EXISTS`).
+			WithArgs(sqlmock.AnyArg(), date.AddDate(0, 0, 1), date, sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "product_code", "balance"}).
+				AddRow(1, "savings", 36500.0).
+				AddRow(2, "savings", 0.0))
+		insert := mock.ExpectPrepare(`INSERT INTO interest_accruals`)
+		insert.ExpectExec().WithArgs(1, date, "savings", 36500.0, 1.0, 1.0).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		insert.ExpectExec().WithArgs(2, date, "savings", 0.0, 0.0, 0.0).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		written, err := repo.AccrueInterest(ctx, date.Add(15*time.Hour), savingsProduct)
+
+		require.NoError(t, err)
+		assert.Equal(t, 2, written)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("rerun for an accrued day writes nothing", func(t *testing.T) {
+		db, mock := testutils.NewMockDB()
+		repo := repository.NewInterestRepository(db, logging.Discard())
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`FROM accounts a .* NOT EXISTS`).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "product_code", "balance"}))
+		mock.ExpectPrepare(`INSERT INTO interest_accruals`)
+		mock.ExpectCommit()
+
+		written, err := repo.AccrueInterest(ctx, date, savingsProduct)
+
+		require.NoError(t, err)
+		assert.Zero(t, written)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("no interest-bearing products", func(t *testing.T) {
+		db, mock := testutils.NewMockDB()
+		repo := repository.NewInterestRepository(db, logging.Discard())
+
+		written, err := repo.AccrueInterest(ctx, date, nil)
+
+		require.NoError(t, err)
+		assert.Zero(t, written)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestPostInterest(t *testing.T) {
+	ctx := context.Background()
+	before := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	expectLock := func(mock sqlmock.Sqlmock, status string) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT status FROM accounts WHERE id = \$1 FOR UPDATE`).WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow(status))
+	}
+
+	t.Run("credits the rounded sum and marks accruals posted", func(t *testing.T) {
+		db, mock := testutils.NewMockDB()
+		repo := repository.NewInterestRepository(db, logging.Discard())
+
+		expectLock(mock, "active")
+		mock.ExpectQuery(`SELECT COALESCE\(SUM\(amount\), 0\) FROM interest_accruals`).WithArgs(1, before).
+			WillReturnRows(sqlmock.NewRows([]string{"sum"}).AddRow(12.3456))
+		mock.ExpectQuery(`UPDATE accounts SET balance = balance \+ \$1`).WithArgs(12.35, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"balance"}).AddRow(1012.35))
+		mock.ExpectQuery(`INSERT INTO transactions`).WithArgs(1, 12.35, "interest", 1012.35).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(77))
+		mock.ExpectExec(`UPDATE interest_accruals SET posted_transaction_id = \$1`).WithArgs(77, 1, before).
+			WillReturnResult(sqlmock.NewResult(0, 28))
+		mock.ExpectCommit()
+
+		txID, err := repo.PostInterest(ctx, 1, before)
+
+		require.NoError(t, err)
+		assert.Equal(t, 77, txID)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("already posted is a no-op", func(t *testing.T) {
+		db, mock := testutils.NewMockDB()
+		repo := repository.NewInterestRepository(db, logging.Discard())
+
+		expectLock(mock, "active")
+		mock.ExpectQuery(`SELECT COALESCE\(SUM\(amount\), 0\) FROM interest_accruals`).
+			WillReturnRows(sqlmock.NewRows([]string{"sum"}).AddRow(0.0))
+		mock.ExpectRollback()
+
+		txID, err := repo.PostInterest(ctx, 1, before)
+
+		require.NoError(t, err)
+		assert.Zero(t, txID)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("sub-cent interest carries over", func(t *testing.T) {
+		db, mock := testutils.NewMockDB()
+		repo := repository.NewInterestRepository(db, logging.Discard())
+
+		expectLock(mock, "active")
+		mock.ExpectQuery(`SELECT COALESCE\(SUM\(amount\), 0\) FROM interest_accruals`).
+			WillReturnRows(sqlmock.NewRows([]string{"sum"}).AddRow(0.004))
+		mock.ExpectRollback()
+
+		txID, err := repo.PostInterest(ctx, 1, before)
+
+		require.NoError(t, err)
+		assert.Zero(t, txID)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("closed accounts are skipped", func(t *testing.T) {
+		db, mock := testutils.NewMockDB()
+		repo := repository.NewInterestRepository(db, logging.Discard())
+
+		expectLock(mock, "closed")
+		mock.ExpectRollback()
+
+		txID, err := repo.PostInterest(ctx, 1, before)
+
+		require.NoError(t, err)
+		assert.Zero(t, txID)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
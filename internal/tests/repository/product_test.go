@@ -0,0 +1,122 @@
+package repository_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	apperrors "github.com/Andrew44Ashraf/fintech-service/internal/errors"
+	"github.com/Andrew44Ashraf/fintech-service/internal/repository"
+	"github.com/Andrew44Ashraf/fintech-service/internal/tests/testutils"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func ruleRows() *sqlmock.Rows {
+	return sqlmock.NewRows([]string{"id", "allowed_transaction_types", "floor",
+		"max_single_withdrawal", "max_daily_withdrawal", "max_monthly_withdrawal", "max_daily_withdrawals"})
+}
+
+func TestProductRules(t *testing.T) {
+	ctx := context.Background()
+	allTypes := pq.StringArray{"deposit", "withdrawal", "transfer_in", "transfer_out"}
+
+	expectAccount := func(mock sqlmock.Sqlmock, balance float64, rules *sqlmock.Rows) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT balance, status FROM accounts WHERE id = \$1 FOR UPDATE`).
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"balance", "status"}).AddRow(balance, "active"))
+		mock.ExpectQuery(`JOIN account_products`).WillReturnRows(rules)
+	}
+
+	t.Run("escrow accounts cannot withdraw", func(t *testing.T) {
+		repo, mock := testutils.NewMockTransactionRepository()
+		expectAccount(mock, 500, ruleRows().
+			AddRow(1, pq.StringArray{"deposit", "transfer_in", "transfer_out"}, 0.0, 0.0, 0.0, 0.0, 0))
+		mock.ExpectRollback()
+
+		_, err := repo.CreateWithdrawal(ctx, 1, 50, repository.Details{})
+
+		assert.ErrorIs(t, err, repository.ErrTransactionNotAllowed)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("overdraft lets the balance go negative", func(t *testing.T) {
+		repo, mock := testutils.NewMockTransactionRepository()
+		expectAccount(mock, 50, ruleRows().AddRow(1, allTypes, -250.0, 0.0, 0.0, 0.0, 0))
+		mock.ExpectQuery(`FROM account_limits`).WillReturnError(sql.ErrNoRows)
+		mock.ExpectQuery(`JOIN fee_schedules`).WillReturnError(sql.ErrNoRows)
+		mock.ExpectQuery(`INSERT INTO transactions`).WithArgs(1, 200.0, "", "", nil).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(5))
+		mock.ExpectExec(`UPDATE accounts SET balance`).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(`SELECT balance FROM accounts`).
+			WillReturnRows(sqlmock.NewRows([]string{"balance"}).AddRow(-150.0))
+		mock.ExpectExec(`UPDATE transactions SET final_balance`).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		txID, err := repo.CreateWithdrawal(ctx, 1, 200, repository.Details{})
+
+		require.NoError(t, err)
+		assert.Equal(t, 5, txID)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("overdraft is capped", func(t *testing.T) {
+		repo, mock := testutils.NewMockTransactionRepository()
+		expectAccount(mock, 50, ruleRows().AddRow(1, allTypes, -250.0, 0.0, 0.0, 0.0, 0))
+		mock.ExpectRollback()
+
+		_, err := repo.CreateWithdrawal(ctx, 1, 301, repository.Details{})
+
+		assert.ErrorIs(t, err, repository.ErrInsufficientFunds)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("minimum balance must be kept", func(t *testing.T) {
+		repo, mock := testutils.NewMockTransactionRepository()
+		expectAccount(mock, 50, ruleRows().AddRow(1, allTypes, 25.0, 0.0, 0.0, 0.0, 0))
+		mock.ExpectRollback()
+
+		_, err := repo.CreateWithdrawal(ctx, 1, 30, repository.Details{})
+
+		assert.ErrorIs(t, err, repository.ErrInsufficientFunds)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("product limits apply over the defaults", func(t *testing.T) {
+		repo, mock := newLimitedRepo(repository.WithdrawalLimits{MaxSingle: 5000})
+		expectAccount(mock, 3000, ruleRows().AddRow(1, allTypes, 0.0, 1000.0, 2000.0, 0.0, 0))
+		mock.ExpectQuery(`FROM account_limits`).WillReturnError(sql.ErrNoRows)
+		mock.ExpectRollback()
+
+		_, err := repo.CreateWithdrawal(ctx, 1, 1500, repository.Details{})
+
+		var lerr *apperrors.LimitExceededError
+		if assert.True(t, errors.As(err, &lerr)) {
+			assert.Equal(t, apperrors.LimitSingleWithdrawal, lerr.Limit)
+			assert.Equal(t, 1000.0, lerr.Max)
+		}
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("transfers out of a product without transfer_out are refused", func(t *testing.T) {
+		repo, mock := testutils.NewMockTransactionRepository()
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT id, balance, status, currency FROM accounts`).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "balance", "status", "currency"}).
+				AddRow(1, 100.0, "active", "USD").
+				AddRow(2, 0.0, "active", "USD"))
+		mock.ExpectQuery(`JOIN account_products`).WillReturnRows(ruleRows().
+			AddRow(1, pq.StringArray{"deposit", "withdrawal"}, 0.0, 0.0, 0.0, 0.0, 0).
+			AddRow(2, allTypes, 0.0, 0.0, 0.0, 0.0, 0))
+		mock.ExpectRollback()
+
+		_, err := repo.CreateTransfer(ctx, 1, 2, 20)
+
+		assert.ErrorIs(t, err, repository.ErrTransactionNotAllowed)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,132 @@
+package repository_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	apperrors "github.com/Andrew44Ashraf/fintech-service/internal/errors"
+	"github.com/Andrew44Ashraf/fintech-service/internal/repository"
+	"github.com/Andrew44Ashraf/fintech-service/internal/tests/testutils"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolveAccount(t *testing.T) {
+	ctx := context.Background()
+	repo, mock := testutils.NewMockRepository()
+	idRow := func(id int) *sqlmock.Rows { return sqlmock.NewRows([]string{"id"}).AddRow(id) }
+
+	t.Run("by public ID", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT id FROM accounts WHERE public_id = \$1`).
+			WithArgs("acc_0f8fad5bd9cb469fa16570867728950e").
+			WillReturnRows(idRow(7))
+
+		id, err := repo.ResolveAccount(ctx, "acc_0f8fad5bd9cb469fa16570867728950e")
+
+		assert.NoError(t, err)
+		assert.Equal(t, 7, id)
+	})
+
+	t.Run("by account number as printed", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT id FROM accounts WHERE account_number = \$1`).
+			WithArgs("FT430000000000000001").
+			WillReturnRows(idRow(7))
+
+		id, err := repo.ResolveAccount(ctx, "FT43 0000 0000 0000 0001")
+
+		assert.NoError(t, err)
+		assert.Equal(t, 7, id)
+	})
+
+	t.Run("unknown account", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT id FROM accounts`).WillReturnError(sql.ErrNoRows)
+
+		_, err := repo.ResolveAccount(ctx, "acc_0f8fad5bd9cb469fa16570867728950e")
+
+		assert.ErrorIs(t, err, repository.ErrAccountNotFound)
+	})
+
+	t.Run("internal IDs and bad check digits are not looked up", func(t *testing.T) {
+		for _, ref := range []string{"1", "FT440000000000000001", "tx_0f8fad5bd9cb469fa16570867728950e"} {
+			_, err := repo.ResolveAccount(ctx, ref)
+
+			var verr *apperrors.ValidationError
+			assert.ErrorAs(t, err, &verr, ref)
+		}
+	})
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestResolveAccounts(t *testing.T) {
+	ctx := context.Background()
+	repo, mock := testutils.NewMockRepository()
+
+	t.Run("public IDs and numbers in one lookup", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT id, public_id, account_number FROM accounts`).
+			WithArgs(`{"acc_0f8fad5bd9cb469fa16570867728950e","acc_00000000000000000000000000000009"}`, `{"FT430000000000000001"}`).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "public_id", "account_number"}).
+				AddRow(7, "acc_0f8fad5bd9cb469fa16570867728950e", "FT980000000000000007").
+				AddRow(1, "acc_00000000000000000000000000000001", "FT430000000000000001"))
+
+		resolved, err := repo.ResolveAccounts(ctx, []string{
+			"acc_0f8fad5bd9cb469fa16570867728950e",
+			"FT43 0000 0000 0000 0001",
+			"acc_00000000000000000000000000000009",
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]int{
+			"acc_0f8fad5bd9cb469fa16570867728950e": 7,
+			"FT43 0000 0000 0000 0001":             1,
+		}, resolved)
+	})
+
+	t.Run("internal IDs are not looked up", func(t *testing.T) {
+		_, err := repo.ResolveAccounts(ctx, []string{"acc_0f8fad5bd9cb469fa16570867728950e", "7"})
+
+		var verr *apperrors.ValidationError
+		assert.ErrorAs(t, err, &verr)
+	})
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestResolveTransaction(t *testing.T) {
+	ctx := context.Background()
+	repo, mock := testutils.NewMockTransactionRepository()
+
+	mock.ExpectQuery(`SELECT id FROM transactions WHERE public_id = \$1`).
+		WithArgs("tx_0f8fad5bd9cb469fa16570867728950e").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(40))
+
+	id, err := repo.ResolveTransaction(ctx, "tx_0f8fad5bd9cb469fa16570867728950e")
+	assert.NoError(t, err)
+	assert.Equal(t, 40, id)
+
+	_, err = repo.ResolveTransaction(ctx, "40")
+	var verr *apperrors.ValidationError
+	assert.ErrorAs(t, err, &verr)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestResolveScheduledPayment(t *testing.T) {
+	ctx := context.Background()
+	repo, mock := newScheduleRepo()
+
+	mock.ExpectQuery(`SELECT id FROM scheduled_payments WHERE public_id = \$1`).
+		WithArgs("sp_0f8fad5bd9cb469fa16570867728950e").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(4))
+
+	id, err := repo.ResolveScheduledPayment(ctx, "sp_0f8fad5bd9cb469fa16570867728950e")
+	assert.NoError(t, err)
+	assert.Equal(t, 4, id)
+
+	_, err = repo.ResolveScheduledPayment(ctx, "4")
+	var verr *apperrors.ValidationError
+	assert.ErrorAs(t, err, &verr)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,76 @@
+package repository_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/Andrew44Ashraf/fintech-service/internal/logging"
+	"github.com/Andrew44Ashraf/fintech-service/internal/repository"
+	"github.com/Andrew44Ashraf/fintech-service/internal/tests/testutils"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// replicaReads sends every read to one database.
+type replicaReads struct{ db *sql.DB }
+
+func (r replicaReads) Reader(context.Context) *sql.DB { return r.db }
+
+func TestReadRouting(t *testing.T) {
+	ctx := context.Background()
+	primary, pmock := testutils.NewMockDB()
+	replica, rmock := testutils.NewMockDB()
+	defer primary.Close()
+	defer replica.Close()
+
+	accounts := repository.NewAccountRepository(primary, logging.Discard(), repository.WithAccountReads(replicaReads{replica}))
+	transactions := repository.NewTransactionRepository(primary, logging.Discard(), repository.WithTransactionReads(replicaReads{replica}))
+	statements := repository.NewStatementRepository(primary, logging.Discard(), repository.WithStatementReads(replicaReads{replica}))
+
+	t.Run("balances are read through the router", func(t *testing.T) {
+		rmock.ExpectQuery(`SELECT balance FROM accounts WHERE id = \$1`).
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"balance"}).AddRow(50.0))
+
+		balance, err := accounts.GetAccountBalance(ctx, 1)
+
+		require.NoError(t, err)
+		assert.Equal(t, 50.0, balance)
+	})
+
+	t.Run("history is read through the router", func(t *testing.T) {
+		rmock.ExpectQuery(`FROM transactions`).
+			WithArgs(1, 10, 0, "").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "account_id", "amount", "type", "status", "created_at",
+				"final_balance", "external_ref", "description", "metadata", "public_id", "value_date", "booking_date"}))
+
+		_, err := transactions.GetTransactions(ctx, 1, 10, 0, "")
+
+		require.NoError(t, err)
+	})
+
+	t.Run("statements are built through the router", func(t *testing.T) {
+		rmock.ExpectBegin()
+		rmock.ExpectQuery(`SELECT balance, created_at, currency FROM accounts`).
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"balance", "created_at", "currency"}))
+		rmock.ExpectRollback()
+
+		_, err := statements.BuildStatement(ctx, 1, time.Now().AddDate(0, -1, 0), time.Now())
+
+		assert.ErrorIs(t, err, repository.ErrAccountNotFound)
+	})
+
+	t.Run("postings go to the primary", func(t *testing.T) {
+		// The posting fails after BEGIN; only where it started matters
+		pmock.ExpectBegin()
+
+		transactions.CreateDeposit(ctx, 1, 10, repository.Details{})
+	})
+
+	assert.NoError(t, rmock.ExpectationsWereMet())
+	assert.NoError(t, pmock.ExpectationsWereMet())
+}
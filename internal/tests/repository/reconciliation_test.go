@@ -0,0 +1,109 @@
+package repository_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/Andrew44Ashraf/fintech-service/internal/logging"
+	"github.com/Andrew44Ashraf/fintech-service/internal/reconciliation"
+	"github.com/Andrew44Ashraf/fintech-service/internal/repository"
+	"github.com/Andrew44Ashraf/fintech-service/internal/tests/testutils"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newReconciliationRepo() (*repository.ReconciliationRepository, sqlmock.Sqlmock) {
+	db, mock := testutils.NewMockDB()
+	return repository.NewReconciliationRepository(db, logging.Discard()), mock
+}
+
+func TestReconcile(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 4, 1, 2, 0, 0, 0, time.UTC)
+	runDate := time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)
+	balanceRows := func() *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"id", "balance", "journal", "last_id", "last_final_balance"})
+	}
+
+	t.Run("scheduled run snapshots balances and records breaks", func(t *testing.T) {
+		repo, mock := newReconciliationRepo()
+		mock.ExpectBegin()
+		mock.ExpectQuery(`INSERT INTO reconciliation_runs`).
+			WithArgs(runDate, repository.TriggerScheduled).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "started_at"}).AddRow(5, now))
+		mock.ExpectQuery(`FROM accounts a\s+LEFT JOIN LATERAL`).
+			WillReturnRows(balanceRows().
+				AddRow(1, 100.0, 100.0, 10, 100.0).
+				AddRow(2, 90.0, 70.0, 11, 70.0).
+				AddRow(3, 25.0, 25.0, nil, nil))
+		snapshot := mock.ExpectPrepare(`INSERT INTO balance_snapshots`)
+		snapshot.ExpectExec().WithArgs(1, runDate, 100.0, 100.0, 10, 5).WillReturnResult(sqlmock.NewResult(0, 1))
+		snapshot.ExpectExec().WithArgs(2, runDate, 90.0, 70.0, 11, 5).WillReturnResult(sqlmock.NewResult(0, 1))
+		snapshot.ExpectExec().WithArgs(3, runDate, 25.0, 25.0, 0, 5).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(`INSERT INTO reconciliation_breaks`).
+			WithArgs(5, 2, "journal_mismatch", 90.0, 70.0, 20.0).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "created_at", "public_id"}).AddRow(1, now, "acc_2"))
+		mock.ExpectQuery(`INSERT INTO reconciliation_breaks`).
+			WithArgs(5, 2, "final_balance_mismatch", 90.0, 70.0, 20.0).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "created_at", "public_id"}).AddRow(2, now, "acc_2"))
+		mock.ExpectQuery(`UPDATE reconciliation_runs`).
+			WithArgs(3, 2, 5).
+			WillReturnRows(sqlmock.NewRows([]string{"finished_at"}).AddRow(now))
+		mock.ExpectCommit()
+
+		run, err := repo.Reconcile(ctx, repository.TriggerScheduled, now)
+
+		require.NoError(t, err)
+		assert.Equal(t, 3, run.AccountsChecked)
+		assert.Equal(t, 2, run.BreakCount)
+		assert.Equal(t, reconciliation.JournalMismatch, run.Breaks[0].Kind)
+		assert.Equal(t, "acc_2", run.Breaks[0].AccountPublicID)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("scheduled run happens once a day", func(t *testing.T) {
+		repo, mock := newReconciliationRepo()
+		mock.ExpectBegin()
+		mock.ExpectQuery(`INSERT INTO reconciliation_runs`).WillReturnError(sql.ErrNoRows)
+		mock.ExpectRollback()
+
+		run, err := repo.Reconcile(ctx, repository.TriggerScheduled, now)
+
+		assert.NoError(t, err)
+		assert.Nil(t, run)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("manual run does not write snapshots", func(t *testing.T) {
+		repo, mock := newReconciliationRepo()
+		mock.ExpectBegin()
+		mock.ExpectQuery(`INSERT INTO reconciliation_runs`).
+			WithArgs(runDate, repository.TriggerManual).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "started_at"}).AddRow(6, now))
+		mock.ExpectQuery(`FROM accounts a\s+LEFT JOIN LATERAL`).
+			WillReturnRows(balanceRows().AddRow(1, 100.0, 100.0, 10, 100.0))
+		mock.ExpectQuery(`UPDATE reconciliation_runs`).
+			WithArgs(1, 0, 6).
+			WillReturnRows(sqlmock.NewRows([]string{"finished_at"}).AddRow(now))
+		mock.ExpectCommit()
+
+		run, err := repo.Reconcile(ctx, repository.TriggerManual, now)
+
+		require.NoError(t, err)
+		assert.Zero(t, run.BreakCount)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestLatestReconciliationRun(t *testing.T) {
+	repo, mock := newReconciliationRepo()
+	mock.ExpectQuery(`FROM reconciliation_runs`).WillReturnError(sql.ErrNoRows)
+
+	_, err := repo.LatestRun(context.Background())
+
+	assert.ErrorIs(t, err, repository.ErrReconciliationRunNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,130 @@
+package repository_test
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"testing"
+	"time"
+
+	apperrors "github.com/Andrew44Ashraf/fintech-service/internal/errors"
+	"github.com/Andrew44Ashraf/fintech-service/internal/repository"
+	"github.com/Andrew44Ashraf/fintech-service/internal/tests/testutils"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func expectDeposit(mock sqlmock.Sqlmock) {
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT status FROM accounts`).
+		WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow("active"))
+	testutils.ExpectAccountRules(mock)
+	mock.ExpectQuery(`INSERT INTO transactions`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectExec(`UPDATE accounts`).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectQuery(`SELECT balance FROM accounts`).
+		WillReturnRows(sqlmock.NewRows([]string{"balance"}).AddRow(100.0))
+	mock.ExpectExec(`UPDATE transactions`).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+}
+
+func expectAborted(mock sqlmock.Sqlmock, code pq.ErrorCode) {
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT status FROM accounts`).WillReturnError(&pq.Error{Code: code})
+	mock.ExpectRollback()
+}
+
+func txMetric(name, key string) int64 {
+	v := repository.TxMetrics.Get(name).(*expvar.Map).Get(key)
+	if v == nil {
+		return 0
+	}
+	return v.(*expvar.Int).Value()
+}
+
+func TestTransactionRetry(t *testing.T) {
+	ctx := context.Background()
+	repository.SetRetryPolicy(repository.RetryPolicy{MaxAttempts: 3})
+	defer repository.SetRetryPolicy(repository.DefaultRetryPolicy)
+
+	t.Run("serialization failure and deadlock are retried", func(t *testing.T) {
+		repo, mock := testutils.NewMockTransactionRepository()
+		retries := txMetric("retries", "deposit")
+		deadlocks := txMetric("sqlstates", "40P01")
+		expectAborted(mock, "40001")
+		expectAborted(mock, "40P01")
+		expectDeposit(mock)
+
+		txID, err := repo.CreateDeposit(ctx, 1, 100, repository.Details{})
+
+		require.NoError(t, err)
+		assert.Equal(t, 1, txID)
+		assert.Equal(t, retries+2, txMetric("retries", "deposit"))
+		assert.Equal(t, deadlocks+1, txMetric("sqlstates", "40P01"))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("failure at commit is retried", func(t *testing.T) {
+		repo, mock := testutils.NewMockTransactionRepository()
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT status FROM accounts`).
+			WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow("active"))
+		testutils.ExpectAccountRules(mock)
+		mock.ExpectQuery(`INSERT INTO transactions`).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+		mock.ExpectExec(`UPDATE accounts`).WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectQuery(`SELECT balance FROM accounts`).
+			WillReturnRows(sqlmock.NewRows([]string{"balance"}).AddRow(100.0))
+		mock.ExpectExec(`UPDATE transactions`).WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit().WillReturnError(&pq.Error{Code: "40001"})
+		expectDeposit(mock)
+
+		_, err := repo.CreateDeposit(ctx, 1, 100, repository.Details{})
+
+		require.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("attempts are bounded", func(t *testing.T) {
+		repo, mock := testutils.NewMockTransactionRepository()
+		exhausted := txMetric("exhausted", "deposit")
+		for range 3 {
+			expectAborted(mock, "40001")
+		}
+
+		_, err := repo.CreateDeposit(ctx, 1, 100, repository.Details{})
+
+		assert.ErrorIs(t, err, repository.ErrContention)
+		assert.Equal(t, apperrors.CodeContention, apperrors.Lookup(err).Code)
+		assert.Equal(t, exhausted+1, txMetric("exhausted", "deposit"))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("other errors are not retried", func(t *testing.T) {
+		repo, mock := testutils.NewMockTransactionRepository()
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT status FROM accounts`).WillReturnError(&pq.Error{Code: "23505"})
+		mock.ExpectRollback()
+
+		_, err := repo.CreateDeposit(ctx, 1, 100, repository.Details{})
+
+		var pqErr *pq.Error
+		assert.True(t, errors.As(err, &pqErr))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("context cancellation stops retrying", func(t *testing.T) {
+		repository.SetRetryPolicy(repository.RetryPolicy{MaxAttempts: 3, BaseDelay: time.Hour, MaxDelay: time.Hour})
+		defer repository.SetRetryPolicy(repository.RetryPolicy{MaxAttempts: 3})
+		repo, mock := testutils.NewMockTransactionRepository()
+		expectAborted(mock, "40001")
+		ctx, cancel := context.WithCancel(ctx)
+		cancel()
+
+		_, err := repo.CreateDeposit(ctx, 1, 100, repository.Details{})
+
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+}
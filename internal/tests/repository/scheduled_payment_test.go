@@ -0,0 +1,212 @@
+package repository_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/Andrew44Ashraf/fintech-service/internal/logging"
+	"github.com/Andrew44Ashraf/fintech-service/internal/repository"
+	"github.com/Andrew44Ashraf/fintech-service/internal/tests/testutils"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	scheduleStart = time.Date(2026, 1, 1, 6, 0, 0, 0, time.UTC)
+	scheduleDue   = time.Date(2026, 3, 1, 6, 0, 0, 0, time.UTC)
+	scheduleNow   = time.Date(2026, 3, 1, 6, 1, 0, 0, time.UTC)
+)
+
+const (
+	testSchedule        = "sp_00000000000000000000000000000004"
+	testScheduleAccount = "acc_00000000000000000000000000000001"
+)
+
+func newScheduleRepo() (*repository.ScheduledPaymentRepository, sqlmock.Sqlmock) {
+	db, mock := testutils.NewMockDB()
+	txRepo := repository.NewTransactionRepository(db, logging.Discard())
+	return repository.NewScheduledPaymentRepository(db, logging.Discard(), txRepo), mock
+}
+
+// dueWithdrawal is a monthly withdrawal of 100 from account 1 due on March 1st.
+func dueWithdrawal(policy string, retryCount int) *sqlmock.Rows {
+	return sqlmock.NewRows([]string{
+		"id", "public_id", "account_id", "account_public_id", "to_account_id", "to_account_public_id", "amount", "description",
+		"cron_expr", "interval_count", "interval_unit",
+		"start_at", "end_at", "due_at", "next_run_at", "on_insufficient_funds", "max_retries", "retry_count",
+		"status", "created_at", "updated_at",
+	}).AddRow(
+		4, testSchedule, 1, testScheduleAccount, 0, "", 100.0, "rent",
+		"", 1, "month",
+		scheduleStart, nil, scheduleDue, scheduleDue, policy, 2, retryCount,
+		"active", scheduleStart, scheduleStart,
+	)
+}
+
+func expectClaim(mock sqlmock.Sqlmock, rows *sqlmock.Rows) {
+	mock.ExpectBegin()
+	mock.ExpectQuery(`FROM scheduled_payments .* FOR UPDATE SKIP LOCKED`).
+		WithArgs(scheduleNow).
+		WillReturnRows(rows)
+	mock.ExpectExec(`^SAVEPOINT scheduled_payment`).WillReturnResult(sqlmock.NewResult(0, 0))
+}
+
+func expectLowBalance(mock sqlmock.Sqlmock) {
+	mock.ExpectQuery(`SELECT balance, status FROM accounts`).WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"balance", "status"}).AddRow(20.0, "active"))
+	testutils.ExpectAccountRules(mock)
+	mock.ExpectExec(`^ROLLBACK TO SAVEPOINT scheduled_payment`).WillReturnResult(sqlmock.NewResult(0, 0))
+}
+
+func TestRunNextScheduledPayment(t *testing.T) {
+	ctx := context.Background()
+	nextMonth := time.Date(2026, 4, 1, 6, 0, 0, 0, time.UTC)
+
+	t.Run("nothing due", func(t *testing.T) {
+		repo, mock := newScheduleRepo()
+		mock.ExpectBegin()
+		mock.ExpectQuery(`FOR UPDATE SKIP LOCKED`).WillReturnError(sql.ErrNoRows)
+		mock.ExpectRollback()
+
+		ran, err := repo.RunNext(ctx, scheduleNow)
+
+		assert.NoError(t, err)
+		assert.False(t, ran)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("posts and moves to the next occurrence", func(t *testing.T) {
+		repo, mock := newScheduleRepo()
+		expectClaim(mock, dueWithdrawal(repository.OnInsufficientFundsSkip, 0))
+		mock.ExpectQuery(`SELECT balance, status FROM accounts`).WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"balance", "status"}).AddRow(500.0, "active"))
+		testutils.ExpectAccountRules(mock)
+		mock.ExpectQuery(`FROM account_limits`).WillReturnError(sql.ErrNoRows)
+		mock.ExpectQuery(`JOIN fee_schedules`).WillReturnError(sql.ErrNoRows)
+		mock.ExpectQuery(`INSERT INTO transactions`).WithArgs(1, 100.0, "", "", nil).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(31))
+		mock.ExpectExec(`UPDATE accounts SET balance`).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(`SELECT balance FROM accounts`).
+			WillReturnRows(sqlmock.NewRows([]string{"balance"}).AddRow(400.0))
+		mock.ExpectExec(`UPDATE transactions SET final_balance`).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`INSERT INTO scheduled_payment_runs`).
+			WithArgs(4, scheduleDue, 1, repository.RunPosted, 31, "").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec(`UPDATE scheduled_payments`).
+			WithArgs(nextMonth, nextMonth, 0, repository.ScheduleActive, 4).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		ran, err := repo.RunNext(ctx, scheduleNow)
+
+		assert.NoError(t, err)
+		assert.True(t, ran)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("skip policy moves on when funds are short", func(t *testing.T) {
+		repo, mock := newScheduleRepo()
+		expectClaim(mock, dueWithdrawal(repository.OnInsufficientFundsSkip, 0))
+		expectLowBalance(mock)
+		mock.ExpectExec(`INSERT INTO scheduled_payment_runs`).
+			WithArgs(4, scheduleDue, 1, repository.RunSkipped, 0, "insufficient_funds").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec(`UPDATE scheduled_payments`).
+			WithArgs(nextMonth, nextMonth, 0, repository.ScheduleActive, 4).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		_, err := repo.RunNext(ctx, scheduleNow)
+
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("retry policy keeps the occurrence and tries again later", func(t *testing.T) {
+		repo, mock := newScheduleRepo()
+		expectClaim(mock, dueWithdrawal(repository.OnInsufficientFundsRetry, 0))
+		expectLowBalance(mock)
+		mock.ExpectExec(`INSERT INTO scheduled_payment_runs`).
+			WithArgs(4, scheduleDue, 1, repository.RunRetrying, 0, "insufficient_funds").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec(`UPDATE scheduled_payments`).
+			WithArgs(scheduleDue, scheduleNow.Add(repository.ScheduleRetryDelay), 1, repository.ScheduleActive, 4).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		_, err := repo.RunNext(ctx, scheduleNow)
+
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("retries exhausted", func(t *testing.T) {
+		repo, mock := newScheduleRepo()
+		expectClaim(mock, dueWithdrawal(repository.OnInsufficientFundsRetry, 2))
+		expectLowBalance(mock)
+		mock.ExpectExec(`INSERT INTO scheduled_payment_runs`).
+			WithArgs(4, scheduleDue, 3, repository.RunFailed, 0, "insufficient_funds").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec(`UPDATE scheduled_payments`).
+			WithArgs(nextMonth, nextMonth, 0, repository.ScheduleActive, 4).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		_, err := repo.RunNext(ctx, scheduleNow)
+
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestCreateScheduledPaymentRejectsPastOneOff(t *testing.T) {
+	repo, mock := newScheduleRepo()
+
+	err := repo.CreateScheduledPayment(context.Background(), &repository.ScheduledPayment{
+		AccountID: 1,
+		Amount:    10,
+		StartAt:   scheduleStart,
+	}, scheduleNow)
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "start_at")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetScheduledPaymentPublicIDs(t *testing.T) {
+	repo, mock := newScheduleRepo()
+	mock.ExpectQuery(`FROM scheduled_payments WHERE id`).WithArgs(4).
+		WillReturnRows(dueWithdrawal(repository.OnInsufficientFundsSkip, 0))
+
+	p, err := repo.GetScheduledPayment(context.Background(), 4)
+
+	require.NoError(t, err)
+	assert.Equal(t, testSchedule, p.PublicID)
+	assert.Equal(t, testScheduleAccount, p.AccountPublicID)
+	assert.Empty(t, p.ToAccountPublicID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCancelScheduledPayment(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("already ended", func(t *testing.T) {
+		repo, mock := newScheduleRepo()
+		mock.ExpectExec(`UPDATE scheduled_payments`).WithArgs(4).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(`FROM scheduled_payments WHERE id`).WithArgs(4).
+			WillReturnRows(dueWithdrawal(repository.OnInsufficientFundsSkip, 0))
+
+		assert.ErrorIs(t, repo.CancelScheduledPayment(ctx, 4), repository.ErrScheduledPaymentEnded)
+	})
+
+	t.Run("missing", func(t *testing.T) {
+		repo, mock := newScheduleRepo()
+		mock.ExpectExec(`UPDATE scheduled_payments`).WithArgs(4).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(`FROM scheduled_payments WHERE id`).WithArgs(4).WillReturnError(sql.ErrNoRows)
+
+		assert.ErrorIs(t, repo.CancelScheduledPayment(ctx, 4), repository.ErrScheduledPaymentNotFound)
+	})
+}
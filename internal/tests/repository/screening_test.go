@@ -0,0 +1,148 @@
+package repository_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	apperrors "github.com/Andrew44Ashraf/fintech-service/internal/errors"
+	"github.com/Andrew44Ashraf/fintech-service/internal/logging"
+	"github.com/Andrew44Ashraf/fintech-service/internal/repository"
+	"github.com/Andrew44Ashraf/fintech-service/internal/screening"
+	"github.com/Andrew44Ashraf/fintech-service/internal/tests/testutils"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+type fixedScreener screening.Result
+
+func (s fixedScreener) Screen(context.Context, screening.Request) (screening.Result, error) {
+	return screening.Result(s), nil
+}
+
+func TestDepositScreening(t *testing.T) {
+	ctx := context.Background()
+
+	expectActiveAccount := func(mock sqlmock.Sqlmock) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT status FROM accounts`).
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow("active"))
+		testutils.ExpectAccountRules(mock)
+	}
+
+	t.Run("review holds the transaction without moving money", func(t *testing.T) {
+		db, mock := testutils.NewMockDB()
+		repo := repository.NewTransactionRepository(db, logging.Discard(),
+			repository.WithScreener(fixedScreener{Decision: screening.Review, Reasons: []string{"round"}}))
+
+		expectActiveAccount(mock)
+		mock.ExpectQuery(`INSERT INTO transactions .* 'pending_review'`).
+			WithArgs(1, 6000.0, "deposit", "", "", nil).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+		mock.ExpectExec(`INSERT INTO transaction_reviews`).WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		txID, err := repo.CreateDeposit(ctx, 1, 6000, repository.Details{})
+
+		assert.ErrorIs(t, err, repository.ErrPendingReview)
+		assert.Equal(t, 7, txID)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("deny rolls back", func(t *testing.T) {
+		db, mock := testutils.NewMockDB()
+		repo := repository.NewTransactionRepository(db, logging.Discard(),
+			repository.WithScreener(fixedScreener{Decision: screening.Deny, Reasons: []string{"huge"}}))
+
+		expectActiveAccount(mock)
+		mock.ExpectRollback()
+
+		_, err := repo.CreateDeposit(ctx, 1, 6000, repository.Details{})
+
+		assert.ErrorIs(t, err, repository.ErrTransactionDenied)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestApproveTransaction(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("posts a held withdrawal", func(t *testing.T) {
+		repo, mock := testutils.NewMockTransactionRepository()
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT account_id, amount, type, status FROM transactions`).
+			WithArgs(7).
+			WillReturnRows(sqlmock.NewRows([]string{"account_id", "amount", "type", "status"}).AddRow(1, 50.0, "withdrawal", "pending_review"))
+		mock.ExpectQuery(`SELECT balance, status FROM accounts`).
+			WillReturnRows(sqlmock.NewRows([]string{"balance", "status"}).AddRow(80.0, "active"))
+		testutils.ExpectAccountRules(mock)
+		mock.ExpectQuery(`FROM account_limits`).WillReturnError(sql.ErrNoRows)
+		mock.ExpectQuery(`JOIN fee_schedules`).WithArgs(1).WillReturnError(sql.ErrNoRows)
+		mock.ExpectQuery(`UPDATE accounts SET balance`).
+			WithArgs(-50.0, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"balance"}).AddRow(30.0))
+		mock.ExpectExec(`UPDATE transactions SET status = 'posted'`).
+			WithArgs(30.0, 7).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`UPDATE transaction_reviews`).
+			WithArgs("approved", "ops@example.com", "", 7).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		assert.NoError(t, repo.ApproveTransaction(ctx, 7, "ops@example.com", ""))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("held withdrawals count against the limits when approved", func(t *testing.T) {
+		repo, mock := newLimitedRepo(repository.WithdrawalLimits{MaxDailyCount: 2})
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT account_id, amount, type, status FROM transactions`).
+			WillReturnRows(sqlmock.NewRows([]string{"account_id", "amount", "type", "status"}).AddRow(1, 50.0, "withdrawal", "pending_review"))
+		mock.ExpectQuery(`SELECT balance, status FROM accounts`).
+			WillReturnRows(sqlmock.NewRows([]string{"balance", "status"}).AddRow(800.0, "active"))
+		testutils.ExpectAccountRules(mock)
+		mock.ExpectQuery(`FROM account_limits`).WillReturnError(sql.ErrNoRows)
+		// Two other held withdrawals were approved earlier today
+		mock.ExpectQuery(`type IN \('withdrawal', 'transfer_out'\) AND status = 'posted'`).
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"daily", "count", "monthly"}).AddRow(100.0, 2, 100.0))
+		mock.ExpectRollback()
+
+		err := repo.ApproveTransaction(ctx, 7, "ops", "")
+
+		var lerr *apperrors.LimitExceededError
+		if assert.ErrorAs(t, err, &lerr) {
+			assert.Equal(t, apperrors.LimitDailyWithdrawals, lerr.Limit)
+		}
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("product no longer allows the type", func(t *testing.T) {
+		repo, mock := testutils.NewMockTransactionRepository()
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT account_id, amount, type, status FROM transactions`).
+			WillReturnRows(sqlmock.NewRows([]string{"account_id", "amount", "type", "status"}).AddRow(1, 50.0, "withdrawal", "pending_review"))
+		mock.ExpectQuery(`SELECT balance, status FROM accounts`).
+			WillReturnRows(sqlmock.NewRows([]string{"balance", "status"}).AddRow(800.0, "active"))
+		mock.ExpectQuery(`JOIN account_products`).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "allowed_transaction_types", "floor", "a", "b", "c", "d"}).
+				AddRow(1, "{deposit}", 0.0, 0.0, 0.0, 0.0, 0))
+		mock.ExpectRollback()
+
+		err := repo.ApproveTransaction(ctx, 7, "ops", "")
+		assert.ErrorIs(t, err, repository.ErrTransactionNotAllowed)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("already decided", func(t *testing.T) {
+		repo, mock := testutils.NewMockTransactionRepository()
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT account_id, amount, type, status FROM transactions`).
+			WillReturnRows(sqlmock.NewRows([]string{"account_id", "amount", "type", "status"}).AddRow(1, 50.0, "withdrawal", "posted"))
+		mock.ExpectRollback()
+
+		err := repo.ApproveTransaction(ctx, 7, "ops", "")
+		assert.ErrorIs(t, err, repository.ErrInvalidTransactionState)
+	})
+}
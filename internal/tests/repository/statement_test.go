@@ -0,0 +1,109 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Andrew44Ashraf/fintech-service/internal/logging"
+	"github.com/Andrew44Ashraf/fintech-service/internal/repository"
+	"github.com/Andrew44Ashraf/fintech-service/internal/tests/testutils"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildStatement(t *testing.T) {
+	ctx := context.Background()
+	from := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 1, 0)
+	lineCols := []string{"id", "type", "amount", "posted_at", "value_date", "final_balance"}
+	pendingCols := []string{"id", "type", "amount", "created_at", "value_date"}
+
+	t.Run("opening balance from previous transaction", func(t *testing.T) {
+		db, mock := testutils.NewMockDB()
+		repo := repository.NewStatementRepository(db, logging.Discard())
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT balance, created_at, currency FROM accounts`).
+			WillReturnRows(sqlmock.NewRows([]string{"balance", "created_at", "currency"}).AddRow(500.0, from.AddDate(-1, 0, 0), "USD"))
+		mock.ExpectQuery(`posted_at >= \$2 AND posted_at < \$3`).
+			WithArgs(1, from, to).
+			WillReturnRows(sqlmock.NewRows(lineCols).
+				AddRow(10, "withdrawal", 20.0, from.Add(time.Hour), from, 180.0).
+				AddRow(11, "deposit", 70.0, from.Add(2*time.Hour), from, 250.0))
+		mock.ExpectQuery(`status IN \('pending', 'pending_review'\)`).WithArgs(1, to).
+			WillReturnRows(sqlmock.NewRows(pendingCols))
+		mock.ExpectQuery(`posted_at < \$2`).
+			WillReturnRows(sqlmock.NewRows([]string{"final_balance"}).AddRow(200.0))
+		mock.ExpectRollback()
+
+		stmt, err := repo.BuildStatement(ctx, 1, from, to)
+		require.NoError(t, err)
+		assert.Equal(t, 200.0, stmt.OpeningBalance)
+		assert.Equal(t, 250.0, stmt.ClosingBalance)
+		assert.Len(t, stmt.Totals, 2)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("opening balance worked back from first line", func(t *testing.T) {
+		db, mock := testutils.NewMockDB()
+		repo := repository.NewStatementRepository(db, logging.Discard())
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT balance, created_at, currency FROM accounts`).
+			WillReturnRows(sqlmock.NewRows([]string{"balance", "created_at", "currency"}).AddRow(500.0, from.AddDate(-1, 0, 0), "USD"))
+		mock.ExpectQuery(`posted_at >= \$2 AND posted_at < \$3`).
+			WillReturnRows(sqlmock.NewRows(lineCols).AddRow(10, "withdrawal", 20.0, from.Add(time.Hour), from, 80.0))
+		mock.ExpectQuery(`status IN \('pending', 'pending_review'\)`).
+			WillReturnRows(sqlmock.NewRows(pendingCols))
+		mock.ExpectQuery(`posted_at < \$2`).
+			WillReturnRows(sqlmock.NewRows([]string{"final_balance"}))
+		mock.ExpectRollback()
+
+		stmt, err := repo.BuildStatement(ctx, 1, from, to)
+		require.NoError(t, err)
+		assert.Equal(t, 100.0, stmt.OpeningBalance)
+		assert.Equal(t, 80.0, stmt.ClosingBalance)
+	})
+
+	t.Run("pending transfers are listed apart from the balances", func(t *testing.T) {
+		db, mock := testutils.NewMockDB()
+		repo := repository.NewStatementRepository(db, logging.Discard())
+		valueDate := to.AddDate(0, 0, -1)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT balance, created_at, currency FROM accounts`).
+			WillReturnRows(sqlmock.NewRows([]string{"balance", "created_at", "currency"}).AddRow(500.0, from.AddDate(-1, 0, 0), "USD"))
+		mock.ExpectQuery(`posted_at >= \$2 AND posted_at < \$3`).
+			WillReturnRows(sqlmock.NewRows(lineCols).AddRow(10, "deposit", 100.0, from.Add(time.Hour), from, 300.0))
+		// Recorded and valued in the period but not posted by its end
+		mock.ExpectQuery(`status IN \('pending', 'pending_review'\) OR \(status = 'posted' AND posted_at >= \$2\)`).WithArgs(1, to).
+			WillReturnRows(sqlmock.NewRows(pendingCols).AddRow(12, "bank_transfer_in", 250.0, valueDate.Add(time.Hour), valueDate))
+		mock.ExpectQuery(`posted_at < \$2`).
+			WillReturnRows(sqlmock.NewRows([]string{"final_balance"}).AddRow(200.0))
+		mock.ExpectRollback()
+
+		stmt, err := repo.BuildStatement(ctx, 1, from, to)
+		require.NoError(t, err)
+		assert.Equal(t, 300.0, stmt.ClosingBalance)
+		require.Len(t, stmt.Pending, 1)
+		assert.Equal(t, 12, stmt.Pending[0].TransactionID)
+		assert.Equal(t, valueDate, stmt.Pending[0].ValueDay())
+		assert.Len(t, stmt.Totals, 1, "pending transactions are not totalled")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("unknown account", func(t *testing.T) {
+		db, mock := testutils.NewMockDB()
+		repo := repository.NewStatementRepository(db, logging.Discard())
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT balance, created_at, currency FROM accounts`).
+			WillReturnRows(sqlmock.NewRows([]string{"balance", "created_at", "currency"}))
+		mock.ExpectRollback()
+
+		_, err := repo.BuildStatement(ctx, 1, from, to)
+		assert.ErrorIs(t, err, repository.ErrAccountNotFound)
+	})
+}
@@ -0,0 +1,112 @@
+package repository_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	apperrors "github.com/Andrew44Ashraf/fintech-service/internal/errors"
+	"github.com/Andrew44Ashraf/fintech-service/internal/logging"
+	"github.com/Andrew44Ashraf/fintech-service/internal/repository"
+	"github.com/Andrew44Ashraf/fintech-service/internal/tests/testutils"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func newLimitedRepo(limits repository.WithdrawalLimits) (*repository.TransactionRepository, sqlmock.Sqlmock) {
+	db, mock := testutils.NewMockDB()
+	return repository.NewTransactionRepository(db, logging.Discard(), repository.WithWithdrawalLimits(limits)), mock
+}
+
+func expectLockedAccount(mock sqlmock.Sqlmock, balance float64) {
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT balance, status FROM accounts WHERE id = \$1 FOR UPDATE`).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"balance", "status"}).AddRow(balance, "active"))
+	testutils.ExpectAccountRules(mock)
+}
+
+func TestWithdrawalLimits(t *testing.T) {
+	ctx := context.Background()
+	noOverride := sqlmock.NewRows([]string{"max_single_withdrawal", "max_daily_withdrawal", "max_monthly_withdrawal", "max_daily_withdrawals"})
+
+	t.Run("single withdrawal limit", func(t *testing.T) {
+		repo, mock := newLimitedRepo(repository.WithdrawalLimits{MaxSingle: 100})
+		expectLockedAccount(mock, 1000)
+		mock.ExpectQuery(`FROM account_limits`).WillReturnRows(noOverride)
+		mock.ExpectRollback()
+
+		_, err := repo.CreateWithdrawal(ctx, 1, 150, repository.Details{})
+
+		var lerr *apperrors.LimitExceededError
+		assert.ErrorIs(t, err, repository.ErrLimitExceeded)
+		if assert.True(t, errors.As(err, &lerr)) {
+			assert.Equal(t, apperrors.LimitSingleWithdrawal, lerr.Limit)
+		}
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("daily amount reports remaining allowance", func(t *testing.T) {
+		repo, mock := newLimitedRepo(repository.WithdrawalLimits{MaxDaily: 500})
+		expectLockedAccount(mock, 1000)
+		mock.ExpectQuery(`FROM account_limits`).WillReturnRows(noOverride)
+		mock.ExpectQuery(`FROM transactions`).
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"daily", "count", "monthly"}).AddRow(400.0, 2, 400.0))
+		mock.ExpectRollback()
+
+		_, err := repo.CreateWithdrawal(ctx, 1, 150, repository.Details{})
+
+		var lerr *apperrors.LimitExceededError
+		if assert.True(t, errors.As(err, &lerr)) {
+			assert.Equal(t, apperrors.LimitDailyWithdrawal, lerr.Limit)
+			assert.Equal(t, 100.0, lerr.Remaining)
+		}
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("account override replaces default", func(t *testing.T) {
+		repo, mock := newLimitedRepo(repository.WithdrawalLimits{MaxDailyCount: 10})
+		expectLockedAccount(mock, 1000)
+		mock.ExpectQuery(`FROM account_limits`).
+			WillReturnRows(sqlmock.NewRows([]string{"a", "b", "c", "d"}).AddRow(nil, nil, nil, 2))
+		mock.ExpectQuery(`FROM transactions`).
+			WillReturnRows(sqlmock.NewRows([]string{"daily", "count", "monthly"}).AddRow(20.0, 2, 20.0))
+		mock.ExpectRollback()
+
+		_, err := repo.CreateWithdrawal(ctx, 1, 5, repository.Details{})
+
+		var lerr *apperrors.LimitExceededError
+		if assert.True(t, errors.As(err, &lerr)) {
+			assert.Equal(t, apperrors.LimitDailyWithdrawals, lerr.Limit)
+			assert.Equal(t, 2.0, lerr.Max)
+		}
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestTransferLimits(t *testing.T) {
+	ctx := context.Background()
+	repo, mock := newLimitedRepo(repository.WithdrawalLimits{MaxDaily: 500})
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT id, balance, status, currency FROM accounts`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "balance", "status", "currency"}).
+			AddRow(1, 1000.0, "active", "USD").
+			AddRow(2, 0.0, "active", "USD"))
+	testutils.ExpectAccountRules(mock)
+	mock.ExpectQuery(`FROM account_limits`).WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"a", "b", "c", "d"}))
+	// Earlier withdrawals and transfers out count alike
+	mock.ExpectQuery(`type IN \('withdrawal', 'transfer_out'\)`).WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"daily", "count", "monthly"}).AddRow(400.0, 2, 400.0))
+	mock.ExpectRollback()
+
+	_, err := repo.CreateTransfer(ctx, 1, 2, 150)
+
+	var lerr *apperrors.LimitExceededError
+	if assert.True(t, errors.As(err, &lerr)) {
+		assert.Equal(t, apperrors.LimitDailyWithdrawal, lerr.Limit)
+		assert.Equal(t, 100.0, lerr.Remaining)
+	}
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,189 @@
+package routes_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Andrew44Ashraf/fintech-service/internal/auth"
+	"github.com/Andrew44Ashraf/fintech-service/internal/logging"
+	"github.com/Andrew44Ashraf/fintech-service/internal/middleware"
+	"github.com/Andrew44Ashraf/fintech-service/internal/ratelimit"
+	"github.com/Andrew44Ashraf/fintech-service/internal/routes"
+	"github.com/Andrew44Ashraf/fintech-service/internal/tests/testutils"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	adminToken = "ops-token"
+	bravoKey   = "key-bravo"
+
+	// IDs of tenant alpha's resources that tenant bravo tries to reach.
+	alphaAccount     = "acc_0123456789abcdef0123456789abcdef"
+	alphaTransaction = "tx_0123456789abcdef0123456789abcdef"
+	alphaSchedule    = "sp_0123456789abcdef0123456789abcdef"
+	alphaID          = "1"
+)
+
+// probe is a request to a route that gets past validation, so the handler
+// goes to the database.
+type probe struct {
+	query       string
+	contentType string
+	body        string
+	ifMatch     string
+}
+
+// probes has a request for every route that needs more than its path.
+// Routes not listed are probed with an empty GET, DELETE or POST.
+var probes = map[string]probe{
+	"POST /api/accounts":                             {body: `{}`},
+	"POST /api/accounts/:id/deposit":                 {body: `{"amount": 10}`},
+	"POST /api/accounts/:id/withdraw":                {body: `{"amount": 10}`},
+	"POST /api/accounts/:id/scheduled-payments":      {body: `{"amount": 10, "every": 1, "unit": "month", "start_at": "2030-01-01T00:00:00Z"}`},
+	"PATCH /api/scheduled-payments/:id":              {body: `{"amount": 20}`},
+	"POST /api/admin/reviews/:id/approve":            {body: `{"operator": "ops"}`},
+	"POST /api/admin/reviews/:id/reject":             {body: `{"operator": "ops"}`},
+	"PATCH /api/admin/accounts/:id":                  {body: `{"status": "frozen", "operator": "ops", "reason": "probe"}`, ifMatch: "*"},
+	"PUT /api/admin/accounts/:id/limits":             {body: `{"max_single_withdrawal": 100}`},
+	"POST /api/admin/accounts/:id/fee-waivers":       {body: `{"fee_type": "withdrawal", "reason": "probe", "operator": "ops"}`},
+	"POST /api/admin/fee-waivers/:id/revoke":         {body: `{"operator": "ops", "reason": "probe"}`},
+	"POST /api/admin/fees/:id/reverse":               {body: `{"operator": "ops", "reason": "probe"}`},
+	"POST /api/admin/balances":                       {body: `{"as_of": "2026-01-01T00:00:00Z", "account_ids": ["acc_0123456789abcdef0123456789abcdef"]}`},
+	"POST /api/admin/bank-statements":                {query: "operator=ops&format=csv", contentType: "text/csv", body: "booking_date,amount,reference\n2026-03-02,10,x\n"},
+	"POST /api/admin/bank-statements/:id/auto-match": {query: "operator=ops"},
+	"POST /api/admin/bank-matches":                   {body: `{"line_id": 1, "transaction_id": "` + alphaTransaction + `", "operator": "ops"}`},
+	"POST /api/admin/bank-matches/:id/unmatch":       {body: `{"operator": "ops", "reason": "probe"}`},
+	"POST /api/batches":                              {contentType: "text/csv", body: "type,account_id,amount\ndeposit," + alphaAccount + ",10\n"},
+}
+
+// noDatabase lists routes that do not touch tenant data.
+var noDatabase = map[string]bool{
+	"GET /api/admin/metrics": true,
+}
+
+func newTenantRouter(t *testing.T) (*gin.Engine, *testutils.TenantLog) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	db, log := testutils.NewTenantDB()
+	t.Cleanup(func() { db.Close() })
+
+	r := gin.New()
+	// Recovery as in production: the fake database returns no row even
+	// where Postgres always would, which some handlers do not expect
+	r.Use(gin.Recovery(), middleware.RequestID())
+	routes.SetupRoutes(r, db, logging.Discard(), routes.Config{
+		Limiter:    ratelimit.NewMemoryStore(),
+		Keys:       auth.NewStaticKeys("alpha:key-alpha", "bravo:"+bravoKey),
+		AdminToken: adminToken,
+	})
+	return r, log
+}
+
+// crossTenantRequest builds a request from tenant bravo for route that
+// addresses tenant alpha's resources. Client requests also claim to be
+// alpha in X-Tenant-ID, which only operators may set.
+func crossTenantRequest(t *testing.T, route gin.RouteInfo) *http.Request {
+	t.Helper()
+	key := route.Method + " " + route.Path
+	p := probes[key]
+
+	path := route.Path
+	for _, seg := range strings.Split(route.Path, "/") {
+		if !strings.HasPrefix(seg, ":") {
+			continue
+		}
+		id := alphaID
+		switch {
+		case strings.HasPrefix(route.Path, "/api/accounts/:id"), strings.HasPrefix(route.Path, "/api/admin/accounts/:id"):
+			id = alphaAccount
+		case strings.HasPrefix(route.Path, "/api/admin/reviews/:id"), strings.HasPrefix(route.Path, "/api/admin/incoming-transfers/:id"),
+			strings.HasPrefix(route.Path, "/api/admin/fees/:id"):
+			id = alphaTransaction
+		case strings.HasPrefix(route.Path, "/api/scheduled-payments/:id"):
+			id = alphaSchedule
+		}
+		path = strings.Replace(path, seg, id, 1)
+	}
+	if p.query != "" {
+		path += "?" + p.query
+	}
+
+	req, err := http.NewRequest(route.Method, path, strings.NewReader(p.body))
+	require.NoError(t, err)
+	if p.body != "" {
+		contentType := p.contentType
+		if contentType == "" {
+			contentType = "application/json"
+		}
+		req.Header.Set("Content-Type", contentType)
+	}
+	if p.ifMatch != "" {
+		req.Header.Set("If-Match", p.ifMatch)
+	}
+
+	if strings.HasPrefix(route.Path, "/api/admin/") || strings.HasPrefix(route.Path, "/api/batches") {
+		req.Header.Set(middleware.AdminTokenHeader, adminToken)
+		req.Header.Set(middleware.TenantHeader, "bravo")
+	} else {
+		req.Header.Set(middleware.APIKeyHeader, bravoKey)
+		req.Header.Set(middleware.TenantHeader, "alpha")
+	}
+	return req
+}
+
+// TestCrossTenantAccess calls every route as tenant bravo with the IDs of
+// tenant alpha's resources and checks that every statement the request ran
+// was scoped to bravo. Row-level security then hides alpha's rows, which
+// the fake database stands in for by returning none.
+func TestCrossTenantAccess(t *testing.T) {
+	router, log := newTenantRouter(t)
+
+	for _, route := range router.Routes() {
+		key := route.Method + " " + route.Path
+		t.Run(key, func(t *testing.T) {
+			log.Reset()
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, crossTenantRequest(t, route))
+
+			statements := log.Statements()
+			if !noDatabase[key] {
+				require.NotEmpty(t, statements, "the probe did not reach the database (status %d: %s)", w.Code, w.Body.String())
+			}
+			for _, s := range statements {
+				assert.Equal(t, "bravo", s.Tenant, s.Query)
+			}
+			if strings.Contains(route.Path, ":") {
+				assert.GreaterOrEqual(t, w.Code, http.StatusBadRequest, "alpha's resource was reached: %s", w.Body.String())
+			}
+		})
+	}
+}
+
+// TestRoutesRequireTenant checks that no route reaches the database
+// without an authenticated tenant.
+func TestRoutesRequireTenant(t *testing.T) {
+	router, log := newTenantRouter(t)
+
+	for _, route := range router.Routes() {
+		key := route.Method + " " + route.Path
+		t.Run(key, func(t *testing.T) {
+			log.Reset()
+			req := crossTenantRequest(t, route)
+			req.Header.Del(middleware.APIKeyHeader)
+			req.Header.Del(middleware.TenantHeader)
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			if !noDatabase[key] {
+				assert.Contains(t, []int{http.StatusUnauthorized, http.StatusBadRequest}, w.Code)
+			}
+			assert.Empty(t, log.Statements())
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package screening_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Andrew44Ashraf/fintech-service/internal/screening"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeFacts struct {
+	openedAt time.Time
+	activity screening.Activity
+}
+
+func (f fakeFacts) AccountOpenedAt(context.Context) (time.Time, error) {
+	return f.openedAt, nil
+}
+
+func (f fakeFacts) RecentActivity(context.Context, time.Duration, []string) (screening.Activity, error) {
+	return f.activity, nil
+}
+
+func TestShippedRulesLoad(t *testing.T) {
+	_, err := screening.LoadRules(filepath.Join("..", "..", "..", "configs", "screening_rules.yaml"))
+	assert.NoError(t, err)
+}
+
+func TestLoadRulesRejectsInvalidRules(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rules.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("rules:\n  - name: bad\n    kind: velocity\n    action: review\n"), 0o600))
+
+	_, err := screening.LoadRules(path)
+	assert.ErrorContains(t, err, "window must be positive")
+}
+
+func TestRulesEngine(t *testing.T) {
+	engine, err := screening.NewRulesEngine([]screening.Rule{
+		{Name: "large", Kind: screening.KindAmountThreshold, Action: screening.Review, MinAmount: 10000, TransactionTypes: []string{"withdrawal"}},
+		{Name: "huge", Kind: screening.KindAmountThreshold, Action: screening.Deny, MinAmount: 100000},
+		{Name: "burst", Kind: screening.KindVelocity, Action: screening.Review, Window: time.Hour, MaxCount: 3},
+		{Name: "new_account", Kind: screening.KindNewAccount, Action: screening.Review, MaxAccountAge: 72 * time.Hour, MinAmount: 1000},
+		{Name: "round", Kind: screening.KindRoundAmount, Action: screening.Review, MultipleOf: 1000, MinAmount: 5000},
+	})
+	require.NoError(t, err)
+
+	oldAccount := fakeFacts{openedAt: time.Now().Add(-365 * 24 * time.Hour)}
+	ctx := context.Background()
+
+	tests := []struct {
+		name     string
+		req      screening.Request
+		decision screening.Decision
+		reasons  int
+	}{
+		{"small deposit", screening.Request{Type: "deposit", Amount: 50, Facts: oldAccount}, screening.Allow, 0},
+		{"large deposit is not a withdrawal", screening.Request{Type: "deposit", Amount: 12345.67, Facts: oldAccount}, screening.Allow, 0},
+		{"large withdrawal", screening.Request{Type: "withdrawal", Amount: 12345.67, Facts: oldAccount}, screening.Review, 1},
+		{"deny wins over review", screening.Request{Type: "withdrawal", Amount: 200000, Facts: oldAccount}, screening.Deny, 3},
+		{"velocity", screening.Request{Type: "withdrawal", Amount: 10, Facts: fakeFacts{openedAt: oldAccount.openedAt, activity: screening.Activity{Count: 3}}}, screening.Review, 1},
+		{"new account", screening.Request{Type: "withdrawal", Amount: 1500, Facts: fakeFacts{openedAt: time.Now().Add(-time.Hour)}}, screening.Review, 1},
+		{"round amount", screening.Request{Type: "deposit", Amount: 6000, Facts: oldAccount}, screening.Review, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := engine.Screen(ctx, tt.req)
+			require.NoError(t, err)
+			assert.Equal(t, tt.decision, res.Decision)
+			assert.Len(t, res.Reasons, tt.reasons)
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package statements_test
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/Andrew44Ashraf/fintech-service/internal/statements"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func sampleStatement() *statements.Statement {
+	from := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	s := &statements.Statement{
+		AccountID:      42,
+		From:           from,
+		To:             from.AddDate(0, 1, 0),
+		OpeningBalance: 100,
+		Lines: []statements.Line{
+			{TransactionID: 1, Date: from.Add(24 * time.Hour), Type: "deposit", Amount: 50, Balance: 150},
+			{TransactionID: 2, Date: from.Add(48 * time.Hour), Type: "withdrawal", Amount: 30, Balance: 120},
+			{TransactionID: 3, Date: from.Add(72 * time.Hour), Type: "deposit", Amount: 5.5, Balance: 125.5},
+		},
+		GeneratedAt: from.AddDate(0, 1, 1),
+	}
+	s.Summarize()
+	return s
+}
+
+func TestSummarize(t *testing.T) {
+	s := sampleStatement()
+
+	assert.Equal(t, 125.5, s.ClosingBalance)
+	assert.Equal(t, []statements.TypeTotal{
+		{Type: "deposit", Count: 2, Amount: 55.5},
+		{Type: "withdrawal", Count: 1, Amount: 30},
+	}, s.Totals)
+}
+
+func TestRenderCSV(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, statements.Render(&buf, sampleStatement(), statements.FormatCSV))
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 6)
+	assert.Equal(t, []string{"2026-03-01", "", "", "opening_balance", "", "", "100.00", ""}, records[1])
+	assert.Equal(t, "2026-03-02", records[2][1], "value date defaults to the booking date")
+	assert.Equal(t, []string{"", "50.00", "150.00", "posted"}, records[2][4:])
+	assert.Equal(t, []string{"30.00", "", "120.00", "posted"}, records[3][4:])
+	assert.Equal(t, []string{"2026-03-31", "", "", "closing_balance", "", "", "125.50", ""}, records[5])
+}
+
+func TestRenderPending(t *testing.T) {
+	s := sampleStatement()
+	valueDate := time.Date(2026, 3, 30, 0, 0, 0, 0, time.UTC)
+	s.Pending = []statements.Line{
+		{TransactionID: 4, Date: valueDate.Add(time.Hour), ValueDate: valueDate, Type: "bank_transfer_in", Amount: 200},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, statements.Render(&buf, s, statements.FormatCSV))
+	records, err := csv.NewReader(&buf).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 7)
+	assert.Equal(t, "closing_balance", records[5][3])
+	assert.Equal(t, []string{"2026-03-30", "4", "bank_transfer_in", "", "200.00", "", "pending"}, records[6][1:])
+
+	buf.Reset()
+	require.NoError(t, statements.Render(&buf, s, statements.FormatHTML))
+	assert.Contains(t, buf.String(), "<h2>Pending</h2>")
+	assert.Contains(t, buf.String(), "bank_transfer_in")
+}
+
+func TestRenderJSONRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, statements.Render(&buf, sampleStatement(), statements.FormatJSON))
+
+	var got statements.Statement
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &got))
+	assert.Equal(t, *sampleStatement(), got)
+}
+
+func TestRenderHTML(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, statements.Render(&buf, sampleStatement(), statements.FormatHTML))
+
+	html := buf.String()
+	assert.Contains(t, html, "2026-03-01 to 2026-03-31")
+	assert.Contains(t, html, "125.50")
+}
+
+func TestRenderUnknownFormat(t *testing.T) {
+	assert.Error(t, statements.Render(&bytes.Buffer{}, sampleStatement(), "pdf"))
+}
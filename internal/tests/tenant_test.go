@@ -0,0 +1,65 @@
+package tests
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/Andrew44Ashraf/fintech-service/internal/logging"
+	"github.com/Andrew44Ashraf/fintech-service/internal/repository"
+	"github.com/Andrew44Ashraf/fintech-service/internal/tenant"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestTenantIsolation checks the row-level security policies: a tenant
+// cannot see or post to another tenant's accounts.
+func TestTenantIsolation(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	var bypass bool
+	err := db.QueryRow("SELECT rolsuper OR rolbypassrls FROM pg_roles WHERE rolname = current_user").Scan(&bypass)
+	require.NoError(t, err)
+	if bypass {
+		t.Skip("the test role bypasses row-level security")
+	}
+
+	logger := logging.Discard()
+	suffix := strconv.FormatInt(time.Now().UnixNano(), 36)
+	alpha := tenant.WithID(context.Background(), "alpha-"+suffix)
+	bravo := tenant.WithID(context.Background(), "bravo-"+suffix)
+	tenants := repository.NewTenantRepository(db, logger)
+	for _, ctx := range []context.Context{alpha, bravo} {
+		_, err := tenants.CreateTenant(context.Background(), tenant.FromContext(ctx), "test")
+		require.NoError(t, err)
+	}
+
+	accounts := repository.NewAccountRepository(db, logger)
+	transactions := repository.NewTransactionRepository(db, logger)
+	account, err := accounts.CreateAccount(alpha, "", 0, repository.Details{ExternalRef: "crm-1"})
+	require.NoError(t, err)
+	_, err = transactions.CreateDeposit(alpha, account.ID, 100, repository.Details{})
+	require.NoError(t, err)
+
+	_, err = accounts.ResolveAccount(bravo, account.PublicID)
+	assert.ErrorIs(t, err, repository.ErrAccountNotFound)
+	_, err = accounts.GetAccount(bravo, account.ID)
+	assert.ErrorIs(t, err, repository.ErrAccountNotFound)
+	_, err = transactions.CreateDeposit(bravo, account.ID, 1, repository.Details{})
+	assert.ErrorIs(t, err, repository.ErrAccountNotFound)
+	history, _ := transactions.GetTransactions(bravo, account.ID, 10, 0, "")
+	assert.Empty(t, history)
+
+	_, err = accounts.GetAccount(context.Background(), account.ID)
+	assert.ErrorIs(t, err, repository.ErrAccountNotFound, "no tenant sees nothing")
+
+	// External references are unique per tenant
+	_, err = accounts.CreateAccount(bravo, "", 0, repository.Details{ExternalRef: "crm-1"})
+	assert.NoError(t, err)
+
+	balance, err := accounts.GetAccountBalance(alpha, account.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, 100.0, balance, "bravo's attempts left alpha's account alone")
+}
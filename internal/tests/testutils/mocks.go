@@ -2,10 +2,10 @@ package testutils
 
 import (
 	"database/sql"
-	"github.com/DATA-DOG/go-sqlmock"
+
+	"github.com/Andrew44Ashraf/fintech-service/internal/logging"
 	"github.com/Andrew44Ashraf/fintech-service/internal/repository"
-	"gorm.io/driver/postgres"
-	"gorm.io/gorm"
+	"github.com/DATA-DOG/go-sqlmock"
 )
 
 // NewMockDB creates a sqlmock database connection
@@ -20,11 +20,13 @@ func NewMockDB() (*sql.DB, sqlmock.Sqlmock) {
 // NewMockRepository creates a repository with mock DB
 func NewMockRepository() (*repository.AccountRepository, sqlmock.Sqlmock) {
 	db, mock := NewMockDB()
-	gormDB, err := gorm.Open(postgres.New(postgres.Config{
-		Conn: db,
-	}), &gorm.Config{})
-	if err != nil {
-		panic("failed to open gorm db")
-	}
-	return repository.NewAccountRepository(gormDB), mock
-}
\ No newline at end of file
+	return repository.NewAccountRepository(db, logging.Discard()), mock
+}
+
+// ExpectAccountRules expects the product rules lookup of a posting and
+// returns no rows, so the accounts are unrestricted.
+func ExpectAccountRules(mock sqlmock.Sqlmock) {
+	mock.ExpectQuery(`JOIN account_products`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "allowed_transaction_types", "floor",
+			"max_single_withdrawal", "max_daily_withdrawal", "max_monthly_withdrawal", "max_daily_withdrawals"}))
+}
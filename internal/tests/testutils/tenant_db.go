@@ -0,0 +1,156 @@
+package testutils
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/Andrew44Ashraf/fintech-service/internal/database"
+)
+
+// TenantStatement is a statement run against a TenantDB with the tenant its
+// session was set to.
+type TenantStatement struct {
+	Tenant string
+	Query  string
+}
+
+// TenantLog records the statements run against a TenantDB.
+type TenantLog struct {
+	mu         sync.Mutex
+	statements []TenantStatement
+	sets       int
+}
+
+// Statements returns the statements run since the last Reset, set_config
+// calls excluded.
+func (l *TenantLog) Statements() []TenantStatement {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]TenantStatement(nil), l.statements...)
+}
+
+// Sets returns how often a session's tenant was set since the last Reset.
+func (l *TenantLog) Sets() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.sets
+}
+
+func (l *TenantLog) Reset() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.statements, l.sets = nil, 0
+}
+
+func (l *TenantLog) record(tenant, query string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.statements = append(l.statements, TenantStatement{Tenant: tenant, Query: query})
+}
+
+// NewTenantDB returns a database opened through database.WithTenants over
+// a fake driver that tracks the tenant setting of each session like
+// Postgres does, rolling it back with the transaction it was made in.
+// Queries return no rows and statements affect none, so handlers see what
+// a tenant sees of another tenant's data.
+func NewTenantDB() (*sql.DB, *TenantLog) {
+	log := &TenantLog{}
+	return sql.OpenDB(database.WithTenants(&fakeConnector{log: log})), log
+}
+
+type fakeConnector struct {
+	log *TenantLog
+}
+
+func (c *fakeConnector) Connect(context.Context) (driver.Conn, error) {
+	return &fakeConn{log: c.log}, nil
+}
+
+func (c *fakeConnector) Driver() driver.Driver { return fakeDriver{} }
+
+type fakeDriver struct{}
+
+func (fakeDriver) Open(string) (driver.Conn, error) {
+	return nil, errors.New("testutils: open the fake driver through its connector")
+}
+
+type fakeConn struct {
+	log     *TenantLog
+	setting string
+	// saved is the setting at BEGIN, restored on rollback.
+	saved string
+}
+
+func (c *fakeConn) Prepare(string) (driver.Stmt, error) {
+	return nil, errors.New("testutils: prepared statements are not supported")
+}
+
+func (c *fakeConn) Close() error { return nil }
+
+func (c *fakeConn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+func (c *fakeConn) BeginTx(context.Context, driver.TxOptions) (driver.Tx, error) {
+	c.saved = c.setting
+	return fakeTx{c}, nil
+}
+
+// run records query, or applies it if it sets the tenant.
+func (c *fakeConn) run(query string, args []driver.NamedValue) bool {
+	if strings.Contains(query, "set_config('"+database.TenantSetting+"'") {
+		c.setting, _ = args[0].Value.(string)
+		c.log.mu.Lock()
+		c.log.sets++
+		c.log.mu.Unlock()
+		return true
+	}
+	c.log.record(c.setting, query)
+	return false
+}
+
+func (c *fakeConn) ExecContext(_ context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	c.run(query, args)
+	return driver.RowsAffected(0), nil
+}
+
+func (c *fakeConn) QueryContext(_ context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	if c.run(query, args) {
+		return &fakeRows{columns: []string{"set_config"}, values: [][]driver.Value{{c.setting}}}, nil
+	}
+	return &fakeRows{}, nil
+}
+
+type fakeTx struct {
+	conn *fakeConn
+}
+
+func (t fakeTx) Commit() error { return nil }
+
+func (t fakeTx) Rollback() error {
+	t.conn.setting = t.conn.saved
+	return nil
+}
+
+type fakeRows struct {
+	columns []string
+	values  [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string { return r.columns }
+
+func (r *fakeRows) Close() error { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.values[0])
+	r.values = r.values[1:]
+	return nil
+}
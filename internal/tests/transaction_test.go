@@ -1,37 +1,51 @@
 package tests
 
 import (
-	"github.com/Andrew44Ashraf/fintech-service/internal/models"
+	"context"
+	"testing"
+
+	"github.com/Andrew44Ashraf/fintech-service/internal/logging"
 	"github.com/Andrew44Ashraf/fintech-service/internal/repository"
+	"github.com/Andrew44Ashraf/fintech-service/internal/tenant"
 	"github.com/stretchr/testify/assert"
-	"testing"
 )
 
 func TestDeposit(t *testing.T) {
-	db := setupTestDB()
+	db := setupTestDB(t)
 	defer db.Close()
+	ctx := tenant.WithID(context.Background(), tenant.Default)
+
+	accountRepo := repository.NewAccountRepository(db, logging.Discard())
+	transactionRepo := repository.NewTransactionRepository(db, logging.Discard())
 
 	// Create a test account
-	accountID, _ := repository.CreateAccount(db)
+	account, err := accountRepo.CreateAccount(ctx, "", 0, repository.Details{})
+	assert.NoError(t, err)
+	accountID := account.ID
 
 	// Perform a deposit
-	txID, err := repository.CreateTransaction(db, accountID, 100.0, models.Deposit)
+	txID, err := transactionRepo.CreateDeposit(ctx, accountID, 100.0, repository.Details{})
 	assert.NoError(t, err, "Expected deposit to succeed")
 	assert.NotZero(t, txID, "Transaction ID should not be zero")
 
 	// Verify the balance
-	balance, err := repository.GetAccountBalance(db, accountID)
+	balance, err := accountRepo.GetAccountBalance(ctx, accountID)
 	assert.NoError(t, err)
 	assert.Equal(t, 100.0, balance, "Balance should be updated after deposit")
 }
 
 func TestWithdrawWithInsufficientFunds(t *testing.T) {
-	db := setupTestDB()
+	db := setupTestDB(t)
 	defer db.Close()
+	ctx := tenant.WithID(context.Background(), tenant.Default)
+
+	accountRepo := repository.NewAccountRepository(db, logging.Discard())
+	transactionRepo := repository.NewTransactionRepository(db, logging.Discard())
 
-	accountID, _ := repository.CreateAccount(db)
+	account, err := accountRepo.CreateAccount(ctx, "", 0, repository.Details{})
+	assert.NoError(t, err)
 
 	// Attempt withdrawal of more than balance
-	_, err := repository.CreateTransaction(db, accountID, 500.0, models.Withdrawal)
+	_, err = transactionRepo.CreateWithdrawal(ctx, account.ID, 500.0, repository.Details{})
 	assert.Error(t, err, "Expected error when withdrawing more than balance")
 }